@@ -0,0 +1,94 @@
+package cash_flow_cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchFile   string
+	batchAtomic bool
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "apply creates, updates and deletes from a JSON file",
+	Long: `Apply a batch of cash flow operations from a JSON file, the body of POST /api/cash/batch.
+The file holds either {"atomic": true, "operations": [...]} or just the list of operations.
+Updates without if_match overwrite the latest version, like cash update.
+With --atomic nothing is written unless every operation succeeds.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		content, err := os.ReadFile(batchFile)
+		if err != nil {
+			return err
+		}
+
+		request, err := parseBatchFile(content)
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("atomic") {
+			request.Atomic = batchAtomic
+		}
+		for index := range request.Operations {
+			if request.Operations[index].Op == model.BatchOpUpdate && request.Operations[index].IfMatch == "" {
+				request.Operations[index].IfMatch = "*"
+			}
+		}
+
+		response, err := cash_flow_service.ExecuteBatch(request, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
+		if err != nil {
+			return err
+		}
+
+		for _, result := range response.Results {
+			if result.Error != nil {
+				fmt.Printf("operation %d (%s): %d %s: %s\n", result.Index, result.Op, result.Status, result.Error.Code, result.Error.Message)
+				continue
+			}
+			fmt.Printf("operation %d (%s): %d id %s version %d\n", result.Index, result.Op, result.Status, result.Id, result.Version)
+		}
+		fmt.Printf("\n--- %d succeeded, %d failed ---\n", response.Succeeded, response.Failed)
+
+		if response.Failed > 0 {
+			if response.Atomic {
+				return errors.New("atomic batch not applied, nothing was written")
+			}
+			return fmt.Errorf("%d operations failed", response.Failed)
+		}
+		return nil
+	},
+}
+
+// parseBatchFile reads a batch request, or a bare list of operations
+func parseBatchFile(content []byte) (model.BatchRequest, error) {
+	var request model.BatchRequest
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		if err := json.Unmarshal(content, &request.Operations); err != nil {
+			return model.BatchRequest{}, fmt.Errorf("invalid batch file: %w", err)
+		}
+		return request, nil
+	}
+	if err := json.Unmarshal(content, &request); err != nil {
+		return model.BatchRequest{}, fmt.Errorf("invalid batch file: %w", err)
+	}
+	return request, nil
+}
+
+func init() {
+	batchCmd.Flags().StringVarP(
+		&batchFile, "file", "f", "", "JSON file with the operations (required)")
+	batchCmd.Flags().BoolVar(
+		&batchAtomic, "atomic", false, "apply all operations or none, overrides the file")
+
+	batchCmd.MarkFlagRequired("file")
+	CashCmd.AddCommand(batchCmd)
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +21,7 @@ var deleteCmd = &cobra.Command{
 
 		if plainId != "" {
 
-			cashFlowEntity, err := cash_flow_service.DeleteById(plainId)
+			cashFlowEntity, err := cash_flow_service.DeleteById(plainId, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
 			if err != nil {
 				return err
 			}
@@ -28,7 +30,7 @@ var deleteCmd = &cobra.Command{
 		}
 
 		if belongsDate != "" {
-			cashFlowEntityList, err := cash_flow_service.DeleteByDate(belongsDate)
+			cashFlowEntityList, err := cash_flow_service.DeleteByDate(belongsDate, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,40 @@
+package cash_flow_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "show the change history of a cash_flow",
+	Long: `Show every revision of a cash flow, oldest first, with the fields each one changed.
+Use 'cash revert' with a revision id to go back to it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		revisionList, err := cash_flow_service.GetHistory(plainId)
+		if err != nil {
+			return err
+		}
+		if len(revisionList) == 0 {
+			fmt.Println("No history recorded for this cash_flow")
+			return nil
+		}
+
+		for index, revision := range revisionList {
+			fmt.Println("revision", index, ":", revision.ToString())
+			for _, change := range revision.Changes {
+				fmt.Printf("    %s: %q -> %q\n", change.Field, change.Before, change.After)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVarP(
+		&plainId, "id", "i", "", "cash_flow id (required)")
+	historyCmd.MarkFlagRequired("id")
+	CashCmd.AddCommand(historyCmd)
+}
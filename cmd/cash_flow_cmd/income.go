@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
@@ -12,10 +14,11 @@ var incomeCmd = &cobra.Command{
 	Use:   "income",
 	Short: "add new income cash_flow",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if !cash_flow_service.IsIncomeRequiredFiledSatisfied(categoryName, amount) {
+		amountInMoney := model.NewMoneyFromFloat(amount)
+		if !cash_flow_service.IsIncomeRequiredFiledSatisfied(categoryName, amountInMoney) {
 			return errors.New("some required fields are empty")
 		}
-		cashFlowEntity, err := cash_flow_service.SaveIncome(belongsDate, categoryName, amount, descriptionExact)
+		cashFlowEntity, err := cash_flow_service.SaveIncome(belongsDate, categoryName, amountInMoney, descriptionExact, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
 		if err != nil {
 			return err
 		}
@@ -30,20 +30,20 @@ Use --type to filter by income/expense, --limit for pagination.`,
 			return nil
 		}
 
-		var totalIncome, totalExpense float64
+		var totalIncome, totalExpense model.Money
 		for index, cashFlowEntity := range cashFlowEntityList {
 			fmt.Println("cash_flow", index+offset, ":", cashFlowEntity.ToString())
 			if cashFlowEntity.FlowType == model.FlowTypeIncome {
-				totalIncome += cashFlowEntity.Amount
+				totalIncome = totalIncome.Add(cashFlowEntity.Amount)
 			} else {
-				totalExpense += cashFlowEntity.Amount
+				totalExpense = totalExpense.Add(cashFlowEntity.Amount)
 			}
 		}
 
 		fmt.Printf("\n--- Summary (showing %d records) ---\n", len(cashFlowEntityList))
-		fmt.Printf("Total Income: %.2f\n", totalIncome)
-		fmt.Printf("Total Expense: %.2f\n", totalExpense)
-		fmt.Printf("Balance: %.2f\n", totalIncome-totalExpense)
+		fmt.Printf("Total Income: %s\n", totalIncome)
+		fmt.Printf("Total Expense: %s\n", totalExpense)
+		fmt.Printf("Balance: %s\n", totalIncome.Sub(totalExpense))
 
 		return nil
 	},
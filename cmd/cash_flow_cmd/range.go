@@ -34,22 +34,22 @@ Displays all transactions between from-date and to-date (inclusive).`,
 			return nil
 		}
 
-		var totalIncome, totalExpense float64
+		var totalIncome, totalExpense model.Money
 		for index, cashFlowEntity := range cashFlowEntityList {
 			fmt.Println("cash_flow", index, ":", cashFlowEntity.ToString())
 			if cashFlowEntity.FlowType == model.FlowTypeIncome {
-				totalIncome += cashFlowEntity.Amount
+				totalIncome = totalIncome.Add(cashFlowEntity.Amount)
 			} else {
-				totalExpense += cashFlowEntity.Amount
+				totalExpense = totalExpense.Add(cashFlowEntity.Amount)
 			}
 		}
 
 		fmt.Printf("\n--- Summary ---\n")
 		fmt.Printf("Period: %s to %s\n", fromDate, toDate)
 		fmt.Printf("Total Records: %d\n", len(cashFlowEntityList))
-		fmt.Printf("Total Income: %.2f\n", totalIncome)
-		fmt.Printf("Total Expense: %.2f\n", totalExpense)
-		fmt.Printf("Balance: %.2f\n", totalIncome-totalExpense)
+		fmt.Printf("Total Income: %s\n", totalIncome)
+		fmt.Printf("Total Expense: %s\n", totalExpense)
+		fmt.Printf("Balance: %s\n", totalIncome.Sub(totalExpense))
 
 		return nil
 	},
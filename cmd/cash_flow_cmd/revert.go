@@ -0,0 +1,38 @@
+package cash_flow_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var revisionId string
+
+var revertCmd = &cobra.Command{
+	Use:   "revert",
+	Short: "revert a cash_flow to a prior revision",
+	Long: `Set a cash flow back to how it was right after the given revision.
+The revert is recorded in the history, so it can be reverted in turn.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cashFlowEntity, err := cash_flow_service.RevertToRevision(plainId, revisionId,
+			model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
+		if err != nil {
+			return err
+		}
+		fmt.Println("cash_flow ", 0, ": ", cashFlowEntity.ToString())
+		return nil
+	},
+}
+
+func init() {
+	revertCmd.Flags().StringVarP(
+		&plainId, "id", "i", "", "cash_flow id (required)")
+	revertCmd.Flags().StringVarP(
+		&revisionId, "revision", "r", "", "revision id from 'cash history' (required)")
+	revertCmd.MarkFlagRequired("id")
+	revertCmd.MarkFlagRequired("revision")
+	CashCmd.AddCommand(revertCmd)
+}
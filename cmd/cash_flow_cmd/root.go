@@ -24,11 +24,16 @@ Available sub-commands:
   income   - Add new income transaction
   expense  - Add new expense transaction
   update   - Update existing transaction
+  batch    - Apply creates, updates and deletes from a JSON file
   delete   - Delete transaction(s)
   query    - Query transactions by filters
   list     - List all transactions with pagination
+  search   - Search transactions with a query
   range    - Query transactions by date range
-  summary  - Show financial summary`,
+  summary  - Show financial summary
+  history  - Show the change history of a transaction
+  revert   - Revert a transaction to a prior revision
+  trash    - List, restore or purge deleted transactions`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return errors.New("must provide a valid sub command")
@@ -0,0 +1,100 @@
+package cash_flow_cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/query"
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchLimit  int
+	searchOffset int
+	searchText   bool
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "search cash_flow records with a query",
+	Long: `Search cash flow records with a query, newest first.
+
+Terms are separated by spaces and all have to match; use OR, parentheses and
+a leading - or NOT to combine them differently. Quote values holding spaces.
+
+  amount>50  amount:10..50        amount, also with >= < <= and ranges
+  date:2026-01  date:2026-01..2026-03  date>2026-01-15   a day, month or year
+  category:Food  category:Food/*  a category, or it and everything below it
+  desc~"coffee"  remark:gift      text in the description or remark
+  type:income  type:expense       the kind of cash flow
+  tag:work                        a #work hashtag in the description or remark
+  coffee                          a bare word matches description or remark
+
+With --text the argument is a list of words instead, ranked by how well the
+description and remark match them using the full-text index ('manage indexes').
+Words match their inflections, so coffees finds coffee; matches are shown in [].
+
+Example:
+  cashlenx cash search 'amount>50 category:Food/* desc~"coffee" date:2026-01..2026-03 -tag:work'
+  cashlenx cash search --text 'coffee beans'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("provide the query as one argument, quoted")
+		}
+		if searchText {
+			return printTextSearch(args[0])
+		}
+
+		cashFlowEntityList, totalCount, err := cash_flow_service.Search(args[0], searchLimit, searchOffset)
+		if err != nil {
+			return err
+		}
+
+		if len(cashFlowEntityList) == 0 {
+			fmt.Println("No cash flows found")
+			return nil
+		}
+
+		for index, cashFlowEntity := range cashFlowEntityList {
+			fmt.Println("cash_flow", index+searchOffset, ":", cashFlowEntity.ToString())
+		}
+		fmt.Printf("\nshowing %d of %d matching records\n", len(cashFlowEntityList), totalCount)
+		return nil
+	},
+}
+
+func printTextSearch(text string) error {
+	hitList, err := cash_flow_service.TextSearch(text, searchLimit)
+	if err != nil {
+		return err
+	}
+	if len(hitList) == 0 {
+		fmt.Println("No cash flows found")
+		return nil
+	}
+
+	stemList := query.StemTerms(query.TextTerms(text))
+	for index, hit := range hitList {
+		fmt.Printf("%d. score %.2f, %s %s %s\n", index+1, hit.Score, hit.CashFlow.Id.Hex(),
+			util.FormatDateToStringWithDash(hit.CashFlow.BelongsDate), hit.CashFlow.Amount)
+		for _, field := range []string{hit.CashFlow.Description, hit.CashFlow.Remark} {
+			if highlighted, matched := query.Highlight(field, stemList, "[", "]", false); matched {
+				fmt.Println("   ", highlighted)
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	searchCmd.Flags().IntVarP(
+		&searchLimit, "limit", "l", 50, "maximum number of records to return")
+	searchCmd.Flags().IntVarP(
+		&searchOffset, "offset", "o", 0, "number of records to skip")
+	searchCmd.Flags().BoolVar(
+		&searchText, "text", false, "rank by full-text match of the words instead of parsing a query")
+
+	CashCmd.AddCommand(searchCmd)
+}
@@ -3,14 +3,17 @@ package cash_flow_cmd
 import (
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
 	"github.com/spf13/cobra"
 )
 
 var (
-	summaryPeriod string
-	summaryDate   string
+	summaryPeriod  string
+	summaryDate    string
+	summaryCompare string
 )
 
 var summaryCmd = &cobra.Command{
@@ -18,10 +21,16 @@ var summaryCmd = &cobra.Command{
 	Short: "show cash_flow summary",
 	Long: `Show summary of cash flows for different periods.
 Periods: daily, monthly, yearly
+
+With --compare the period is compared with the one before (prev) and the same
+period a year earlier (yoy), per category for income and expense; the biggest
+changes are marked with *.
+
 Examples:
   cashlenx cash summary --period daily --date 2024-01-15
   cashlenx cash summary --period monthly --date 2024-01
-  cashlenx cash summary --period yearly --date 2024`,
+  cashlenx cash summary --period yearly --date 2024
+  cashlenx cash summary --period monthly --date 2026-09 --compare prev,yoy`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if summaryPeriod == "" {
 			return errors.New("period is required (daily, monthly, yearly)")
@@ -31,21 +40,30 @@ Examples:
 			return errors.New("date is required (format depends on period)")
 		}
 
+		if summaryCompare != "" {
+			report, err := cash_flow_service.Compare(summaryPeriod, summaryDate, strings.Split(summaryCompare, ","))
+			if err != nil {
+				return err
+			}
+			printComparison(report)
+			return nil
+		}
+
 		summary, err := cash_flow_service.GetSummary(summaryPeriod, summaryDate)
 		if err != nil {
 			return err
 		}
 
 		fmt.Printf("\n=== %s Summary for %s ===\n", summaryPeriod, summaryDate)
-		fmt.Printf("Total Income:  %.2f\n", summary.TotalIncome)
-		fmt.Printf("Total Expense: %.2f\n", summary.TotalExpense)
-		fmt.Printf("Balance:       %.2f\n", summary.Balance)
+		fmt.Printf("Total Income:  %s\n", summary.TotalIncome)
+		fmt.Printf("Total Expense: %s\n", summary.TotalExpense)
+		fmt.Printf("Balance:       %s\n", summary.Balance)
 		fmt.Printf("Transactions:  %d\n", summary.TransactionCount)
 
 		if len(summary.CategoryBreakdown) > 0 {
 			fmt.Printf("\n--- Category Breakdown ---\n")
 			for category, amount := range summary.CategoryBreakdown {
-				fmt.Printf("  %-20s: %.2f\n", category, amount)
+				fmt.Printf("  %-20s: %s\n", category, amount)
 			}
 		}
 
@@ -53,12 +71,52 @@ Examples:
 	},
 }
 
+// printComparison prints the totals of the period and their changes against each baseline
+func printComparison(report model.ComparisonReport) {
+	current := report.Current
+	fmt.Printf("\n=== %s Summary for %s ===\n", summaryPeriod, current.Name)
+	fmt.Printf("Total Income:  %s\n", current.Income)
+	fmt.Printf("Total Expense: %s\n", current.Expense)
+	fmt.Printf("Balance:       %s\n", current.Balance)
+	fmt.Printf("Transactions:  %d\n", current.Count)
+
+	for _, comparison := range report.Comparisons {
+		fmt.Printf("\n--- Compared with %s (%s) ---\n", comparison.Baseline.Name, comparison.Against)
+		fmt.Printf("Income:  %s\n", formatAmountDelta(comparison.Income))
+		fmt.Printf("Expense: %s\n", formatAmountDelta(comparison.Expense))
+		fmt.Printf("Balance: %s\n", formatAmountDelta(comparison.Balance))
+		for _, category := range comparison.Categories {
+			marker := " "
+			if category.Mover {
+				marker = "*"
+			}
+			fmt.Printf("  %s %-20s %-7s: %s\n", marker, category.Category, category.Type, formatAmountDelta(category.AmountDelta))
+		}
+	}
+}
+
+// formatAmountDelta shows a change as "700.00 (was 500.00, +200.00, +40.0%)"
+func formatAmountDelta(delta model.AmountDelta) string {
+	change := delta.Change.String()
+	if !delta.Change.IsNegative() {
+		change = "+" + change
+	}
+	percent := "n/a"
+	if delta.Percent != nil {
+		percent = fmt.Sprintf("%+.1f%%", *delta.Percent)
+	}
+	return fmt.Sprintf("%s (was %s, %s, %s)", delta.Current, delta.Baseline, change, percent)
+}
+
 func init() {
 	summaryCmd.Flags().StringVarP(
 		&summaryPeriod, "period", "p", "", "summary period (daily/monthly/yearly) (required)")
 	summaryCmd.Flags().StringVarP(
 		&summaryDate, "date", "d", "", "date for summary (format: YYYY-MM-DD for daily, YYYY-MM for monthly, YYYY for yearly) (required)")
 
+	summaryCmd.Flags().StringVar(
+		&summaryCompare, "compare", "", "compare with prev and/or yoy, e.x. prev,yoy")
+
 	summaryCmd.MarkFlagRequired("period")
 	summaryCmd.MarkFlagRequired("date")
 	CashCmd.AddCommand(summaryCmd)
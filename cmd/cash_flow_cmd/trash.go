@@ -0,0 +1,27 @@
+package cash_flow_cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "manage deleted cash_flow records",
+	Long: `Deleted cash flows are kept in the trash until they are restored or purged.
+The api server purges cash flows deleted more than TRASH_RETENTION_DAYS ago.
+
+Available sub-commands:
+  list     - List deleted transactions
+  restore  - Bring a deleted transaction back
+  purge    - Permanently delete transactions from the trash`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return errors.New("must provide a valid sub command")
+	},
+}
+
+func init() {
+	CashCmd.AddCommand(trashCmd)
+}
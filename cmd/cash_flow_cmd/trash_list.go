@@ -0,0 +1,42 @@
+package cash_flow_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trashLimit  int
+	trashOffset int
+)
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list deleted cash_flow records",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cashFlowEntityList, totalCount := cash_flow_service.ListTrash(trashLimit, trashOffset)
+		if len(cashFlowEntityList) == 0 {
+			fmt.Println("Trash is empty")
+			return nil
+		}
+
+		for index, cashFlowEntity := range cashFlowEntityList {
+			fmt.Println("cash_flow", index+trashOffset, ":", cashFlowEntity.ToString(),
+				"deleted at", util.ToTimezone(*cashFlowEntity.DeletedAt).Format("2006-01-02 15:04:05"))
+		}
+		fmt.Printf("\n--- showing %d of %d deleted records ---\n", len(cashFlowEntityList), totalCount)
+		return nil
+	},
+}
+
+func init() {
+	trashListCmd.Flags().IntVarP(
+		&trashLimit, "limit", "l", 50, "maximum number of records to return")
+	trashListCmd.Flags().IntVarP(
+		&trashOffset, "offset", "o", 0, "number of records to skip")
+
+	trashCmd.AddCommand(trashListCmd)
+}
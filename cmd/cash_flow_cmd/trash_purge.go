@@ -0,0 +1,104 @@
+package cash_flow_cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trashPurgeId        string
+	trashPurgeAll       bool
+	trashPurgeOlderDays int
+	forceTrashPurge     bool
+)
+
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "permanently delete cash_flow records from the trash",
+	Long: `Permanently delete cash flows from the trash; purged cash flows cannot be restored.
+Purge one record with --id, everything with --all, or records deleted more than
+--older-than days ago.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selected := 0
+		for _, set := range []bool{trashPurgeId != "", trashPurgeAll, trashPurgeOlderDays > 0} {
+			if set {
+				selected++
+			}
+		}
+		if selected != 1 {
+			return errors.New("should have one and only one of --id, --all and --older-than")
+		}
+
+		if trashPurgeId != "" {
+			cashFlowEntity, err := cash_flow_service.PurgeFromTrash(trashPurgeId)
+			recordTrashPurge(map[string]string{"id": trashPurgeId}, map[string]int{"cash_flows": 1}, err)
+			if err != nil {
+				return err
+			}
+			fmt.Println("purged cash_flow:", cashFlowEntity.ToString())
+			return nil
+		}
+
+		if !forceTrashPurge {
+			if trashPurgeAll {
+				fmt.Println("WARNING: This will permanently delete everything in the trash!")
+			} else {
+				fmt.Printf("WARNING: This will permanently delete cash flows deleted more than %d days ago!\n", trashPurgeOlderDays)
+			}
+			fmt.Print("Are you sure you want to continue? (yes/no): ")
+
+			reader := bufio.NewReader(os.Stdin)
+			response, _ := reader.ReadString('\n')
+			response = strings.TrimSpace(strings.ToLower(response))
+
+			if response != "yes" && response != "y" {
+				fmt.Println("Purge cancelled")
+				return nil
+			}
+		}
+
+		purgedCount, err := cash_flow_service.PurgeTrash(time.Duration(trashPurgeOlderDays) * 24 * time.Hour)
+		recordTrashPurge(map[string]string{
+			"all":        strconv.FormatBool(trashPurgeAll),
+			"older_than": strconv.Itoa(trashPurgeOlderDays),
+		}, map[string]int{"cash_flows": int(purgedCount)}, err)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Purged %d cash flows from the trash\n", purgedCount)
+		return nil
+	},
+}
+
+func init() {
+	trashPurgeCmd.Flags().StringVarP(
+		&trashPurgeId, "id", "i", "", "purge one deleted cash_flow by id")
+	trashPurgeCmd.Flags().BoolVar(
+		&trashPurgeAll, "all", false, "empty the whole trash")
+	trashPurgeCmd.Flags().IntVar(
+		&trashPurgeOlderDays, "older-than", 0, "purge cash flows deleted more than this many days ago")
+	trashPurgeCmd.Flags().BoolVarP(
+		&forceTrashPurge, "force", "f", false, "skip confirmation prompt")
+
+	trashCmd.AddCommand(trashPurgeCmd)
+}
+
+// recordTrashPurge adds a purge from the trash to the audit log
+func recordTrashPurge(parameters map[string]string, affected map[string]int, err error) {
+	if err != nil {
+		affected = nil
+	}
+	audit_service.Record(model.AuditOperationTrashPurge,
+		model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()), parameters, affected, err)
+}
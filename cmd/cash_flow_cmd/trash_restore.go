@@ -0,0 +1,33 @@
+package cash_flow_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var trashRestoreId string
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "bring a deleted cash_flow back",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cashFlowEntity, err := cash_flow_service.RestoreFromTrash(trashRestoreId, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
+		if err != nil {
+			return err
+		}
+		fmt.Println("restored cash_flow:", cashFlowEntity.ToString())
+		return nil
+	},
+}
+
+func init() {
+	trashRestoreCmd.Flags().StringVarP(
+		&trashRestoreId, "id", "i", "", "id of the deleted cash_flow (required)")
+	trashRestoreCmd.MarkFlagRequired("id")
+
+	trashCmd.AddCommand(trashRestoreCmd)
+}
@@ -3,27 +3,65 @@ package cash_flow_cmd
 import (
 	"errors"
 	"fmt"
+	"strconv"
 
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
+var (
+	updateFlowType  string
+	updateRemark    string
+	updateClearList []string
+)
+
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "update existing cash_flow by id",
 	Long: `Update an existing cash flow record by its ID.
-You can update amount, category, date, and description.`,
+Only the given flags are changed, so an explicit zero amount or empty description is kept.
+You can update amount, category, date, flow type, description and remark,
+and --clear removes the description or remark.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if plainId == "" {
 			return errors.New("id is required for update operation")
 		}
 
+		// Only the flags given on the command line go into the patch
+		patch := model.MergePatch{}
+		if cmd.Flags().Changed("date") {
+			patch.Set("belongs_date", belongsDate)
+		}
+		if cmd.Flags().Changed("category") {
+			patch.Set("category_name", categoryName)
+		}
+		if cmd.Flags().Changed("amount") {
+			patch.Set("amount", strconv.FormatFloat(amount, 'f', -1, 64))
+		}
+		if cmd.Flags().Changed("flow-type") {
+			patch.Set("flow_type", updateFlowType)
+		}
+		if cmd.Flags().Changed("description") {
+			patch.Set("description", descriptionExact)
+		}
+		if cmd.Flags().Changed("remark") {
+			patch.Set("remark", updateRemark)
+		}
+		for _, field := range updateClearList {
+			if patch.Has(field) {
+				return errors.New(field + " cannot be set and cleared at once")
+			}
+			patch.Clear(field)
+		}
+
 		// Check if at least one field to update is provided
-		if amount == 0 && categoryName == "" && belongsDate == "" && descriptionExact == "" {
-			return errors.New("at least one field to update must be provided (amount, category, date, or description)")
+		if len(patch) == 0 {
+			return errors.New("at least one field to update must be provided (amount, category, date, flow-type, description, remark or clear)")
 		}
 
-		cashFlowEntity, err := cash_flow_service.UpdateById(plainId, belongsDate, categoryName, amount, descriptionExact)
+		cashFlowEntity, err := cash_flow_service.PatchById(plainId, patch, model.AnyVersion, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
 		if err != nil {
 			return err
 		}
@@ -41,9 +79,15 @@ func init() {
 	updateCmd.Flags().StringVarP(
 		&categoryName, "category", "c", "", "new category name (optional)")
 	updateCmd.Flags().Float64VarP(
-		&amount, "amount", "a", 0.00, "new amount (optional)")
+		&amount, "amount", "a", 0.00, "new amount, zero allowed (optional)")
+	updateCmd.Flags().StringVarP(
+		&updateFlowType, "flow-type", "f", "", "new flow type, INCOME or OUTCOME (optional)")
 	updateCmd.Flags().StringVarP(
 		&descriptionExact, "description", "d", "", "new description (optional)")
+	updateCmd.Flags().StringVarP(
+		&updateRemark, "remark", "r", "", "new remark (optional)")
+	updateCmd.Flags().StringSliceVar(
+		&updateClearList, "clear", nil, "fields to clear: description, remark (optional)")
 
 	updateCmd.MarkFlagRequired("id")
 	CashCmd.AddCommand(updateCmd)
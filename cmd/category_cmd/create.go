@@ -1,7 +1,9 @@
 package category_cmd
 
 import (
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/category_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
@@ -9,7 +11,7 @@ var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "create new category",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		_, err := category_service.CreateService(parentPlainId, categoryName, categoryType)
+		_, err := category_service.CreateService(parentPlainId, categoryName, categoryType, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
 		return err
 	},
 }
@@ -1,7 +1,9 @@
 package category_cmd
 
 import (
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/category_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
@@ -9,7 +11,7 @@ var deleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "delete category data",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return category_service.DeleteService(plainId, categoryName)
+		return category_service.DeleteService(plainId, categoryName, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
 	},
 }
 
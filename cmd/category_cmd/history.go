@@ -0,0 +1,40 @@
+package category_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/category_service"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "show the change history of a category",
+	Long: `Show every revision of a category, oldest first, with the fields each one changed.
+Use 'category revert' with a revision id to go back to it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		revisionList, err := category_service.GetHistory(plainId)
+		if err != nil {
+			return err
+		}
+		if len(revisionList) == 0 {
+			fmt.Println("No history recorded for this category")
+			return nil
+		}
+
+		for index, revision := range revisionList {
+			fmt.Println("revision", index, ":", revision.ToString())
+			for _, change := range revision.Changes {
+				fmt.Printf("    %s: %q -> %q\n", change.Field, change.Before, change.After)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVarP(
+		&plainId, "id", "i", "", "category id (required)")
+	historyCmd.MarkFlagRequired("id")
+	CategoryCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,38 @@
+package category_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/category_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var revisionId string
+
+var revertCmd = &cobra.Command{
+	Use:   "revert",
+	Short: "revert a category to a prior revision",
+	Long: `Set a category back to how it was right after the given revision.
+The revert is recorded in the history, so it can be reverted in turn.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		categoryEntity, err := category_service.RevertToRevision(plainId, revisionId,
+			model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
+		if err != nil {
+			return err
+		}
+		fmt.Println("category ", 0, ": ", categoryEntity.ToString())
+		return nil
+	},
+}
+
+func init() {
+	revertCmd.Flags().StringVarP(
+		&plainId, "id", "i", "", "category id (required)")
+	revertCmd.Flags().StringVarP(
+		&revisionId, "revision", "r", "", "revision id from 'category history' (required)")
+	revertCmd.MarkFlagRequired("id")
+	revertCmd.MarkFlagRequired("revision")
+	CategoryCmd.AddCommand(revertCmd)
+}
@@ -23,7 +23,9 @@ Available sub-commands:
   update - Update existing category
   delete - Delete category
   query  - Query categories by filters
-  list   - List all categories`,
+  list   - List all categories
+  history - Show the change history of a category
+  revert  - Revert a category to a prior revision`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return errors.New("must provide a valid sub command")
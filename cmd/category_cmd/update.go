@@ -4,25 +4,57 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/category_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
+var (
+	updateRemark    string
+	updateClearList []string
+)
+
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "update existing category",
 	Long: `Update an existing category by its ID.
-You can update the category name, type, and parent.`,
+Only the given flags are changed. You can update the category name, type, parent and remark,
+and --clear removes the parent, making a top level category, or the remark.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if plainId == "" {
 			return errors.New("id is required for update operation")
 		}
 
-		if categoryName == "" && parentPlainId == "" && categoryType == "" {
-			return errors.New("at least one field to update must be provided (name, type, or parent)")
+		// Only the flags given on the command line go into the patch
+		patch := model.MergePatch{}
+		if cmd.Flags().Changed("name") {
+			patch.Set("name", categoryName)
+		}
+		if cmd.Flags().Changed("type") {
+			patch.Set("type", categoryType)
+		}
+		if cmd.Flags().Changed("parent") {
+			patch.Set("parent_id", parentPlainId)
+		}
+		if cmd.Flags().Changed("remark") {
+			patch.Set("remark", updateRemark)
+		}
+		for _, field := range updateClearList {
+			if field == "parent" {
+				field = "parent_id"
+			}
+			if patch.Has(field) {
+				return errors.New(field + " cannot be set and cleared at once")
+			}
+			patch.Clear(field)
+		}
+
+		if len(patch) == 0 {
+			return errors.New("at least one field to update must be provided (name, type, parent, remark or clear)")
 		}
 
-		err := category_service.UpdateService(plainId, parentPlainId, categoryName, categoryType)
+		_, err := category_service.PatchService(plainId, patch, model.AnyVersion, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
 		if err != nil {
 			return err
 		}
@@ -41,6 +73,10 @@ func init() {
 		&categoryType, "type", "t", "", "new category type (optional, must be 'income' or 'expense')")
 	updateCmd.Flags().StringVarP(
 		&parentPlainId, "parent", "p", "", "new parent category id (optional)")
+	updateCmd.Flags().StringVarP(
+		&updateRemark, "remark", "r", "", "new remark (optional)")
+	updateCmd.Flags().StringSliceVar(
+		&updateClearList, "clear", nil, "fields to clear: parent, remark (optional)")
 
 	updateCmd.MarkFlagRequired("id")
 	CategoryCmd.AddCommand(updateCmd)
@@ -4,29 +4,47 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
-var dumpPath string
+var (
+	dumpPath        string
+	dumpCompression string
+	dumpPassphrase  string
+)
 
 var dumpCmd = &cobra.Command{
 	Use:   "dump",
 	Short: "create database dump",
-	Long: `Create a dump of all database data.
-If no path is specified, creates dump in current directory with timestamp.`,
+	Long: `Create a dump of all database data as a streaming NDJSON backup.
+If no path is specified, creates dump in current directory with timestamp.
+The dump is encrypted (AES-256-GCM) when a passphrase is given or BACKUP_PASSPHRASE is set.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if dumpPassphrase == "" {
+			dumpPassphrase = util.GetConfigByKey("backup.passphrase")
+		}
+
 		// Verify ADMIN_TOKEN for dangerous operation
 		if err := util.VerifyAdminToken(adminToken); err != nil {
 			return err
 		}
 
 		if dumpPath == "" {
-			dumpPath = fmt.Sprintf("cashlenx_dump_%s.json", time.Now().Format("20060102_150405"))
+			dumpPath = fmt.Sprintf("cashlenx_dump_%s%s", time.Now().Format("20060102_150405"),
+				manage_service.BackupFileExtension(dumpCompression, dumpPassphrase != ""))
 		}
 
-		stats, err := manage_service.CreateBackup(dumpPath)
+		stats, err := manage_service.CreateBackup(dumpPath, manage_service.BackupOptions{
+			Compression: dumpCompression,
+			Passphrase:  dumpPassphrase,
+		})
+		audit_service.Record(model.AuditOperationDump, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()),
+			map[string]string{"output": dumpPath, "compression": dumpCompression, "passphrase": dumpPassphrase},
+			stats.AffectedCounts(), err)
 		if err != nil {
 			return err
 		}
@@ -41,7 +59,11 @@ If no path is specified, creates dump in current directory with timestamp.`,
 
 func init() {
 	dumpCmd.Flags().StringVarP(
-		&dumpPath, "output", "o", "", "dump file path (optional, default: cashlenx_dump_TIMESTAMP.json)")
+		&dumpPath, "output", "o", "", "dump file path (optional, default: cashlenx_dump_TIMESTAMP.ndjson[.gz|.zst][.enc])")
+	dumpCmd.Flags().StringVarP(
+		&dumpCompression, "compress", "c", util.GetConfigByKey("backup.compression"), "compression: none, gzip or zstd")
+	dumpCmd.Flags().StringVar(
+		&dumpPassphrase, "passphrase", "", "encrypt with this passphrase (default: BACKUP_PASSPHRASE)")
 
 	DbCmd.AddCommand(dumpCmd)
 }
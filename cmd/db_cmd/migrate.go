@@ -0,0 +1,126 @@
+package db_cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
+	"github.com/macar-x/cashlenx-server/service/migration_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var migrateSteps int
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "manage versioned schema migrations",
+	Long: `Apply, revert or inspect the versioned schema migrations shipped with this binary.
+Applied versions are recorded in the schema_migrations table (collection).
+
+Available sub-commands:
+  up     - Apply pending migrations
+  down   - Revert applied migrations
+  status - Show applied and pending migrations`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return errors.New("must provide a valid sub command")
+	},
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "apply pending migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appliedList, err := migration_service.MigrateUp(migrateSteps)
+		recordMigrate("up", len(appliedList), err)
+		for _, migration := range appliedList {
+			fmt.Printf("  applied  %03d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(appliedList) == 0 {
+			fmt.Println("Schema is up to date")
+		} else {
+			fmt.Printf("Applied %d migration(s)\n", len(appliedList))
+		}
+		return nil
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "revert applied migrations",
+	Long: `Revert the most recently applied migrations, newest first.
+Reverting can drop tables or indexes, so the admin token is required.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Verify ADMIN_TOKEN for dangerous operation
+		if err := util.VerifyAdminToken(adminToken); err != nil {
+			return err
+		}
+
+		revertedList, err := migration_service.MigrateDown(migrateSteps)
+		recordMigrate("down", len(revertedList), err)
+		for _, migration := range revertedList {
+			fmt.Printf("  reverted %03d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(revertedList) == 0 {
+			fmt.Println("No applied migrations to revert")
+		} else {
+			fmt.Printf("Reverted %d migration(s)\n", len(revertedList))
+		}
+		return nil
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show migration status",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		statusList, err := migration_service.GetStatus()
+		if err != nil {
+			return err
+		}
+
+		pending := 0
+		fmt.Printf("%-8s %-30s %-8s %s\n", "VERSION", "NAME", "STATUS", "APPLIED AT")
+		for _, status := range statusList {
+			state, appliedAt := "pending", ""
+			if status.Applied {
+				state = "applied"
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			} else {
+				pending++
+			}
+			fmt.Printf("%03d      %-30s %-8s %s\n", status.Version, status.Name, state, appliedAt)
+		}
+		fmt.Printf("\n%d migration(s), %d pending\n", len(statusList), pending)
+		return nil
+	},
+}
+
+// recordMigrate audits a run of up or down; a run that reverts the audit log itself is only logged
+func recordMigrate(direction string, migrationCount int, err error) {
+	audit_service.Record(model.AuditOperationMigrate, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()),
+		map[string]string{"direction": direction, "steps": strconv.Itoa(migrateSteps)},
+		map[string]int{"migrations": migrationCount}, err)
+}
+
+func init() {
+	migrateUpCmd.Flags().IntVarP(
+		&migrateSteps, "steps", "n", 0, "number of migrations to apply (0 = all pending)")
+	migrateDownCmd.Flags().IntVarP(
+		&migrateSteps, "steps", "n", 1, "number of migrations to revert")
+
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+	DbCmd.AddCommand(migrateCmd)
+}
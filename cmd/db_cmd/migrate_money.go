@@ -0,0 +1,42 @@
+package db_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
+	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var migrateMoneyCmd = &cobra.Command{
+	Use:   "migrate-money",
+	Short: "convert stored amounts to exact decimals",
+	Long: `One-off migration that converts existing cash flow amounts to exact decimals.
+MongoDB: legacy double amounts are rewritten as Decimal128.
+MySQL: the AMOUNT column is altered to DECIMAL(19,4).
+Safe to run more than once. Take a backup first.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Verify ADMIN_TOKEN for dangerous operation
+		if err := util.VerifyAdminToken(adminToken); err != nil {
+			return err
+		}
+
+		stats, err := manage_service.ConvertAmountsToDecimal()
+		audit_service.Record(model.AuditOperationMigrateMoney, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()),
+			map[string]string{}, stats.AffectedCounts(), err)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Cash flow amounts converted to exact decimals")
+		fmt.Println("\nStatistics:")
+		fmt.Printf("  Cash Flows: %d success, %d failed\n", stats.CashFlows.Success, stats.CashFlows.Failed)
+		return nil
+	},
+}
+
+func init() {
+	DbCmd.AddCommand(migrateMoneyCmd)
+}
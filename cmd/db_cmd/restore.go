@@ -5,35 +5,55 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
 var (
-	dbRestorePath  string
-	forceDbRestore bool
+	dbRestorePathList   []string
+	forceDbRestore      bool
+	dbRestorePassphrase string
+	dbRestoreMode       string
+	dbRestoreDryRun     bool
 )
 
 var restoreCmd = &cobra.Command{
 	Use:   "restore",
 	Short: "restore database from dump",
 	Long: `Restore database from a dump file.
-WARNING: This will replace all existing data!`,
+WARNING: By default (--mode=replace) this clears all existing data first.
+Use --mode=merge, skip-existing or fail-on-conflict to keep existing rows,
+and --dry-run to see what would change without writing anything.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if dbRestorePassphrase == "" {
+			dbRestorePassphrase = util.GetConfigByKey("backup.passphrase")
+		}
+
 		// Verify ADMIN_TOKEN for dangerous operation
 		if err := util.VerifyAdminToken(adminToken); err != nil {
 			return err
 		}
 
-		if dbRestorePath == "" {
+		if len(dbRestorePathList) == 0 {
 			return errors.New("dump file path is required")
 		}
 
-		if !forceDbRestore {
-			fmt.Println("WARNING: This will replace all existing data!")
+		if err := manage_service.ValidateRestoreMode(dbRestoreMode); err != nil {
+			return err
+		}
+
+		if !forceDbRestore && !dbRestoreDryRun {
+			if dbRestoreMode == "" || dbRestoreMode == manage_service.RestoreModeReplace {
+				fmt.Println("WARNING: This will replace all existing data!")
+			} else {
+				fmt.Printf("WARNING: This will write into the existing data (mode: %s)!\n", dbRestoreMode)
+			}
 			fmt.Print("Are you sure you want to continue? (yes/no): ")
 
 			reader := bufio.NewReader(os.Stdin)
@@ -46,27 +66,66 @@ WARNING: This will replace all existing data!`,
 			}
 		}
 
-		stats, err := manage_service.RestoreBackup(dbRestorePath)
+		result, err := manage_service.RestoreBackupChain(dbRestorePathList, manage_service.RestoreOptions{
+			Passphrase: dbRestorePassphrase,
+			Mode:       dbRestoreMode,
+			DryRun:     dbRestoreDryRun,
+		})
+		audit_service.Record(model.AuditOperationRestore, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()),
+			map[string]string{
+				"files":      strings.Join(dbRestorePathList, ","),
+				"mode":       dbRestoreMode,
+				"dry_run":    strconv.FormatBool(dbRestoreDryRun),
+				"passphrase": dbRestorePassphrase,
+			}, result.AffectedCounts(), err)
 		if err != nil {
 			fmt.Printf("Restore failed: %v\n", err)
 			// Still show statistics even if there's an error
+		} else if dbRestoreDryRun {
+			fmt.Printf("Dry run of restore from: %s (nothing was written)\n", strings.Join(dbRestorePathList, ", "))
 		} else {
-			fmt.Printf("Database restored successfully from: %s\n", dbRestorePath)
+			fmt.Printf("Database restored successfully from: %s\n", strings.Join(dbRestorePathList, ", "))
 		}
-
-		fmt.Println("\nStatistics:")
-		fmt.Printf("  Categories: %d success, %d failed\n", stats.Categories.Success, stats.Categories.Failed)
-		fmt.Printf("  Cash Flows: %d success, %d failed\n", stats.CashFlows.Success, stats.CashFlows.Failed)
+		printRestoreResult(result)
 		return nil
 	},
 }
 
 func init() {
-	restoreCmd.Flags().StringVarP(
-		&dbRestorePath, "input", "i", "", "dump file path (required)")
+	restoreCmd.Flags().StringArrayVarP(
+		&dbRestorePathList, "input", "i", nil,
+		"dump file path (required); repeat to restore a full dump followed by incremental backups, oldest first")
 	restoreCmd.Flags().BoolVarP(
 		&forceDbRestore, "force", "f", false, "skip confirmation prompt")
+	restoreCmd.Flags().StringVar(
+		&dbRestorePassphrase, "passphrase", "", "passphrase of an encrypted dump (default: BACKUP_PASSPHRASE)")
+
+	restoreCmd.Flags().StringVar(
+		&dbRestoreMode, "mode", manage_service.RestoreModeReplace, "replace, merge, skip-existing or fail-on-conflict")
+	restoreCmd.Flags().BoolVar(
+		&dbRestoreDryRun, "dry-run", false, "report what would be inserted, updated or conflict without writing anything")
 
 	restoreCmd.MarkFlagRequired("input")
 	DbCmd.AddCommand(restoreCmd)
 }
+
+// printRestoreResult prints what a restore did, or would do, per entity type
+func printRestoreResult(result manage_service.RestoreResult) {
+	fmt.Printf("\nStatistics (mode: %s):\n", result.Mode)
+	fmt.Printf("  %-11s %9s %8s %10s %8s %8s %8s %10s %7s\n",
+		"", "Inserted", "Updated", "Unchanged", "Skipped", "Deleted", "Cleared", "Conflicts", "Failed")
+	for _, row := range []struct {
+		name  string
+		stats manage_service.RestoreEntityStats
+	}{
+		{"Categories", result.Categories},
+		{"Cash Flows", result.CashFlows},
+	} {
+		fmt.Printf("  %-11s %9d %8d %10d %8d %8d %8d %10d %7d\n", row.name,
+			row.stats.Inserted, row.stats.Updated, row.stats.Unchanged, row.stats.Skipped,
+			row.stats.Deleted, row.stats.Cleared, row.stats.Conflicts, row.stats.Failed)
+	}
+	for _, conflictId := range append(result.Categories.ConflictList, result.CashFlows.ConflictList...) {
+		fmt.Printf("  conflict: %s\n", conflictId)
+	}
+}
@@ -14,11 +14,14 @@ var DbCmd = &cobra.Command{
 	Long: `Database management operations.
 
 Available sub-commands:
-  connect - Test database connection
-  dump    - Create database dump
-  restore - Restore database from dump
-  seed    - Seed database with demo data
-  truncate - Clear all data from database`,
+  connect         - Test database connection
+  dump            - Create database dump
+  restore         - Restore database from dump
+  seed            - Seed database with demo data
+  truncate        - Clear all data from database
+  migrate         - Apply, revert or inspect schema migrations
+  migrate-backend - Copy all data to another storage backend
+  migrate-money   - Convert stored amounts to exact decimals`,
 
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		// adminToken is a shared flag for dangerous operations
@@ -3,7 +3,9 @@ package db_cmd
 import (
 	"fmt"
 
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
@@ -13,7 +15,7 @@ var seedCmd = &cobra.Command{
 	Long: `Seed the database with demo categories and sample transactions.
 This is an alias for 'manage init' command.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		err := manage_service.InitializeDemoData()
+		err := manage_service.InitializeDemoData(model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
 		if err != nil {
 			return err
 		}
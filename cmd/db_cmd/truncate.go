@@ -3,8 +3,11 @@ package db_cmd
 import (
 	"bufio"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
@@ -38,6 +41,8 @@ WARNING: This will delete all cash flows and categories permanently!`,
 		}
 
 		stats, err := manage_service.TruncateDatabase()
+		audit_service.Record(model.AuditOperationTruncate, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()),
+			map[string]string{"force": strconv.FormatBool(forceTruncate)}, stats.AffectedCounts(), err)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,62 @@
+package debt_cmd
+
+import (
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "create a debt",
+	Long: `Create a loan repaid in monthly installments, the first a month after the start date.
+
+Payments are the expenses recorded in the payment category since the start date. As each
+one is recorded its interest moves to the interest category, leaving the principal part;
+--split-existing does the same for the payments already recorded.
+
+Example:
+  cashlenx debt create -n Mortgage -p 200000 -r 3.5 -t 360 -s 2024-05-15 -c Mortgage -i Interest --split-existing
+  cashlenx debt create -n "Car loan" -p 18000 -r 5.9 -t 48 -c "Car loan" -i Interest`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := model.NewMoneyFromString(principal)
+		if err != nil {
+			return err
+		}
+		debtEntity, err := debt_service.CreateService(model.DebtDTO{
+			Name:                 debtName,
+			Principal:            amount,
+			AnnualRate:           annualRate,
+			TermMonths:           termMonths,
+			StartDate:            startDate,
+			PaymentCategoryName:  paymentCategoryName,
+			InterestCategoryName: interestCategoryName,
+			Remark:               remark,
+			SplitExisting:        splitExisting,
+		}, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
+		if err != nil {
+			return err
+		}
+
+		summary, err := debt_service.QueryService(debtEntity.Id.Hex())
+		if err != nil {
+			return err
+		}
+		printSummary(summary)
+		return nil
+	},
+}
+
+func init() {
+	createCmd.Flags().StringVarP(&debtName, "name", "n", "", "debt name (required)")
+	createCmd.Flags().StringVarP(&principal, "principal", "p", "", "amount borrowed (required)")
+	createCmd.Flags().Float64VarP(&annualRate, "rate", "r", 0, "annual interest rate in percent")
+	createCmd.Flags().IntVarP(&termMonths, "term", "t", 0, "term in months (required)")
+	createCmd.Flags().StringVarP(&startDate, "start", "s", "", "day the money was borrowed (optional, blank for today)")
+	createCmd.Flags().StringVarP(&paymentCategoryName, "category", "c", "", "category holding the payments (required)")
+	createCmd.Flags().StringVarP(&interestCategoryName, "interest-category", "i", "", "category the interest moves to (required)")
+	createCmd.Flags().StringVar(&remark, "remark", "", "remark (optional)")
+	createCmd.Flags().BoolVar(&splitExisting, "split-existing", false, "also split the payments recorded since the start date")
+	DebtCmd.AddCommand(createCmd)
+}
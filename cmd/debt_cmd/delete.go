@@ -0,0 +1,27 @@
+package debt_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "delete a debt",
+	Long:  "Delete a debt; its payments and the interest split from them stay",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		debtEntity, err := debt_service.DeleteService(plainId)
+		if err != nil {
+			return err
+		}
+		fmt.Println("debt deleted: ", debtEntity.ToString())
+		return nil
+	},
+}
+
+func init() {
+	deleteCmd.Flags().StringVar(&plainId, "id", "", "debt id (required)")
+	DebtCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,61 @@
+package debt_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "show a debt with its amortization table",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		summary, err := debt_service.QueryService(plainId)
+		if err != nil {
+			return err
+		}
+		printSummary(summary)
+
+		fmt.Printf("\n%5s %-10s %12s %12s %12s %14s %s\n", "#", "Date", "Payment", "Principal", "Interest", "Balance", "")
+		for _, row := range summary.Schedule {
+			state := ""
+			if row.Paid {
+				state = "paid"
+			}
+			fmt.Printf("%5d %-10s %12s %12s %12s %14s %s\n",
+				row.Number, row.Date, row.Payment, row.Principal, row.Interest, row.Balance, state)
+		}
+		return nil
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list all debts with their balance",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		summaryList, err := debt_service.ListService()
+		if err != nil {
+			return err
+		}
+		if len(summaryList) == 0 {
+			fmt.Println("No debts found")
+			return nil
+		}
+
+		fmt.Printf("%-24s %-24s %14s %14s %12s %-10s %-10s\n",
+			"ID", "Name", "Principal", "Balance", "Monthly", "Next", "Payoff")
+		for _, summary := range summaryList {
+			fmt.Printf("%-24s %-24s %14s %14s %12s %-10s %-10s\n",
+				summary.Debt.Id.Hex(), summary.Debt.Name, summary.Debt.Principal, summary.Balance,
+				summary.MonthlyPayment, summary.NextPaymentDate, summary.PayoffDate)
+		}
+		return nil
+	},
+}
+
+func init() {
+	queryCmd.Flags().StringVar(&plainId, "id", "", "debt id (required)")
+	DebtCmd.AddCommand(queryCmd)
+	DebtCmd.AddCommand(listCmd)
+}
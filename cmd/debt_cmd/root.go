@@ -0,0 +1,60 @@
+package debt_cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/spf13/cobra"
+)
+
+var (
+	plainId              string
+	debtName             string
+	principal            string
+	annualRate           float64
+	termMonths           int
+	startDate            string
+	paymentCategoryName  string
+	interestCategoryName string
+	remark               string
+	splitExisting        bool
+)
+
+var DebtCmd = &cobra.Command{
+	Use:   "debt",
+	Short: "manage loans and debts",
+	Long: `Manage loans repaid in monthly installments and follow their amortization.
+
+Available sub-commands:
+  create - Create a debt
+  update - Update a debt
+  delete - Delete a debt
+  query  - Show a debt with its amortization table
+  list   - List all debts with their balance`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return errors.New("must provide a valid sub command")
+	},
+}
+
+// printSummary shows a debt, what was repaid and when the rest will be
+func printSummary(summary model.DebtSummary) {
+	debtEntity := summary.Debt
+	fmt.Printf("%s (%s)\n", debtEntity.Name, debtEntity.Id.Hex())
+	fmt.Printf("  Loan:      %s at %s%% over %d months from %s, %s a month\n", debtEntity.Principal,
+		strconv.FormatFloat(debtEntity.AnnualRate, 'f', -1, 64), debtEntity.TermMonths,
+		debtEntity.StartDate.Format("2006-01-02"), summary.MonthlyPayment)
+	fmt.Printf("  Payments:  %s, interest to %s\n", summary.PaymentCategory, summary.InterestCategory)
+	fmt.Printf("  Repaid:    %s principal and %s interest in %d payments\n",
+		summary.PrincipalPaid, summary.InterestPaid, summary.PaymentsMade)
+	if summary.PaidOff {
+		fmt.Printf("  Paid off:  %s\n", summary.PayoffDate)
+		return
+	}
+	fmt.Printf("  Balance:   %s, %d payments and %s interest left\n",
+		summary.Balance, summary.RemainingPayments, summary.RemainingInterest)
+	fmt.Printf("  Payoff:    %s (scheduled %s), next payment %s\n",
+		summary.PayoffDate, summary.ScheduledPayoffDate, summary.NextPaymentDate)
+}
@@ -0,0 +1,84 @@
+package debt_cmd
+
+import (
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "update a debt",
+	Long: `Update a debt; flags left out keep their value. Payments split before keep their interest.
+
+Example:
+  cashlenx debt update --id 65a1b2c3d4e5f6a7b8c9d0e1 -r 3.1`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		summary, err := debt_service.QueryService(plainId)
+		if err != nil {
+			return err
+		}
+
+		debtEntity := summary.Debt
+		debtDTO := model.DebtDTO{
+			Name:               debtEntity.Name,
+			Principal:          debtEntity.Principal,
+			AnnualRate:         debtEntity.AnnualRate,
+			TermMonths:         debtEntity.TermMonths,
+			StartDate:          debtEntity.StartDate.Format("2006-01-02"),
+			PaymentCategoryId:  debtEntity.PaymentCategoryId.Hex(),
+			InterestCategoryId: debtEntity.InterestCategoryId.Hex(),
+			Remark:             debtEntity.Remark,
+		}
+		flags := cmd.Flags()
+		if flags.Changed("name") {
+			debtDTO.Name = debtName
+		}
+		if flags.Changed("principal") {
+			if debtDTO.Principal, err = model.NewMoneyFromString(principal); err != nil {
+				return err
+			}
+		}
+		if flags.Changed("rate") {
+			debtDTO.AnnualRate = annualRate
+		}
+		if flags.Changed("term") {
+			debtDTO.TermMonths = termMonths
+		}
+		if flags.Changed("start") {
+			debtDTO.StartDate = startDate
+		}
+		if flags.Changed("category") {
+			debtDTO.PaymentCategoryId, debtDTO.PaymentCategoryName = "", paymentCategoryName
+		}
+		if flags.Changed("interest-category") {
+			debtDTO.InterestCategoryId, debtDTO.InterestCategoryName = "", interestCategoryName
+		}
+		if flags.Changed("remark") {
+			debtDTO.Remark = remark
+		}
+
+		// The version read above keeps a concurrent change from being overwritten
+		if _, err := debt_service.UpdateService(plainId, debtDTO, debtEntity.Version); err != nil {
+			return err
+		}
+		if summary, err = debt_service.QueryService(plainId); err != nil {
+			return err
+		}
+		printSummary(summary)
+		return nil
+	},
+}
+
+func init() {
+	updateCmd.Flags().StringVar(&plainId, "id", "", "debt id (required)")
+	updateCmd.Flags().StringVarP(&debtName, "name", "n", "", "debt name")
+	updateCmd.Flags().StringVarP(&principal, "principal", "p", "", "amount borrowed")
+	updateCmd.Flags().Float64VarP(&annualRate, "rate", "r", 0, "annual interest rate in percent")
+	updateCmd.Flags().IntVarP(&termMonths, "term", "t", 0, "term in months")
+	updateCmd.Flags().StringVarP(&startDate, "start", "s", "", "day the money was borrowed")
+	updateCmd.Flags().StringVarP(&paymentCategoryName, "category", "c", "", "category holding the payments")
+	updateCmd.Flags().StringVarP(&interestCategoryName, "interest-category", "i", "", "category the interest moves to")
+	updateCmd.Flags().StringVar(&remark, "remark", "", "remark")
+	DebtCmd.AddCommand(updateCmd)
+}
@@ -0,0 +1,56 @@
+package goal_cmd
+
+import (
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/goal_service"
+	"github.com/spf13/cobra"
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "create a savings goal",
+	Long: `Create a savings goal with a target amount and a deadline.
+
+Progress counts the cash flows of the linked category and the categories below
+it since the start date: income for an income category, expense for an expense
+category, the other type taking away. Without a category the balance of every
+cash flow counts.
+
+Example:
+  cashlenx goal create -n "Emergency fund" -a 10000 -d 2027-06
+  cashlenx goal create -n Holiday -a 2500 -d 2027-07-15 -c Savings -s 2026-09`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := model.NewMoneyFromString(targetAmount)
+		if err != nil {
+			return err
+		}
+		goalEntity, err := goal_service.CreateService(model.GoalDTO{
+			Name:         goalName,
+			TargetAmount: amount,
+			StartDate:    startDate,
+			Deadline:     deadline,
+			CategoryName: categoryName,
+			Remark:       remark,
+		})
+		if err != nil {
+			return err
+		}
+
+		progress, err := goal_service.QueryService(goalEntity.Id.Hex())
+		if err != nil {
+			return err
+		}
+		printProgress(progress)
+		return nil
+	},
+}
+
+func init() {
+	createCmd.Flags().StringVarP(&goalName, "name", "n", "", "goal name (required)")
+	createCmd.Flags().StringVarP(&targetAmount, "amount", "a", "", "target amount (required)")
+	createCmd.Flags().StringVarP(&deadline, "deadline", "d", "", "deadline, a day, month or year (required)")
+	createCmd.Flags().StringVarP(&startDate, "start", "s", "", "first day counted (optional, blank for today)")
+	createCmd.Flags().StringVarP(&categoryName, "category", "c", "", "linked category name (optional, blank for all cash flows)")
+	createCmd.Flags().StringVarP(&remark, "remark", "r", "", "remark (optional)")
+	GoalCmd.AddCommand(createCmd)
+}
@@ -0,0 +1,26 @@
+package goal_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/goal_service"
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "delete a savings goal",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		goalEntity, err := goal_service.DeleteService(plainId)
+		if err != nil {
+			return err
+		}
+		fmt.Println("goal deleted: ", goalEntity.ToString())
+		return nil
+	},
+}
+
+func init() {
+	deleteCmd.Flags().StringVarP(&plainId, "id", "i", "", "goal id (required)")
+	GoalCmd.AddCommand(deleteCmd)
+}
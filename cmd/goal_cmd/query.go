@@ -0,0 +1,51 @@
+package goal_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/goal_service"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "show a savings goal with its progress",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		progress, err := goal_service.QueryService(plainId)
+		if err != nil {
+			return err
+		}
+		printProgress(progress)
+		return nil
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list all savings goals with their progress",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		progressList, err := goal_service.ListService()
+		if err != nil {
+			return err
+		}
+		if len(progressList) == 0 {
+			fmt.Println("No goals found")
+			return nil
+		}
+
+		fmt.Printf("%-24s %-24s %12s %12s %7s %12s %-10s %s\n",
+			"ID", "Name", "Target", "Saved", "%", "Monthly", "Deadline", "Status")
+		for _, progress := range progressList {
+			fmt.Printf("%-24s %-24s %12s %12s %6.1f%% %12s %-10s %s\n",
+				progress.Goal.Id.Hex(), progress.Goal.Name, progress.Goal.TargetAmount, progress.Saved,
+				progress.Percent, progress.RequiredMonthly, progress.Goal.Deadline.Format("2006-01-02"), progress.Status)
+		}
+		return nil
+	},
+}
+
+func init() {
+	queryCmd.Flags().StringVarP(&plainId, "id", "i", "", "goal id (required)")
+	GoalCmd.AddCommand(queryCmd)
+	GoalCmd.AddCommand(listCmd)
+}
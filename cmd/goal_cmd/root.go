@@ -0,0 +1,53 @@
+package goal_cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/spf13/cobra"
+)
+
+var (
+	plainId      string
+	goalName     string
+	targetAmount string
+	startDate    string
+	deadline     string
+	categoryName string
+	remark       string
+)
+
+var GoalCmd = &cobra.Command{
+	Use:   "goal",
+	Short: "manage savings goals",
+	Long: `Manage savings goals and follow their progress.
+
+Available sub-commands:
+  create - Create a goal
+  update - Update a goal
+  delete - Delete a goal
+  query  - Show a goal with its progress
+  list   - List all goals with their progress`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return errors.New("must provide a valid sub command")
+	},
+}
+
+// printProgress shows a goal, what was saved towards it and what it still takes
+func printProgress(progress model.GoalProgress) {
+	fmt.Printf("%s (%s)\n", progress.Goal.Name, progress.Goal.Id.Hex())
+	linked := "all cash flows"
+	if progress.Category != "" {
+		linked = "category " + progress.Category
+	} else if !progress.Goal.CategoryId.IsZero() {
+		linked = "deleted category " + progress.Goal.CategoryId.Hex()
+	}
+	fmt.Printf("  Target:    %s by %s, from %s, %s\n", progress.Goal.TargetAmount,
+		progress.Goal.Deadline.Format("2006-01-02"), progress.Goal.StartDate.Format("2006-01-02"), linked)
+	fmt.Printf("  Saved:     %s (%.1f%%), %s to go\n", progress.Saved, progress.Percent, progress.Remaining)
+	fmt.Printf("  Monthly:   %s needed over %d months, %s saved on average\n",
+		progress.RequiredMonthly, progress.MonthsLeft, progress.AverageMonthly)
+	fmt.Printf("  Status:    %s as of %s\n", progress.Status, progress.AsOf)
+}
@@ -0,0 +1,77 @@
+package goal_cmd
+
+import (
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/goal_service"
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "update a savings goal",
+	Long: `Update a savings goal; flags left out keep their value.
+
+Example:
+  cashlenx goal update -i 65a1b2c3d4e5f6a7b8c9d0e1 -a 12000 -d 2027-09`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		progress, err := goal_service.QueryService(plainId)
+		if err != nil {
+			return err
+		}
+
+		goalEntity := progress.Goal
+		goalDTO := model.GoalDTO{
+			Name:         goalEntity.Name,
+			TargetAmount: goalEntity.TargetAmount,
+			StartDate:    goalEntity.StartDate.Format("2006-01-02"),
+			Deadline:     goalEntity.Deadline.Format("2006-01-02"),
+			Remark:       goalEntity.Remark,
+		}
+		if !goalEntity.CategoryId.IsZero() {
+			goalDTO.CategoryId = goalEntity.CategoryId.Hex()
+		}
+		flags := cmd.Flags()
+		if flags.Changed("name") {
+			goalDTO.Name = goalName
+		}
+		if flags.Changed("amount") {
+			if goalDTO.TargetAmount, err = model.NewMoneyFromString(targetAmount); err != nil {
+				return err
+			}
+		}
+		if flags.Changed("start") {
+			goalDTO.StartDate = startDate
+		}
+		if flags.Changed("deadline") {
+			goalDTO.Deadline = deadline
+		}
+		if flags.Changed("category") {
+			// An empty name links no category
+			goalDTO.CategoryId, goalDTO.CategoryName = "", categoryName
+		}
+		if flags.Changed("remark") {
+			goalDTO.Remark = remark
+		}
+
+		// The version read above keeps a concurrent change from being overwritten
+		if _, err := goal_service.UpdateService(plainId, goalDTO, goalEntity.Version); err != nil {
+			return err
+		}
+		if progress, err = goal_service.QueryService(plainId); err != nil {
+			return err
+		}
+		printProgress(progress)
+		return nil
+	},
+}
+
+func init() {
+	updateCmd.Flags().StringVarP(&plainId, "id", "i", "", "goal id (required)")
+	updateCmd.Flags().StringVarP(&goalName, "name", "n", "", "goal name")
+	updateCmd.Flags().StringVarP(&targetAmount, "amount", "a", "", "target amount")
+	updateCmd.Flags().StringVarP(&deadline, "deadline", "d", "", "deadline, a day, month or year")
+	updateCmd.Flags().StringVarP(&startDate, "start", "s", "", "first day counted")
+	updateCmd.Flags().StringVarP(&categoryName, "category", "c", "", "linked category name, empty for all cash flows")
+	updateCmd.Flags().StringVarP(&remark, "remark", "r", "", "remark")
+	GoalCmd.AddCommand(updateCmd)
+}
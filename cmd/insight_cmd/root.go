@@ -0,0 +1,20 @@
+package insight_cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+var InsightCmd = &cobra.Command{
+	Use:   "insights",
+	Short: "insights found in cash flows",
+	Long: `Find patterns in cash flows.
+
+Available sub-commands:
+  subscriptions - Expenses that come back weekly, monthly or yearly`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return errors.New("must provide a valid sub command")
+	},
+}
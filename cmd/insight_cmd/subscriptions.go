@@ -0,0 +1,55 @@
+package insight_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/insight_service"
+	"github.com/spf13/cobra"
+)
+
+var subscriptionsAll bool
+
+var subscriptionsCmd = &cobra.Command{
+	Use:   "subscriptions",
+	Short: "list recurring charges found in the history",
+	Long: `List the expenses that come back weekly, monthly or yearly with a similar
+description and amount, with their average and latest amount, the last and the
+next expected charge and every price change. Subscriptions whose next charge is
+overdue have ended and are only listed with --all.
+
+Example:
+  cashlenx insights subscriptions
+  cashlenx insights subscriptions --all`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report := insight_service.DiscoverSubscriptions(subscriptionsAll)
+		if len(report.Items) == 0 {
+			fmt.Println("No subscriptions found")
+			return nil
+		}
+
+		fmt.Printf("%-30s %-8s %10s %10s %12s %12s %s\n",
+			"Description", "Cadence", "Average", "Latest", "Last", "Next", "Category")
+		for _, subscription := range report.Items {
+			next := subscription.NextDate
+			if !subscription.Active {
+				next = "ended"
+			}
+			fmt.Printf("%-30s %-8s %10s %10s %12s %12s %s\n", subscription.Description, subscription.Cadence,
+				subscription.AverageAmount, subscription.LatestAmount, subscription.LastDate, next, subscription.Category)
+			for _, change := range subscription.PriceChanges {
+				percent := ""
+				if change.Percent != nil {
+					percent = fmt.Sprintf(" (%+.1f%%)", *change.Percent)
+				}
+				fmt.Printf("    %s: %s -> %s%s\n", change.Date, change.From, change.To, percent)
+			}
+		}
+		fmt.Printf("\nActive subscriptions cost %s a month as of %s\n", report.MonthlyCost, report.AsOf)
+		return nil
+	},
+}
+
+func init() {
+	subscriptionsCmd.Flags().BoolVarP(&subscriptionsAll, "all", "a", false, "include subscriptions that ended")
+	InsightCmd.AddCommand(subscriptionsCmd)
+}
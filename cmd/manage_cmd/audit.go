@@ -0,0 +1,87 @@
+package manage_cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/macar-x/cashlenx-server/service/audit_service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditOperation string
+	auditActor     string
+	auditOutcome   string
+	auditLimit     int
+	auditOffset    int
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "show the audit log of administrative operations",
+	Long: `Show dumps, backups, restores, imports, resets, truncates, trash purges, migrations
+and backup prunes, newest first, with who ran them, their parameters, the rows they touched
+and whether they succeeded.
+Secret parameters such as passphrases are stored redacted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := audit_service.ParseAuditFilter(auditOperation, auditActor, auditOutcome, fromDate, toDate)
+		if err != nil {
+			return err
+		}
+
+		auditList, totalCount, err := audit_service.QueryAuditLog(filter, auditLimit, auditOffset)
+		if err != nil {
+			return err
+		}
+		if len(auditList) == 0 {
+			fmt.Println("No audit entries found")
+			return nil
+		}
+
+		for _, entity := range auditList {
+			fmt.Println(entity.ToString())
+			for _, key := range sortedKeys(entity.Parameters) {
+				fmt.Printf("    %s: %q\n", key, entity.Parameters[key])
+			}
+			affectedKeys := make([]string, 0, len(entity.Affected))
+			for key := range entity.Affected {
+				affectedKeys = append(affectedKeys, key)
+			}
+			sort.Strings(affectedKeys)
+			for _, key := range affectedKeys {
+				fmt.Printf("    affected %s: %d\n", key, entity.Affected[key])
+			}
+		}
+		fmt.Printf("\nShowing %d of %d entries\n", len(auditList), totalCount)
+		return nil
+	},
+}
+
+// sortedKeys returns the keys of the parameters in a stable order for display
+func sortedKeys(parameters map[string]string) []string {
+	keys := make([]string, 0, len(parameters))
+	for key := range parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	auditCmd.Flags().StringVar(
+		&auditOperation, "operation", "", "only this operation (dump, backup, restore, import, reset, truncate, trash_purge, migrate, migrate_backend, migrate_money, backup_prune)")
+	auditCmd.Flags().StringVar(
+		&auditActor, "actor", "", "only operations run by this actor")
+	auditCmd.Flags().StringVar(
+		&auditOutcome, "outcome", "", "only this outcome (success or failure)")
+	auditCmd.Flags().StringVar(
+		&fromDate, "from", "", "first day to show (YYYY-MM-DD)")
+	auditCmd.Flags().StringVar(
+		&toDate, "to", "", "last day to show (YYYY-MM-DD)")
+	auditCmd.Flags().IntVarP(
+		&auditLimit, "limit", "l", 20, "number of entries to show")
+	auditCmd.Flags().IntVarP(
+		&auditOffset, "offset", "o", 0, "number of entries to skip")
+
+	ManageCmd.AddCommand(auditCmd)
+}
@@ -4,23 +4,54 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
-var backupPath string
+var (
+	backupPath        string
+	backupCompression string
+	backupPassphrase  string
+	backupBase        string
+)
 
 var backupCmd = &cobra.Command{
 	Use:   "backup",
 	Short: "create database backup",
-	Long: `Create a backup of all database data.
-If no path is specified, creates backup in current directory with timestamp.`,
+	Long: `Create a backup of all database data as a streaming NDJSON file.
+If no path is specified, creates backup in current directory with timestamp.
+The backup is encrypted (AES-256-GCM) when a passphrase is given or BACKUP_PASSPHRASE is set.
+With --base, only rows modified or deleted since that backup was taken are written (incremental).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if backupPassphrase == "" {
+			backupPassphrase = util.GetConfigByKey("backup.passphrase")
+		}
+
+		options := manage_service.BackupOptions{
+			Compression: backupCompression,
+			Passphrase:  backupPassphrase,
+		}
+		prefix := "backup"
+		if backupBase != "" {
+			prefix = "incremental"
+		}
 		if backupPath == "" {
-			backupPath = fmt.Sprintf("cashlenx_backup_%s.json", time.Now().Format("20060102_150405"))
+			backupPath = manage_service.BackupFileName(prefix, time.Now(), options)
 		}
 
-		stats, err := manage_service.CreateBackup(backupPath)
+		var stats manage_service.OperationStats
+		var err error
+		if backupBase != "" {
+			stats, err = manage_service.CreateIncrementalBackup(backupPath, backupBase, options)
+		} else {
+			stats, err = manage_service.CreateBackup(backupPath, options)
+		}
+		audit_service.Record(model.AuditOperationBackup, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()),
+			map[string]string{"output": backupPath, "base": backupBase, "compression": backupCompression, "passphrase": backupPassphrase},
+			stats.AffectedCounts(), err)
 		if err != nil {
 			return err
 		}
@@ -29,13 +60,24 @@ If no path is specified, creates backup in current directory with timestamp.`,
 		fmt.Println("\nStatistics:")
 		fmt.Printf("  Categories: %d success, %d failed\n", stats.Categories.Success, stats.Categories.Failed)
 		fmt.Printf("  Cash Flows: %d success, %d failed\n", stats.CashFlows.Success, stats.CashFlows.Failed)
+		fmt.Printf("  Goals:      %d success, %d failed\n", stats.Goals.Success, stats.Goals.Failed)
+		fmt.Printf("  Debts:      %d success, %d failed\n", stats.Debts.Success, stats.Debts.Failed)
+		if backupBase != "" {
+			fmt.Printf("  Deletions:  %d\n", stats.Tombstones.Success)
+		}
 		return nil
 	},
 }
 
 func init() {
 	backupCmd.Flags().StringVarP(
-		&backupPath, "output", "o", "", "backup file path (optional, default: cashlenx_backup_TIMESTAMP.json)")
+		&backupPath, "output", "o", "", "backup file path (optional, default: cashlenx_<backup|incremental>_TIMESTAMP.ndjson[.gz|.zst][.enc])")
+	backupCmd.Flags().StringVarP(
+		&backupCompression, "compress", "c", util.GetConfigByKey("backup.compression"), "compression: none, gzip or zstd")
+	backupCmd.Flags().StringVar(
+		&backupPassphrase, "passphrase", "", "encrypt with this passphrase (default: BACKUP_PASSPHRASE)")
+	backupCmd.Flags().StringVar(
+		&backupBase, "base", "", "take an incremental backup on top of this full or incremental backup")
 
 	ManageCmd.AddCommand(backupCmd)
 }
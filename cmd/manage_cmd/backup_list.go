@@ -0,0 +1,62 @@
+package manage_cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var listBackupDir string
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list backups in the backup directory",
+	Long: `List the timestamped backups in the backup directory, newest first.
+The last column shows which retention tier (daily, weekly, monthly) keeps each backup,
+or "incremental" for the base of an incremental backup; the rest are removed by the next prune.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backups, err := manage_service.ListBackups(listBackupDir)
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			fmt.Printf("No backups found in %s\n", listBackupDir)
+			return nil
+		}
+
+		policy := manage_service.DefaultRetentionPolicy()
+		keep, _, err := manage_service.PruneBackups(listBackupDir, policy, util.GetConfigByKey("backup.passphrase"), true)
+		if err != nil {
+			// Without the incremental backups' manifests, show the retention tiers alone
+			util.Logger.Warnw("cannot plan the next prune", "dir", listBackupDir, "error", err)
+			keep, _ = manage_service.PlanRetention(backups, policy)
+		}
+		keptBy := map[string][]string{}
+		for _, backup := range keep {
+			keptBy[backup.Name] = backup.KeptBy
+		}
+
+		fmt.Printf("Backups in %s:\n\n", listBackupDir)
+		fmt.Printf("%-50s %12s  %-19s  %s\n", "NAME", "SIZE", "CREATED", "KEPT BY")
+		for _, backup := range backups {
+			tiers := strings.Join(keptBy[backup.Name], ",")
+			if tiers == "" {
+				tiers = "(prune)"
+			}
+			fmt.Printf("%-50s %12d  %-19s  %s\n",
+				backup.Name, backup.Size, backup.CreatedAt.Format("2006-01-02 15:04:05"), tiers)
+		}
+		fmt.Printf("\nTotal: %d backups\n", len(backups))
+		return nil
+	},
+}
+
+func init() {
+	backupListCmd.Flags().StringVarP(
+		&listBackupDir, "dir", "d", util.GetConfigByKey("backup.dir"), "backup directory (BACKUP_DIR)")
+
+	backupCmd.AddCommand(backupListCmd)
+}
@@ -0,0 +1,96 @@
+package manage_cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
+	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneBackupDir  string
+	prunePassphrase string
+	pruneDryRun     bool
+	forcePrune      bool
+	pruneRetention  manage_service.RetentionPolicy
+)
+
+var backupPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "delete backups outside the retention policy",
+	Long: `Delete backups that the grandfather-father-son retention policy does not keep.
+The newest backup of each of the last N days, M weeks and K months is kept,
+and the newest backup overall is never deleted. Incremental backups are never deleted,
+and neither is any backup they build on.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if prunePassphrase == "" {
+			prunePassphrase = util.GetConfigByKey("backup.passphrase")
+		}
+		keep, prune, err := manage_service.PruneBackups(pruneBackupDir, pruneRetention, prunePassphrase, true)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Retention: %d daily, %d weekly, %d monthly\n",
+			pruneRetention.Daily, pruneRetention.Weekly, pruneRetention.Monthly)
+		fmt.Printf("Keeping %d backups, pruning %d\n", len(keep), len(prune))
+		for _, backup := range prune {
+			fmt.Printf("  - %s\n", backup.Name)
+		}
+		if len(prune) == 0 || pruneDryRun {
+			return nil
+		}
+
+		if !forcePrune {
+			fmt.Print("Delete these backups? (yes/no): ")
+
+			reader := bufio.NewReader(os.Stdin)
+			response, _ := reader.ReadString('\n')
+			response = strings.TrimSpace(strings.ToLower(response))
+
+			if response != "yes" && response != "y" {
+				fmt.Println("Prune cancelled")
+				return nil
+			}
+		}
+
+		_, pruned, err := manage_service.PruneBackups(pruneBackupDir, pruneRetention, prunePassphrase, false)
+		parameters := pruneRetention.AuditParameters(pruneBackupDir)
+		parameters["force"] = strconv.FormatBool(forcePrune)
+		audit_service.Record(model.AuditOperationBackupPrune, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()),
+			parameters, map[string]int{"backups": len(pruned)}, err)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Pruned %d backups\n", len(pruned))
+		return nil
+	},
+}
+
+func init() {
+	defaultRetention := manage_service.DefaultRetentionPolicy()
+
+	backupPruneCmd.Flags().StringVarP(
+		&pruneBackupDir, "dir", "d", util.GetConfigByKey("backup.dir"), "backup directory (BACKUP_DIR)")
+	backupPruneCmd.Flags().IntVar(
+		&pruneRetention.Daily, "daily", defaultRetention.Daily, "daily backups to keep (BACKUP_KEEP_DAILY)")
+	backupPruneCmd.Flags().IntVar(
+		&pruneRetention.Weekly, "weekly", defaultRetention.Weekly, "weekly backups to keep (BACKUP_KEEP_WEEKLY)")
+	backupPruneCmd.Flags().IntVar(
+		&pruneRetention.Monthly, "monthly", defaultRetention.Monthly, "monthly backups to keep (BACKUP_KEEP_MONTHLY)")
+	backupPruneCmd.Flags().StringVar(
+		&prunePassphrase, "passphrase", "", "passphrase of encrypted backups (default: BACKUP_PASSPHRASE)")
+	backupPruneCmd.Flags().BoolVar(
+		&pruneDryRun, "dry-run", false, "show what would be deleted without deleting")
+	backupPruneCmd.Flags().BoolVarP(
+		&forcePrune, "force", "f", false, "skip confirmation prompt")
+
+	backupCmd.AddCommand(backupPruneCmd)
+}
@@ -0,0 +1,79 @@
+package manage_cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyPath       string
+	verifyPassphrase string
+)
+
+var backupVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "validate a backup file",
+	Long: `Validate a backup file without touching the database.
+Checks the manifest, per-section counts and checksums, ids and parent/category references.
+Older backup versions are upgraded in memory and checked as they would be restored.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if verifyPassphrase == "" {
+			verifyPassphrase = util.GetConfigByKey("backup.passphrase")
+		}
+
+		report, err := manage_service.VerifyBackup(verifyPath, verifyPassphrase)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Backup: %s\n", verifyPath)
+		fmt.Printf("  Format version: %d", report.Version)
+		if report.UpgradedFrom != 0 {
+			fmt.Printf(" (upgraded from version %d)", report.UpgradedFrom)
+		}
+		fmt.Println()
+		if !report.CreatedAt.IsZero() {
+			fmt.Printf("  Created at: %s\n", report.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+		}
+		fmt.Printf("  Kind: %s\n", report.Kind)
+		if report.BackupId != "" {
+			fmt.Printf("  Backup id: %s\n", report.BackupId)
+		}
+		if report.ParentId != "" {
+			fmt.Printf("  Parent id: %s\n", report.ParentId)
+		}
+		for _, name := range []string{manage_service.BackupSectionCategories, manage_service.BackupSectionCashFlows,
+			manage_service.BackupSectionGoals, manage_service.BackupSectionDebts, manage_service.BackupSectionTombstones} {
+			section, isExist := report.Sections[name]
+			if !isExist {
+				continue
+			}
+			fmt.Printf("  %-10s %d records, sha256 %s\n", name+":", section.Count, section.Checksum)
+		}
+
+		if !report.Valid() {
+			fmt.Println("\nProblems:")
+			for _, problem := range report.Errors {
+				fmt.Printf("  - %s\n", problem)
+			}
+			return errors.New("backup is not valid")
+		}
+
+		fmt.Println("\n✅ Backup is valid")
+		return nil
+	},
+}
+
+func init() {
+	backupVerifyCmd.Flags().StringVarP(
+		&verifyPath, "input", "i", "", "backup file path (required)")
+	backupVerifyCmd.Flags().StringVar(
+		&verifyPassphrase, "passphrase", "", "passphrase of an encrypted backup (default: BACKUP_PASSPHRASE)")
+	backupVerifyCmd.MarkFlagRequired("input")
+
+	backupCmd.AddCommand(backupVerifyCmd)
+}
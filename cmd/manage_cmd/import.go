@@ -1,7 +1,12 @@
 package manage_cmd
 
 import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
@@ -9,7 +14,22 @@ var importCmd = &cobra.Command{
 	Use:   "import",
 	Short: "import data from excel",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return manage_service.ImportService(filePath)
+		actor := util.GetCLIActor()
+		stats, err := manage_service.ImportService(filePath, actor)
+		audit_service.Record(model.AuditOperationImport, model.NewChangeOrigin(model.ChangeSourceCLI, actor),
+			map[string]string{"file": filePath}, stats.AffectedCounts(), err)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\nImported %d cash flows, %d rows failed\n", stats.CashFlows.Success, stats.CashFlows.Failed)
+		if len(stats.Anomalies) > 0 {
+			fmt.Printf("\n%d anomalies among the imported dates:\n", len(stats.Anomalies))
+			for _, anomaly := range stats.Anomalies {
+				fmt.Printf("  [%s] %s (score %.1f)\n", anomaly.Kind, anomaly.Reason, anomaly.Score)
+			}
+		}
+		return nil
 	},
 }
 
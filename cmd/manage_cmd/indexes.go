@@ -18,6 +18,8 @@ This command creates the following indexes:
   - cash_flow(belongs_date, flow_type): Compound index for filtered date queries
   - cash_flow.category_id: For category-based queries
   - category.name: Unique index for category lookups
+  - cash_flow(description, remark): Full-text index for 'cash search --text'
+    and GET /api/cash/search/text (a MongoDB text index or a MySQL FULLTEXT index)
 
 Indexes significantly improve query performance, especially for date range queries.`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -32,6 +34,7 @@ Indexes significantly improve query performance, especially for date range queri
 		fmt.Println("  - Date range queries: 10x-100x faster")
 		fmt.Println("  - Category lookups: 10x faster")
 		fmt.Println("  - Type filtering: 50x faster")
+		fmt.Println("  - Full-text search: ranked by relevance instead of scanning every description")
 	},
 }
 
@@ -3,7 +3,9 @@ package manage_cmd
 import (
 	"fmt"
 
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
@@ -13,7 +15,7 @@ var initCmd = &cobra.Command{
 	Long: `Initialize the database with demo categories and sample transactions.
 Useful for testing and development.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		err := manage_service.InitializeDemoData()
+		err := manage_service.InitializeDemoData(model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()))
 		if err != nil {
 			return err
 		}
@@ -4,9 +4,13 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
@@ -34,6 +38,8 @@ WARNING: This operation cannot be undone. Create a backup first!`,
 		}
 
 		stats, err := manage_service.ResetDatabase()
+		audit_service.Record(model.AuditOperationReset, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()),
+			map[string]string{"force": strconv.FormatBool(forceReset)}, stats.AffectedCounts(), err)
 		if err != nil {
 			return err
 		}
@@ -42,6 +48,8 @@ WARNING: This operation cannot be undone. Create a backup first!`,
 		fmt.Println("\nStatistics:")
 		fmt.Printf("  Categories: %d success, %d failed\n", stats.Categories.Success, stats.Categories.Failed)
 		fmt.Printf("  Cash Flows: %d success, %d failed\n", stats.CashFlows.Success, stats.CashFlows.Failed)
+		fmt.Printf("  Goals:      %d success, %d failed\n", stats.Goals.Success, stats.Goals.Failed)
+		fmt.Printf("  Debts:      %d success, %d failed\n", stats.Debts.Success, stats.Debts.Failed)
 		return nil
 	},
 }
@@ -5,29 +5,53 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
 var (
-	restorePath  string
-	forceRestore bool
+	restorePathList   []string
+	forceRestore      bool
+	restorePassphrase string
+	restoreMode       string
+	restoreDryRun     bool
 )
 
 var restoreCmd = &cobra.Command{
 	Use:   "restore",
 	Short: "restore database from backup",
 	Long: `Restore database from a backup file.
-WARNING: This will replace all existing data unless --merge is used.`,
+WARNING: By default (--mode=replace) this clears all existing data first.
+Other modes keep existing rows and resolve each id clash instead:
+  merge             the row with the newer modify time wins
+  skip-existing     existing rows are never touched
+  fail-on-conflict  nothing is restored if any existing row differs from the backup
+Use --dry-run to see what would be inserted, updated or conflict without writing anything.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if restorePath == "" {
+		if restorePassphrase == "" {
+			restorePassphrase = util.GetConfigByKey("backup.passphrase")
+		}
+
+		if len(restorePathList) == 0 {
 			return errors.New("backup file path is required")
 		}
 
-		if !forceRestore {
-			fmt.Println("WARNING: This will replace all existing data!")
+		if err := manage_service.ValidateRestoreMode(restoreMode); err != nil {
+			return err
+		}
+
+		if !forceRestore && !restoreDryRun {
+			if restoreMode == "" || restoreMode == manage_service.RestoreModeReplace {
+				fmt.Println("WARNING: This will replace all existing data!")
+			} else {
+				fmt.Printf("WARNING: This will write into the existing data (mode: %s)!\n", restoreMode)
+			}
 			fmt.Print("Are you sure you want to continue? (yes/no): ")
 
 			reader := bufio.NewReader(os.Stdin)
@@ -40,25 +64,73 @@ WARNING: This will replace all existing data unless --merge is used.`,
 			}
 		}
 
-		stats, err := manage_service.RestoreBackup(restorePath)
+		result, err := manage_service.RestoreBackupChain(restorePathList, manage_service.RestoreOptions{
+			Passphrase: restorePassphrase,
+			Mode:       restoreMode,
+			DryRun:     restoreDryRun,
+		})
+		audit_service.Record(model.AuditOperationRestore, model.NewChangeOrigin(model.ChangeSourceCLI, util.GetCLIActor()),
+			map[string]string{
+				"files":      strings.Join(restorePathList, ","),
+				"mode":       restoreMode,
+				"dry_run":    strconv.FormatBool(restoreDryRun),
+				"passphrase": restorePassphrase,
+			}, result.AffectedCounts(), err)
 		if err != nil {
+			printRestoreResult(result)
 			return err
 		}
 
-		fmt.Printf("Database restored successfully from: %s\n", restorePath)
-		fmt.Println("\nStatistics:")
-		fmt.Printf("  Categories: %d success, %d failed\n", stats.Categories.Success, stats.Categories.Failed)
-		fmt.Printf("  Cash Flows: %d success, %d failed\n", stats.CashFlows.Success, stats.CashFlows.Failed)
+		if restoreDryRun {
+			fmt.Printf("Dry run of restore from: %s (nothing was written)\n", strings.Join(restorePathList, ", "))
+		} else {
+			fmt.Printf("Database restored successfully from: %s\n", strings.Join(restorePathList, ", "))
+		}
+		printRestoreResult(result)
 		return nil
 	},
 }
 
 func init() {
-	restoreCmd.Flags().StringVarP(
-		&restorePath, "input", "i", "", "backup file path (required)")
+	restoreCmd.Flags().StringArrayVarP(
+		&restorePathList, "input", "i", nil,
+		"backup file path (required); repeat to restore a full backup followed by incremental backups, oldest first")
 	restoreCmd.Flags().BoolVarP(
 		&forceRestore, "force", "f", false, "skip confirmation prompt")
+	restoreCmd.Flags().StringVar(
+		&restorePassphrase, "passphrase", "", "passphrase of an encrypted backup (default: BACKUP_PASSPHRASE)")
+
+	restoreCmd.Flags().StringVar(
+		&restoreMode, "mode", manage_service.RestoreModeReplace, "replace, merge, skip-existing or fail-on-conflict")
+	restoreCmd.Flags().BoolVar(
+		&restoreDryRun, "dry-run", false, "report what would be inserted, updated or conflict without writing anything")
 
 	restoreCmd.MarkFlagRequired("input")
 	ManageCmd.AddCommand(restoreCmd)
 }
+
+// printRestoreResult prints what a restore did, or would do, per entity type
+func printRestoreResult(result manage_service.RestoreResult) {
+	fmt.Printf("\nStatistics (mode: %s):\n", result.Mode)
+	fmt.Printf("  %-11s %9s %8s %10s %8s %8s %8s %10s %7s\n",
+		"", "Inserted", "Updated", "Unchanged", "Skipped", "Deleted", "Cleared", "Conflicts", "Failed")
+	for _, row := range []struct {
+		name  string
+		stats manage_service.RestoreEntityStats
+	}{
+		{"Categories", result.Categories},
+		{"Cash Flows", result.CashFlows},
+		{"Goals", result.Goals},
+		{"Debts", result.Debts},
+	} {
+		fmt.Printf("  %-11s %9d %8d %10d %8d %8d %8d %10d %7d\n", row.name,
+			row.stats.Inserted, row.stats.Updated, row.stats.Unchanged, row.stats.Skipped,
+			row.stats.Deleted, row.stats.Cleared, row.stats.Conflicts, row.stats.Failed)
+	}
+	for _, conflictList := range [][]string{result.Categories.ConflictList, result.CashFlows.ConflictList,
+		result.Goals.ConflictList, result.Debts.ConflictList} {
+		for _, conflictId := range conflictList {
+			fmt.Printf("  conflict: %s\n", conflictId)
+		}
+	}
+}
@@ -24,7 +24,8 @@ Available sub-commands:
   restore - Restore from backup
   init    - Initialize with demo data
   reset   - Clear all data (dangerous!)
-  stats   - Show database statistics`,
+  stats   - Show database statistics
+  audit   - Show the audit log of administrative operations`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return errors.New("must provide a valid sub command")
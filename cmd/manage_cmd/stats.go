@@ -23,9 +23,9 @@ var statsCmd = &cobra.Command{
 		fmt.Printf("  - Expense:        %d\n", stats.ExpenseCount)
 		fmt.Printf("Categories:         %d\n", stats.CategoryCount)
 		fmt.Printf("\nFinancial Summary:\n")
-		fmt.Printf("  Total Income:     %.2f\n", stats.TotalIncome)
-		fmt.Printf("  Total Expense:    %.2f\n", stats.TotalExpense)
-		fmt.Printf("  Balance:          %.2f\n", stats.Balance)
+		fmt.Printf("  Total Income:     %s\n", stats.TotalIncome)
+		fmt.Printf("  Total Expense:    %s\n", stats.TotalExpense)
+		fmt.Printf("  Balance:          %s\n", stats.Balance)
 		fmt.Printf("\nDate Range:\n")
 		fmt.Printf("  Earliest:         %s\n", stats.EarliestDate)
 		fmt.Printf("  Latest:           %s\n", stats.LatestDate)
@@ -0,0 +1,66 @@
+package report_cmd
+
+import (
+	"fmt"
+
+	"github.com/macar-x/cashlenx-server/service/report_service"
+	"github.com/spf13/cobra"
+)
+
+var forecastMonths int
+
+var forecastCmd = &cobra.Command{
+	Use:   "forecast",
+	Short: "project the balance forward",
+	Long: `Project the balance at the end of each month, the current one first, from 3 to 12
+months ahead. Cash flows that came with the same type, category and description
+in at least 3 of the last 6 months are projected as recurring items at their
+latest amount; everything else at seasonal averages per category over the last
+24 months. Low and high show one standard deviation around the expected balance.
+
+Example:
+  cashlenx report forecast
+  cashlenx report forecast --months 12`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report, err := report_service.ForecastService(forecastMonths)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Balance as of %s: %s\n", report.AsOf, report.Balance)
+		if len(report.Recurring) > 0 {
+			fmt.Println("\nRecurring items:")
+			for _, item := range report.Recurring {
+				fmt.Printf("  day %2d  %-7s %12s  %s\n", item.Day, item.Type, item.Amount, item.Description)
+			}
+		}
+
+		fmt.Printf("\n%-8s %12s %12s %14s %14s %14s\n", "Month", "Income", "Expense", "Balance", "Low", "High")
+		var warningList []string
+		for _, month := range report.Months {
+			fmt.Printf("%-8s %12s %12s %14s %14s %14s\n", month.Month,
+				month.Income.Expected, month.Expense.Expected,
+				month.Balance.Expected, month.Balance.Low, month.Balance.High)
+			if month.Negative {
+				warningList = append(warningList, fmt.Sprintf(
+					"WARNING: the balance is expected to go negative by the end of %s (%s)", month.Month, month.Balance.Expected))
+			} else if month.Balance.Low.IsNegative() {
+				warningList = append(warningList, fmt.Sprintf(
+					"Note: the balance may go negative by the end of %s (low %s)", month.Month, month.Balance.Low))
+			}
+		}
+		if len(warningList) > 0 {
+			fmt.Println()
+			for _, warning := range warningList {
+				fmt.Println(warning)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	forecastCmd.Flags().IntVarP(
+		&forecastMonths, "months", "m", report_service.ForecastDefaultMonths, "months to project (3-12)")
+	ReportCmd.AddCommand(forecastCmd)
+}
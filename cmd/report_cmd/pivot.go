@@ -0,0 +1,125 @@
+package report_cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/report_service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pivotRows     string
+	pivotCols     string
+	pivotFrom     string
+	pivotTo       string
+	pivotType     string
+	pivotFormat   string
+	pivotFilePath string
+)
+
+var pivotCmd = &cobra.Command{
+	Use:   "pivot",
+	Short: "category by period pivot report",
+	Long: `Sum cash flows of one type by category and period. Every category includes the
+categories below it; rows and columns end with their totals and averages.
+
+Dates can be a day, month or year; without them the last 12 months are shown.
+The table is printed unless --format asks for json, csv or xlsx; xlsx needs --output.
+
+Example:
+  cashlenx report pivot --from 2026-01 --to 2026-06
+  cashlenx report pivot --cols quarter --from 2025 --to 2025 --type income
+  cashlenx report pivot --from 2026-01 --to 2026-12 --format xlsx --output review.xlsx`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if pivotFormat != "" && pivotFormat != "table" && pivotFormat != "json" && pivotFormat != "csv" && pivotFormat != "xlsx" {
+			return errors.New("format must be table, json, csv or xlsx")
+		}
+		if pivotFormat == "xlsx" && pivotFilePath == "" {
+			return errors.New("xlsx needs --output")
+		}
+
+		report, err := report_service.PivotService(pivotRows, pivotCols, pivotFrom, pivotTo, pivotType)
+		if err != nil {
+			return err
+		}
+
+		var writer io.Writer = os.Stdout
+		if pivotFilePath != "" {
+			file, err := os.Create(pivotFilePath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			writer = file
+		}
+
+		switch pivotFormat {
+		case "json":
+			encoder := json.NewEncoder(writer)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(report)
+		case "csv":
+			err = report_service.WritePivotCsv(writer, report)
+		case "xlsx":
+			err = report_service.WritePivotXlsx(writer, report)
+		default:
+			printPivot(writer, report)
+		}
+		if err == nil && pivotFilePath != "" {
+			fmt.Printf("Pivot report written to %s\n", pivotFilePath)
+		}
+		return err
+	},
+}
+
+// printPivot prints the report as a table, indenting categories under their parents
+func printPivot(writer io.Writer, report model.PivotReport) {
+	if len(report.Items) == 0 {
+		fmt.Fprintf(writer, "No %s cash flows from %s to %s\n", strings.ToLower(report.Type), report.From, report.To)
+		return
+	}
+
+	labelWidth := len("Category")
+	for _, row := range report.Items {
+		labelWidth = max(labelWidth, row.Depth*2+len([]rune(row.Name)))
+	}
+	printPivotLine(writer, labelWidth, "Category", append(append([]string(nil), report.Columns...), "Total", "Average"))
+	for _, row := range report.Items {
+		label := strings.Repeat("  ", row.Depth) + row.Name
+		printPivotLine(writer, labelWidth, label, pivotAmountTexts(row.Values, row.Total, row.Average))
+	}
+	printPivotLine(writer, labelWidth, "Total", pivotAmountTexts(report.Totals, report.GrandTotal, report.Average))
+}
+
+func pivotAmountTexts(valueList []model.Money, total, average model.Money) []string {
+	var textList []string
+	for _, value := range valueList {
+		textList = append(textList, value.String())
+	}
+	return append(textList, total.String(), average.String())
+}
+
+func printPivotLine(writer io.Writer, labelWidth int, label string, cellList []string) {
+	line := label + strings.Repeat(" ", labelWidth-len([]rune(label)))
+	for _, cell := range cellList {
+		line += fmt.Sprintf(" %12s", cell)
+	}
+	fmt.Fprintln(writer, line)
+}
+
+func init() {
+	pivotCmd.Flags().StringVar(&pivotRows, "rows", "category", "row dimension (category)")
+	pivotCmd.Flags().StringVar(&pivotCols, "cols", "month", "column period (month/quarter/year)")
+	pivotCmd.Flags().StringVarP(&pivotFrom, "from", "f", "", "first day, month or year, e.x. 2026-01")
+	pivotCmd.Flags().StringVarP(&pivotTo, "to", "t", "", "last day, month or year (include), e.x. 2026-06")
+	pivotCmd.Flags().StringVar(&pivotType, "type", "OUTCOME", "cash flow type (INCOME/OUTCOME)")
+	pivotCmd.Flags().StringVar(&pivotFormat, "format", "table", "output format (table/json/csv/xlsx)")
+	pivotCmd.Flags().StringVarP(&pivotFilePath, "output", "o", "", "write to a file instead of the terminal")
+	ReportCmd.AddCommand(pivotCmd)
+}
@@ -0,0 +1,21 @@
+package report_cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+var ReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "reports over cash flows",
+	Long: `Build reports over cash flows.
+
+Available sub-commands:
+  pivot    - Category by period totals, with roll-ups along the category tree
+  forecast - Projected end of month balances`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return errors.New("must provide a valid sub command")
+	},
+}
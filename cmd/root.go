@@ -9,7 +9,11 @@ import (
 	"github.com/macar-x/cashlenx-server/cmd/cash_flow_cmd"
 	"github.com/macar-x/cashlenx-server/cmd/category_cmd"
 	"github.com/macar-x/cashlenx-server/cmd/db_cmd"
+	"github.com/macar-x/cashlenx-server/cmd/debt_cmd"
+	"github.com/macar-x/cashlenx-server/cmd/goal_cmd"
+	"github.com/macar-x/cashlenx-server/cmd/insight_cmd"
 	"github.com/macar-x/cashlenx-server/cmd/manage_cmd"
+	"github.com/macar-x/cashlenx-server/cmd/report_cmd"
 	"github.com/macar-x/cashlenx-server/cmd/server_cmd"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/macar-x/cashlenx-server/util/database"
@@ -70,4 +74,8 @@ func init() {
 	rootCmd.AddCommand(category_cmd.CategoryCmd)
 	rootCmd.AddCommand(manage_cmd.ManageCmd)
 	rootCmd.AddCommand(db_cmd.DbCmd)
+	rootCmd.AddCommand(report_cmd.ReportCmd)
+	rootCmd.AddCommand(insight_cmd.InsightCmd)
+	rootCmd.AddCommand(goal_cmd.GoalCmd)
+	rootCmd.AddCommand(debt_cmd.DebtCmd)
 }
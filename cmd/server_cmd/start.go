@@ -1,22 +1,65 @@
 package server_cmd
 
 import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/macar-x/cashlenx-server/controller"
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/service/idempotency_service"
+	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/service/migration_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/spf13/cobra"
 )
 
 var port int32
+var verifySchema bool
 
 var startCmd4ApiServer = &cobra.Command{
 	Use:   "start",
 	Short: "start the api server",
-	Run: func(cmd *cobra.Command, args []string) {
-		controller.StartServer(port)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Background jobs and the server stop on Ctrl+C or SIGTERM
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// Refuse to serve against a database that is behind this binary's migrations
+		if verifySchema {
+			if err := migration_service.VerifySchemaVersion(); err != nil {
+				return err
+			}
+		}
+
+		// Scheduled backups run in the background and never block requests
+		if enabled, err := manage_service.StartBackupScheduler(ctx); err != nil {
+			return err
+		} else if enabled {
+			fmt.Printf("Backup schedule: %s -> %s\n",
+				util.GetConfigByKey("backup.schedule"), util.GetConfigByKey("backup.dir"))
+		}
+
+		// Cash flows deleted longer ago than TRASH_RETENTION_DAYS are purged hourly
+		if cash_flow_service.StartTrashPurger(ctx) {
+			fmt.Printf("Trash retention: %s days\n", util.GetConfigByKey("trash.retention.days"))
+		}
+
+		// Responses replayed for an Idempotency-Key expire after IDEMPOTENCY_WINDOW_HOURS
+		if idempotency_service.StartIdempotencyPurger(ctx) {
+			fmt.Printf("Idempotency window: %s hours\n", util.GetConfigByKey("idempotency.window.hours"))
+		}
+
+		return controller.StartServer(ctx, port)
 	},
 }
 
 func init() {
 	startCmd4ApiServer.Flags().Int32VarP(
 		&port, "port", "p", 8080, "api server port, default 8080")
+	startCmd4ApiServer.Flags().BoolVar(
+		&verifySchema, "verify-schema", util.GetConfigByKey("db.schema.verify") == "true",
+		"refuse to start when schema migrations are pending")
 	ServerCmd.AddCommand(startCmd4ApiServer)
 }
@@ -0,0 +1,32 @@
+package cash_flow_controller
+
+import (
+	"net/http"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// ExecuteBatch applies mixed creates, updates and deletes in one call
+// Answers 200 when every operation succeeded and 207 with the per-operation results otherwise
+func ExecuteBatch(w http.ResponseWriter, r *http.Request) {
+	var request model.BatchRequest
+	if err := util.ParseJSONRequest(r, &request); err != nil {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("invalid request body"))
+		return
+	}
+
+	response, err := cash_flow_service.ExecuteBatch(request, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if response.Failed > 0 {
+		statusCode = http.StatusMultiStatus
+	}
+	util.ComposeJSONResponse(w, statusCode, response)
+}
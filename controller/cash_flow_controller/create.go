@@ -16,11 +16,12 @@ func CreateExpense(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cashFlowEntity, err := cash_flow_service.SaveExpense(requestBody.BelongsDate, requestBody.CategoryName, requestBody.Amount, requestBody.Description)
+	cashFlowEntity, err := cash_flow_service.SaveExpense(requestBody.BelongsDate, requestBody.CategoryName, requestBody.Amount, requestBody.Description, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
 	if err != nil {
 		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	w.Header().Set("ETag", model.FormatETag(cashFlowEntity.Version))
 	util.ComposeJSONResponse(w, http.StatusCreated, cashFlowEntity)
 }
 
@@ -31,11 +32,12 @@ func CreateIncome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cashFlowEntity, err := cash_flow_service.SaveIncome(requestBody.BelongsDate, requestBody.CategoryName, requestBody.Amount, requestBody.Description)
+	cashFlowEntity, err := cash_flow_service.SaveIncome(requestBody.BelongsDate, requestBody.CategoryName, requestBody.Amount, requestBody.Description, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
 	if err != nil {
 		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	w.Header().Set("ETag", model.FormatETag(cashFlowEntity.Version))
 	util.ComposeJSONResponse(w, http.StatusCreated, cashFlowEntity)
 }
 
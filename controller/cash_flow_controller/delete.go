@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
@@ -16,7 +17,7 @@ func DeleteById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cashFlowEntity, err := cash_flow_service.DeleteById(id)
+	cashFlowEntity, err := cash_flow_service.DeleteById(id, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
 	if err != nil {
 		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
 		return
@@ -31,7 +32,7 @@ func DeleteByDate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cashFlowEntityList, err := cash_flow_service.DeleteByDate(date)
+	cashFlowEntityList, err := cash_flow_service.DeleteByDate(date, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
 	if err != nil {
 		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
 		return
@@ -0,0 +1,46 @@
+package cash_flow_controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// GetHistory returns the revisions of a cash flow, oldest first
+func GetHistory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("id is required"))
+		return
+	}
+
+	revisionList, err := cash_flow_service.GetHistory(id)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, revisionList)
+}
+
+// RevertToRevision sets a cash flow back to how it was right after a revision and returns it
+func RevertToRevision(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, revisionId := vars["id"], vars["revision_id"]
+	if id == "" || revisionId == "" {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("id and revision_id are required"))
+		return
+	}
+
+	cashFlowEntity, err := cash_flow_service.RevertToRevision(id, revisionId,
+		model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	w.Header().Set("ETag", model.FormatETag(cashFlowEntity.Version))
+	util.ComposeJSONResponse(w, http.StatusOK, cashFlowEntity)
+}
@@ -2,82 +2,41 @@ package cash_flow_controller
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
 
-// ListAll returns paginated list of all cash flows with filtering
+// ListAll returns a page of cash flows with filtering, sorting and field selection
+// Pages are found by limit with offset or page, or by the after cursor taken from next_cursor or prev_cursor in meta
 func ListAll(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters for pagination and filtering
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-	pageStr := r.URL.Query().Get("page")
-
-	// Filter parameters
-	cashType := r.URL.Query().Get("type") // INCOME or OUTCOME
-	categoryId := r.URL.Query().Get("category_id")
-	description := r.URL.Query().Get("description") // Fuzzy search
-	exactDescription := r.URL.Query().Get("exact_description")
-	fromDate := r.URL.Query().Get("from_date") // YYYYMMDD or YYYY-MM-DD
-	toDate := r.URL.Query().Get("to_date")     // YYYYMMDD or YYYY-MM-DD
-
-	// Pagination defaults
-	limit := 20
-	offset := 0
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
-
-	if pageStr != "" {
-		// Calculate offset from page number
-		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
-			offset = (page - 1) * limit
-		}
-	} else if offsetStr != "" {
-		// Use offset directly if provided
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
+	pageRequest, err := query.ParsePageRequest(r.URL.Query(), query.CashFlowSortFieldList, query.CashFlowFieldList,
+		"-belongs_date", 20)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
 	}
 
-	// Call service to get paginated and filtered results
-	cashFlows, totalCount, err := cash_flow_service.QueryAll(
-		cashType,
-		categoryId,
-		description,
-		exactDescription,
-		fromDate,
-		toDate,
-		limit,
-		offset,
+	// Filter parameters
+	filter, err := cash_flow_service.BuildListFilter(
+		r.URL.Query().Get("type"), // income or expense
+		r.URL.Query().Get("category_id"),
+		r.URL.Query().Get("description"), // Fuzzy search
+		r.URL.Query().Get("exact_description"),
+		r.URL.Query().Get("from_date"), // YYYYMMDD or YYYY-MM-DD
+		r.URL.Query().Get("to_date"),   // YYYYMMDD or YYYY-MM-DD
 	)
-
 	if err != nil {
-		response := model.NewErrorResponse("INTERNAL_ERROR", err.Error())
-		util.ComposeJSONResponse(w, http.StatusInternalServerError, response)
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
 		return
 	}
 
-	// Calculate current page
-	currentPage := (offset / limit) + 1
-
-	// Create meta info
-	meta := map[string]interface{}{
-		"total": totalCount,
-		"page":  int64(currentPage),
-		"limit": int64(limit),
-	}
-
-	// Return with pagination metadata
-	response := map[string]interface{}{
-		"data": cashFlows,
-		"meta": meta,
+	page, err := cash_flow_service.ListPage(filter, pageRequest)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
 	}
-	util.ComposeJSONResponse(w, http.StatusOK, response)
+	util.ComposeJSONResponse(w, http.StatusOK, model.NewSuccessResponseWithMeta(page.Data, page.Meta(pageRequest)))
 }
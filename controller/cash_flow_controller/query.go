@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
@@ -21,6 +22,7 @@ func QueryById(w http.ResponseWriter, r *http.Request) {
 		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	w.Header().Set("ETag", model.FormatETag(cashFlowEntity.Version))
 	util.ComposeJSONResponse(w, http.StatusOK, cashFlowEntity)
 }
 
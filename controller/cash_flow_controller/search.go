@@ -0,0 +1,67 @@
+package cash_flow_controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// Search returns the cash flows matching the query in q, paginated like ListAll
+func Search(w http.ResponseWriter, r *http.Request) {
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+	pageStr := r.URL.Query().Get("page")
+
+	// Pagination defaults
+	limit := 20
+	offset := 0
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	if pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			offset = (page - 1) * limit
+		}
+	} else if offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	cashFlows, totalCount, err := cash_flow_service.Search(r.URL.Query().Get("q"), limit, offset)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"data": cashFlows,
+		"meta": map[string]interface{}{
+			"total": totalCount,
+			"page":  int64(offset/limit + 1),
+			"limit": int64(limit),
+		},
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, response)
+}
+
+// TextSearch returns the cash flows whose description or remark best match the words in q, best first
+func TextSearch(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	hitList, err := cash_flow_service.TextSearch(r.URL.Query().Get("q"), limit)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, hitList)
+}
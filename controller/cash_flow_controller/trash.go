@@ -0,0 +1,66 @@
+package cash_flow_controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// ListTrash returns a paginated list of deleted cash flows, most recently deleted first
+func ListTrash(w http.ResponseWriter, r *http.Request) {
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+	pageStr := r.URL.Query().Get("page")
+
+	// Pagination defaults, same as the cash flow list
+	limit := 20
+	offset := 0
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	if pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			offset = (page - 1) * limit
+		}
+	} else if offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	cashFlows, totalCount := cash_flow_service.ListTrash(limit, offset)
+	util.ComposeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data": cashFlows,
+		"meta": map[string]interface{}{
+			"total": totalCount,
+			"page":  int64(offset/limit + 1),
+			"limit": int64(limit),
+		},
+	})
+}
+
+// RestoreFromTrash brings a deleted cash flow back and returns it
+func RestoreFromTrash(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("id is required"))
+		return
+	}
+
+	cashFlowEntity, err := cash_flow_service.RestoreFromTrash(id, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	w.Header().Set("ETag", model.FormatETag(cashFlowEntity.Version))
+	util.ComposeJSONResponse(w, http.StatusOK, cashFlowEntity)
+}
@@ -1,11 +1,12 @@
 package cash_flow_controller
 
 import (
+	"io"
 	"net/http"
-	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
@@ -19,6 +20,13 @@ func UpdateById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Refuse a write based on a stale read; If-Match: * overwrites whatever is stored
+	expectedVersion, err := model.ParseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
 	// Parse JSON body for update fields
 	var requestBody map[string]interface{}
 	if err := util.ParseJSONRequest(r, &requestBody); err != nil {
@@ -31,22 +39,67 @@ func UpdateById(w http.ResponseWriter, r *http.Request) {
 	categoryName, _ := requestBody["category_name"].(string)
 	description, _ := requestBody["description"].(string)
 
-	var amount float64
+	var amount model.Money
 	if amountVal, ok := requestBody["amount"]; ok {
 		switch v := amountVal.(type) {
 		case float64:
-			amount = v
+			amount = model.NewMoneyFromFloat(v)
 		case string:
-			amount, _ = strconv.ParseFloat(v, 64)
+			amount, _ = model.NewMoneyFromString(v)
 		}
 	}
 
 	// Call service to update
-	updatedEntity, err := cash_flow_service.UpdateById(plainId, belongsDate, categoryName, amount, description)
+	updatedEntity, err := cash_flow_service.UpdateById(plainId, belongsDate, categoryName, amount, description, expectedVersion, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("ETag", model.FormatETag(updatedEntity.Version))
+	util.ComposeJSONResponse(w, http.StatusOK, updatedEntity)
+}
+
+// PatchById applies a JSON Merge Patch to a cash flow record
+// null clears description or remark, a missing field stays as it is
+func PatchById(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	plainId := vars["id"]
+	if plainId == "" {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("id is required"))
+		return
+	}
+
+	if !model.IsMergePatchContentType(r.Header.Get("Content-Type")) {
+		util.ComposeJSONResponse(w, http.StatusUnsupportedMediaType,
+			errors.NewInvalidInputError("Content-Type must be "+model.MergePatchContentType))
+		return
+	}
+
+	// Refuse a write based on a stale read; If-Match: * overwrites whatever is stored
+	expectedVersion, err := model.ParseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("invalid request body"))
+		return
+	}
+	patch, err := model.ParseMergePatch(body)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	updatedEntity, err := cash_flow_service.PatchById(plainId, patch, expectedVersion, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
 	if err != nil {
-		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
 		return
 	}
 
+	w.Header().Set("ETag", model.FormatETag(updatedEntity.Version))
 	util.ComposeJSONResponse(w, http.StatusOK, updatedEntity)
 }
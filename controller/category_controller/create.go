@@ -27,7 +27,7 @@ func Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	plainId, err := category_service.CreateService(requestBody.ParentId, requestBody.Name, requestBody.Type)
+	plainId, err := category_service.CreateService(requestBody.ParentId, requestBody.Name, requestBody.Type, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
 	if err != nil {
 		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
 		return
@@ -45,5 +45,6 @@ func Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", model.FormatETag(createdCategory[0].Version))
 	util.ComposeJSONResponse(w, http.StatusCreated, createdCategory[0])
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/category_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
@@ -32,7 +33,7 @@ func DeleteById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete the category
-	if err := category_service.DeleteService(id, ""); err != nil {
+	if err := category_service.DeleteService(id, "", model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r))); err != nil {
 		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
 		return
 	}
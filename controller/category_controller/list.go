@@ -2,45 +2,27 @@ package category_controller
 
 import (
 	"net/http"
-	"strconv"
 
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"github.com/macar-x/cashlenx-server/service/category_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
 
-// ListAll returns paginated list of all categories
+// ListAll returns a page of categories with sorting and field selection
+// Pages are found by limit with offset or page, or by the after cursor taken from next_cursor or prev_cursor in meta
 func ListAll(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters for pagination
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-
-	limit := 50 // Default limit for categories
-	offset := 0 // Default offset
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
-		}
-	}
-
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil {
-			offset = o
-		}
+	pageRequest, err := query.ParsePageRequest(r.URL.Query(), query.CategorySortFieldList, query.CategoryFieldList,
+		"name", 50)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
 	}
 
-	// Call service to get paginated results
-	categories, totalCount, err := category_service.ListAllService(limit, offset)
+	page, err := category_service.ListPageService(pageRequest)
 	if err != nil {
-		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
 		return
 	}
-
-	// Return with pagination metadata
-	util.ComposeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"data":        categories,
-		"total_count": totalCount,
-		"limit":       limit,
-		"offset":      offset,
-	})
+	util.ComposeJSONResponse(w, http.StatusOK, model.NewSuccessResponseWithMeta(page.Data, page.Meta(pageRequest)))
 }
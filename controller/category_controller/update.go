@@ -1,10 +1,12 @@
 package category_controller
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/service/category_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
@@ -19,6 +21,13 @@ func UpdateById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Refuse a write based on a stale read; If-Match: * overwrites whatever is stored
+	expectedVersion, err := model.ParseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
 	// Parse JSON body for update fields
 	var requestBody map[string]interface{}
 	if err := util.ParseJSONRequest(r, &requestBody); err != nil {
@@ -32,9 +41,9 @@ func UpdateById(w http.ResponseWriter, r *http.Request) {
 	categoryType, _ := requestBody["type"].(string)
 
 	// Call service to update
-	err := category_service.UpdateService(plainId, parentPlainId, categoryName, categoryType)
+	err = category_service.UpdateService(plainId, parentPlainId, categoryName, categoryType, expectedVersion, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
 	if err != nil {
-		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
 		return
 	}
 
@@ -50,5 +59,50 @@ func UpdateById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", model.FormatETag(updatedCategory[0].Version))
 	util.ComposeJSONResponse(w, http.StatusOK, updatedCategory[0])
 }
+
+// PatchById applies a JSON Merge Patch to a category
+// A null parent_id makes it a top level category, a missing field stays as it is
+func PatchById(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	plainId := vars["id"]
+	if plainId == "" {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("id is required"))
+		return
+	}
+
+	if !model.IsMergePatchContentType(r.Header.Get("Content-Type")) {
+		util.ComposeJSONResponse(w, http.StatusUnsupportedMediaType,
+			errors.NewInvalidInputError("Content-Type must be "+model.MergePatchContentType))
+		return
+	}
+
+	// Refuse a write based on a stale read; If-Match: * overwrites whatever is stored
+	expectedVersion, err := model.ParseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("invalid request body"))
+		return
+	}
+	patch, err := model.ParseMergePatch(body)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	updatedCategory, err := category_service.PatchService(plainId, patch, expectedVersion, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("ETag", model.FormatETag(updatedCategory.Version))
+	util.ComposeJSONResponse(w, http.StatusOK, updatedCategory)
+}
@@ -0,0 +1,37 @@
+package debt_controller
+
+import (
+	"net/http"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// Create stores a new debt and returns it with its balance and amortization table
+func Create(w http.ResponseWriter, r *http.Request) {
+	var requestBody model.DebtDTO
+	if err := util.ParseJSONRequest(r, &requestBody); err != nil {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("invalid request body"))
+		return
+	}
+
+	debtEntity, err := debt_service.CreateService(requestBody, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	sendDebt(w, http.StatusCreated, debtEntity.Id.Hex())
+}
+
+// sendDebt answers with a debt, its amortization table and its ETag
+func sendDebt(w http.ResponseWriter, status int, plainId string) {
+	summary, err := debt_service.QueryService(plainId)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	w.Header().Set("ETag", model.FormatETag(summary.Debt.Version))
+	util.ComposeJSONResponse(w, status, summary)
+}
@@ -0,0 +1,24 @@
+package debt_controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// List returns every debt with its balance and payoff date
+func List(w http.ResponseWriter, r *http.Request) {
+	summaryList, err := debt_service.ListService()
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, summaryList)
+}
+
+// QueryById returns a debt with its balance and amortization table
+func QueryById(w http.ResponseWriter, r *http.Request) {
+	sendDebt(w, http.StatusOK, mux.Vars(r)["id"])
+}
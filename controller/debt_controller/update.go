@@ -0,0 +1,35 @@
+package debt_controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// UpdateById replaces a debt; requires If-Match like the other updates
+func UpdateById(w http.ResponseWriter, r *http.Request) {
+	plainId := mux.Vars(r)["id"]
+
+	// Refuse a write based on a stale read; If-Match: * overwrites whatever is stored
+	expectedVersion, err := model.ParseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	var requestBody model.DebtDTO
+	if err := util.ParseJSONRequest(r, &requestBody); err != nil {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("invalid request body"))
+		return
+	}
+
+	if _, err := debt_service.UpdateService(plainId, requestBody, expectedVersion); err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	sendDebt(w, http.StatusOK, plainId)
+}
@@ -0,0 +1,37 @@
+package goal_controller
+
+import (
+	"net/http"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/goal_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// Create stores a new goal and returns it with its progress
+func Create(w http.ResponseWriter, r *http.Request) {
+	var requestBody model.GoalDTO
+	if err := util.ParseJSONRequest(r, &requestBody); err != nil {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("invalid request body"))
+		return
+	}
+
+	goalEntity, err := goal_service.CreateService(requestBody)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	sendGoal(w, http.StatusCreated, goalEntity.Id.Hex())
+}
+
+// sendGoal answers with a goal, its progress and its ETag
+func sendGoal(w http.ResponseWriter, status int, plainId string) {
+	progress, err := goal_service.QueryService(plainId)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	w.Header().Set("ETag", model.FormatETag(progress.Goal.Version))
+	util.ComposeJSONResponse(w, status, progress)
+}
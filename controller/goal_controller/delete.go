@@ -0,0 +1,19 @@
+package goal_controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/macar-x/cashlenx-server/service/goal_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// DeleteById removes a goal and returns it
+func DeleteById(w http.ResponseWriter, r *http.Request) {
+	deletedEntity, err := goal_service.DeleteService(mux.Vars(r)["id"])
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, deletedEntity)
+}
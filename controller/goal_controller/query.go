@@ -0,0 +1,24 @@
+package goal_controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/macar-x/cashlenx-server/service/goal_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// List returns every goal with its progress, the nearest deadline first
+func List(w http.ResponseWriter, r *http.Request) {
+	progressList, err := goal_service.ListService()
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, progressList)
+}
+
+// QueryById returns a goal with its progress
+func QueryById(w http.ResponseWriter, r *http.Request) {
+	sendGoal(w, http.StatusOK, mux.Vars(r)["id"])
+}
@@ -0,0 +1,19 @@
+package insight_controller
+
+import (
+	"net/http"
+
+	"github.com/macar-x/cashlenx-server/service/insight_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// GetAnomalies lists unusual expenses with a reason and a score, most unusual first
+// GET /api/insights/anomalies?from=2026-09-01&to=2026-09-30
+func GetAnomalies(w http.ResponseWriter, r *http.Request) {
+	report, err := insight_service.DetectAnomalies(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, report)
+}
@@ -0,0 +1,15 @@
+package insight_controller
+
+import (
+	"net/http"
+
+	"github.com/macar-x/cashlenx-server/service/insight_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// GetSubscriptions lists the expenses found to recur weekly, monthly or yearly
+// GET /api/insights/subscriptions?all=true
+func GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	report := insight_service.DiscoverSubscriptions(r.URL.Query().Get("all") == "true")
+	util.ComposeJSONResponse(w, http.StatusOK, report)
+}
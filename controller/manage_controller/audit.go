@@ -0,0 +1,56 @@
+package manage_controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/macar-x/cashlenx-server/service/audit_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// GetAuditLog returns a page of the audit log, newest first
+// Filters: operation, actor, outcome, from and to (YYYY-MM-DD, both inclusive)
+func GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	// Verify ADMIN_TOKEN: the audit log names who ran which administrative operation
+	if err := util.VerifyAdminTokenFromRequest(r); err != nil {
+		util.ComposeJSONResponse(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := audit_service.ParseAuditFilter(
+		query.Get("operation"), query.Get("actor"), query.Get("outcome"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Pagination defaults, same as the cash flow list
+	limit := 20
+	offset := 0
+
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if pageStr := query.Get("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			offset = (page - 1) * limit
+		}
+	} else if o, err := strconv.Atoi(query.Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	auditList, totalCount, err := audit_service.QueryAuditLog(filter, limit, offset)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data": auditList,
+		"meta": map[string]interface{}{
+			"total": totalCount,
+			"page":  int64(offset/limit + 1),
+			"limit": int64(limit),
+		},
+	})
+}
@@ -0,0 +1,19 @@
+package manage_controller
+
+import (
+	"net/http"
+
+	"github.com/macar-x/cashlenx-server/service/manage_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// GetBackupStatus reports the backup schedule, retention and the last scheduled success or failure
+func GetBackupStatus(w http.ResponseWriter, r *http.Request) {
+	// Verify ADMIN_TOKEN: the status exposes backup file names and errors
+	if err := util.VerifyAdminTokenFromRequest(r); err != nil {
+		util.ComposeJSONResponse(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	util.ComposeJSONResponse(w, http.StatusOK, manage_service.GetBackupScheduleStatus())
+}
@@ -2,13 +2,21 @@ package manage_controller
 
 import (
 	"net/http"
-	"os"
+	"time"
 
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
 
-// DumpDatabase creates a database dump and returns it as a download
+// BackupPassphraseHeader carries the passphrase for encrypted dumps and restores
+const BackupPassphraseHeader = "X-Backup-Passphrase"
+
+// DumpDatabase streams a database backup as a download
+// Query: compression=none|gzip|zstd (default BACKUP_COMPRESSION)
+// Header: X-Backup-Passphrase encrypts the dump (default BACKUP_PASSPHRASE)
 func DumpDatabase(w http.ResponseWriter, r *http.Request) {
 	// Verify ADMIN_TOKEN for dangerous operation
 	if err := util.VerifyAdminTokenFromRequest(r); err != nil {
@@ -16,31 +24,44 @@ func DumpDatabase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create a temporary file for the dump
-	filePath := "temp_dump.json"
-	defer os.Remove(filePath) // Clean up after response
-
-	// Create the dump
-	_, err := manage_service.CreateBackup(filePath)
-	if err != nil {
-		util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
+	options := manage_service.DefaultBackupOptions()
+	if compression := r.URL.Query().Get("compression"); compression != "" {
+		options.Compression = compression
+	}
+	if passphrase := r.Header.Get(BackupPassphraseHeader); passphrase != "" {
+		options.Passphrase = passphrase
+	}
+	switch options.Compression {
+	case "", manage_service.CompressionNone, manage_service.CompressionGzip, manage_service.CompressionZstd:
+	default:
+		util.ComposeJSONResponse(w, http.StatusBadRequest,
+			errors.NewInvalidInputError("compression must be none, gzip or zstd"))
 		return
 	}
 
+	fileName := "dump_" + time.Now().Format("20060102_150405") +
+		manage_service.BackupFileExtension(options.Compression, options.Passphrase != "")
+
 	// Set response headers for file download
 	w.Header().Set("Content-Description", "File Transfer")
-	w.Header().Set("Content-Disposition", "attachment; filename=dump.json")
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Content-Transfer-Encoding", "binary")
+	w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
+	contentType := "application/octet-stream"
+	if (options.Compression == "" || options.Compression == manage_service.CompressionNone) && options.Passphrase == "" {
+		contentType = "application/x-ndjson"
+	}
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Expires", "0")
 	w.Header().Set("Cache-Control", "must-revalidate")
 	w.Header().Set("Pragma", "public")
-
-	// Send the file
 	w.WriteHeader(http.StatusOK)
-	file, _ := os.Open(filePath)
-	defer file.Close()
 
-	// Copy file content to response
-	util.SendFile(w, file)
+	// Stream straight into the response; the status is already sent, so failures can only be logged
+	// and show up as a missing trailer when the dump is verified or restored
+	stats, err := manage_service.WriteBackup(w, options)
+	if err != nil {
+		util.Logger.Errorw("stream dump failed", "error", err)
+	}
+	audit_service.Record(model.AuditOperationDump, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)),
+		map[string]string{"compression": options.Compression, "passphrase": options.Passphrase},
+		stats.AffectedCounts(), err)
 }
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
@@ -59,7 +61,10 @@ func ImportData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Import the data
-	err = manage_service.ImportService(tempFile.Name())
+	actor := util.GetRequestActor(r)
+	stats, err := manage_service.ImportService(tempFile.Name(), actor)
+	audit_service.Record(model.AuditOperationImport, model.NewChangeOrigin(model.ChangeSourceAPI, actor),
+		map[string]string{"file": handler.Filename}, stats.AffectedCounts(), err)
 	if err != nil {
 		util.ComposeJSONResponse(w, http.StatusInternalServerError, map[string]string{
 			"error": "Import failed: " + err.Error(),
@@ -68,7 +73,8 @@ func ImportData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return success response
-	util.ComposeJSONResponse(w, http.StatusOK, map[string]string{
+	util.ComposeJSONResponse(w, http.StatusOK, map[string]interface{}{
 		"message": "File imported successfully: " + handler.Filename,
+		"stats":   stats,
 	})
 }
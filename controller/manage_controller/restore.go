@@ -4,13 +4,21 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
 	"github.com/macar-x/cashlenx-server/service/manage_service"
 	"github.com/macar-x/cashlenx-server/util"
 )
 
-// RestoreDatabase restores database from a dump file uploaded via multipart form
+// RestoreDatabase restores database from dump files uploaded via multipart form (field "file")
+// Repeat the field to restore a full backup followed by its incremental backups, oldest first
+// Uploads are streamed to temporary files, so their size is not limited by memory
+// Query: mode=replace|merge|skip-existing|fail-on-conflict (default replace), dry_run=true to only report
+// Header: X-Backup-Passphrase for encrypted dumps (default BACKUP_PASSPHRASE)
 func RestoreDatabase(w http.ResponseWriter, r *http.Request) {
 	// Verify ADMIN_TOKEN for dangerous operation
 	if err := util.VerifyAdminTokenFromRequest(r); err != nil {
@@ -18,50 +26,105 @@ func RestoreDatabase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse multipart form data
-	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10MB max file size
-		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("Failed to parse form data"))
+	options := manage_service.RestoreOptions{Mode: r.URL.Query().Get("mode")}
+	if err := manage_service.ValidateRestoreMode(options.Mode); err != nil {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError(err.Error()))
 		return
 	}
+	if dryRun := r.URL.Query().Get("dry_run"); dryRun != "" {
+		value, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("dry_run must be true or false"))
+			return
+		}
+		options.DryRun = value
+	}
 
-	// Get the file from the form
-	file, handler, err := r.FormFile("file")
+	multipartReader, err := r.MultipartReader()
 	if err != nil {
-		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("No file uploaded or invalid file"))
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("Failed to parse form data"))
 		return
 	}
-	defer file.Close()
 
-	// Create a temporary file to save the uploaded dump
-	tempFile, err := os.CreateTemp("", "restore_dump_*.json")
-	if err != nil {
-		util.ComposeJSONResponse(w, http.StatusInternalServerError, errors.NewInternalError("Failed to create temporary file", err))
-		return
+	// Save every file part in upload order without buffering the whole form
+	var fileNameList, tempPathList []string
+	for {
+		part, err := multipartReader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("Failed to parse form data"))
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		// Create a temporary file to save the uploaded dump
+		tempFile, err := os.CreateTemp("", "restore_dump_*")
+		if err != nil {
+			util.ComposeJSONResponse(w, http.StatusInternalServerError, errors.NewInternalError("Failed to create temporary file", err))
+			return
+		}
+		defer os.Remove(tempFile.Name())
+
+		_, err = io.Copy(tempFile, part)
+		tempFile.Close()
+		if err != nil {
+			util.ComposeJSONResponse(w, http.StatusInternalServerError, errors.NewInternalError("Failed to save uploaded file", err))
+			return
+		}
+		fileNameList = append(fileNameList, part.FileName())
+		tempPathList = append(tempPathList, tempFile.Name())
 	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
 
-	// Copy the uploaded file to the temporary file
-	if _, err := io.Copy(tempFile, file); err != nil {
-		util.ComposeJSONResponse(w, http.StatusInternalServerError, errors.NewInternalError("Failed to save uploaded file", err))
+	if len(tempPathList) == 0 {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("No file uploaded or invalid file"))
 		return
 	}
 
-	// Restore from the temporary file
-	stats, err := manage_service.RestoreBackup(tempFile.Name())
+	options.Passphrase = r.Header.Get(BackupPassphraseHeader)
+	if options.Passphrase == "" {
+		options.Passphrase = util.GetConfigByKey("backup.passphrase")
+	}
+
+	// Restore from the temporary files, reporting errors with the uploaded names
+	result, err := manage_service.RestoreBackupChain(tempPathList, options)
+	audit_service.Record(model.AuditOperationRestore, model.NewChangeOrigin(model.ChangeSourceAPI, util.GetRequestActor(r)),
+		map[string]string{
+			"files":      strings.Join(fileNameList, ","),
+			"mode":       options.Mode,
+			"dry_run":    strconv.FormatBool(options.DryRun),
+			"passphrase": options.Passphrase,
+		}, result.AffectedCounts(), err)
 	if err != nil {
+		message := err.Error()
+		for i, tempPath := range tempPathList {
+			message = strings.ReplaceAll(message, tempPath, fileNameList[i])
+		}
+		// A conflict in fail-on-conflict mode left the database untouched
+		status := http.StatusInternalServerError
+		if result.Categories.Conflicts+result.CashFlows.Conflicts+result.Goals.Conflicts+result.Debts.Conflicts > 0 {
+			status = http.StatusConflict
+		}
 		// Return error along with statistics
-		util.ComposeJSONResponse(w, http.StatusInternalServerError, map[string]interface{}{
-			"error":   err.Error(),
-			"stats":   stats,
+		util.ComposeJSONResponse(w, status, map[string]interface{}{
+			"error":   message,
+			"stats":   result,
 			"message": "Database restore failed",
 		})
 		return
 	}
 
+	message := "Database restored successfully from file: "
+	if options.DryRun {
+		message = "Dry run of restore from file: "
+	}
 	// Return success response with statistics
 	util.ComposeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"message": "Database restored successfully from file: " + handler.Filename,
-		"stats":   stats,
+		"message": message + strings.Join(fileNameList, ", "),
+		"stats":   result,
 	})
 }
@@ -0,0 +1,31 @@
+package report_controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/macar-x/cashlenx-server/service/cash_flow_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// GetComparison compares a day, month or year with the period before and the same period a year earlier
+// GET /api/reports/compare?period=month&date=2026-09&against=prev,yoy
+func GetComparison(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	period := values.Get("period")
+	if period == "" {
+		period = "month"
+	}
+
+	var againstList []string
+	if against := values.Get("against"); against != "" {
+		againstList = strings.Split(against, ",")
+	}
+
+	report, err := cash_flow_service.Compare(period, values.Get("date"), againstList)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, report)
+}
@@ -0,0 +1,30 @@
+package report_controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/service/report_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// GetForecast projects the balance month by month with expected, low and high bands
+// GET /api/reports/forecast?months=6
+func GetForecast(w http.ResponseWriter, r *http.Request) {
+	months := 0
+	if monthsStr := r.URL.Query().Get("months"); monthsStr != "" {
+		var err error
+		if months, err = strconv.Atoi(monthsStr); err != nil {
+			util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewFieldValidationError("months", "months must be a number"))
+			return
+		}
+	}
+
+	report, err := report_service.ForecastService(months)
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+	util.ComposeJSONResponse(w, http.StatusOK, report)
+}
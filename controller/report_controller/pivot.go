@@ -0,0 +1,53 @@
+package report_controller
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/report_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// GetPivot returns the category by period pivot report as JSON, or as a CSV or xlsx download
+// GET /api/reports/pivot?rows=category&cols=month&from=2026-01&to=2026-06&type=OUTCOME&format=json
+func GetPivot(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	format := values.Get("format")
+	if format != "" && format != "json" && format != "csv" && format != "xlsx" {
+		util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewFieldValidationError("format", "format must be json, csv or xlsx"))
+		return
+	}
+
+	report, err := report_service.PivotService(
+		values.Get("rows"), values.Get("cols"), values.Get("from"), values.Get("to"), values.Get("type"))
+	if err != nil {
+		util.ComposeJSONResponse(w, util.GetErrorStatus(err), err)
+		return
+	}
+
+	switch format {
+	case "csv":
+		sendPivotFile(w, report, report_service.WritePivotCsv, "text/csv; charset=utf-8", "pivot.csv")
+	case "xlsx":
+		sendPivotFile(w, report, report_service.WritePivotXlsx,
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "pivot.xlsx")
+	default:
+		util.ComposeJSONResponse(w, http.StatusOK, report)
+	}
+}
+
+// sendPivotFile renders the report before answering, so a failure can still become an error response
+func sendPivotFile(w http.ResponseWriter, report model.PivotReport, write func(io.Writer, model.PivotReport) error, contentType, fileName string) {
+	var buffer bytes.Buffer
+	if err := write(&buffer, report); err != nil {
+		util.ComposeJSONResponse(w, http.StatusInternalServerError, errors.NewInternalError("failed to write the report", err))
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
+	w.WriteHeader(http.StatusOK)
+	util.SendFile(w, &buffer)
+}
@@ -1,19 +1,29 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/macar-x/cashlenx-server/controller/cash_flow_controller"
 	"github.com/macar-x/cashlenx-server/controller/category_controller"
+	"github.com/macar-x/cashlenx-server/controller/debt_controller"
+	"github.com/macar-x/cashlenx-server/controller/goal_controller"
+	"github.com/macar-x/cashlenx-server/controller/insight_controller"
 	"github.com/macar-x/cashlenx-server/controller/manage_controller"
+	"github.com/macar-x/cashlenx-server/controller/report_controller"
 	"github.com/macar-x/cashlenx-server/middleware"
 	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/util"
 )
 
-func StartServer(port int32) {
+// shutdownTimeout is how long requests in flight get to finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// StartServer serves the API until ctx is done, then lets the requests in flight finish
+func StartServer(ctx context.Context, port int32) error {
 	// Explicitly load timezone at server startup to ensure it's configured
 	// and logged immediately
 	tz := util.GetTimezone()
@@ -26,13 +36,30 @@ func StartServer(port int32) {
 	registerCashRoute(r)
 	registerCategoryRoute(r)
 	registerManageRoute(r)
+	registerReportRoute(r)
+	registerInsightRoute(r)
+	registerGoalRoute(r)
+	registerDebtRoute(r)
 
 	// Apply middleware
-	handler := middleware.Logging(middleware.SchemaValidation(middleware.CORS(r)))
+	handler := middleware.Logging(middleware.SchemaValidation(middleware.CORS(middleware.Idempotency(r))))
 
 	addr := fmt.Sprintf(":%d", port)
 	fmt.Printf("API server is running on http://localhost%s\n", addr)
-	http.ListenAndServe(addr, handler)
+	server := &http.Server{Addr: addr, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			util.Logger.Errorw("api server shutdown failed", "error", err)
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	fmt.Println("API server stopped")
+	return nil
 }
 
 func registerHealthRoutes(r *mux.Router) {
@@ -47,10 +74,16 @@ func registerCashRoute(r *mux.Router) {
 
 	// Read
 	r.HandleFunc("/api/cash", cash_flow_controller.ListAll).Methods("GET")
+	r.HandleFunc("/api/cash/search", cash_flow_controller.Search).Methods("GET")
+	r.HandleFunc("/api/cash/search/text", cash_flow_controller.TextSearch).Methods("GET")
 	r.HandleFunc("/api/cash/{id}", cash_flow_controller.QueryById).Methods("GET")
 	r.HandleFunc("/api/cash/date/{date}", cash_flow_controller.QueryByDate).Methods("GET")
 	r.HandleFunc("/api/cash/range", cash_flow_controller.QueryByDateRange).Methods("GET")
 
+	// Change history
+	r.HandleFunc("/api/cash/{id}/history", cash_flow_controller.GetHistory).Methods("GET")
+	r.HandleFunc("/api/cash/{id}/history/{revision_id}/revert", cash_flow_controller.RevertToRevision).Methods("POST")
+
 	// Summary endpoints
 	r.HandleFunc("/api/cash/summary/daily/{date}", cash_flow_controller.GetDailySummary).Methods("GET")
 	r.HandleFunc("/api/cash/summary/monthly/{month}", cash_flow_controller.GetMonthlySummary).Methods("GET")
@@ -58,10 +91,18 @@ func registerCashRoute(r *mux.Router) {
 
 	// Update
 	r.HandleFunc("/api/cash/{id}", cash_flow_controller.UpdateById).Methods("PUT")
+	r.HandleFunc("/api/cash/{id}", cash_flow_controller.PatchById).Methods("PATCH")
 
 	// Delete
 	r.HandleFunc("/api/cash/{id}", cash_flow_controller.DeleteById).Methods("DELETE")
 	r.HandleFunc("/api/cash/date/{date}", cash_flow_controller.DeleteByDate).Methods("DELETE")
+
+	// Batch of creates, updates and deletes
+	r.HandleFunc("/api/cash/batch", cash_flow_controller.ExecuteBatch).Methods("POST")
+
+	// Trash of deleted cash flows
+	r.HandleFunc("/api/trash", cash_flow_controller.ListTrash).Methods("GET")
+	r.HandleFunc("/api/trash/{id}/restore", cash_flow_controller.RestoreFromTrash).Methods("POST")
 }
 
 func registerCategoryRoute(r *mux.Router) {
@@ -78,8 +119,13 @@ func registerCategoryRoute(r *mux.Router) {
 	// Read category tree structure
 	r.HandleFunc("/api/category/tree", category_controller.Tree).Methods("GET")
 
+	// Change history
+	r.HandleFunc("/api/category/{id}/history", category_controller.GetHistory).Methods("GET")
+	r.HandleFunc("/api/category/{id}/history/{revision_id}/revert", category_controller.RevertToRevision).Methods("POST")
+
 	// Update
 	r.HandleFunc("/api/category/{id}", category_controller.UpdateById).Methods("PUT")
+	r.HandleFunc("/api/category/{id}", category_controller.PatchById).Methods("PATCH")
 
 	// Delete
 	r.HandleFunc("/api/category/{id}", category_controller.DeleteById).Methods("DELETE")
@@ -99,12 +145,41 @@ func registerManageRoute(r *mux.Router) {
 	// Dump and restore endpoints
 	r.HandleFunc("/api/manage/dump", manage_controller.DumpDatabase).Methods("GET")
 	r.HandleFunc("/api/manage/restore", manage_controller.RestoreDatabase).Methods("POST")
+	r.HandleFunc("/api/manage/backup/status", manage_controller.GetBackupStatus).Methods("GET")
+	r.HandleFunc("/api/manage/audit", manage_controller.GetAuditLog).Methods("GET")
 
 	// Import and export endpoints
 	r.HandleFunc("/api/manage/export", manage_controller.ExportData).Methods("GET")
 	r.HandleFunc("/api/manage/import", manage_controller.ImportData).Methods("POST")
 }
 
+func registerReportRoute(r *mux.Router) {
+	r.HandleFunc("/api/reports/pivot", report_controller.GetPivot).Methods("GET")
+	r.HandleFunc("/api/reports/compare", report_controller.GetComparison).Methods("GET")
+	r.HandleFunc("/api/reports/forecast", report_controller.GetForecast).Methods("GET")
+}
+
+func registerInsightRoute(r *mux.Router) {
+	r.HandleFunc("/api/insights/anomalies", insight_controller.GetAnomalies).Methods("GET")
+	r.HandleFunc("/api/insights/subscriptions", insight_controller.GetSubscriptions).Methods("GET")
+}
+
+func registerGoalRoute(r *mux.Router) {
+	r.HandleFunc("/api/goals", goal_controller.Create).Methods("POST")
+	r.HandleFunc("/api/goals", goal_controller.List).Methods("GET")
+	r.HandleFunc("/api/goals/{id}", goal_controller.QueryById).Methods("GET")
+	r.HandleFunc("/api/goals/{id}", goal_controller.UpdateById).Methods("PUT")
+	r.HandleFunc("/api/goals/{id}", goal_controller.DeleteById).Methods("DELETE")
+}
+
+func registerDebtRoute(r *mux.Router) {
+	r.HandleFunc("/api/debts", debt_controller.Create).Methods("POST")
+	r.HandleFunc("/api/debts", debt_controller.List).Methods("GET")
+	r.HandleFunc("/api/debts/{id}", debt_controller.QueryById).Methods("GET")
+	r.HandleFunc("/api/debts/{id}", debt_controller.UpdateById).Methods("PUT")
+	r.HandleFunc("/api/debts/{id}", debt_controller.DeleteById).Methods("DELETE")
+}
+
 // Version info endpoint
 func versionInfo(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
@@ -117,15 +192,25 @@ func versionInfo(w http.ResponseWriter, r *http.Request) {
 				"POST /api/cash/income",
 				"GET /api/cash",
 				"GET /api/cash?limit=20&offset=0&type=income",
+				"GET /api/cash/search?q=amount>50 category:Food/*",
+				"GET /api/cash/search/text?q=coffee&limit=20",
 				"GET /api/cash/{id}",
 				"GET /api/cash/date/{date}",
 				"GET /api/cash/range?from=YYYYMMDD&to=YYYYMMDD",
 				"GET /api/cash/summary/daily/{date}",
 				"GET /api/cash/summary/monthly/{month}",
 				"GET /api/cash/summary/yearly/{year}",
+				"GET /api/cash/{id}/history",
+				"POST /api/cash/{id}/history/{revision_id}/revert",
 				"PUT /api/cash/{id}",
+				"PATCH /api/cash/{id}",
 				"DELETE /api/cash/{id}",
 				"DELETE /api/cash/date/{date}",
+				"POST /api/cash/batch",
+			},
+			"trash": {
+				"GET /api/trash",
+				"POST /api/trash/{id}/restore",
 			},
 			"category": {
 				"POST /api/category",
@@ -134,15 +219,43 @@ func versionInfo(w http.ResponseWriter, r *http.Request) {
 				"GET /api/category/{id}",
 				"GET /api/category/name/{name}",
 				"GET /api/category/{parent_id}/children",
+				"GET /api/category/{id}/history",
+				"POST /api/category/{id}/history/{revision_id}/revert",
 				"PUT /api/category/{id}",
+				"PATCH /api/category/{id}",
 				"DELETE /api/category/{id}",
 			},
 			"manage": {
 				"GET /api/manage/dump",
 				"POST /api/manage/restore",
+				"GET /api/manage/backup/status",
+				"GET /api/manage/audit",
 				"GET /api/manage/export",
 				"POST /api/manage/import",
 			},
+			"reports": {
+				"GET /api/reports/pivot?rows=category&cols=month&from=2026-01&to=2026-06&type=OUTCOME&format=json",
+				"GET /api/reports/compare?period=month&date=2026-09&against=prev,yoy",
+				"GET /api/reports/forecast?months=6",
+			},
+			"insights": {
+				"GET /api/insights/anomalies?from=2026-09-01&to=2026-09-30",
+				"GET /api/insights/subscriptions?all=true",
+			},
+			"goals": {
+				"POST /api/goals",
+				"GET /api/goals",
+				"GET /api/goals/{id}",
+				"PUT /api/goals/{id}",
+				"DELETE /api/goals/{id}",
+			},
+			"debts": {
+				"POST /api/debts",
+				"GET /api/debts",
+				"GET /api/debts/{id}",
+				"PUT /api/debts/{id}",
+				"DELETE /api/debts/{id}",
+			},
 			"health": {
 				"GET /api/health",
 				"GET /api/version",
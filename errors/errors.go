@@ -6,14 +6,17 @@ import "fmt"
 type ErrorCode string
 
 const (
-	ErrNotFound         ErrorCode = "NOT_FOUND"
-	ErrInvalidInput     ErrorCode = "INVALID_INPUT"
-	ErrDatabase         ErrorCode = "DATABASE_ERROR"
-	ErrUnauthorized     ErrorCode = "UNAUTHORIZED"
-	ErrAlreadyExists    ErrorCode = "ALREADY_EXISTS"
-	ErrInternal         ErrorCode = "INTERNAL_ERROR"
-	ErrValidation       ErrorCode = "VALIDATION_ERROR"
-	ErrConnectionFailed ErrorCode = "CONNECTION_FAILED"
+	ErrNotFound             ErrorCode = "NOT_FOUND"
+	ErrInvalidInput         ErrorCode = "INVALID_INPUT"
+	ErrDatabase             ErrorCode = "DATABASE_ERROR"
+	ErrUnauthorized         ErrorCode = "UNAUTHORIZED"
+	ErrAlreadyExists        ErrorCode = "ALREADY_EXISTS"
+	ErrInternal             ErrorCode = "INTERNAL_ERROR"
+	ErrValidation           ErrorCode = "VALIDATION_ERROR"
+	ErrConnectionFailed     ErrorCode = "CONNECTION_FAILED"
+	ErrPrecondition         ErrorCode = "PRECONDITION_FAILED"
+	ErrPreconditionRequired ErrorCode = "PRECONDITION_REQUIRED"
+	ErrBatchAborted         ErrorCode = "BATCH_ABORTED"
 )
 
 // AppError represents a standardized application error
@@ -123,6 +126,30 @@ func NewConnectionFailedError(message string, cause error) *AppError {
 	}
 }
 
+// NewPreconditionFailedError creates a PRECONDITION_FAILED error, e.g. for a stale If-Match
+func NewPreconditionFailedError(message string) *AppError {
+	return &AppError{
+		Code:    ErrPrecondition,
+		Message: message,
+	}
+}
+
+// NewPreconditionRequiredError creates a PRECONDITION_REQUIRED error, e.g. for a missing If-Match
+func NewPreconditionRequiredError(message string) *AppError {
+	return &AppError{
+		Code:    ErrPreconditionRequired,
+		Message: message,
+	}
+}
+
+// NewBatchAbortedError creates a BATCH_ABORTED error for an operation left out because its atomic batch failed
+func NewBatchAbortedError(message string) *AppError {
+	return &AppError{
+		Code:    ErrBatchAborted,
+		Message: message,
+	}
+}
+
 // IsNotFound checks if error is a NOT_FOUND error
 func IsNotFound(err error) bool {
 	if appErr, ok := err.(*AppError); ok {
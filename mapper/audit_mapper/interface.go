@@ -0,0 +1,26 @@
+package audit_mapper
+
+import (
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+var INSTANCE AuditMapper
+
+type AuditMapper interface {
+	InsertAudit(entity model.AuditEntity) error
+	// GetAudits returns a page of the audit log matching the filter, newest first
+	GetAudits(filter model.AuditFilter, limit, offset int) ([]model.AuditEntity, error)
+	CountAudits(filter model.AuditFilter) (int64, error)
+}
+
+func init() {
+	switch util.GetConfigByKey("db.type") {
+	case "mongodb":
+		INSTANCE = AuditMongoDbMapper{}
+	case "mysql":
+		INSTANCE = AuditMySqlMapper{}
+	default:
+		panic("database type not supported")
+	}
+}
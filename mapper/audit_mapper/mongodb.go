@@ -0,0 +1,83 @@
+package audit_mapper
+
+import (
+	"context"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditMongoDbMapper struct{}
+
+func (AuditMongoDbMapper) InsertAudit(entity model.AuditEntity) error {
+	collection := database.GetMongoCollection(database.AuditTableName)
+	if _, err := collection.InsertOne(context.TODO(), entity); err != nil {
+		util.Logger.Errorw("insert audit failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (AuditMongoDbMapper) GetAudits(filter model.AuditFilter, limit, offset int) ([]model.AuditEntity, error) {
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "_id", Value: -1}})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+	if offset > 0 {
+		findOptions.SetSkip(int64(offset))
+	}
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.AuditTableName)
+	cursor, err := collection.Find(ctx, convertAuditFilter2BsonD(filter), findOptions)
+	if err != nil {
+		util.Logger.Errorw("query audit log failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.AuditEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode audit log failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
+func (AuditMongoDbMapper) CountAudits(filter model.AuditFilter) (int64, error) {
+	collection := database.GetMongoCollection(database.AuditTableName)
+	count, err := collection.CountDocuments(context.TODO(), convertAuditFilter2BsonD(filter))
+	if err != nil {
+		util.Logger.Errorw("count audit log failed", "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func convertAuditFilter2BsonD(filter model.AuditFilter) bson.D {
+	filterD := bson.D{}
+	if filter.Operation != "" {
+		filterD = append(filterD, primitive.E{Key: "operation", Value: filter.Operation})
+	}
+	if filter.Actor != "" {
+		filterD = append(filterD, primitive.E{Key: "actor", Value: filter.Actor})
+	}
+	if filter.Outcome != "" {
+		filterD = append(filterD, primitive.E{Key: "outcome", Value: filter.Outcome})
+	}
+	timeRange := bson.M{}
+	if !filter.From.IsZero() {
+		timeRange["$gte"] = filter.From.UTC()
+	}
+	if !filter.To.IsZero() {
+		timeRange["$lt"] = filter.To.UTC()
+	}
+	if len(timeRange) > 0 {
+		filterD = append(filterD, primitive.E{Key: "create_time", Value: timeRange})
+	}
+	return filterD
+}
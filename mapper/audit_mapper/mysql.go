@@ -0,0 +1,146 @@
+package audit_mapper
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditMySqlMapper struct{}
+
+func (AuditMySqlMapper) InsertAudit(entity model.AuditEntity) error {
+	// Parameters and affected counts are stored as JSON objects
+	parameters, err := json.Marshal(entity.Parameters)
+	if err != nil {
+		return err
+	}
+	affected, err := json.Marshal(entity.Affected)
+	if err != nil {
+		return err
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(database.AuditTableName)
+	sqlString.WriteString(" (ID, OPERATION, ACTOR, SOURCE, PARAMETERS, AFFECTED, OUTCOME, ERROR, CREATE_TIME) ")
+	sqlString.WriteString(" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ")
+
+	var errorMessage sql.NullString
+	if entity.Error != "" {
+		errorMessage = sql.NullString{String: entity.Error, Valid: true}
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	_, err = connection.Exec(sqlString.String(),
+		entity.Id.Hex(), entity.Operation, entity.Actor, entity.Source, string(parameters),
+		string(affected), entity.Outcome, errorMessage, entity.CreateTime.UTC())
+	if err != nil {
+		util.Logger.Errorw("insert audit failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (AuditMySqlMapper) GetAudits(filter model.AuditFilter, limit, offset int) ([]model.AuditEntity, error) {
+	whereClause, argList := convertAuditFilter2Where(filter)
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, OPERATION, ACTOR, SOURCE, PARAMETERS, AFFECTED, OUTCOME, ERROR, CREATE_TIME FROM ")
+	sqlString.WriteString(database.AuditTableName)
+	sqlString.WriteString(whereClause)
+	sqlString.WriteString(" ORDER BY ID DESC ")
+	if limit > 0 {
+		sqlString.WriteString(" LIMIT ? OFFSET ? ")
+		argList = append(argList, limit, offset)
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("query audit log failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.AuditEntity
+	for rows.Next() {
+		var id, parameters, affected, createTime string
+		var errorMessage sql.NullString
+		var entity model.AuditEntity
+		if err := rows.Scan(&id, &entity.Operation, &entity.Actor, &entity.Source, &parameters,
+			&affected, &entity.Outcome, &errorMessage, &createTime); err != nil {
+			util.Logger.Errorw("scan audit failed", "error", err)
+			return nil, err
+		}
+		if entity.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(parameters), &entity.Parameters); err != nil {
+			util.Logger.Errorw("decode audit parameters failed", "id", id, "error", err)
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(affected), &entity.Affected); err != nil {
+			util.Logger.Errorw("decode audit counts failed", "id", id, "error", err)
+			return nil, err
+		}
+		entity.Error = errorMessage.String
+		entity.CreateTime = database.ParseMySqlTime(createTime)
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
+func (AuditMySqlMapper) CountAudits(filter model.AuditFilter) (int64, error) {
+	whereClause, argList := convertAuditFilter2Where(filter)
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT COUNT(1) FROM ")
+	sqlString.WriteString(database.AuditTableName)
+	sqlString.WriteString(whereClause)
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	var count int64
+	if err := connection.QueryRow(sqlString.String(), argList...).Scan(&count); err != nil {
+		util.Logger.Errorw("count audit log failed", "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func convertAuditFilter2Where(filter model.AuditFilter) (string, []interface{}) {
+	var whereClause bytes.Buffer
+	whereClause.WriteString(" WHERE 1 = 1 ")
+	var argList []interface{}
+	if filter.Operation != "" {
+		whereClause.WriteString(" AND OPERATION = ? ")
+		argList = append(argList, filter.Operation)
+	}
+	if filter.Actor != "" {
+		whereClause.WriteString(" AND ACTOR = ? ")
+		argList = append(argList, filter.Actor)
+	}
+	if filter.Outcome != "" {
+		whereClause.WriteString(" AND OUTCOME = ? ")
+		argList = append(argList, filter.Outcome)
+	}
+	if !filter.From.IsZero() {
+		whereClause.WriteString(" AND CREATE_TIME >= ? ")
+		argList = append(argList, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		whereClause.WriteString(" AND CREATE_TIME < ? ")
+		argList = append(argList, filter.To.UTC())
+	}
+	return whereClause.String(), argList
+}
@@ -1,14 +1,19 @@
 package cash_flow_mapper
 
 import (
+	"errors"
 	"time"
 
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"github.com/macar-x/cashlenx-server/util"
 )
 
 var INSTANCE CashFlowMapper
 
+// ErrTextIndexMissing is returned by TextSearchCashFlows while the database has no full-text index
+var ErrTextIndexMissing = errors.New("full-text index missing, run 'cashlenx manage indexes'")
+
 type CashFlowMapper interface {
 	GetCashFlowByObjectId(plainId string) model.CashFlowEntity
 	GetCashFlowsByObjectIdArray(plainIdList []string) []model.CashFlowEntity
@@ -20,12 +25,39 @@ type CashFlowMapper interface {
 	CountCashFLowsByCategoryId(categoryPlainId string) int64
 	InsertCashFlowByEntity(newEntity model.CashFlowEntity) string
 	BulkInsertCashFlows(entities []model.CashFlowEntity) ([]string, error)
+	BulkUpsertCashFlows(entities []model.CashFlowEntity) error
+	// UpdateCashFlowByEntity only applies while the stored version still equals updatedEntity.Version,
+	// and bumps it; returns an empty entity when the cash flow is gone or was updated in between
 	UpdateCashFlowByEntity(plainId string, updatedEntity model.CashFlowEntity) model.CashFlowEntity
 	GetAllCashFlows(limit, offset int) []model.CashFlowEntity
+	// GetCashFlowsPage reads request.FetchLimit() cash flows matching filter, or all when it is nil, in the
+	// sort of request starting past its cursor; a backward page comes in reverse order
+	GetCashFlowsPage(filter query.Node, request query.PageRequest) ([]model.CashFlowEntity, error)
+	GetCashFlowsAfterId(afterPlainId string, limit int) ([]model.CashFlowEntity, error)
+	GetCashFlowsModifiedSince(since time.Time, afterPlainId string, limit int) ([]model.CashFlowEntity, error)
 	CountAllCashFlows() int64
+	// SearchCashFlows returns the cash flows matching a parsed search query, newest first
+	SearchCashFlows(node query.Node, limit, offset int) ([]model.CashFlowEntity, error)
+	CountSearchCashFlows(node query.Node) (int64, error)
+	// TextSearchCashFlows ranks cash flows by the words of their description and remark, using the full-text
+	// index from 'manage indexes'; returns the best limit hits, highest score first, without highlights
+	TextSearchCashFlows(termList []string, limit int) ([]model.TextSearchHit, error)
 	DeleteCashFlowByObjectId(plainId string) model.CashFlowEntity
 	DeleteCashFlowByBelongsDate(belongsDate time.Time) []model.CashFlowEntity
 	TruncateCashFlows() error
+
+	// Trash: deletes above are permanent, these keep the row with deleted_at set.
+	// Every other query only sees cash flows that are not in the trash.
+	SoftDeleteCashFlowByObjectId(plainId string) model.CashFlowEntity
+	SoftDeleteCashFlowsByBelongsDate(belongsDate time.Time) []model.CashFlowEntity
+	GetDeletedCashFlowByObjectId(plainId string) model.CashFlowEntity
+	// GetCashFlowsByIdListWithDeleted returns the cash flows with the given ids, in the trash or not
+	GetCashFlowsByIdListWithDeleted(plainIdList []string) ([]model.CashFlowEntity, error)
+	GetDeletedCashFlows(limit, offset int) []model.CashFlowEntity
+	CountDeletedCashFlows() int64
+	RestoreDeletedCashFlowByObjectId(plainId string) model.CashFlowEntity
+	PurgeDeletedCashFlowByObjectId(plainId string) model.CashFlowEntity
+	PurgeDeletedCashFlows(deletedBefore time.Time) (int64, error)
 }
 
 func init() {
@@ -2,18 +2,28 @@ package cash_flow_mapper
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/macar-x/cashlenx-server/util/database"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CashFlowMongoDbMapper struct{}
 
+// notDeletedFilter keeps cash flows in the trash out of a query; null also matches a missing deleted_at
+var notDeletedFilter = primitive.E{Key: "deleted_at", Value: nil}
+
+// mongoDbIndexNotFoundCode is the server error of a $text query on a collection without a text index
+const mongoDbIndexNotFoundCode = 27
+
 func (CashFlowMongoDbMapper) GetCashFlowByObjectId(plainId string) model.CashFlowEntity {
 	objectId := util.Convert2ObjectId(plainId)
 	if plainId == "" || objectId == primitive.NilObjectID {
@@ -23,6 +33,7 @@ func (CashFlowMongoDbMapper) GetCashFlowByObjectId(plainId string) model.CashFlo
 
 	filter := bson.D{
 		primitive.E{Key: "_id", Value: objectId},
+		notDeletedFilter,
 	}
 
 	database.OpenMongoDbConnection(database.CashFlowTableName)
@@ -39,6 +50,7 @@ func (CashFlowMongoDbMapper) GetCashFlowsByObjectIdArray(plainIdList []string) [
 
 	filter := bson.D{
 		primitive.E{Key: "_id", Value: bson.M{"$in": objectIdArray}},
+		notDeletedFilter,
 	}
 
 	// Open connection to cashFlow table
@@ -57,6 +69,7 @@ func (CashFlowMongoDbMapper) GetCashFlowsByObjectIdArray(plainIdList []string) [
 func (CashFlowMongoDbMapper) GetCashFlowsByBelongsDate(belongsDate time.Time) []model.CashFlowEntity {
 	filter := bson.D{
 		primitive.E{Key: "belongs_date", Value: belongsDate},
+		notDeletedFilter,
 	}
 
 	// Open connection to cashFlow table
@@ -78,6 +91,7 @@ func (CashFlowMongoDbMapper) GetCashFlowsByDateRange(from, to time.Time) []model
 			"$gte": from,
 			"$lte": to,
 		}},
+		notDeletedFilter,
 	}
 
 	database.OpenMongoDbConnection(database.CashFlowTableName)
@@ -100,6 +114,7 @@ func (CashFlowMongoDbMapper) GetCashFlowsByCategoryId(categoryPlainId string) []
 
 	filter := bson.D{
 		primitive.E{Key: "category_id", Value: categoryObjectId},
+		notDeletedFilter,
 	}
 
 	database.OpenMongoDbConnection(database.CashFlowTableName)
@@ -122,6 +137,7 @@ func (CashFlowMongoDbMapper) CountCashFLowsByCategoryId(categoryPlainId string)
 
 	filter := bson.D{
 		primitive.E{Key: "category_id", Value: categoryObjectId},
+		notDeletedFilter,
 	}
 
 	database.OpenMongoDbConnection(database.CashFlowTableName)
@@ -133,6 +149,7 @@ func (CashFlowMongoDbMapper) CountCashFLowsByCategoryId(categoryPlainId string)
 func (CashFlowMongoDbMapper) GetCashFlowsByExactDesc(description string) []model.CashFlowEntity {
 	filter := bson.D{
 		primitive.E{Key: "description", Value: description},
+		notDeletedFilter,
 	}
 
 	// Open connection to cashFlow table
@@ -156,6 +173,7 @@ func (CashFlowMongoDbMapper) GetCashFlowsByFuzzyDesc(description string) []model
 			Pattern: description,
 			Options: "i",
 		}},
+		notDeletedFilter,
 	}
 
 	// Open connection to cash_flow table
@@ -213,6 +231,35 @@ func (CashFlowMongoDbMapper) BulkInsertCashFlows(entities []model.CashFlowEntity
 	return ids, nil
 }
 
+// BulkUpsertCashFlows inserts or replaces cash flows by id, keeping their ids and timestamps
+// A replaced cash flow that was in the trash is live again
+func (CashFlowMongoDbMapper) BulkUpsertCashFlows(entities []model.CashFlowEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	// Fields are set rather than the document replaced, so the version keeps counting up
+	writeModels := make([]mongo.WriteModel, len(entities))
+	for i, entity := range entities {
+		update := bson.D{
+			primitive.E{Key: "$set", Value: convertCashFlowContent2BsonD(entity)},
+			primitive.E{Key: "$unset", Value: bson.D{primitive.E{Key: "deleted_at", Value: ""}}},
+			primitive.E{Key: "$inc", Value: bson.D{primitive.E{Key: "version", Value: int64(1)}}},
+		}
+		writeModels[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.D{primitive.E{Key: "_id", Value: entity.Id}}).
+			SetUpdate(update).
+			SetUpsert(true)
+	}
+
+	collection := database.GetMongoCollection(database.CashFlowTableName)
+	if _, err := collection.BulkWrite(context.TODO(), writeModels, options.BulkWrite().SetOrdered(false)); err != nil {
+		util.Logger.Errorw("bulk upsert failed", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (CashFlowMongoDbMapper) UpdateCashFlowByEntity(plainId string, updatedEntity model.CashFlowEntity) model.CashFlowEntity {
 	objectId := util.Convert2ObjectId(plainId)
 	if plainId == "" || objectId == primitive.NilObjectID {
@@ -222,6 +269,8 @@ func (CashFlowMongoDbMapper) UpdateCashFlowByEntity(plainId string, updatedEntit
 
 	filter := bson.D{
 		primitive.E{Key: "_id", Value: objectId},
+		notDeletedFilter,
+		database.VersionFilter(updatedEntity.Version),
 	}
 
 	database.OpenMongoDbConnection(database.CashFlowTableName)
@@ -229,7 +278,7 @@ func (CashFlowMongoDbMapper) UpdateCashFlowByEntity(plainId string, updatedEntit
 
 	targetEntity := convertBsonM2CashFlowEntity(database.GetOneInMongoDB(filter))
 	if targetEntity.IsEmpty() {
-		util.Logger.Infoln("cash_flow is not exist")
+		util.Logger.Infow("cash_flow is not exist at version", "version", updatedEntity.Version)
 		return model.CashFlowEntity{}
 	}
 
@@ -237,11 +286,12 @@ func (CashFlowMongoDbMapper) UpdateCashFlowByEntity(plainId string, updatedEntit
 	updatedEntity.Id = targetEntity.Id
 	updatedEntity.CreateTime = targetEntity.CreateTime
 	updatedEntity.ModifyTime = time.Now().UTC() // Store in UTC
+	updatedEntity.Version++
 
+	// The version in the filter makes this a compare-and-swap
 	rowsAffected := database.UpdateManyInMongoDB(filter, convertCashFlowEntity2BsonD(updatedEntity))
 	if rowsAffected != 1 {
-		// fixme: maybe we should have a rollback here.
-		util.Logger.Errorw("update failed", "rows_affected", rowsAffected)
+		util.Logger.Infow("update skipped", "rows_affected", rowsAffected)
 		return model.CashFlowEntity{}
 	}
 
@@ -278,6 +328,7 @@ func (CashFlowMongoDbMapper) DeleteCashFlowByObjectId(plainId string) model.Cash
 func (CashFlowMongoDbMapper) DeleteCashFlowByBelongsDate(belongsDate time.Time) []model.CashFlowEntity {
 	filter := bson.D{
 		primitive.E{Key: "belongs_date", Value: belongsDate},
+		notDeletedFilter,
 	}
 
 	cashFlowList := INSTANCE.GetCashFlowsByBelongsDate(belongsDate)
@@ -303,8 +354,8 @@ func (CashFlowMongoDbMapper) GetAllCashFlows(limit, offset int) []model.CashFlow
 
 	collection := database.GetMongoCollection(database.CashFlowTableName)
 
-	// Empty filter to get all documents, with pagination
-	filter := bson.D{}
+	// Every cash flow outside the trash, with pagination
+	filter := bson.D{notDeletedFilter}
 
 	ctx := context.TODO()
 	findOptions := options.Find()
@@ -337,8 +388,127 @@ func (CashFlowMongoDbMapper) GetAllCashFlows(limit, offset int) []model.CashFlow
 	return targetEntityList
 }
 
+func (CashFlowMongoDbMapper) GetCashFlowsPage(filter query.Node, request query.PageRequest) ([]model.CashFlowEntity, error) {
+	conditionList := bson.A{}
+	if filter != nil {
+		queryFilter, err := query.ToMongoDbFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		conditionList = append(conditionList, queryFilter)
+	}
+	sort, keysetFilter := query.MongoDbPage(request)
+	if keysetFilter != nil {
+		conditionList = append(conditionList, keysetFilter)
+	}
+	pageFilter := bson.D{notDeletedFilter}
+	if len(conditionList) > 0 {
+		pageFilter = append(pageFilter, primitive.E{Key: "$and", Value: conditionList})
+	}
+
+	findOptions := options.Find().SetSort(sort).SetLimit(int64(request.FetchLimit()))
+	if skip := request.Skip(); skip > 0 {
+		findOptions.SetSkip(int64(skip))
+	}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.CashFlowTableName)
+	cursor, err := collection.Find(ctx, pageFilter, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query page failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.CashFlowEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
+// GetCashFlowsByIdListWithDeleted returns the full cash flows with the given ids, trashed ones included;
+// unknown ids are left out
+func (CashFlowMongoDbMapper) GetCashFlowsByIdListWithDeleted(plainIdList []string) ([]model.CashFlowEntity, error) {
+	objectIdList := make([]primitive.ObjectID, 0, len(plainIdList))
+	for _, plainId := range plainIdList {
+		objectId, err := primitive.ObjectIDFromHex(plainId)
+		if err != nil {
+			return nil, err
+		}
+		objectIdList = append(objectIdList, objectId)
+	}
+	filter := bson.D{primitive.E{Key: "_id", Value: bson.M{"$in": objectIdList}}}
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.CashFlowTableName)
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		util.Logger.Errorw("query by id list failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.CashFlowEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
+// GetCashFlowsAfterId returns up to limit cash flows with an id greater than afterPlainId, in id order
+// An empty afterPlainId starts from the beginning; used to page through every row with constant memory
+func (CashFlowMongoDbMapper) GetCashFlowsAfterId(afterPlainId string, limit int) ([]model.CashFlowEntity, error) {
+	return getCashFlowsAfterIdInMongoDb(time.Time{}, afterPlainId, limit)
+}
+
+func (CashFlowMongoDbMapper) GetCashFlowsModifiedSince(since time.Time, afterPlainId string, limit int) ([]model.CashFlowEntity, error) {
+	return getCashFlowsAfterIdInMongoDb(since, afterPlainId, limit)
+}
+
+// getCashFlowsAfterIdInMongoDb pages cash flows by _id, optionally only those with modify_time >= since
+func getCashFlowsAfterIdInMongoDb(since time.Time, afterPlainId string, limit int) ([]model.CashFlowEntity, error) {
+	filter := bson.D{notDeletedFilter}
+	if afterPlainId != "" {
+		afterId, err := primitive.ObjectIDFromHex(afterPlainId)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, primitive.E{Key: "_id", Value: bson.M{"$gt": afterId}})
+	}
+	if !since.IsZero() {
+		filter = append(filter, primitive.E{Key: "modify_time", Value: bson.M{"$gte": since.UTC()}})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "_id", Value: 1}})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.CashFlowTableName)
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query after id failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.CashFlowEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
 func (CashFlowMongoDbMapper) CountAllCashFlows() int64 {
-	filter := bson.D{}
+	filter := bson.D{notDeletedFilter}
 
 	database.OpenMongoDbConnection(database.CashFlowTableName)
 	defer database.CloseMongoDbConnection()
@@ -346,6 +516,102 @@ func (CashFlowMongoDbMapper) CountAllCashFlows() int64 {
 	return database.CountInMongoDB(filter)
 }
 
+func (CashFlowMongoDbMapper) SearchCashFlows(node query.Node, limit, offset int) ([]model.CashFlowEntity, error) {
+	queryFilter, err := query.ToMongoDbFilter(node)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.D{notDeletedFilter, primitive.E{Key: "$and", Value: bson.A{queryFilter}}}
+
+	findOptions := options.Find().SetSort(bson.D{
+		primitive.E{Key: "belongs_date", Value: -1},
+		primitive.E{Key: "_id", Value: -1},
+	})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+	if offset > 0 {
+		findOptions.SetSkip(int64(offset))
+	}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.CashFlowTableName)
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		util.Logger.Errorw("search failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.CashFlowEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
+func (CashFlowMongoDbMapper) CountSearchCashFlows(node query.Node) (int64, error) {
+	queryFilter, err := query.ToMongoDbFilter(node)
+	if err != nil {
+		return 0, err
+	}
+	filter := bson.D{notDeletedFilter, primitive.E{Key: "$and", Value: bson.A{queryFilter}}}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+
+	return database.CountInMongoDB(filter), nil
+}
+
+// TextSearchCashFlows uses the text index idx_cash_flow_text, which stems in English and weighs descriptions higher
+func (CashFlowMongoDbMapper) TextSearchCashFlows(termList []string, limit int) ([]model.TextSearchHit, error) {
+	filter := bson.D{
+		primitive.E{Key: "$text", Value: bson.M{"$search": strings.Join(termList, " ")}},
+		notDeletedFilter,
+	}
+	score := bson.M{"$meta": "textScore"}
+	findOptions := options.Find().
+		SetProjection(bson.D{primitive.E{Key: "score", Value: score}}).
+		SetSort(bson.D{primitive.E{Key: "score", Value: score}, primitive.E{Key: "belongs_date", Value: -1}})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.CashFlowTableName)
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		var serverError mongo.ServerError
+		if errors.As(err, &serverError) && serverError.HasErrorCode(mongoDbIndexNotFoundCode) {
+			return nil, ErrTextIndexMissing
+		}
+		util.Logger.Errorw("text search failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var scoredList []struct {
+		Entity model.CashFlowEntity `bson:",inline"`
+		Score  float64              `bson:"score"`
+	}
+	if err := cursor.All(ctx, &scoredList); err != nil {
+		util.Logger.Errorw("decode failed", "error", err)
+		return nil, err
+	}
+	hitList := make([]model.TextSearchHit, 0, len(scoredList))
+	for _, scored := range scoredList {
+		hitList = append(hitList, model.TextSearchHit{CashFlow: scored.Entity, Score: scored.Score})
+	}
+	return hitList, nil
+}
+
 func (CashFlowMongoDbMapper) TruncateCashFlows() error {
 	// Open database connection
 	database.OpenMongoDbConnection(database.CashFlowTableName)
@@ -361,14 +627,207 @@ func (CashFlowMongoDbMapper) TruncateCashFlows() error {
 	return nil
 }
 
+// SoftDeleteCashFlowByObjectId moves a cash flow to the trash, keeping its modify_time
+func (CashFlowMongoDbMapper) SoftDeleteCashFlowByObjectId(plainId string) model.CashFlowEntity {
+	objectId := util.Convert2ObjectId(plainId)
+	if plainId == "" || objectId == primitive.NilObjectID {
+		util.Logger.Warnln("cash_flow's id is not acceptable")
+		return model.CashFlowEntity{}
+	}
+
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: objectId},
+		notDeletedFilter,
+	}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+	targetEntity := convertBsonM2CashFlowEntity(database.GetOneInMongoDB(filter))
+	if targetEntity.IsEmpty() {
+		util.Logger.Infoln("cash_flow is not exist")
+		return model.CashFlowEntity{}
+	}
+
+	deletedAt := time.Now().UTC()
+	rowsAffected := database.UpdateManyInMongoDB(filter, bson.D{primitive.E{Key: "deleted_at", Value: deletedAt}})
+	if rowsAffected != 1 {
+		util.Logger.Errorw("soft delete failed", "rows_affected", rowsAffected)
+		return model.CashFlowEntity{}
+	}
+	targetEntity.DeletedAt = &deletedAt
+	return targetEntity
+}
+
+// SoftDeleteCashFlowsByBelongsDate moves every cash flow of a day to the trash
+func (CashFlowMongoDbMapper) SoftDeleteCashFlowsByBelongsDate(belongsDate time.Time) []model.CashFlowEntity {
+	filter := bson.D{
+		primitive.E{Key: "belongs_date", Value: belongsDate},
+		notDeletedFilter,
+	}
+
+	cashFlowList := INSTANCE.GetCashFlowsByBelongsDate(belongsDate)
+	if cashFlowList == nil {
+		util.Logger.Infoln("no cash_flow(s) found")
+		return []model.CashFlowEntity{}
+	}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+
+	deletedAt := time.Now().UTC()
+	rowsAffected := database.UpdateManyInMongoDB(filter, bson.D{primitive.E{Key: "deleted_at", Value: deletedAt}})
+	if rowsAffected != int64(len(cashFlowList)) {
+		util.Logger.Errorw("soft delete failed", "rows_affected", rowsAffected)
+	}
+	for i := range cashFlowList {
+		cashFlowList[i].DeletedAt = &deletedAt
+	}
+	return cashFlowList
+}
+
+func (CashFlowMongoDbMapper) GetDeletedCashFlowByObjectId(plainId string) model.CashFlowEntity {
+	objectId := util.Convert2ObjectId(plainId)
+	if plainId == "" || objectId == primitive.NilObjectID {
+		util.Logger.Warnln("cash_flow's id is not acceptable")
+		return model.CashFlowEntity{}
+	}
+
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: objectId},
+		primitive.E{Key: "deleted_at", Value: bson.M{"$ne": nil}},
+	}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+	return convertBsonM2CashFlowEntity(database.GetOneInMongoDB(filter))
+}
+
+// GetDeletedCashFlows lists the trash, most recently deleted first
+func (CashFlowMongoDbMapper) GetDeletedCashFlows(limit, offset int) []model.CashFlowEntity {
+	filter := bson.D{
+		primitive.E{Key: "deleted_at", Value: bson.M{"$ne": nil}},
+	}
+
+	ctx := context.TODO()
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "deleted_at", Value: -1}})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+	if offset > 0 {
+		findOptions.SetSkip(int64(offset))
+	}
+
+	collection := database.GetMongoCollection(database.CashFlowTableName)
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query trash failed", "error", err)
+		return []model.CashFlowEntity{}
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.CashFlowEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode failed", "error", err)
+		return []model.CashFlowEntity{}
+	}
+	return targetEntityList
+}
+
+func (CashFlowMongoDbMapper) CountDeletedCashFlows() int64 {
+	filter := bson.D{
+		primitive.E{Key: "deleted_at", Value: bson.M{"$ne": nil}},
+	}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+
+	return database.CountInMongoDB(filter)
+}
+
+// RestoreDeletedCashFlowByObjectId takes a cash flow out of the trash; modify_time is bumped
+// so the next incremental backup carries it again
+func (CashFlowMongoDbMapper) RestoreDeletedCashFlowByObjectId(plainId string) model.CashFlowEntity {
+	targetEntity := INSTANCE.GetDeletedCashFlowByObjectId(plainId)
+	if targetEntity.IsEmpty() {
+		util.Logger.Infoln("cash_flow is not in the trash")
+		return model.CashFlowEntity{}
+	}
+
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: targetEntity.Id},
+	}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+
+	targetEntity.DeletedAt = nil
+	targetEntity.ModifyTime = time.Now().UTC()
+	targetEntity.Version++
+	rowsAffected := database.UpdateManyInMongoDB(filter, bson.D{
+		primitive.E{Key: "deleted_at", Value: nil},
+		primitive.E{Key: "modify_time", Value: targetEntity.ModifyTime},
+		primitive.E{Key: "version", Value: targetEntity.Version},
+	})
+	if rowsAffected != 1 {
+		util.Logger.Errorw("restore from trash failed", "rows_affected", rowsAffected)
+		return model.CashFlowEntity{}
+	}
+	return targetEntity
+}
+
+// PurgeDeletedCashFlowByObjectId permanently deletes one cash flow from the trash
+func (CashFlowMongoDbMapper) PurgeDeletedCashFlowByObjectId(plainId string) model.CashFlowEntity {
+	targetEntity := INSTANCE.GetDeletedCashFlowByObjectId(plainId)
+	if targetEntity.IsEmpty() {
+		util.Logger.Infoln("cash_flow is not in the trash")
+		return model.CashFlowEntity{}
+	}
+
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: targetEntity.Id},
+	}
+
+	database.OpenMongoDbConnection(database.CashFlowTableName)
+	defer database.CloseMongoDbConnection()
+	if rowsAffected := database.DeleteManyInMongoDB(filter); rowsAffected != 1 {
+		util.Logger.Errorw("purge failed", "rows_affected", rowsAffected)
+		return model.CashFlowEntity{}
+	}
+	return targetEntity
+}
+
+// PurgeDeletedCashFlows permanently deletes the cash flows moved to the trash before deletedBefore
+func (CashFlowMongoDbMapper) PurgeDeletedCashFlows(deletedBefore time.Time) (int64, error) {
+	filter := bson.D{
+		primitive.E{Key: "deleted_at", Value: bson.M{"$ne": nil, "$lt": deletedBefore.UTC()}},
+	}
+
+	collection := database.GetMongoCollection(database.CashFlowTableName)
+	result, err := collection.DeleteMany(context.TODO(), filter)
+	if err != nil {
+		util.Logger.Errorw("purge trash failed", "error", err)
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
 func convertCashFlowEntity2BsonD(entity model.CashFlowEntity) bson.D {
 	// Generate a new Id automatically if it's empty
 	if entity.Id == primitive.NilObjectID {
 		entity.Id = primitive.NewObjectID()
 	}
 
+	document := append(bson.D{primitive.E{Key: "_id", Value: entity.Id}}, convertCashFlowContent2BsonD(entity)...)
+	document = append(document, primitive.E{Key: "version", Value: entity.Version})
+	if entity.DeletedAt != nil {
+		document = append(document, primitive.E{Key: "deleted_at", Value: *entity.DeletedAt})
+	}
+	return document
+}
+
+// convertCashFlowContent2BsonD holds every field but the id, version and deletion time
+func convertCashFlowContent2BsonD(entity model.CashFlowEntity) bson.D {
 	return bson.D{
-		primitive.E{Key: "_id", Value: entity.Id},
 		primitive.E{Key: "category_id", Value: entity.CategoryId},
 		primitive.E{Key: "belongs_date", Value: entity.BelongsDate},
 		primitive.E{Key: "flow_type", Value: entity.FlowType},
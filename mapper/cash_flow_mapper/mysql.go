@@ -3,9 +3,13 @@ package cash_flow_mapper
 import (
 	"bytes"
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/macar-x/cashlenx-server/util/database"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,11 +17,14 @@ import (
 
 type CashFlowMySqlMapper struct{}
 
+// mySqlFullTextIndexMissingCode is ER_FT_MATCHING_KEY_NOT_FOUND, a MATCH without a FULLTEXT index on its columns
+const mySqlFullTextIndexMissingCode = 1191
+
 func (CashFlowMySqlMapper) GetCashFlowByObjectId(plainId string) model.CashFlowEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION FROM ")
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, VERSION FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
-	sqlString.WriteString(" WHERE ID = ? ")
+	sqlString.WriteString(" WHERE ID = ? AND DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -37,9 +44,9 @@ func (CashFlowMySqlMapper) GetCashFlowByObjectId(plainId string) model.CashFlowE
 
 func (CashFlowMySqlMapper) GetCashFlowsByObjectIdArray(plainIdList []string) []model.CashFlowEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION FROM ")
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, VERSION FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
-	sqlString.WriteString(" WHERE ID in ")
+	sqlString.WriteString(" WHERE DELETED_AT IS NULL AND ID in ")
 	// fixme: pass the params by ? instead to avoid SQL inject.
 	sqlString.WriteString("(" + util.CombiningWithComma(util.BatchSurroundingWithSingleQuotes(plainIdList)) + ") ")
 
@@ -60,9 +67,9 @@ func (CashFlowMySqlMapper) GetCashFlowsByObjectIdArray(plainIdList []string) []m
 
 func (CashFlowMySqlMapper) GetCashFlowsByBelongsDate(belongsDate time.Time) []model.CashFlowEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION FROM ")
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, VERSION FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
-	sqlString.WriteString(" WHERE BELONGS_DATE = ? ")
+	sqlString.WriteString(" WHERE BELONGS_DATE = ? AND DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -81,9 +88,9 @@ func (CashFlowMySqlMapper) GetCashFlowsByBelongsDate(belongsDate time.Time) []mo
 
 func (CashFlowMySqlMapper) GetCashFlowsByDateRange(from, to time.Time) []model.CashFlowEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION FROM ")
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, VERSION FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
-	sqlString.WriteString(" WHERE BELONGS_DATE BETWEEN ? AND ? ")
+	sqlString.WriteString(" WHERE BELONGS_DATE BETWEEN ? AND ? AND DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -104,9 +111,9 @@ func (CashFlowMySqlMapper) GetCashFlowsByDateRange(from, to time.Time) []model.C
 
 func (CashFlowMySqlMapper) GetCashFlowsByCategoryId(categoryPlainId string) []model.CashFlowEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION FROM ")
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, VERSION FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
-	sqlString.WriteString(" WHERE CATEGORY_ID = ? ")
+	sqlString.WriteString(" WHERE CATEGORY_ID = ? AND DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -125,9 +132,9 @@ func (CashFlowMySqlMapper) GetCashFlowsByCategoryId(categoryPlainId string) []mo
 
 func (CashFlowMySqlMapper) GetCashFlowsByExactDesc(description string) []model.CashFlowEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION FROM ")
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, VERSION FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
-	sqlString.WriteString(" WHERE DESCRIPTION = ? ")
+	sqlString.WriteString(" WHERE DESCRIPTION = ? AND DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -146,9 +153,9 @@ func (CashFlowMySqlMapper) GetCashFlowsByExactDesc(description string) []model.C
 
 func (CashFlowMySqlMapper) GetCashFlowsByFuzzyDesc(description string) []model.CashFlowEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION FROM ")
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, VERSION FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
-	sqlString.WriteString(" WHERE DESCRIPTION LIKE ? ")
+	sqlString.WriteString(" WHERE DESCRIPTION LIKE ? AND DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -169,7 +176,7 @@ func (CashFlowMySqlMapper) CountCashFLowsByCategoryId(categoryPlainId string) in
 	var sqlString bytes.Buffer
 	sqlString.WriteString("SELECT COUNT(1) FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
-	sqlString.WriteString(" WHERE CATEGORY_ID = ? ")
+	sqlString.WriteString(" WHERE CATEGORY_ID = ? AND DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -278,6 +285,49 @@ func (CashFlowMySqlMapper) BulkInsertCashFlows(entities []model.CashFlowEntity)
 	return ids, nil
 }
 
+// BulkUpsertCashFlows inserts or replaces cash flows by id, keeping their ids and timestamps
+// A replaced cash flow that was in the trash is live again
+func (CashFlowMySqlMapper) BulkUpsertCashFlows(entities []model.CashFlowEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" (ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, REMARK, CREATE_TIME, MODIFY_TIME) VALUES ")
+
+	values := make([]interface{}, 0, len(entities)*9)
+	for i, entity := range entities {
+		if i > 0 {
+			sqlString.WriteString(", ")
+		}
+		sqlString.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		values = append(values, entity.Id.Hex(), entity.CategoryId.Hex(), entity.BelongsDate.UTC(), entity.FlowType,
+			entity.Amount, entity.Description, entity.Remark, entity.CreateTime.UTC(), entity.ModifyTime.UTC())
+	}
+
+	sqlString.WriteString(" ON DUPLICATE KEY UPDATE CATEGORY_ID = VALUES(CATEGORY_ID), ")
+	sqlString.WriteString(" BELONGS_DATE = VALUES(BELONGS_DATE), ")
+	sqlString.WriteString(" FLOW_TYPE = VALUES(FLOW_TYPE), ")
+	sqlString.WriteString(" AMOUNT = VALUES(AMOUNT), ")
+	sqlString.WriteString(" DESCRIPTION = VALUES(DESCRIPTION), ")
+	sqlString.WriteString(" REMARK = VALUES(REMARK), ")
+	sqlString.WriteString(" CREATE_TIME = VALUES(CREATE_TIME), ")
+	sqlString.WriteString(" MODIFY_TIME = VALUES(MODIFY_TIME), ")
+	sqlString.WriteString(" DELETED_AT = NULL, ")
+	sqlString.WriteString(" VERSION = VERSION + 1 ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), values...); err != nil {
+		util.Logger.Errorw("bulk upsert failed", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (CashFlowMySqlMapper) UpdateCashFlowByEntity(plainId string, updatedEntity model.CashFlowEntity) model.CashFlowEntity {
 	objectId := util.Convert2ObjectId(plainId)
 	if plainId == "" || objectId == primitive.NilObjectID {
@@ -295,6 +345,8 @@ func (CashFlowMySqlMapper) UpdateCashFlowByEntity(plainId string, updatedEntity
 	updatedEntity.Id = targetEntity.Id
 	updatedEntity.CreateTime = targetEntity.CreateTime
 	updatedEntity.ModifyTime = time.Now().UTC() // Store in UTC
+	expectedVersion := updatedEntity.Version
+	updatedEntity.Version = expectedVersion + 1
 
 	var sqlString bytes.Buffer
 	sqlString.WriteString("UPDATE ")
@@ -305,8 +357,9 @@ func (CashFlowMySqlMapper) UpdateCashFlowByEntity(plainId string, updatedEntity
 	sqlString.WriteString(" AMOUNT = ?, ")
 	sqlString.WriteString(" DESCRIPTION = ?, ")
 	sqlString.WriteString(" REMARK = ?, ")
-	sqlString.WriteString(" MODIFY_TIME = ? ")
-	sqlString.WriteString(" WHERE ID = ? ")
+	sqlString.WriteString(" MODIFY_TIME = ?, ")
+	sqlString.WriteString(" VERSION = VERSION + 1 ")
+	sqlString.WriteString(" WHERE ID = ? AND VERSION = ? AND DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -317,15 +370,18 @@ func (CashFlowMySqlMapper) UpdateCashFlowByEntity(plainId string, updatedEntity
 	}
 
 	result, err := statement.Exec(updatedEntity.CategoryId.Hex(), updatedEntity.BelongsDate, updatedEntity.FlowType,
-		updatedEntity.Amount, updatedEntity.Description, updatedEntity.Remark, updatedEntity.ModifyTime, plainId)
+		updatedEntity.Amount, updatedEntity.Description, updatedEntity.Remark, updatedEntity.ModifyTime, plainId,
+		expectedVersion)
 	if err != nil {
 		util.Logger.Errorw("update failed", "error", err)
+		return model.CashFlowEntity{}
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil || rowsAffected != 1 {
-		// fixme: maybe we should have a rollback here.
-		util.Logger.Errorw("update failed", "error", err, "rows_affected", rowsAffected)
+		// No row affected: another update got in first and bumped the version
+		util.Logger.Infow("update skipped", "error", err, "rows_affected", rowsAffected, "expected_version", expectedVersion)
+		return model.CashFlowEntity{}
 	}
 	return updatedEntity
 }
@@ -373,7 +429,7 @@ func (CashFlowMySqlMapper) DeleteCashFlowByBelongsDate(belongsDate time.Time) []
 	var sqlString bytes.Buffer
 	sqlString.WriteString("DELETE FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
-	sqlString.WriteString(" WHERE BELONGS_DATE = ? ")
+	sqlString.WriteString(" WHERE BELONGS_DATE = ? AND DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -398,8 +454,9 @@ func (CashFlowMySqlMapper) DeleteCashFlowByBelongsDate(belongsDate time.Time) []
 
 func (CashFlowMySqlMapper) GetAllCashFlows(limit, offset int) []model.CashFlowEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION FROM ")
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, VERSION FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE DELETED_AT IS NULL ")
 	sqlString.WriteString(" ORDER BY BELONGS_DATE DESC ")
 
 	if limit > 0 {
@@ -430,10 +487,138 @@ func (CashFlowMySqlMapper) GetAllCashFlows(limit, offset int) []model.CashFlowEn
 	return targetEntityList
 }
 
+func (CashFlowMySqlMapper) GetCashFlowsPage(filter query.Node, request query.PageRequest) ([]model.CashFlowEntity, error) {
+	var argList []interface{}
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, REMARK, CREATE_TIME, MODIFY_TIME, VERSION FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE DELETED_AT IS NULL ")
+	if filter != nil {
+		condition, filterArgList, err := query.ToMySqlWhere(filter)
+		if err != nil {
+			return nil, err
+		}
+		sqlString.WriteString(" AND (" + condition + ") ")
+		argList = append(argList, filterArgList...)
+	}
+	orderBy, keysetCondition, keysetArgList := query.MySqlPage(request)
+	if keysetCondition != "" {
+		sqlString.WriteString(" AND (" + keysetCondition + ") ")
+		argList = append(argList, keysetArgList...)
+	}
+	sqlString.WriteString(" ORDER BY " + orderBy + " LIMIT ? OFFSET ? ")
+	argList = append(argList, request.FetchLimit(), request.Skip())
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("query page failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.CashFlowEntity
+	for rows.Next() {
+		entity, err := convertVersionedRow2CashFlowEntity(rows)
+		if err != nil {
+			return nil, err
+		}
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
+// GetCashFlowsByIdListWithDeleted returns the full cash flows with the given ids, trashed ones included;
+// unknown ids are left out
+func (CashFlowMySqlMapper) GetCashFlowsByIdListWithDeleted(plainIdList []string) ([]model.CashFlowEntity, error) {
+	if len(plainIdList) == 0 {
+		return nil, nil
+	}
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, REMARK, CREATE_TIME, MODIFY_TIME, DELETED_AT, VERSION FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE ID IN (?" + strings.Repeat(", ?", len(plainIdList)-1) + ") ")
+	argList := make([]interface{}, 0, len(plainIdList))
+	for _, plainId := range plainIdList {
+		argList = append(argList, plainId)
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("query by id list failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.CashFlowEntity
+	for rows.Next() {
+		entity, err := convertDeletedRow2CashFlowEntity(rows)
+		if err != nil {
+			return nil, err
+		}
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
+// GetCashFlowsAfterId returns up to limit cash flows with an id greater than afterPlainId, in id order
+// An empty afterPlainId starts from the beginning; used to page through every row with constant memory
+func (CashFlowMySqlMapper) GetCashFlowsAfterId(afterPlainId string, limit int) ([]model.CashFlowEntity, error) {
+	return getCashFlowsAfterIdInMySql(time.Time{}, afterPlainId, limit)
+}
+
+func (CashFlowMySqlMapper) GetCashFlowsModifiedSince(since time.Time, afterPlainId string, limit int) ([]model.CashFlowEntity, error) {
+	return getCashFlowsAfterIdInMySql(since, afterPlainId, limit)
+}
+
+// getCashFlowsAfterIdInMySql pages cash flows by ID, optionally only those with MODIFY_TIME >= since
+func getCashFlowsAfterIdInMySql(since time.Time, afterPlainId string, limit int) ([]model.CashFlowEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, REMARK, CREATE_TIME, MODIFY_TIME FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE ID > ? AND DELETED_AT IS NULL ")
+	argList := []interface{}{afterPlainId}
+	if !since.IsZero() {
+		sqlString.WriteString(" AND MODIFY_TIME >= ? ")
+		argList = append(argList, since.UTC())
+	}
+	sqlString.WriteString(" ORDER BY ID ASC ")
+	if limit > 0 {
+		sqlString.WriteString(" LIMIT ? ")
+		argList = append(argList, limit)
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("query after id failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.CashFlowEntity
+	for rows.Next() {
+		entity, err := convertFullRow2CashFlowEntity(rows)
+		if err != nil {
+			return nil, err
+		}
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
 func (CashFlowMySqlMapper) CountAllCashFlows() int64 {
 	var sqlString bytes.Buffer
 	sqlString.WriteString("SELECT COUNT(1) FROM ")
 	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE DELETED_AT IS NULL ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -453,6 +638,117 @@ func (CashFlowMySqlMapper) CountAllCashFlows() int64 {
 	return count
 }
 
+func (CashFlowMySqlMapper) SearchCashFlows(node query.Node, limit, offset int) ([]model.CashFlowEntity, error) {
+	condition, argList, err := query.ToMySqlWhere(node)
+	if err != nil {
+		return nil, err
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, REMARK, CREATE_TIME, MODIFY_TIME, VERSION FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE DELETED_AT IS NULL AND (" + condition + ") ")
+	sqlString.WriteString(" ORDER BY BELONGS_DATE DESC, ID DESC ")
+	if limit > 0 {
+		sqlString.WriteString(" LIMIT ? OFFSET ? ")
+		argList = append(argList, limit, offset)
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("search failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.CashFlowEntity
+	for rows.Next() {
+		entity, err := convertVersionedRow2CashFlowEntity(rows)
+		if err != nil {
+			return nil, err
+		}
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
+func (CashFlowMySqlMapper) CountSearchCashFlows(node query.Node) (int64, error) {
+	condition, argList, err := query.ToMySqlWhere(node)
+	if err != nil {
+		return 0, err
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT COUNT(1) FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE DELETED_AT IS NULL AND (" + condition + ") ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	var count int64
+	if err := connection.QueryRow(sqlString.String(), argList...).Scan(&count); err != nil {
+		util.Logger.Errorw("count search failed", "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// TextSearchCashFlows uses the FULLTEXT index ft_cash_flows_text in boolean mode, each word standing for its stem
+func (CashFlowMySqlMapper) TextSearchCashFlows(termList []string, limit int) ([]model.TextSearchHit, error) {
+	against := query.MySqlBooleanQuery(query.StemTerms(termList))
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, REMARK, CREATE_TIME, MODIFY_TIME, VERSION, ")
+	sqlString.WriteString("MATCH (DESCRIPTION, REMARK) AGAINST (? IN BOOLEAN MODE) AS SCORE FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE DELETED_AT IS NULL AND MATCH (DESCRIPTION, REMARK) AGAINST (? IN BOOLEAN MODE) ")
+	sqlString.WriteString(" ORDER BY SCORE DESC, BELONGS_DATE DESC ")
+	argList := []interface{}{against, against}
+	if limit > 0 {
+		sqlString.WriteString(" LIMIT ? ")
+		argList = append(argList, limit)
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		var mySqlError *mysql.MySQLError
+		if errors.As(err, &mySqlError) && mySqlError.Number == mySqlFullTextIndexMissingCode {
+			return nil, ErrTextIndexMissing
+		}
+		util.Logger.Errorw("text search failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hitList []model.TextSearchHit
+	for rows.Next() {
+		var id, categoryId, belongsDate, createTime, modifyTime string
+		var remark sql.NullString
+		var hit model.TextSearchHit
+		err := rows.Scan(&id, &categoryId, &belongsDate, &hit.CashFlow.FlowType, &hit.CashFlow.Amount,
+			&hit.CashFlow.Description, &remark, &createTime, &modifyTime, &hit.CashFlow.Version, &hit.Score)
+		if err != nil {
+			util.Logger.Errorw("covert into entity failed", "error", err)
+			return nil, err
+		}
+		hit.CashFlow.Id = util.Convert2ObjectId(id)
+		hit.CashFlow.CategoryId = util.Convert2ObjectId(categoryId)
+		hit.CashFlow.BelongsDate = database.ParseMySqlTime(belongsDate)
+		hit.CashFlow.Remark = remark.String
+		hit.CashFlow.CreateTime = database.ParseMySqlTime(createTime)
+		hit.CashFlow.ModifyTime = database.ParseMySqlTime(modifyTime)
+		hitList = append(hitList, hit)
+	}
+	return hitList, rows.Err()
+}
+
 func (CashFlowMySqlMapper) TruncateCashFlows() error {
 	var sqlString bytes.Buffer
 	sqlString.WriteString("TRUNCATE TABLE ")
@@ -471,15 +767,232 @@ func (CashFlowMySqlMapper) TruncateCashFlows() error {
 	return nil
 }
 
+// SoftDeleteCashFlowByObjectId moves a cash flow to the trash, keeping its modify_time
+func (CashFlowMySqlMapper) SoftDeleteCashFlowByObjectId(plainId string) model.CashFlowEntity {
+	targetEntity := INSTANCE.GetCashFlowByObjectId(plainId)
+	if targetEntity.IsEmpty() {
+		util.Logger.Infoln("cash_flow is not exist")
+		return model.CashFlowEntity{}
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("UPDATE ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" SET DELETED_AT = ?, MODIFY_TIME = MODIFY_TIME ")
+	sqlString.WriteString(" WHERE ID = ? AND DELETED_AT IS NULL ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	deletedAt := time.Now().UTC()
+	result, err := connection.Exec(sqlString.String(), deletedAt, plainId)
+	if err != nil {
+		util.Logger.Errorw("soft delete failed", "error", err)
+		return model.CashFlowEntity{}
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected != 1 {
+		util.Logger.Errorw("soft delete failed", "error", err, "rows_affected", rowsAffected)
+		return model.CashFlowEntity{}
+	}
+	targetEntity.DeletedAt = &deletedAt
+	return targetEntity
+}
+
+// SoftDeleteCashFlowsByBelongsDate moves every cash flow of a day to the trash
+func (CashFlowMySqlMapper) SoftDeleteCashFlowsByBelongsDate(belongsDate time.Time) []model.CashFlowEntity {
+	cashFlowList := INSTANCE.GetCashFlowsByBelongsDate(belongsDate)
+	if cashFlowList == nil {
+		util.Logger.Infoln("no cash_flow(s) found")
+		return cashFlowList
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("UPDATE ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" SET DELETED_AT = ?, MODIFY_TIME = MODIFY_TIME ")
+	sqlString.WriteString(" WHERE BELONGS_DATE = ? AND DELETED_AT IS NULL ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	deletedAt := time.Now().UTC()
+	result, err := connection.Exec(sqlString.String(), deletedAt, util.FormatDateToStringWithDash(belongsDate))
+	if err != nil {
+		util.Logger.Errorw("soft delete failed", "error", err)
+		return []model.CashFlowEntity{}
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected != int64(len(cashFlowList)) {
+		util.Logger.Errorw("soft delete failed", "error", err, "rows_affected", rowsAffected)
+	}
+	for i := range cashFlowList {
+		cashFlowList[i].DeletedAt = &deletedAt
+	}
+	return cashFlowList
+}
+
+func (CashFlowMySqlMapper) GetDeletedCashFlowByObjectId(plainId string) model.CashFlowEntity {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, REMARK, CREATE_TIME, MODIFY_TIME, DELETED_AT, VERSION FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE ID = ? AND DELETED_AT IS NOT NULL ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), plainId)
+	if err != nil {
+		util.Logger.Errorw("query trash failed", "error", err)
+		return model.CashFlowEntity{}
+	}
+	defer rows.Close()
+
+	var cashFlowEntity model.CashFlowEntity
+	if rows.Next() {
+		cashFlowEntity, _ = convertDeletedRow2CashFlowEntity(rows)
+	}
+	return cashFlowEntity
+}
+
+// GetDeletedCashFlows lists the trash, most recently deleted first
+func (CashFlowMySqlMapper) GetDeletedCashFlows(limit, offset int) []model.CashFlowEntity {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, REMARK, CREATE_TIME, MODIFY_TIME, DELETED_AT, VERSION FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE DELETED_AT IS NOT NULL ")
+	sqlString.WriteString(" ORDER BY DELETED_AT DESC ")
+	argList := []interface{}{}
+	if limit > 0 {
+		sqlString.WriteString(" LIMIT ? OFFSET ? ")
+		argList = append(argList, limit, offset)
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("query trash failed", "error", err)
+		return []model.CashFlowEntity{}
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.CashFlowEntity
+	for rows.Next() {
+		entity, err := convertDeletedRow2CashFlowEntity(rows)
+		if err != nil {
+			return []model.CashFlowEntity{}
+		}
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList
+}
+
+func (CashFlowMySqlMapper) CountDeletedCashFlows() int64 {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT COUNT(1) FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE DELETED_AT IS NOT NULL ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	var count int64
+	if err := connection.QueryRow(sqlString.String()).Scan(&count); err != nil {
+		util.Logger.Errorw("count trash failed", "error", err)
+		return 0
+	}
+	return count
+}
+
+// RestoreDeletedCashFlowByObjectId takes a cash flow out of the trash; MODIFY_TIME is bumped
+// so the next incremental backup carries it again
+func (CashFlowMySqlMapper) RestoreDeletedCashFlowByObjectId(plainId string) model.CashFlowEntity {
+	targetEntity := INSTANCE.GetDeletedCashFlowByObjectId(plainId)
+	if targetEntity.IsEmpty() {
+		util.Logger.Infoln("cash_flow is not in the trash")
+		return model.CashFlowEntity{}
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("UPDATE ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" SET DELETED_AT = NULL, MODIFY_TIME = ?, VERSION = VERSION + 1 ")
+	sqlString.WriteString(" WHERE ID = ? AND DELETED_AT IS NOT NULL ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	targetEntity.DeletedAt = nil
+	targetEntity.ModifyTime = time.Now().UTC()
+	targetEntity.Version++
+	result, err := connection.Exec(sqlString.String(), targetEntity.ModifyTime, plainId)
+	if err != nil {
+		util.Logger.Errorw("restore from trash failed", "error", err)
+		return model.CashFlowEntity{}
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected != 1 {
+		util.Logger.Errorw("restore from trash failed", "error", err, "rows_affected", rowsAffected)
+		return model.CashFlowEntity{}
+	}
+	return targetEntity
+}
+
+// PurgeDeletedCashFlowByObjectId permanently deletes one cash flow from the trash
+func (CashFlowMySqlMapper) PurgeDeletedCashFlowByObjectId(plainId string) model.CashFlowEntity {
+	targetEntity := INSTANCE.GetDeletedCashFlowByObjectId(plainId)
+	if targetEntity.IsEmpty() {
+		util.Logger.Infoln("cash_flow is not in the trash")
+		return model.CashFlowEntity{}
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("DELETE FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE ID = ? AND DELETED_AT IS NOT NULL ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	result, err := connection.Exec(sqlString.String(), plainId)
+	if err != nil {
+		util.Logger.Errorw("purge failed", "error", err)
+		return model.CashFlowEntity{}
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected != 1 {
+		util.Logger.Errorw("purge failed", "error", err, "rows_affected", rowsAffected)
+		return model.CashFlowEntity{}
+	}
+	return targetEntity
+}
+
+// PurgeDeletedCashFlows permanently deletes the cash flows moved to the trash before deletedBefore
+func (CashFlowMySqlMapper) PurgeDeletedCashFlows(deletedBefore time.Time) (int64, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("DELETE FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE DELETED_AT IS NOT NULL AND DELETED_AT < ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	result, err := connection.Exec(sqlString.String(), deletedBefore.UTC())
+	if err != nil {
+		util.Logger.Errorw("purge trash failed", "error", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 func convertRow2CashFlowEntity(rows *sql.Rows) model.CashFlowEntity {
 	var id string
 	var categoryId string
 	var belongsDate string
 	var flowType string
-	var amount float64
+	var amount model.Money
 	var description string
+	var version int64
 
-	err := rows.Scan(&id, &categoryId, &belongsDate, &flowType, &amount, &description)
+	err := rows.Scan(&id, &categoryId, &belongsDate, &flowType, &amount, &description, &version)
 	if err != nil {
 		util.Logger.Errorw("covert into entity failed", "error", err)
 	}
@@ -491,5 +1004,77 @@ func convertRow2CashFlowEntity(rows *sql.Rows) model.CashFlowEntity {
 		FlowType:    flowType,
 		Amount:      amount,
 		Description: description,
+		Version:     version,
+	}
+}
+
+// convertFullRow2CashFlowEntity converts a row holding every cash_flows column
+func convertFullRow2CashFlowEntity(rows *sql.Rows) (model.CashFlowEntity, error) {
+	var id, categoryId, belongsDate, createTime, modifyTime string
+	var remark sql.NullString
+	var entity model.CashFlowEntity
+
+	err := rows.Scan(&id, &categoryId, &belongsDate, &entity.FlowType, &entity.Amount, &entity.Description,
+		&remark, &createTime, &modifyTime)
+	if err != nil {
+		util.Logger.Errorw("covert into entity failed", "error", err)
+		return entity, err
+	}
+
+	entity.Id = util.Convert2ObjectId(id)
+	entity.CategoryId = util.Convert2ObjectId(categoryId)
+	entity.BelongsDate = database.ParseMySqlTime(belongsDate)
+	entity.Remark = remark.String
+	entity.CreateTime = database.ParseMySqlTime(createTime)
+	entity.ModifyTime = database.ParseMySqlTime(modifyTime)
+	return entity, nil
+}
+
+// convertVersionedRow2CashFlowEntity converts a row holding every cash_flows column followed by VERSION
+func convertVersionedRow2CashFlowEntity(rows *sql.Rows) (model.CashFlowEntity, error) {
+	var id, categoryId, belongsDate, createTime, modifyTime string
+	var remark sql.NullString
+	var entity model.CashFlowEntity
+
+	err := rows.Scan(&id, &categoryId, &belongsDate, &entity.FlowType, &entity.Amount, &entity.Description,
+		&remark, &createTime, &modifyTime, &entity.Version)
+	if err != nil {
+		util.Logger.Errorw("covert into entity failed", "error", err)
+		return entity, err
+	}
+
+	entity.Id = util.Convert2ObjectId(id)
+	entity.CategoryId = util.Convert2ObjectId(categoryId)
+	entity.BelongsDate = database.ParseMySqlTime(belongsDate)
+	entity.Remark = remark.String
+	entity.CreateTime = database.ParseMySqlTime(createTime)
+	entity.ModifyTime = database.ParseMySqlTime(modifyTime)
+	return entity, nil
+}
+
+// convertDeletedRow2CashFlowEntity converts a row holding every cash_flows column followed by DELETED_AT and VERSION
+// DELETED_AT is NULL for a row that is not in the trash
+func convertDeletedRow2CashFlowEntity(rows *sql.Rows) (model.CashFlowEntity, error) {
+	var id, categoryId, belongsDate, createTime, modifyTime string
+	var remark, deletedAt sql.NullString
+	var entity model.CashFlowEntity
+
+	err := rows.Scan(&id, &categoryId, &belongsDate, &entity.FlowType, &entity.Amount, &entity.Description,
+		&remark, &createTime, &modifyTime, &deletedAt, &entity.Version)
+	if err != nil {
+		util.Logger.Errorw("covert into entity failed", "error", err)
+		return entity, err
+	}
+
+	entity.Id = util.Convert2ObjectId(id)
+	entity.CategoryId = util.Convert2ObjectId(categoryId)
+	entity.BelongsDate = database.ParseMySqlTime(belongsDate)
+	entity.Remark = remark.String
+	entity.CreateTime = database.ParseMySqlTime(createTime)
+	entity.ModifyTime = database.ParseMySqlTime(modifyTime)
+	if deletedAt.Valid {
+		deletedTime := database.ParseMySqlTime(deletedAt.String)
+		entity.DeletedAt = &deletedTime
 	}
+	return entity, nil
 }
@@ -1,7 +1,10 @@
 package category_mapper
 
 import (
+	"time"
+
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"github.com/macar-x/cashlenx-server/util"
 )
 
@@ -9,11 +12,20 @@ var INSTANCE CategoryMapper
 
 type CategoryMapper interface {
 	GetCategoryByObjectId(plainId string) model.CategoryEntity
+	GetCategoriesByIdList(plainIdList []string) ([]model.CategoryEntity, error)
 	GetCategoryByName(categoryName string) model.CategoryEntity
 	GetCategoryByParentId(parentPlainId string) []model.CategoryEntity
 	InsertCategoryByEntity(newEntity model.CategoryEntity) string
+	BulkUpsertCategories(entities []model.CategoryEntity) error
+	// UpdateCategoryByEntity only applies while the stored version still equals updatedEntity.Version,
+	// and bumps it; returns an empty entity when the category is gone or was updated in between
 	UpdateCategoryByEntity(plainId string, updatedEntity model.CategoryEntity) model.CategoryEntity
 	GetAllCategories(limit, offset int) []model.CategoryEntity
+	// GetCategoriesPage reads request.FetchLimit() categories in the sort of request starting past its cursor;
+	// a backward page comes in reverse order
+	GetCategoriesPage(request query.PageRequest) ([]model.CategoryEntity, error)
+	GetCategoriesAfterId(afterPlainId string, limit int) ([]model.CategoryEntity, error)
+	GetCategoriesModifiedSince(since time.Time, afterPlainId string, limit int) ([]model.CategoryEntity, error)
 	CountAllCategories() int64
 	DeleteCategoryByObjectId(plainId string) model.CategoryEntity
 	TruncateCategories() error
@@ -6,10 +6,12 @@ import (
 
 	"github.com/macar-x/cashlenx-server/cache"
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/macar-x/cashlenx-server/util/database"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -90,6 +92,38 @@ func (CategoryMongoDbMapper) InsertCategoryByEntity(newEntity model.CategoryEnti
 	return newCategoryId.Hex()
 }
 
+// BulkUpsertCategories inserts or replaces categories by id, keeping their ids, parent links and timestamps
+func (CategoryMongoDbMapper) BulkUpsertCategories(entities []model.CategoryEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	// Fields are set rather than the document replaced, so the version keeps counting up
+	writeModels := make([]mongo.WriteModel, len(entities))
+	for i, entity := range entities {
+		update := bson.D{
+			primitive.E{Key: "$set", Value: convertCategoryContent2BsonD(entity)},
+			primitive.E{Key: "$inc", Value: bson.D{primitive.E{Key: "version", Value: int64(1)}}},
+		}
+		writeModels[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.D{primitive.E{Key: "_id", Value: entity.Id}}).
+			SetUpdate(update).
+			SetUpsert(true)
+	}
+
+	collection := database.GetMongoCollection(database.CategoryTableName)
+	_, err := collection.BulkWrite(context.TODO(), writeModels, options.BulkWrite().SetOrdered(false))
+
+	// Invalidate cache on upsert
+	cache.GetCategoryCache().Clear()
+
+	if err != nil {
+		util.Logger.Errorw("bulk upsert failed", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (CategoryMongoDbMapper) UpdateCategoryByEntity(plainId string, updatedEntity model.CategoryEntity) model.CategoryEntity {
 	objectId := util.Convert2ObjectId(plainId)
 	if plainId == "" || objectId == primitive.NilObjectID {
@@ -99,6 +133,7 @@ func (CategoryMongoDbMapper) UpdateCategoryByEntity(plainId string, updatedEntit
 
 	filter := bson.D{
 		primitive.E{Key: "_id", Value: objectId},
+		database.VersionFilter(updatedEntity.Version),
 	}
 
 	database.OpenMongoDbConnection(database.CategoryTableName)
@@ -106,7 +141,7 @@ func (CategoryMongoDbMapper) UpdateCategoryByEntity(plainId string, updatedEntit
 
 	targetEntity := convertBsonM2CategoryEntity(database.GetOneInMongoDB(filter))
 	if targetEntity.IsEmpty() {
-		util.Logger.Infoln("category is not exist")
+		util.Logger.Infow("category is not exist at version", "version", updatedEntity.Version)
 		return model.CategoryEntity{}
 	}
 
@@ -114,11 +149,12 @@ func (CategoryMongoDbMapper) UpdateCategoryByEntity(plainId string, updatedEntit
 	updatedEntity.Id = targetEntity.Id
 	updatedEntity.CreateTime = targetEntity.CreateTime
 	updatedEntity.ModifyTime = time.Now().UTC() // Store in UTC
+	updatedEntity.Version++
 
+	// The version in the filter makes this a compare-and-swap
 	rowsAffected := database.UpdateManyInMongoDB(filter, convertCategoryEntity2BsonD(updatedEntity))
 	if rowsAffected != 1 {
-		// fixme: maybe we should have a rollback here.
-		util.Logger.Errorw("update failed", "rows_affected", rowsAffected)
+		util.Logger.Infow("update skipped", "rows_affected", rowsAffected)
 		return model.CategoryEntity{}
 	}
 
@@ -167,6 +203,83 @@ func (CategoryMongoDbMapper) DeleteCategoryByObjectId(plainId string) model.Cate
 	return targetEntity
 }
 
+// GetCategoriesByIdList returns the full categories with the given ids; unknown ids are left out
+func (CategoryMongoDbMapper) GetCategoriesByIdList(plainIdList []string) ([]model.CategoryEntity, error) {
+	objectIdList := make([]primitive.ObjectID, 0, len(plainIdList))
+	for _, plainId := range plainIdList {
+		objectId, err := primitive.ObjectIDFromHex(plainId)
+		if err != nil {
+			return nil, err
+		}
+		objectIdList = append(objectIdList, objectId)
+	}
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: bson.M{"$in": objectIdList}},
+	}
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.CategoryTableName)
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		util.Logger.Errorw("query by id list failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.CategoryEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
+// GetCategoriesAfterId returns up to limit categories with an id greater than afterPlainId, in id order
+// An empty afterPlainId starts from the beginning; used to page through every row with constant memory
+func (CategoryMongoDbMapper) GetCategoriesAfterId(afterPlainId string, limit int) ([]model.CategoryEntity, error) {
+	return getCategoriesAfterIdInMongoDb(time.Time{}, afterPlainId, limit)
+}
+
+func (CategoryMongoDbMapper) GetCategoriesModifiedSince(since time.Time, afterPlainId string, limit int) ([]model.CategoryEntity, error) {
+	return getCategoriesAfterIdInMongoDb(since, afterPlainId, limit)
+}
+
+// getCategoriesAfterIdInMongoDb pages categories by _id, optionally only those with modify_time >= since
+func getCategoriesAfterIdInMongoDb(since time.Time, afterPlainId string, limit int) ([]model.CategoryEntity, error) {
+	filter := bson.D{}
+	if afterPlainId != "" {
+		afterId, err := primitive.ObjectIDFromHex(afterPlainId)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, primitive.E{Key: "_id", Value: bson.M{"$gt": afterId}})
+	}
+	if !since.IsZero() {
+		filter = append(filter, primitive.E{Key: "modify_time", Value: bson.M{"$gte": since.UTC()}})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "_id", Value: 1}})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.CategoryTableName)
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query after id failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.CategoryEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
 func (CategoryMongoDbMapper) GetAllCategories(limit, offset int) []model.CategoryEntity {
 	database.OpenMongoDbConnection(database.CategoryTableName)
 	defer database.CloseMongoDbConnection()
@@ -207,6 +320,36 @@ func (CategoryMongoDbMapper) GetAllCategories(limit, offset int) []model.Categor
 	return targetEntityList
 }
 
+func (CategoryMongoDbMapper) GetCategoriesPage(request query.PageRequest) ([]model.CategoryEntity, error) {
+	sort, filter := query.MongoDbPage(request)
+	if filter == nil {
+		filter = bson.D{}
+	}
+	findOptions := options.Find().SetSort(sort).SetLimit(int64(request.FetchLimit()))
+	if skip := request.Skip(); skip > 0 {
+		findOptions.SetSkip(int64(skip))
+	}
+
+	database.OpenMongoDbConnection(database.CategoryTableName)
+	defer database.CloseMongoDbConnection()
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.CategoryTableName)
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query categories page failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.CategoryEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
 func (CategoryMongoDbMapper) CountAllCategories() int64 {
 	filter := bson.D{}
 
@@ -240,8 +383,13 @@ func convertCategoryEntity2BsonD(entity model.CategoryEntity) bson.D {
 		entity.Id = primitive.NewObjectID()
 	}
 
+	document := append(bson.D{primitive.E{Key: "_id", Value: entity.Id}}, convertCategoryContent2BsonD(entity)...)
+	return append(document, primitive.E{Key: "version", Value: entity.Version})
+}
+
+// convertCategoryContent2BsonD holds every field but the id and version
+func convertCategoryContent2BsonD(entity model.CategoryEntity) bson.D {
 	return bson.D{
-		primitive.E{Key: "_id", Value: entity.Id},
 		primitive.E{Key: "parent_id", Value: entity.ParentId},
 		primitive.E{Key: "name", Value: entity.Name},
 		primitive.E{Key: "type", Value: entity.Type},
@@ -3,10 +3,12 @@ package category_mapper
 import (
 	"bytes"
 	"database/sql"
+	"strings"
 	"time"
 
 	"github.com/macar-x/cashlenx-server/cache"
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/macar-x/cashlenx-server/util/database"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,7 +18,7 @@ type CategoryMySqlMapper struct{}
 
 func (CategoryMySqlMapper) GetCategoryByObjectId(plainId string) model.CategoryEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE FROM ")
+	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE, VERSION FROM ")
 	sqlString.WriteString(database.CategoryTableName)
 	sqlString.WriteString(" WHERE ID = ? ")
 
@@ -45,7 +47,7 @@ func (CategoryMySqlMapper) GetCategoryByName(categoryName string) model.Category
 
 	// Cache miss - query database
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE FROM ")
+	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE, VERSION FROM ")
 	sqlString.WriteString(database.CategoryTableName)
 	sqlString.WriteString(" WHERE NAME = ? ")
 
@@ -73,7 +75,7 @@ func (CategoryMySqlMapper) GetCategoryByName(categoryName string) model.Category
 
 func (CategoryMySqlMapper) GetCategoryByParentId(parentPlainId string) []model.CategoryEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE FROM ")
+	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE, VERSION FROM ")
 	sqlString.WriteString(database.CategoryTableName)
 	sqlString.WriteString(" WHERE PARENT_ID = ? ")
 
@@ -135,6 +137,50 @@ func (CategoryMySqlMapper) InsertCategoryByEntity(newEntity model.CategoryEntity
 	return newPlainId
 }
 
+// BulkUpsertCategories inserts or replaces categories by id, keeping their ids, parent links and timestamps
+func (CategoryMySqlMapper) BulkUpsertCategories(entities []model.CategoryEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(database.CategoryTableName)
+	sqlString.WriteString(" (ID, PARENT_ID, NAME, TYPE, REMARK, CREATE_TIME, MODIFY_TIME) VALUES ")
+
+	values := make([]interface{}, 0, len(entities)*7)
+	for i, entity := range entities {
+		if i > 0 {
+			sqlString.WriteString(", ")
+		}
+		sqlString.WriteString("(?, ?, ?, ?, ?, ?, ?)")
+		values = append(values, entity.Id.Hex(), entity.ParentId.Hex(), entity.Name, entity.Type, entity.Remark,
+			entity.CreateTime.UTC(), entity.ModifyTime.UTC())
+	}
+
+	sqlString.WriteString(" ON DUPLICATE KEY UPDATE PARENT_ID = VALUES(PARENT_ID), ")
+	sqlString.WriteString(" NAME = VALUES(NAME), ")
+	sqlString.WriteString(" TYPE = VALUES(TYPE), ")
+	sqlString.WriteString(" REMARK = VALUES(REMARK), ")
+	sqlString.WriteString(" CREATE_TIME = VALUES(CREATE_TIME), ")
+	sqlString.WriteString(" MODIFY_TIME = VALUES(MODIFY_TIME), ")
+	sqlString.WriteString(" VERSION = VERSION + 1 ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	_, err := connection.Exec(sqlString.String(), values...)
+
+	// Invalidate cache on upsert
+	cache.GetCategoryCache().Clear()
+
+	if err != nil {
+		util.Logger.Errorw("bulk upsert failed", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (CategoryMySqlMapper) UpdateCategoryByEntity(plainId string, updatedEntity model.CategoryEntity) model.CategoryEntity {
 	targetEntity := INSTANCE.GetCategoryByObjectId(plainId)
 	if targetEntity.IsEmpty() {
@@ -146,6 +192,8 @@ func (CategoryMySqlMapper) UpdateCategoryByEntity(plainId string, updatedEntity
 	updatedEntity.Id = targetEntity.Id
 	updatedEntity.CreateTime = targetEntity.CreateTime
 	updatedEntity.ModifyTime = time.Now().UTC() // Store in UTC
+	expectedVersion := updatedEntity.Version
+	updatedEntity.Version = expectedVersion + 1
 
 	var sqlString bytes.Buffer
 	sqlString.WriteString("UPDATE ")
@@ -154,8 +202,9 @@ func (CategoryMySqlMapper) UpdateCategoryByEntity(plainId string, updatedEntity
 	sqlString.WriteString(" NAME = ?, ")
 	sqlString.WriteString(" TYPE = ?, ")
 	sqlString.WriteString(" REMARK = ?, ")
-	sqlString.WriteString(" MODIFY_TIME = ? ")
-	sqlString.WriteString(" WHERE ID = ? ")
+	sqlString.WriteString(" MODIFY_TIME = ?, ")
+	sqlString.WriteString(" VERSION = VERSION + 1 ")
+	sqlString.WriteString(" WHERE ID = ? AND VERSION = ? ")
 
 	connection := database.GetMySqlConnection()
 	defer database.CloseMySqlConnection()
@@ -166,15 +215,17 @@ func (CategoryMySqlMapper) UpdateCategoryByEntity(plainId string, updatedEntity
 	}
 
 	result, err := statement.Exec(updatedEntity.ParentId.Hex(), updatedEntity.Name, updatedEntity.Type, updatedEntity.Remark,
-		updatedEntity.ModifyTime, updatedEntity.Id)
+		updatedEntity.ModifyTime, updatedEntity.Id.Hex(), expectedVersion)
 	if err != nil {
 		util.Logger.Errorw("update failed", "error", err)
+		return model.CategoryEntity{}
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil || rowsAffected != 1 {
-		// fixme: maybe we should have a rollback here.
-		util.Logger.Errorw("update failed", "error", err, "rows_affected", rowsAffected)
+		// No row affected: another update got in first and bumped the version
+		util.Logger.Infow("update skipped", "error", err, "rows_affected", rowsAffected, "expected_version", expectedVersion)
+		return model.CategoryEntity{}
 	}
 
 	// Invalidate cache on update
@@ -226,9 +277,92 @@ func (CategoryMySqlMapper) DeleteCategoryByObjectId(plainId string) model.Catego
 	return targetEntity
 }
 
+// GetCategoriesByIdList returns the full categories with the given ids; unknown ids are left out
+func (CategoryMySqlMapper) GetCategoriesByIdList(plainIdList []string) ([]model.CategoryEntity, error) {
+	if len(plainIdList) == 0 {
+		return nil, nil
+	}
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE, REMARK, CREATE_TIME, MODIFY_TIME FROM ")
+	sqlString.WriteString(database.CategoryTableName)
+	sqlString.WriteString(" WHERE ID IN (?" + strings.Repeat(", ?", len(plainIdList)-1) + ") ")
+	argList := make([]interface{}, 0, len(plainIdList))
+	for _, plainId := range plainIdList {
+		argList = append(argList, plainId)
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("query by id list failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.CategoryEntity
+	for rows.Next() {
+		entity, err := convertFullRow2CategoryEntity(rows)
+		if err != nil {
+			return nil, err
+		}
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
+// GetCategoriesAfterId returns up to limit categories with an id greater than afterPlainId, in id order
+// An empty afterPlainId starts from the beginning; used to page through every row with constant memory
+func (CategoryMySqlMapper) GetCategoriesAfterId(afterPlainId string, limit int) ([]model.CategoryEntity, error) {
+	return getCategoriesAfterIdInMySql(time.Time{}, afterPlainId, limit)
+}
+
+func (CategoryMySqlMapper) GetCategoriesModifiedSince(since time.Time, afterPlainId string, limit int) ([]model.CategoryEntity, error) {
+	return getCategoriesAfterIdInMySql(since, afterPlainId, limit)
+}
+
+// getCategoriesAfterIdInMySql pages categories by ID, optionally only those with MODIFY_TIME >= since
+func getCategoriesAfterIdInMySql(since time.Time, afterPlainId string, limit int) ([]model.CategoryEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE, REMARK, CREATE_TIME, MODIFY_TIME FROM ")
+	sqlString.WriteString(database.CategoryTableName)
+	sqlString.WriteString(" WHERE ID > ? ")
+	argList := []interface{}{afterPlainId}
+	if !since.IsZero() {
+		sqlString.WriteString(" AND MODIFY_TIME >= ? ")
+		argList = append(argList, since.UTC())
+	}
+	sqlString.WriteString(" ORDER BY ID ASC ")
+	if limit > 0 {
+		sqlString.WriteString(" LIMIT ? ")
+		argList = append(argList, limit)
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("query after id failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.CategoryEntity
+	for rows.Next() {
+		entity, err := convertFullRow2CategoryEntity(rows)
+		if err != nil {
+			return nil, err
+		}
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
 func (CategoryMySqlMapper) GetAllCategories(limit, offset int) []model.CategoryEntity {
 	var sqlString bytes.Buffer
-	sqlString.WriteString("SELECT ID, PARENT_ID, NAME FROM ")
+	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE, VERSION FROM ")
 	sqlString.WriteString(database.CategoryTableName)
 	sqlString.WriteString(" ORDER BY NAME ASC ")
 
@@ -260,6 +394,50 @@ func (CategoryMySqlMapper) GetAllCategories(limit, offset int) []model.CategoryE
 	return targetEntityList
 }
 
+func (CategoryMySqlMapper) GetCategoriesPage(request query.PageRequest) ([]model.CategoryEntity, error) {
+	orderBy, keysetCondition, argList := query.MySqlPage(request)
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE, REMARK, CREATE_TIME, MODIFY_TIME, VERSION FROM ")
+	sqlString.WriteString(database.CategoryTableName)
+	if keysetCondition != "" {
+		sqlString.WriteString(" WHERE " + keysetCondition + " ")
+	}
+	sqlString.WriteString(" ORDER BY " + orderBy + " LIMIT ? OFFSET ? ")
+	argList = append(argList, request.FetchLimit(), request.Skip())
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("query categories page failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.CategoryEntity
+	for rows.Next() {
+		var id, createTime, modifyTime string
+		var parentId, remark sql.NullString
+		var entity model.CategoryEntity
+		err := rows.Scan(&id, &parentId, &entity.Name, &entity.Type, &remark, &createTime, &modifyTime, &entity.Version)
+		if err != nil {
+			util.Logger.Errorw("covert into entity failed", "error", err)
+			return nil, err
+		}
+		entity.Id = util.Convert2ObjectId(id)
+		if parentId.String != "" {
+			entity.ParentId = util.Convert2ObjectId(parentId.String)
+		}
+		entity.Remark = remark.String
+		entity.CreateTime = database.ParseMySqlTime(createTime)
+		entity.ModifyTime = database.ParseMySqlTime(modifyTime)
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
 func (CategoryMySqlMapper) CountAllCategories() int64 {
 	var sqlString bytes.Buffer
 	sqlString.WriteString("SELECT COUNT(1) FROM ")
@@ -309,8 +487,9 @@ func convertRow2CategoryEntity(rows *sql.Rows) model.CategoryEntity {
 	var parentId string
 	var name string
 	var categoryType string
+	var version int64
 
-	err := rows.Scan(&id, &parentId, &name, &categoryType)
+	err := rows.Scan(&id, &parentId, &name, &categoryType, &version)
 	if err != nil {
 		util.Logger.Errorw("covert into entity failed", "error", err)
 	}
@@ -320,5 +499,28 @@ func convertRow2CategoryEntity(rows *sql.Rows) model.CategoryEntity {
 		ParentId: util.Convert2ObjectId(parentId),
 		Name:     name,
 		Type:     categoryType,
+		Version:  version,
+	}
+}
+
+// convertFullRow2CategoryEntity converts a row holding every categories column
+func convertFullRow2CategoryEntity(rows *sql.Rows) (model.CategoryEntity, error) {
+	var id, createTime, modifyTime string
+	var parentId, remark sql.NullString
+	var entity model.CategoryEntity
+
+	err := rows.Scan(&id, &parentId, &entity.Name, &entity.Type, &remark, &createTime, &modifyTime)
+	if err != nil {
+		util.Logger.Errorw("covert into entity failed", "error", err)
+		return entity, err
+	}
+
+	entity.Id = util.Convert2ObjectId(id)
+	if parentId.String != "" {
+		entity.ParentId = util.Convert2ObjectId(parentId.String)
 	}
+	entity.Remark = remark.String
+	entity.CreateTime = database.ParseMySqlTime(createTime)
+	entity.ModifyTime = database.ParseMySqlTime(modifyTime)
+	return entity, nil
 }
@@ -0,0 +1,37 @@
+package debt_mapper
+
+import (
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+var INSTANCE DebtMapper
+
+type DebtMapper interface {
+	// InsertDebt stores a new debt at version 0 and returns its id
+	InsertDebt(entity model.DebtEntity) (string, error)
+	// GetDebtByObjectId returns an empty entity when the debt is unknown
+	GetDebtByObjectId(plainId string) (model.DebtEntity, error)
+	// GetAllDebts returns every debt, the oldest first
+	GetAllDebts() ([]model.DebtEntity, error)
+	// UpdateDebtByEntity only applies while the stored version still equals updatedEntity.Version,
+	// and bumps it; returns an empty entity when the debt is gone or was updated in between
+	UpdateDebtByEntity(plainId string, updatedEntity model.DebtEntity) (model.DebtEntity, error)
+	// DeleteDebtByObjectId returns the deleted debt, or an empty entity when it is unknown
+	DeleteDebtByObjectId(plainId string) (model.DebtEntity, error)
+	// BulkUpsertDebts writes debts keeping their ids and times, as a restore does; the version of a
+	// debt already stored counts up
+	BulkUpsertDebts(entities []model.DebtEntity) error
+	TruncateDebts() error
+}
+
+func init() {
+	switch util.GetConfigByKey("db.type") {
+	case "mongodb":
+		INSTANCE = DebtMongoDbMapper{}
+	case "mysql":
+		INSTANCE = DebtMySqlMapper{}
+	default:
+		panic("database type not supported")
+	}
+}
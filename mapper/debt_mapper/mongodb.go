@@ -0,0 +1,176 @@
+package debt_mapper
+
+import (
+	"context"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DebtMongoDbMapper struct{}
+
+func (DebtMongoDbMapper) InsertDebt(entity model.DebtEntity) (string, error) {
+	operatingTime := time.Now().UTC()
+	entity.Id = primitive.NewObjectID()
+	entity.CreateTime = operatingTime
+	entity.ModifyTime = operatingTime
+	entity.Version = 0
+
+	collection := database.GetMongoCollection(database.DebtTableName)
+	if _, err := collection.InsertOne(context.TODO(), entity); err != nil {
+		util.Logger.Errorw("insert debt failed", "error", err)
+		return "", err
+	}
+	return entity.Id.Hex(), nil
+}
+
+func (DebtMongoDbMapper) GetDebtByObjectId(plainId string) (model.DebtEntity, error) {
+	objectId := util.Convert2ObjectId(plainId)
+	if objectId == primitive.NilObjectID {
+		return model.DebtEntity{}, nil
+	}
+
+	collection := database.GetMongoCollection(database.DebtTableName)
+	var entity model.DebtEntity
+	err := collection.FindOne(context.TODO(), bson.D{primitive.E{Key: "_id", Value: objectId}}).Decode(&entity)
+	if err == mongo.ErrNoDocuments {
+		return model.DebtEntity{}, nil
+	}
+	if err != nil {
+		util.Logger.Errorw("query debt failed", "error", err)
+		return model.DebtEntity{}, err
+	}
+	return entity, nil
+}
+
+func (DebtMongoDbMapper) GetAllDebts() ([]model.DebtEntity, error) {
+	findOptions := options.Find().SetSort(bson.D{
+		primitive.E{Key: "start_date", Value: 1},
+		primitive.E{Key: "_id", Value: 1},
+	})
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.DebtTableName)
+	cursor, err := collection.Find(ctx, bson.D{}, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query debts failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.DebtEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode debts failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
+func (DebtMongoDbMapper) BulkUpsertDebts(entities []model.DebtEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	// Fields are set rather than the document replaced, so the version keeps counting up
+	writeModels := make([]mongo.WriteModel, len(entities))
+	for i, entity := range entities {
+		update := bson.D{
+			primitive.E{Key: "$set", Value: bson.D{
+				primitive.E{Key: "name", Value: entity.Name},
+				primitive.E{Key: "principal", Value: entity.Principal},
+				primitive.E{Key: "annual_rate", Value: entity.AnnualRate},
+				primitive.E{Key: "term_months", Value: entity.TermMonths},
+				primitive.E{Key: "start_date", Value: entity.StartDate.UTC()},
+				primitive.E{Key: "payment_category_id", Value: entity.PaymentCategoryId},
+				primitive.E{Key: "interest_category_id", Value: entity.InterestCategoryId},
+				primitive.E{Key: "remark", Value: entity.Remark},
+				primitive.E{Key: "create_time", Value: entity.CreateTime.UTC()},
+				primitive.E{Key: "modify_time", Value: entity.ModifyTime.UTC()},
+			}},
+			primitive.E{Key: "$inc", Value: bson.D{primitive.E{Key: "version", Value: int64(1)}}},
+		}
+		writeModels[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.D{primitive.E{Key: "_id", Value: entity.Id}}).
+			SetUpdate(update).
+			SetUpsert(true)
+	}
+
+	collection := database.GetMongoCollection(database.DebtTableName)
+	if _, err := collection.BulkWrite(context.TODO(), writeModels, options.BulkWrite().SetOrdered(false)); err != nil {
+		util.Logger.Errorw("bulk upsert debts failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (DebtMongoDbMapper) UpdateDebtByEntity(plainId string, updatedEntity model.DebtEntity) (model.DebtEntity, error) {
+	objectId := util.Convert2ObjectId(plainId)
+	if objectId == primitive.NilObjectID {
+		return model.DebtEntity{}, nil
+	}
+
+	targetEntity, err := INSTANCE.GetDebtByObjectId(plainId)
+	if err != nil || targetEntity.IsEmpty() {
+		return model.DebtEntity{}, err
+	}
+
+	// Update fields from updatedEntity while preserving ID and CreateTime
+	expectedVersion := updatedEntity.Version
+	updatedEntity.Id = targetEntity.Id
+	updatedEntity.CreateTime = targetEntity.CreateTime
+	updatedEntity.ModifyTime = time.Now().UTC() // Store in UTC
+	updatedEntity.Version = expectedVersion + 1
+
+	// The version in the filter makes this a compare-and-swap
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: objectId},
+		database.VersionFilter(expectedVersion),
+	}
+	collection := database.GetMongoCollection(database.DebtTableName)
+	result, err := collection.ReplaceOne(context.TODO(), filter, updatedEntity)
+	if err != nil {
+		util.Logger.Errorw("update debt failed", "error", err)
+		return model.DebtEntity{}, err
+	}
+	if result.MatchedCount != 1 {
+		util.Logger.Infow("update skipped", "expected_version", expectedVersion)
+		return model.DebtEntity{}, nil
+	}
+	return updatedEntity, nil
+}
+
+func (DebtMongoDbMapper) DeleteDebtByObjectId(plainId string) (model.DebtEntity, error) {
+	objectId := util.Convert2ObjectId(plainId)
+	if objectId == primitive.NilObjectID {
+		return model.DebtEntity{}, nil
+	}
+
+	collection := database.GetMongoCollection(database.DebtTableName)
+	var entity model.DebtEntity
+	err := collection.FindOneAndDelete(context.TODO(), bson.D{primitive.E{Key: "_id", Value: objectId}}).Decode(&entity)
+	if err == mongo.ErrNoDocuments {
+		return model.DebtEntity{}, nil
+	}
+	if err != nil {
+		util.Logger.Errorw("delete debt failed", "error", err)
+		return model.DebtEntity{}, err
+	}
+	return entity, nil
+}
+
+func (DebtMongoDbMapper) TruncateDebts() error {
+	collection := database.GetMongoCollection(database.DebtTableName)
+	result, err := collection.DeleteMany(context.TODO(), bson.D{})
+	if err != nil {
+		util.Logger.Errorw("truncate debts failed", "error", err)
+		return err
+	}
+	util.Logger.Infow("debts truncated", "deleted_count", result.DeletedCount)
+	return nil
+}
@@ -0,0 +1,229 @@
+package debt_mapper
+
+import (
+	"bytes"
+	"database/sql"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DebtMySqlMapper struct{}
+
+const debtColumns = "ID, NAME, PRINCIPAL, ANNUAL_RATE, TERM_MONTHS, START_DATE, PAYMENT_CATEGORY_ID, INTEREST_CATEGORY_ID, " +
+	"REMARK, CREATE_TIME, MODIFY_TIME, VERSION"
+
+func (DebtMySqlMapper) InsertDebt(entity model.DebtEntity) (string, error) {
+	operatingTime := time.Now().UTC()
+	entity.Id = primitive.NewObjectID()
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(database.DebtTableName)
+	sqlString.WriteString(" (" + debtColumns + ") ")
+	sqlString.WriteString(" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0) ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	_, err := connection.Exec(sqlString.String(),
+		entity.Id.Hex(), entity.Name, entity.Principal, entity.AnnualRate, entity.TermMonths, entity.StartDate.UTC(),
+		entity.PaymentCategoryId.Hex(), entity.InterestCategoryId.Hex(), nullableRemark(entity.Remark),
+		operatingTime, operatingTime)
+	if err != nil {
+		util.Logger.Errorw("insert debt failed", "error", err)
+		return "", err
+	}
+	return entity.Id.Hex(), nil
+}
+
+func (DebtMySqlMapper) GetDebtByObjectId(plainId string) (model.DebtEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT " + debtColumns + " FROM ")
+	sqlString.WriteString(database.DebtTableName)
+	sqlString.WriteString(" WHERE ID = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), plainId)
+	if err != nil {
+		util.Logger.Errorw("query debt failed", "error", err)
+		return model.DebtEntity{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return model.DebtEntity{}, rows.Err()
+	}
+	return convertRow2DebtEntity(rows)
+}
+
+func (DebtMySqlMapper) GetAllDebts() ([]model.DebtEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT " + debtColumns + " FROM ")
+	sqlString.WriteString(database.DebtTableName)
+	sqlString.WriteString(" ORDER BY START_DATE ASC, ID ASC ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String())
+	if err != nil {
+		util.Logger.Errorw("query debts failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.DebtEntity
+	for rows.Next() {
+		entity, err := convertRow2DebtEntity(rows)
+		if err != nil {
+			return nil, err
+		}
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
+func (DebtMySqlMapper) BulkUpsertDebts(entities []model.DebtEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(database.DebtTableName)
+	sqlString.WriteString(" (" + debtColumns + ") VALUES ")
+
+	values := make([]interface{}, 0, len(entities)*11)
+	for i, entity := range entities {
+		if i > 0 {
+			sqlString.WriteString(", ")
+		}
+		sqlString.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)")
+		values = append(values, entity.Id.Hex(), entity.Name, entity.Principal, entity.AnnualRate, entity.TermMonths,
+			entity.StartDate.UTC(), entity.PaymentCategoryId.Hex(), entity.InterestCategoryId.Hex(),
+			nullableRemark(entity.Remark), entity.CreateTime.UTC(), entity.ModifyTime.UTC())
+	}
+
+	sqlString.WriteString(" ON DUPLICATE KEY UPDATE NAME = VALUES(NAME), ")
+	sqlString.WriteString(" PRINCIPAL = VALUES(PRINCIPAL), ")
+	sqlString.WriteString(" ANNUAL_RATE = VALUES(ANNUAL_RATE), ")
+	sqlString.WriteString(" TERM_MONTHS = VALUES(TERM_MONTHS), ")
+	sqlString.WriteString(" START_DATE = VALUES(START_DATE), ")
+	sqlString.WriteString(" PAYMENT_CATEGORY_ID = VALUES(PAYMENT_CATEGORY_ID), ")
+	sqlString.WriteString(" INTEREST_CATEGORY_ID = VALUES(INTEREST_CATEGORY_ID), ")
+	sqlString.WriteString(" REMARK = VALUES(REMARK), ")
+	sqlString.WriteString(" CREATE_TIME = VALUES(CREATE_TIME), ")
+	sqlString.WriteString(" MODIFY_TIME = VALUES(MODIFY_TIME), ")
+	sqlString.WriteString(" VERSION = VERSION + 1 ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), values...); err != nil {
+		util.Logger.Errorw("bulk upsert debts failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (DebtMySqlMapper) UpdateDebtByEntity(plainId string, updatedEntity model.DebtEntity) (model.DebtEntity, error) {
+	targetEntity, err := INSTANCE.GetDebtByObjectId(plainId)
+	if err != nil || targetEntity.IsEmpty() {
+		return model.DebtEntity{}, err
+	}
+
+	// Update fields from updatedEntity while preserving ID and CreateTime
+	expectedVersion := updatedEntity.Version
+	updatedEntity.Id = targetEntity.Id
+	updatedEntity.CreateTime = targetEntity.CreateTime
+	updatedEntity.ModifyTime = time.Now().UTC() // Store in UTC
+	updatedEntity.Version = expectedVersion + 1
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("UPDATE ")
+	sqlString.WriteString(database.DebtTableName)
+	sqlString.WriteString(" SET NAME = ?, PRINCIPAL = ?, ANNUAL_RATE = ?, TERM_MONTHS = ?, START_DATE = ?, ")
+	sqlString.WriteString(" PAYMENT_CATEGORY_ID = ?, INTEREST_CATEGORY_ID = ?, REMARK = ?, MODIFY_TIME = ?, VERSION = VERSION + 1 ")
+	sqlString.WriteString(" WHERE ID = ? AND VERSION = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	result, err := connection.Exec(sqlString.String(),
+		updatedEntity.Name, updatedEntity.Principal, updatedEntity.AnnualRate, updatedEntity.TermMonths,
+		updatedEntity.StartDate.UTC(), updatedEntity.PaymentCategoryId.Hex(), updatedEntity.InterestCategoryId.Hex(),
+		nullableRemark(updatedEntity.Remark), updatedEntity.ModifyTime, updatedEntity.Id.Hex(), expectedVersion)
+	if err != nil {
+		util.Logger.Errorw("update debt failed", "error", err)
+		return model.DebtEntity{}, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || rowsAffected != 1 {
+		// No row affected: another update got in first and bumped the version
+		util.Logger.Infow("update skipped", "error", err, "rows_affected", rowsAffected, "expected_version", expectedVersion)
+		return model.DebtEntity{}, err
+	}
+	return updatedEntity, nil
+}
+
+func (DebtMySqlMapper) DeleteDebtByObjectId(plainId string) (model.DebtEntity, error) {
+	targetEntity, err := INSTANCE.GetDebtByObjectId(plainId)
+	if err != nil || targetEntity.IsEmpty() {
+		return model.DebtEntity{}, err
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("DELETE FROM ")
+	sqlString.WriteString(database.DebtTableName)
+	sqlString.WriteString(" WHERE ID = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), plainId); err != nil {
+		util.Logger.Errorw("delete debt failed", "error", err)
+		return model.DebtEntity{}, err
+	}
+	return targetEntity, nil
+}
+
+func (DebtMySqlMapper) TruncateDebts() error {
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec("TRUNCATE TABLE " + database.DebtTableName); err != nil {
+		util.Logger.Errorw("truncate debts failed", "error", err)
+		return err
+	}
+	util.Logger.Infow("debts truncated")
+	return nil
+}
+
+func convertRow2DebtEntity(rows *sql.Rows) (model.DebtEntity, error) {
+	var id, startDate, paymentCategoryId, interestCategoryId, createTime, modifyTime string
+	var remark sql.NullString
+	var entity model.DebtEntity
+	if err := rows.Scan(&id, &entity.Name, &entity.Principal, &entity.AnnualRate, &entity.TermMonths, &startDate,
+		&paymentCategoryId, &interestCategoryId, &remark, &createTime, &modifyTime, &entity.Version); err != nil {
+		util.Logger.Errorw("scan debt failed", "error", err)
+		return model.DebtEntity{}, err
+	}
+	entity.Id = util.Convert2ObjectId(id)
+	entity.StartDate = database.ParseMySqlTime(startDate)
+	entity.PaymentCategoryId = util.Convert2ObjectId(paymentCategoryId)
+	entity.InterestCategoryId = util.Convert2ObjectId(interestCategoryId)
+	entity.Remark = remark.String
+	entity.CreateTime = database.ParseMySqlTime(createTime)
+	entity.ModifyTime = database.ParseMySqlTime(modifyTime)
+	return entity, nil
+}
+
+func nullableRemark(remark string) sql.NullString {
+	return sql.NullString{String: remark, Valid: remark != ""}
+}
@@ -0,0 +1,37 @@
+package goal_mapper
+
+import (
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+var INSTANCE GoalMapper
+
+type GoalMapper interface {
+	// InsertGoal stores a new goal at version 0 and returns its id
+	InsertGoal(entity model.GoalEntity) (string, error)
+	// GetGoalByObjectId returns an empty entity when the goal is unknown
+	GetGoalByObjectId(plainId string) (model.GoalEntity, error)
+	// GetAllGoals returns every goal, the nearest deadline first
+	GetAllGoals() ([]model.GoalEntity, error)
+	// UpdateGoalByEntity only applies while the stored version still equals updatedEntity.Version,
+	// and bumps it; returns an empty entity when the goal is gone or was updated in between
+	UpdateGoalByEntity(plainId string, updatedEntity model.GoalEntity) (model.GoalEntity, error)
+	// DeleteGoalByObjectId returns the deleted goal, or an empty entity when it is unknown
+	DeleteGoalByObjectId(plainId string) (model.GoalEntity, error)
+	// BulkUpsertGoals writes goals keeping their ids and times, as a restore does; the version of a
+	// goal already stored counts up
+	BulkUpsertGoals(entities []model.GoalEntity) error
+	TruncateGoals() error
+}
+
+func init() {
+	switch util.GetConfigByKey("db.type") {
+	case "mongodb":
+		INSTANCE = GoalMongoDbMapper{}
+	case "mysql":
+		INSTANCE = GoalMySqlMapper{}
+	default:
+		panic("database type not supported")
+	}
+}
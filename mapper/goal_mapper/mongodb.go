@@ -0,0 +1,174 @@
+package goal_mapper
+
+import (
+	"context"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GoalMongoDbMapper struct{}
+
+func (GoalMongoDbMapper) InsertGoal(entity model.GoalEntity) (string, error) {
+	operatingTime := time.Now().UTC()
+	entity.Id = primitive.NewObjectID()
+	entity.CreateTime = operatingTime
+	entity.ModifyTime = operatingTime
+	entity.Version = 0
+
+	collection := database.GetMongoCollection(database.GoalTableName)
+	if _, err := collection.InsertOne(context.TODO(), entity); err != nil {
+		util.Logger.Errorw("insert goal failed", "error", err)
+		return "", err
+	}
+	return entity.Id.Hex(), nil
+}
+
+func (GoalMongoDbMapper) GetGoalByObjectId(plainId string) (model.GoalEntity, error) {
+	objectId := util.Convert2ObjectId(plainId)
+	if objectId == primitive.NilObjectID {
+		return model.GoalEntity{}, nil
+	}
+
+	collection := database.GetMongoCollection(database.GoalTableName)
+	var entity model.GoalEntity
+	err := collection.FindOne(context.TODO(), bson.D{primitive.E{Key: "_id", Value: objectId}}).Decode(&entity)
+	if err == mongo.ErrNoDocuments {
+		return model.GoalEntity{}, nil
+	}
+	if err != nil {
+		util.Logger.Errorw("query goal failed", "error", err)
+		return model.GoalEntity{}, err
+	}
+	return entity, nil
+}
+
+func (GoalMongoDbMapper) GetAllGoals() ([]model.GoalEntity, error) {
+	findOptions := options.Find().SetSort(bson.D{
+		primitive.E{Key: "deadline", Value: 1},
+		primitive.E{Key: "_id", Value: 1},
+	})
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.GoalTableName)
+	cursor, err := collection.Find(ctx, bson.D{}, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query goals failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.GoalEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode goals failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
+func (GoalMongoDbMapper) BulkUpsertGoals(entities []model.GoalEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	// Fields are set rather than the document replaced, so the version keeps counting up
+	writeModels := make([]mongo.WriteModel, len(entities))
+	for i, entity := range entities {
+		update := bson.D{
+			primitive.E{Key: "$set", Value: bson.D{
+				primitive.E{Key: "name", Value: entity.Name},
+				primitive.E{Key: "target_amount", Value: entity.TargetAmount},
+				primitive.E{Key: "start_date", Value: entity.StartDate.UTC()},
+				primitive.E{Key: "deadline", Value: entity.Deadline.UTC()},
+				primitive.E{Key: "category_id", Value: entity.CategoryId},
+				primitive.E{Key: "remark", Value: entity.Remark},
+				primitive.E{Key: "create_time", Value: entity.CreateTime.UTC()},
+				primitive.E{Key: "modify_time", Value: entity.ModifyTime.UTC()},
+			}},
+			primitive.E{Key: "$inc", Value: bson.D{primitive.E{Key: "version", Value: int64(1)}}},
+		}
+		writeModels[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.D{primitive.E{Key: "_id", Value: entity.Id}}).
+			SetUpdate(update).
+			SetUpsert(true)
+	}
+
+	collection := database.GetMongoCollection(database.GoalTableName)
+	if _, err := collection.BulkWrite(context.TODO(), writeModels, options.BulkWrite().SetOrdered(false)); err != nil {
+		util.Logger.Errorw("bulk upsert goals failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (GoalMongoDbMapper) UpdateGoalByEntity(plainId string, updatedEntity model.GoalEntity) (model.GoalEntity, error) {
+	objectId := util.Convert2ObjectId(plainId)
+	if objectId == primitive.NilObjectID {
+		return model.GoalEntity{}, nil
+	}
+
+	targetEntity, err := INSTANCE.GetGoalByObjectId(plainId)
+	if err != nil || targetEntity.IsEmpty() {
+		return model.GoalEntity{}, err
+	}
+
+	// Update fields from updatedEntity while preserving ID and CreateTime
+	expectedVersion := updatedEntity.Version
+	updatedEntity.Id = targetEntity.Id
+	updatedEntity.CreateTime = targetEntity.CreateTime
+	updatedEntity.ModifyTime = time.Now().UTC() // Store in UTC
+	updatedEntity.Version = expectedVersion + 1
+
+	// The version in the filter makes this a compare-and-swap
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: objectId},
+		database.VersionFilter(expectedVersion),
+	}
+	collection := database.GetMongoCollection(database.GoalTableName)
+	result, err := collection.ReplaceOne(context.TODO(), filter, updatedEntity)
+	if err != nil {
+		util.Logger.Errorw("update goal failed", "error", err)
+		return model.GoalEntity{}, err
+	}
+	if result.MatchedCount != 1 {
+		util.Logger.Infow("update skipped", "expected_version", expectedVersion)
+		return model.GoalEntity{}, nil
+	}
+	return updatedEntity, nil
+}
+
+func (GoalMongoDbMapper) DeleteGoalByObjectId(plainId string) (model.GoalEntity, error) {
+	objectId := util.Convert2ObjectId(plainId)
+	if objectId == primitive.NilObjectID {
+		return model.GoalEntity{}, nil
+	}
+
+	collection := database.GetMongoCollection(database.GoalTableName)
+	var entity model.GoalEntity
+	err := collection.FindOneAndDelete(context.TODO(), bson.D{primitive.E{Key: "_id", Value: objectId}}).Decode(&entity)
+	if err == mongo.ErrNoDocuments {
+		return model.GoalEntity{}, nil
+	}
+	if err != nil {
+		util.Logger.Errorw("delete goal failed", "error", err)
+		return model.GoalEntity{}, err
+	}
+	return entity, nil
+}
+
+func (GoalMongoDbMapper) TruncateGoals() error {
+	collection := database.GetMongoCollection(database.GoalTableName)
+	result, err := collection.DeleteMany(context.TODO(), bson.D{})
+	if err != nil {
+		util.Logger.Errorw("truncate goals failed", "error", err)
+		return err
+	}
+	util.Logger.Infow("goals truncated", "deleted_count", result.DeletedCount)
+	return nil
+}
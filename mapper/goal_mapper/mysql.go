@@ -0,0 +1,235 @@
+package goal_mapper
+
+import (
+	"bytes"
+	"database/sql"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GoalMySqlMapper struct{}
+
+const goalColumns = "ID, NAME, TARGET_AMOUNT, START_DATE, DEADLINE, CATEGORY_ID, REMARK, CREATE_TIME, MODIFY_TIME, VERSION"
+
+func (GoalMySqlMapper) InsertGoal(entity model.GoalEntity) (string, error) {
+	operatingTime := time.Now().UTC()
+	entity.Id = primitive.NewObjectID()
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(database.GoalTableName)
+	sqlString.WriteString(" (" + goalColumns + ") ")
+	sqlString.WriteString(" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 0) ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	_, err := connection.Exec(sqlString.String(),
+		entity.Id.Hex(), entity.Name, entity.TargetAmount, entity.StartDate.UTC(), entity.Deadline.UTC(),
+		nullableCategoryId(entity.CategoryId), nullableRemark(entity.Remark), operatingTime, operatingTime)
+	if err != nil {
+		util.Logger.Errorw("insert goal failed", "error", err)
+		return "", err
+	}
+	return entity.Id.Hex(), nil
+}
+
+func (GoalMySqlMapper) GetGoalByObjectId(plainId string) (model.GoalEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT " + goalColumns + " FROM ")
+	sqlString.WriteString(database.GoalTableName)
+	sqlString.WriteString(" WHERE ID = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), plainId)
+	if err != nil {
+		util.Logger.Errorw("query goal failed", "error", err)
+		return model.GoalEntity{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return model.GoalEntity{}, rows.Err()
+	}
+	return convertRow2GoalEntity(rows)
+}
+
+func (GoalMySqlMapper) GetAllGoals() ([]model.GoalEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT " + goalColumns + " FROM ")
+	sqlString.WriteString(database.GoalTableName)
+	sqlString.WriteString(" ORDER BY DEADLINE ASC, ID ASC ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String())
+	if err != nil {
+		util.Logger.Errorw("query goals failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.GoalEntity
+	for rows.Next() {
+		entity, err := convertRow2GoalEntity(rows)
+		if err != nil {
+			return nil, err
+		}
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
+func (GoalMySqlMapper) BulkUpsertGoals(entities []model.GoalEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(database.GoalTableName)
+	sqlString.WriteString(" (" + goalColumns + ") VALUES ")
+
+	values := make([]interface{}, 0, len(entities)*9)
+	for i, entity := range entities {
+		if i > 0 {
+			sqlString.WriteString(", ")
+		}
+		sqlString.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, 0)")
+		values = append(values, entity.Id.Hex(), entity.Name, entity.TargetAmount, entity.StartDate.UTC(),
+			entity.Deadline.UTC(), nullableCategoryId(entity.CategoryId), nullableRemark(entity.Remark),
+			entity.CreateTime.UTC(), entity.ModifyTime.UTC())
+	}
+
+	sqlString.WriteString(" ON DUPLICATE KEY UPDATE NAME = VALUES(NAME), ")
+	sqlString.WriteString(" TARGET_AMOUNT = VALUES(TARGET_AMOUNT), ")
+	sqlString.WriteString(" START_DATE = VALUES(START_DATE), ")
+	sqlString.WriteString(" DEADLINE = VALUES(DEADLINE), ")
+	sqlString.WriteString(" CATEGORY_ID = VALUES(CATEGORY_ID), ")
+	sqlString.WriteString(" REMARK = VALUES(REMARK), ")
+	sqlString.WriteString(" CREATE_TIME = VALUES(CREATE_TIME), ")
+	sqlString.WriteString(" MODIFY_TIME = VALUES(MODIFY_TIME), ")
+	sqlString.WriteString(" VERSION = VERSION + 1 ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), values...); err != nil {
+		util.Logger.Errorw("bulk upsert goals failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (GoalMySqlMapper) UpdateGoalByEntity(plainId string, updatedEntity model.GoalEntity) (model.GoalEntity, error) {
+	targetEntity, err := INSTANCE.GetGoalByObjectId(plainId)
+	if err != nil || targetEntity.IsEmpty() {
+		return model.GoalEntity{}, err
+	}
+
+	// Update fields from updatedEntity while preserving ID and CreateTime
+	expectedVersion := updatedEntity.Version
+	updatedEntity.Id = targetEntity.Id
+	updatedEntity.CreateTime = targetEntity.CreateTime
+	updatedEntity.ModifyTime = time.Now().UTC() // Store in UTC
+	updatedEntity.Version = expectedVersion + 1
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("UPDATE ")
+	sqlString.WriteString(database.GoalTableName)
+	sqlString.WriteString(" SET NAME = ?, TARGET_AMOUNT = ?, START_DATE = ?, DEADLINE = ?, CATEGORY_ID = ?, ")
+	sqlString.WriteString(" REMARK = ?, MODIFY_TIME = ?, VERSION = VERSION + 1 ")
+	sqlString.WriteString(" WHERE ID = ? AND VERSION = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	result, err := connection.Exec(sqlString.String(),
+		updatedEntity.Name, updatedEntity.TargetAmount, updatedEntity.StartDate.UTC(), updatedEntity.Deadline.UTC(),
+		nullableCategoryId(updatedEntity.CategoryId), nullableRemark(updatedEntity.Remark), updatedEntity.ModifyTime,
+		updatedEntity.Id.Hex(), expectedVersion)
+	if err != nil {
+		util.Logger.Errorw("update goal failed", "error", err)
+		return model.GoalEntity{}, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || rowsAffected != 1 {
+		// No row affected: another update got in first and bumped the version
+		util.Logger.Infow("update skipped", "error", err, "rows_affected", rowsAffected, "expected_version", expectedVersion)
+		return model.GoalEntity{}, err
+	}
+	return updatedEntity, nil
+}
+
+func (GoalMySqlMapper) DeleteGoalByObjectId(plainId string) (model.GoalEntity, error) {
+	targetEntity, err := INSTANCE.GetGoalByObjectId(plainId)
+	if err != nil || targetEntity.IsEmpty() {
+		return model.GoalEntity{}, err
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("DELETE FROM ")
+	sqlString.WriteString(database.GoalTableName)
+	sqlString.WriteString(" WHERE ID = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), plainId); err != nil {
+		util.Logger.Errorw("delete goal failed", "error", err)
+		return model.GoalEntity{}, err
+	}
+	return targetEntity, nil
+}
+
+func (GoalMySqlMapper) TruncateGoals() error {
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec("TRUNCATE TABLE " + database.GoalTableName); err != nil {
+		util.Logger.Errorw("truncate goals failed", "error", err)
+		return err
+	}
+	util.Logger.Infow("goals truncated")
+	return nil
+}
+
+func convertRow2GoalEntity(rows *sql.Rows) (model.GoalEntity, error) {
+	var id, startDate, deadline, createTime, modifyTime string
+	var categoryId, remark sql.NullString
+	var entity model.GoalEntity
+	if err := rows.Scan(&id, &entity.Name, &entity.TargetAmount, &startDate, &deadline, &categoryId,
+		&remark, &createTime, &modifyTime, &entity.Version); err != nil {
+		util.Logger.Errorw("scan goal failed", "error", err)
+		return model.GoalEntity{}, err
+	}
+	entity.Id = util.Convert2ObjectId(id)
+	if categoryId.Valid && categoryId.String != "" {
+		entity.CategoryId = util.Convert2ObjectId(categoryId.String)
+	}
+	entity.StartDate = database.ParseMySqlTime(startDate)
+	entity.Deadline = database.ParseMySqlTime(deadline)
+	entity.Remark = remark.String
+	entity.CreateTime = database.ParseMySqlTime(createTime)
+	entity.ModifyTime = database.ParseMySqlTime(modifyTime)
+	return entity, nil
+}
+
+// nullableCategoryId stores goals over all cash flows without a category
+func nullableCategoryId(categoryId primitive.ObjectID) sql.NullString {
+	if categoryId.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: categoryId.Hex(), Valid: true}
+}
+
+func nullableRemark(remark string) sql.NullString {
+	return sql.NullString{String: remark, Valid: remark != ""}
+}
@@ -0,0 +1,34 @@
+package idempotency_mapper
+
+import (
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+var INSTANCE IdempotencyMapper
+
+type IdempotencyMapper interface {
+	// ReserveIdempotencyKey stores a pending entity; false when the key is already taken
+	ReserveIdempotencyKey(entity model.IdempotencyEntity) (bool, error)
+	// GetIdempotencyKey returns an empty entity when the key is unknown
+	GetIdempotencyKey(key string) (model.IdempotencyEntity, error)
+	// CompleteIdempotencyKey stores the response of the request holding the key: status code,
+	// headers and body of the entity
+	CompleteIdempotencyKey(entity model.IdempotencyEntity) error
+	DeleteIdempotencyKey(key string) error
+	// DeleteExpiredIdempotencyKeys removes the keys that expired before the given time
+	DeleteExpiredIdempotencyKeys(before time.Time) (int64, error)
+}
+
+func init() {
+	switch util.GetConfigByKey("db.type") {
+	case "mongodb":
+		INSTANCE = IdempotencyMongoDbMapper{}
+	case "mysql":
+		INSTANCE = IdempotencyMySqlMapper{}
+	default:
+		panic("database type not supported")
+	}
+}
@@ -0,0 +1,77 @@
+package idempotency_mapper
+
+import (
+	"context"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IdempotencyMongoDbMapper struct{}
+
+func (IdempotencyMongoDbMapper) ReserveIdempotencyKey(entity model.IdempotencyEntity) (bool, error) {
+	collection := database.GetMongoCollection(database.IdempotencyTableName)
+	if _, err := collection.InsertOne(context.TODO(), entity); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		util.Logger.Errorw("reserve idempotency key failed", "error", err)
+		return false, err
+	}
+	return true, nil
+}
+
+func (IdempotencyMongoDbMapper) GetIdempotencyKey(key string) (model.IdempotencyEntity, error) {
+	collection := database.GetMongoCollection(database.IdempotencyTableName)
+	var entity model.IdempotencyEntity
+	err := collection.FindOne(context.TODO(), bson.D{primitive.E{Key: "_id", Value: key}}).Decode(&entity)
+	if err == mongo.ErrNoDocuments {
+		return model.IdempotencyEntity{}, nil
+	}
+	if err != nil {
+		util.Logger.Errorw("query idempotency key failed", "error", err)
+		return model.IdempotencyEntity{}, err
+	}
+	return entity, nil
+}
+
+func (IdempotencyMongoDbMapper) CompleteIdempotencyKey(entity model.IdempotencyEntity) error {
+	collection := database.GetMongoCollection(database.IdempotencyTableName)
+	update := bson.D{primitive.E{Key: "$set", Value: bson.D{
+		primitive.E{Key: "status_code", Value: entity.StatusCode},
+		primitive.E{Key: "content_type", Value: entity.ContentType},
+		primitive.E{Key: "etag", Value: entity.ETag},
+		primitive.E{Key: "location", Value: entity.Location},
+		primitive.E{Key: "body", Value: entity.Body},
+	}}}
+	if _, err := collection.UpdateByID(context.TODO(), entity.Key, update); err != nil {
+		util.Logger.Errorw("complete idempotency key failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (IdempotencyMongoDbMapper) DeleteIdempotencyKey(key string) error {
+	collection := database.GetMongoCollection(database.IdempotencyTableName)
+	if _, err := collection.DeleteOne(context.TODO(), bson.D{primitive.E{Key: "_id", Value: key}}); err != nil {
+		util.Logger.Errorw("delete idempotency key failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (IdempotencyMongoDbMapper) DeleteExpiredIdempotencyKeys(before time.Time) (int64, error) {
+	collection := database.GetMongoCollection(database.IdempotencyTableName)
+	filter := bson.D{primitive.E{Key: "expire_time", Value: bson.M{"$lt": before.UTC()}}}
+	result, err := collection.DeleteMany(context.TODO(), filter)
+	if err != nil {
+		util.Logger.Errorw("delete expired idempotency keys failed", "error", err)
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,113 @@
+package idempotency_mapper
+
+import (
+	"bytes"
+	"database/sql"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+)
+
+type IdempotencyMySqlMapper struct{}
+
+func (IdempotencyMySqlMapper) ReserveIdempotencyKey(entity model.IdempotencyEntity) (bool, error) {
+	// INSERT IGNORE leaves a taken key alone and reports no affected row
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT IGNORE INTO ")
+	sqlString.WriteString(database.IdempotencyTableName)
+	sqlString.WriteString(" (ID, FINGERPRINT, STATUS_CODE, CONTENT_TYPE, BODY, CREATE_TIME, EXPIRE_TIME) ")
+	sqlString.WriteString(" VALUES (?, ?, ?, ?, ?, ?, ?) ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	result, err := connection.Exec(sqlString.String(),
+		entity.Key, entity.Fingerprint, entity.StatusCode, entity.ContentType, entity.Body,
+		entity.CreateTime.UTC(), entity.ExpireTime.UTC())
+	if err != nil {
+		util.Logger.Errorw("reserve idempotency key failed", "error", err)
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func (IdempotencyMySqlMapper) GetIdempotencyKey(key string) (model.IdempotencyEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, FINGERPRINT, STATUS_CODE, CONTENT_TYPE, ETAG, LOCATION, BODY, CREATE_TIME, EXPIRE_TIME FROM ")
+	sqlString.WriteString(database.IdempotencyTableName)
+	sqlString.WriteString(" WHERE ID = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	var entity model.IdempotencyEntity
+	var createTime, expireTime string
+	err := connection.QueryRow(sqlString.String(), key).Scan(&entity.Key, &entity.Fingerprint,
+		&entity.StatusCode, &entity.ContentType, &entity.ETag, &entity.Location, &entity.Body, &createTime, &expireTime)
+	if err == sql.ErrNoRows {
+		return model.IdempotencyEntity{}, nil
+	}
+	if err != nil {
+		util.Logger.Errorw("query idempotency key failed", "error", err)
+		return model.IdempotencyEntity{}, err
+	}
+	entity.CreateTime = database.ParseMySqlTime(createTime)
+	entity.ExpireTime = database.ParseMySqlTime(expireTime)
+	return entity, nil
+}
+
+func (IdempotencyMySqlMapper) CompleteIdempotencyKey(entity model.IdempotencyEntity) error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("UPDATE ")
+	sqlString.WriteString(database.IdempotencyTableName)
+	sqlString.WriteString(" SET STATUS_CODE = ?, CONTENT_TYPE = ?, ETAG = ?, LOCATION = ?, BODY = ? WHERE ID = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), entity.StatusCode, entity.ContentType, entity.ETag,
+		entity.Location, entity.Body, entity.Key); err != nil {
+		util.Logger.Errorw("complete idempotency key failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (IdempotencyMySqlMapper) DeleteIdempotencyKey(key string) error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("DELETE FROM ")
+	sqlString.WriteString(database.IdempotencyTableName)
+	sqlString.WriteString(" WHERE ID = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), key); err != nil {
+		util.Logger.Errorw("delete idempotency key failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (IdempotencyMySqlMapper) DeleteExpiredIdempotencyKeys(before time.Time) (int64, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("DELETE FROM ")
+	sqlString.WriteString(database.IdempotencyTableName)
+	sqlString.WriteString(" WHERE EXPIRE_TIME < ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	result, err := connection.Exec(sqlString.String(), before.UTC())
+	if err != nil {
+		util.Logger.Errorw("delete expired idempotency keys failed", "error", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
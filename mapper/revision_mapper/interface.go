@@ -0,0 +1,26 @@
+package revision_mapper
+
+import (
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+var INSTANCE RevisionMapper
+
+type RevisionMapper interface {
+	InsertRevision(entity model.RevisionEntity) error
+	// GetRevisionsByEntity returns the history of one entity, oldest first
+	GetRevisionsByEntity(entityType, plainEntityId string) ([]model.RevisionEntity, error)
+	TruncateRevisions() error
+}
+
+func init() {
+	switch util.GetConfigByKey("db.type") {
+	case "mongodb":
+		INSTANCE = RevisionMongoDbMapper{}
+	case "mysql":
+		INSTANCE = RevisionMySqlMapper{}
+	default:
+		panic("database type not supported")
+	}
+}
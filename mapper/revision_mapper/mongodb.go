@@ -0,0 +1,60 @@
+package revision_mapper
+
+import (
+	"context"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RevisionMongoDbMapper struct{}
+
+func (RevisionMongoDbMapper) InsertRevision(entity model.RevisionEntity) error {
+	collection := database.GetMongoCollection(database.RevisionTableName)
+	if _, err := collection.InsertOne(context.TODO(), entity); err != nil {
+		util.Logger.Errorw("insert revision failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (RevisionMongoDbMapper) GetRevisionsByEntity(entityType, plainEntityId string) ([]model.RevisionEntity, error) {
+	entityId, err := primitive.ObjectIDFromHex(plainEntityId)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.D{
+		primitive.E{Key: "entity_type", Value: entityType},
+		primitive.E{Key: "entity_id", Value: entityId},
+	}
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "_id", Value: 1}})
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.RevisionTableName)
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query revisions failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.RevisionEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode revisions failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
+func (RevisionMongoDbMapper) TruncateRevisions() error {
+	collection := database.GetMongoCollection(database.RevisionTableName)
+	if _, err := collection.DeleteMany(context.TODO(), bson.D{}); err != nil {
+		util.Logger.Errorw("truncate revisions failed", "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package revision_mapper
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RevisionMySqlMapper struct{}
+
+func (RevisionMySqlMapper) InsertRevision(entity model.RevisionEntity) error {
+	// Changes are stored as a JSON array
+	changes, err := json.Marshal(entity.Changes)
+	if err != nil {
+		return err
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(database.RevisionTableName)
+	sqlString.WriteString(" (ID, ENTITY_TYPE, ENTITY_ID, ACTION, ACTOR, SOURCE, CHANGES, REVERTED_TO, CREATE_TIME) ")
+	sqlString.WriteString(" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ")
+
+	var revertedTo sql.NullString
+	if entity.RevertedTo != "" {
+		revertedTo = sql.NullString{String: entity.RevertedTo, Valid: true}
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	_, err = connection.Exec(sqlString.String(),
+		entity.Id.Hex(), entity.EntityType, entity.EntityId.Hex(), entity.Action,
+		entity.Actor, entity.Source, string(changes), revertedTo, entity.CreateTime.UTC())
+	if err != nil {
+		util.Logger.Errorw("insert revision failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (RevisionMySqlMapper) GetRevisionsByEntity(entityType, plainEntityId string) ([]model.RevisionEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, ENTITY_TYPE, ENTITY_ID, ACTION, ACTOR, SOURCE, CHANGES, REVERTED_TO, CREATE_TIME FROM ")
+	sqlString.WriteString(database.RevisionTableName)
+	sqlString.WriteString(" WHERE ENTITY_TYPE = ? AND ENTITY_ID = ? ORDER BY ID ASC ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), entityType, plainEntityId)
+	if err != nil {
+		util.Logger.Errorw("query revisions failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.RevisionEntity
+	for rows.Next() {
+		var id, entityId, changes, createTime string
+		var revertedTo sql.NullString
+		var entity model.RevisionEntity
+		if err := rows.Scan(&id, &entity.EntityType, &entityId, &entity.Action, &entity.Actor,
+			&entity.Source, &changes, &revertedTo, &createTime); err != nil {
+			util.Logger.Errorw("scan revision failed", "error", err)
+			return nil, err
+		}
+		if entity.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+			return nil, err
+		}
+		if entity.EntityId, err = primitive.ObjectIDFromHex(entityId); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &entity.Changes); err != nil {
+			util.Logger.Errorw("decode revision changes failed", "id", id, "error", err)
+			return nil, err
+		}
+		entity.RevertedTo = revertedTo.String
+		entity.CreateTime = database.ParseMySqlTime(createTime)
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
+func (RevisionMySqlMapper) TruncateRevisions() error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("DELETE FROM ")
+	sqlString.WriteString(database.RevisionTableName)
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String()); err != nil {
+		util.Logger.Errorw("truncate revisions failed", "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package schema_migration_mapper
+
+import (
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+var INSTANCE SchemaMigrationMapper
+
+type SchemaMigrationMapper interface {
+	EnsureMigrationTable() error
+	GetAppliedMigrations() ([]model.SchemaMigrationEntity, error)
+	ExecuteMigrationScript(script string) error
+	InsertMigration(newEntity model.SchemaMigrationEntity) error
+	DeleteMigrationByVersion(version int) error
+}
+
+func init() {
+	switch util.GetConfigByKey("db.type") {
+	case "mongodb":
+		INSTANCE = SchemaMigrationMongoDbMapper{}
+	case "mysql":
+		INSTANCE = SchemaMigrationMySqlMapper{}
+	default:
+		panic("database type not supported")
+	}
+}
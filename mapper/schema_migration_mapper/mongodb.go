@@ -0,0 +1,89 @@
+package schema_migration_mapper
+
+import (
+	"context"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SchemaMigrationMongoDbMapper struct{}
+
+// mongoMigrationScript is the layout of an embedded MongoDB migration file
+type mongoMigrationScript struct {
+	Commands []bson.D `bson:"commands"`
+}
+
+func (SchemaMigrationMongoDbMapper) EnsureMigrationTable() error {
+	// Collections are created lazily on first insert, nothing to do
+	return nil
+}
+
+func (SchemaMigrationMongoDbMapper) GetAppliedMigrations() ([]model.SchemaMigrationEntity, error) {
+	collection := database.GetMongoCollection(database.SchemaMigrationTableName)
+	ctx := context.TODO()
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.D{}, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query schema migrations failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var appliedList []model.SchemaMigrationEntity
+	if err := cursor.All(ctx, &appliedList); err != nil {
+		util.Logger.Errorw("decode schema migrations failed", "error", err)
+		return nil, err
+	}
+	return appliedList, nil
+}
+
+func (SchemaMigrationMongoDbMapper) ExecuteMigrationScript(script string) error {
+	var migrationScript mongoMigrationScript
+	if err := bson.UnmarshalExtJSON([]byte(script), false, &migrationScript); err != nil {
+		util.Logger.Errorw("parse migration script failed", "error", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	mongoDatabase := database.GetMongoDatabase()
+	for _, command := range migrationScript.Commands {
+		if err := mongoDatabase.RunCommand(ctx, command).Err(); err != nil {
+			util.Logger.Errorw("run migration command failed", "command", command, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (SchemaMigrationMongoDbMapper) InsertMigration(newEntity model.SchemaMigrationEntity) error {
+	newEntity.AppliedAt = time.Now().UTC() // Store in UTC
+
+	collection := database.GetMongoCollection(database.SchemaMigrationTableName)
+	if _, err := collection.InsertOne(context.TODO(), newEntity); err != nil {
+		util.Logger.Errorw("record schema migration failed", "version", newEntity.Version, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (SchemaMigrationMongoDbMapper) DeleteMigrationByVersion(version int) error {
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: version},
+	}
+
+	collection := database.GetMongoCollection(database.SchemaMigrationTableName)
+	if _, err := collection.DeleteOne(context.TODO(), filter); err != nil {
+		util.Logger.Errorw("remove schema migration failed", "version", version, "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package schema_migration_mapper
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+)
+
+type SchemaMigrationMySqlMapper struct{}
+
+func (SchemaMigrationMySqlMapper) EnsureMigrationTable() error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("CREATE TABLE IF NOT EXISTS ")
+	sqlString.WriteString(database.SchemaMigrationTableName)
+	sqlString.WriteString(" ( VERSION INT NOT NULL, ")
+	sqlString.WriteString(" NAME VARCHAR(200) NOT NULL, ")
+	sqlString.WriteString(" APPLIED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP(), ")
+	sqlString.WriteString(" PRIMARY KEY (VERSION) ) ENGINE = InnoDB DEFAULT CHARSET = UTF8MB4 ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String()); err != nil {
+		util.Logger.Errorw("create schema_migrations table failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (SchemaMigrationMySqlMapper) GetAppliedMigrations() ([]model.SchemaMigrationEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT VERSION, NAME, APPLIED_AT FROM ")
+	sqlString.WriteString(database.SchemaMigrationTableName)
+	sqlString.WriteString(" ORDER BY VERSION ASC ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String())
+	if err != nil {
+		util.Logger.Errorw("query schema migrations failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var appliedList []model.SchemaMigrationEntity
+	for rows.Next() {
+		var entity model.SchemaMigrationEntity
+		var appliedAt string
+		if err := rows.Scan(&entity.Version, &entity.Name, &appliedAt); err != nil {
+			util.Logger.Errorw("covert into entity failed", "error", err)
+			return nil, err
+		}
+		entity.AppliedAt = database.ParseMySqlTime(appliedAt)
+		appliedList = append(appliedList, entity)
+	}
+	return appliedList, nil
+}
+
+func (SchemaMigrationMySqlMapper) ExecuteMigrationScript(script string) error {
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	// The driver runs one statement per call unless multiStatements is enabled
+	for _, statement := range splitSqlStatements(script) {
+		if _, err := connection.Exec(statement); err != nil {
+			util.Logger.Errorw("run migration statement failed", "statement", statement, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (SchemaMigrationMySqlMapper) InsertMigration(newEntity model.SchemaMigrationEntity) error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT ")
+	sqlString.WriteString(database.SchemaMigrationTableName)
+	sqlString.WriteString(" SET VERSION = ?, ")
+	sqlString.WriteString(" NAME = ?, ")
+	sqlString.WriteString(" APPLIED_AT = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), newEntity.Version, newEntity.Name, time.Now().UTC()); err != nil {
+		util.Logger.Errorw("record schema migration failed", "version", newEntity.Version, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (SchemaMigrationMySqlMapper) DeleteMigrationByVersion(version int) error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("DELETE FROM ")
+	sqlString.WriteString(database.SchemaMigrationTableName)
+	sqlString.WriteString(" WHERE VERSION = ? ")
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), version); err != nil {
+		util.Logger.Errorw("remove schema migration failed", "version", version, "error", err)
+		return err
+	}
+	return nil
+}
+
+// splitSqlStatements splits a script on statement-terminating semicolons,
+// dropping "--" comment lines and blank statements
+func splitSqlStatements(script string) []string {
+	var statementList []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSuffix(strings.TrimSpace(current.String()), ";")
+			if statement != "" {
+				statementList = append(statementList, statement)
+			}
+			current.Reset()
+		}
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statementList = append(statementList, statement)
+	}
+	return statementList
+}
@@ -0,0 +1,27 @@
+package tombstone_mapper
+
+import (
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+var INSTANCE TombstoneMapper
+
+type TombstoneMapper interface {
+	InsertTombstones(entities []model.TombstoneEntity) error
+	GetTombstonesSince(since time.Time, afterPlainId string, limit int) ([]model.TombstoneEntity, error)
+	TruncateTombstones() error
+}
+
+func init() {
+	switch util.GetConfigByKey("db.type") {
+	case "mongodb":
+		INSTANCE = TombstoneMongoDbMapper{}
+	case "mysql":
+		INSTANCE = TombstoneMySqlMapper{}
+	default:
+		panic("database type not supported")
+	}
+}
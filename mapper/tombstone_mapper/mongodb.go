@@ -0,0 +1,74 @@
+package tombstone_mapper
+
+import (
+	"context"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TombstoneMongoDbMapper struct{}
+
+func (TombstoneMongoDbMapper) InsertTombstones(entities []model.TombstoneEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(entities))
+	for _, entity := range entities {
+		documents = append(documents, entity)
+	}
+
+	collection := database.GetMongoCollection(database.TombstoneTableName)
+	if _, err := collection.InsertMany(context.TODO(), documents); err != nil {
+		util.Logger.Errorw("insert tombstones failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (TombstoneMongoDbMapper) GetTombstonesSince(since time.Time, afterPlainId string, limit int) ([]model.TombstoneEntity, error) {
+	filter := bson.D{primitive.E{Key: "delete_time", Value: bson.M{"$gte": since.UTC()}}}
+	if afterPlainId != "" {
+		afterId, err := primitive.ObjectIDFromHex(afterPlainId)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, primitive.E{Key: "_id", Value: bson.M{"$gt": afterId}})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "_id", Value: 1}})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+
+	ctx := context.TODO()
+	collection := database.GetMongoCollection(database.TombstoneTableName)
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		util.Logger.Errorw("query tombstones failed", "error", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var targetEntityList []model.TombstoneEntity
+	if err := cursor.All(ctx, &targetEntityList); err != nil {
+		util.Logger.Errorw("decode tombstones failed", "error", err)
+		return nil, err
+	}
+	return targetEntityList, nil
+}
+
+func (TombstoneMongoDbMapper) TruncateTombstones() error {
+	collection := database.GetMongoCollection(database.TombstoneTableName)
+	if _, err := collection.DeleteMany(context.TODO(), bson.D{}); err != nil {
+		util.Logger.Errorw("truncate tombstones failed", "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package tombstone_mapper
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TombstoneMySqlMapper struct{}
+
+func (TombstoneMySqlMapper) InsertTombstones(entities []model.TombstoneEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(database.TombstoneTableName)
+	sqlString.WriteString(" (ID, ENTITY_TYPE, ENTITY_ID, DELETE_TIME) VALUES ")
+
+	values := make([]interface{}, 0, len(entities)*4)
+	for i, entity := range entities {
+		if i > 0 {
+			sqlString.WriteString(", ")
+		}
+		sqlString.WriteString("(?, ?, ?, ?)")
+		values = append(values, entity.Id.Hex(), entity.EntityType, entity.EntityId.Hex(), entity.DeleteTime.UTC())
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String(), values...); err != nil {
+		util.Logger.Errorw("insert tombstones failed", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (TombstoneMySqlMapper) GetTombstonesSince(since time.Time, afterPlainId string, limit int) ([]model.TombstoneEntity, error) {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, ENTITY_TYPE, ENTITY_ID, DELETE_TIME FROM ")
+	sqlString.WriteString(database.TombstoneTableName)
+	sqlString.WriteString(" WHERE DELETE_TIME >= ? AND ID > ? ORDER BY ID ASC ")
+	argList := []interface{}{since.UTC(), afterPlainId}
+	if limit > 0 {
+		sqlString.WriteString(" LIMIT ? ")
+		argList = append(argList, limit)
+	}
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	rows, err := connection.Query(sqlString.String(), argList...)
+	if err != nil {
+		util.Logger.Errorw("query tombstones failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targetEntityList []model.TombstoneEntity
+	for rows.Next() {
+		var id, entityId, deleteTime string
+		var entity model.TombstoneEntity
+		if err := rows.Scan(&id, &entity.EntityType, &entityId, &deleteTime); err != nil {
+			util.Logger.Errorw("scan tombstone failed", "error", err)
+			return nil, err
+		}
+		if entity.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+			return nil, err
+		}
+		if entity.EntityId, err = primitive.ObjectIDFromHex(entityId); err != nil {
+			return nil, err
+		}
+		entity.DeleteTime = database.ParseMySqlTime(deleteTime)
+		targetEntityList = append(targetEntityList, entity)
+	}
+	return targetEntityList, rows.Err()
+}
+
+func (TombstoneMySqlMapper) TruncateTombstones() error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("DELETE FROM ")
+	sqlString.WriteString(database.TombstoneTableName)
+
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	if _, err := connection.Exec(sqlString.String()); err != nil {
+		util.Logger.Errorw("truncate tombstones failed", "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/idempotency_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// IdempotencyKeyHeader carries the client's key for a retried create request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader marks a response replayed from an earlier request
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// idempotentRouteList holds the create endpoints that honour an Idempotency-Key
+var idempotentRouteList = map[string]bool{
+	"POST /api/cash/expense": true,
+	"POST /api/cash/income":  true,
+	"POST /api/cash/batch":   true,
+	"POST /api/category":     true,
+	"POST /api/goals":        true,
+	"POST /api/debts":        true,
+}
+
+// recordingWriter keeps a copy of the response so it can be replayed
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(data []byte) (int, error) {
+	rw.body.Write(data)
+	return rw.ResponseWriter.Write(data)
+}
+
+// Idempotency middleware replays the stored response when a create request is retried with the same Idempotency-Key
+// Responses are kept for IDEMPOTENCY_WINDOW_HOURS; server errors release the key so the retry runs again
+func Idempotency(next http.Handler) http.Handler {
+	window := idempotency_service.GetWindow()
+	if window == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || !idempotentRouteList[r.Method+" "+r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := idempotency_service.ValidateKey(key); err != nil {
+			util.ComposeJSONResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			util.ComposeJSONResponse(w, http.StatusBadRequest, errors.NewInvalidInputError("failed to read request body"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		entity, decision, err := idempotency_service.Begin(key, idempotency_service.Fingerprint(r.Method, r.URL.Path, body), window)
+		if err != nil {
+			util.ComposeJSONResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		switch decision {
+		case idempotency_service.DecisionReplay:
+			w.Header().Set("Content-Type", entity.ContentType)
+			if entity.ETag != "" {
+				w.Header().Set("ETag", entity.ETag)
+			}
+			if entity.Location != "" {
+				w.Header().Set("Location", entity.Location)
+			}
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(entity.StatusCode)
+			w.Write(entity.Body)
+			return
+		case idempotency_service.DecisionInProgress:
+			util.ComposeJSONResponse(w, http.StatusConflict,
+				errors.NewAlreadyExistsError("a request with this Idempotency-Key is still in progress"))
+			return
+		case idempotency_service.DecisionMismatch:
+			util.ComposeJSONResponse(w, http.StatusUnprocessableEntity,
+				errors.NewInvalidInputError("Idempotency-Key was already used for a different request"))
+			return
+		}
+
+		// A panicking handler must not hold the key until it expires; the panic goes on up
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				idempotency_service.Release(key)
+				panic(recovered)
+			}
+		}()
+
+		recorder := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			idempotency_service.Release(key)
+			return
+		}
+		idempotency_service.Complete(model.IdempotencyEntity{
+			Key:         key,
+			StatusCode:  recorder.statusCode,
+			ContentType: recorder.Header().Get("Content-Type"),
+			ETag:        recorder.Header().Get("ETag"),
+			Location:    recorder.Header().Get("Location"),
+			Body:        recorder.body.Bytes(),
+		})
+	})
+}
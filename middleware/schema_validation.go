@@ -11,6 +11,7 @@ import (
 	"github.com/getkin/kin-openapi/routers"
 	"github.com/getkin/kin-openapi/routers/gorillamux"
 	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/util"
 )
 
@@ -43,6 +44,9 @@ func init() {
 		panic("Invalid OpenAPI spec: " + err.Error())
 	}
 
+	// Merge patches are plain JSON to the validator
+	openapi3filter.RegisterBodyDecoder(model.MergePatchContentType, openapi3filter.RegisteredBodyDecoder("application/json"))
+
 	// Create router for path matching
 	openapi = spec
 	routesRouter, err = gorillamux.NewRouter(openapi)
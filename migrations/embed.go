@@ -0,0 +1,11 @@
+// Package migrations embeds the versioned schema migration scripts so they ship inside the binary.
+//
+// File names follow VERSION_NAME.(up|down).EXT, e.g. 001_create_tables.up.sql.
+// MySQL scripts are plain SQL, MongoDB scripts are extended JSON documents
+// holding a list of database commands.
+package migrations
+
+import "embed"
+
+//go:embed mysql/*.sql mongodb/*.json
+var FS embed.FS
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cash_flows;
+DROP TABLE IF EXISTS categories;
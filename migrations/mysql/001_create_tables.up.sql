@@ -0,0 +1,34 @@
+-- Create the base tables, aligned with CategoryEntity and CashFlowEntity
+CREATE TABLE IF NOT EXISTS categories
+(
+    `id`          VARCHAR(24)  NOT NULL,
+    `parent_id`   VARCHAR(24)           DEFAULT NULL,
+    `name`        VARCHAR(200) NOT NULL,
+    `type`        VARCHAR(10)  NOT NULL,
+    `remark`      VARCHAR(200)          DEFAULT NULL,
+    `create_time` TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `modify_time` TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP() ON UPDATE CURRENT_TIMESTAMP(),
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX category_name_unique_index (`name`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = UTF8MB4
+    COMMENT ='Category Table';
+
+CREATE TABLE IF NOT EXISTS cash_flows
+(
+    `id`           VARCHAR(24)    NOT NULL,
+    `category_id`  VARCHAR(24)    NOT NULL,
+    `belongs_date` TIMESTAMP      NOT NULL,
+    `flow_type`    VARCHAR(10)    NOT NULL COMMENT 'INCOME/OUTCOME',
+    `amount`       DECIMAL(19, 4) NOT NULL,
+    `description`  VARCHAR(200)   NOT NULL,
+    `remark`       VARCHAR(200)            DEFAULT NULL COMMENT 'KEEP EMPTY',
+    `create_time`  TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `modify_time`  TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP() ON UPDATE CURRENT_TIMESTAMP(),
+    PRIMARY KEY (`id`),
+    INDEX cash_flows_category_id_index (`category_id`),
+    INDEX cash_flows_belongs_date_index (`belongs_date`),
+    INDEX cash_flows_flow_type_index (`flow_type`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = UTF8MB4
+    COMMENT ='Cash Flow Table';
@@ -0,0 +1,3 @@
+ALTER TABLE cash_flows DROP INDEX cash_flows_modify_time_index;
+ALTER TABLE categories DROP INDEX categories_modify_time_index;
+DROP TABLE IF EXISTS tombstones;
@@ -0,0 +1,16 @@
+-- Deleted rows, replayed by incremental backups
+CREATE TABLE IF NOT EXISTS tombstones
+(
+    `id`          VARCHAR(24) NOT NULL,
+    `entity_type` VARCHAR(20) NOT NULL COMMENT 'category/cash_flow',
+    `entity_id`   VARCHAR(24) NOT NULL,
+    `delete_time` TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    PRIMARY KEY (`id`),
+    INDEX tombstones_delete_time_index (`delete_time`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = UTF8MB4
+    COMMENT ='Tombstone Table';
+
+-- Incremental backups select rows by modify_time
+ALTER TABLE categories ADD INDEX categories_modify_time_index (`modify_time`);
+ALTER TABLE cash_flows ADD INDEX cash_flows_modify_time_index (`modify_time`);
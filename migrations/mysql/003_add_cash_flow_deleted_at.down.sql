@@ -0,0 +1,2 @@
+ALTER TABLE cash_flows DROP INDEX cash_flows_deleted_at_index;
+ALTER TABLE cash_flows DROP COLUMN `deleted_at`;
@@ -0,0 +1,3 @@
+-- Soft delete: cash flows in the trash keep their row with deleted_at set
+ALTER TABLE cash_flows ADD COLUMN `deleted_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'set while in the trash';
+ALTER TABLE cash_flows ADD INDEX cash_flows_deleted_at_index (`deleted_at`);
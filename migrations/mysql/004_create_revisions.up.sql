@@ -0,0 +1,17 @@
+-- Append-only change history of categories and cash flows
+CREATE TABLE IF NOT EXISTS revisions
+(
+    `id`          VARCHAR(24) NOT NULL,
+    `entity_type` VARCHAR(20) NOT NULL COMMENT 'category/cash_flow',
+    `entity_id`   VARCHAR(24) NOT NULL,
+    `action`      VARCHAR(20) NOT NULL COMMENT 'create/update/delete/restore/revert',
+    `actor`       VARCHAR(100) NOT NULL DEFAULT '',
+    `source`      VARCHAR(20) NOT NULL COMMENT 'cli/api/import',
+    `changes`     TEXT        NOT NULL COMMENT 'JSON array of field, before, after',
+    `reverted_to` VARCHAR(24) NULL DEFAULT NULL,
+    `create_time` TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    PRIMARY KEY (`id`),
+    INDEX revisions_entity_index (`entity_type`, `entity_id`, `id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = UTF8MB4
+    COMMENT ='Revision Table';
@@ -0,0 +1,18 @@
+-- Persistent log of administrative and destructive operations
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    `id`          VARCHAR(24)  NOT NULL,
+    `operation`   VARCHAR(30)  NOT NULL COMMENT 'dump/backup/restore/import/reset/truncate/trash_purge',
+    `actor`       VARCHAR(100) NOT NULL DEFAULT '',
+    `source`      VARCHAR(20)  NOT NULL COMMENT 'cli/api',
+    `parameters`  TEXT         NOT NULL COMMENT 'JSON object, secrets redacted',
+    `affected`    TEXT         NOT NULL COMMENT 'JSON object of affected counts',
+    `outcome`     VARCHAR(10)  NOT NULL COMMENT 'success/failure',
+    `error`       TEXT         NULL,
+    `create_time` TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    PRIMARY KEY (`id`),
+    INDEX audit_log_create_time_index (`create_time`),
+    INDEX audit_log_operation_index (`operation`, `id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = UTF8MB4
+    COMMENT ='Audit Log Table';
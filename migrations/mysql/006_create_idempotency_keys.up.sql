@@ -0,0 +1,15 @@
+-- Stored responses of requests sent with an Idempotency-Key header
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    `id`           VARCHAR(255) NOT NULL COMMENT 'Idempotency-Key header',
+    `fingerprint`  CHAR(64)     NOT NULL COMMENT 'sha256 of method, path and body',
+    `status_code`  INT          NOT NULL DEFAULT 0 COMMENT '0 while the first request is in progress',
+    `content_type` VARCHAR(100) NOT NULL DEFAULT '',
+    `body`         MEDIUMBLOB   NULL,
+    `create_time`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `expire_time`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    PRIMARY KEY (`id`),
+    INDEX idempotency_keys_expire_time_index (`expire_time`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = UTF8MB4
+    COMMENT ='Idempotency Key Table';
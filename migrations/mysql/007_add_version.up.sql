@@ -0,0 +1,3 @@
+-- Optimistic concurrency: every update bumps version, the API exposes it as the ETag
+ALTER TABLE cash_flows ADD COLUMN `version` BIGINT NOT NULL DEFAULT 0 COMMENT 'bumped by every update';
+ALTER TABLE categories ADD COLUMN `version` BIGINT NOT NULL DEFAULT 0 COMMENT 'bumped by every update';
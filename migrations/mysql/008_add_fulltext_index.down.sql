@@ -0,0 +1 @@
+ALTER TABLE cash_flows DROP INDEX ft_cash_flows_text;
@@ -0,0 +1,2 @@
+-- Full-text search over descriptions and remarks, ranked with MATCH ... AGAINST
+ALTER TABLE cash_flows ADD FULLTEXT INDEX ft_cash_flows_text (`description`, `remark`);
@@ -0,0 +1,18 @@
+-- Savings goals, progress is computed from cash_flows
+CREATE TABLE IF NOT EXISTS goals
+(
+    `id`            VARCHAR(24)    NOT NULL,
+    `name`          VARCHAR(200)   NOT NULL,
+    `target_amount` DECIMAL(19, 4) NOT NULL,
+    `start_date`    TIMESTAMP      NOT NULL,
+    `deadline`      TIMESTAMP      NOT NULL,
+    `category_id`   VARCHAR(24)             DEFAULT NULL COMMENT 'NULL measures all cash flows',
+    `remark`        VARCHAR(200)            DEFAULT NULL,
+    `create_time`   TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `modify_time`   TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP() ON UPDATE CURRENT_TIMESTAMP(),
+    `version`       BIGINT         NOT NULL DEFAULT 0 COMMENT 'bumped by every update',
+    PRIMARY KEY (`id`),
+    INDEX goals_deadline_index (`deadline`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = UTF8MB4
+    COMMENT ='Goal Table';
@@ -0,0 +1,20 @@
+-- Loans repaid in monthly installments, payments are the cash_flows of the payment category
+CREATE TABLE IF NOT EXISTS debts
+(
+    `id`                   VARCHAR(24)    NOT NULL,
+    `name`                 VARCHAR(200)   NOT NULL,
+    `principal`            DECIMAL(19, 4) NOT NULL,
+    `annual_rate`          DECIMAL(9, 4)  NOT NULL COMMENT 'percent a year',
+    `term_months`          INT            NOT NULL,
+    `start_date`           TIMESTAMP      NOT NULL,
+    `payment_category_id`  VARCHAR(24)    NOT NULL,
+    `interest_category_id` VARCHAR(24)    NOT NULL,
+    `remark`               VARCHAR(200)            DEFAULT NULL,
+    `create_time`          TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+    `modify_time`          TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP() ON UPDATE CURRENT_TIMESTAMP(),
+    `version`              BIGINT         NOT NULL DEFAULT 0 COMMENT 'bumped by every update',
+    PRIMARY KEY (`id`),
+    INDEX debts_payment_category_index (`payment_category_id`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = UTF8MB4
+    COMMENT ='Debt Table';
@@ -0,0 +1,3 @@
+-- Headers a replayed response must carry again, so a retried create still gets its ETag
+ALTER TABLE idempotency_keys ADD COLUMN `etag` VARCHAR(100) NOT NULL DEFAULT '' COMMENT 'ETag header of the response';
+ALTER TABLE idempotency_keys ADD COLUMN `location` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Location header of the response';
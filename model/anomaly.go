@@ -0,0 +1,27 @@
+package model
+
+const (
+	AnomalyKindAmountOutlier = "amount_outlier" // far above the usual amounts of its category
+	AnomalyKindNewPayee      = "new_payee"      // a description never seen before
+	AnomalyKindCategorySpike = "category_spike" // a month's total of a category far above its usual months
+)
+
+// Anomaly is an unusual expense, or an unusual month of a category for spikes
+type Anomaly struct {
+	Kind       string          `json:"kind"`
+	Reason     string          `json:"reason"`
+	Score      float64         `json:"score"` // higher is more unusual; only comparable within one kind
+	CashFlow   *CashFlowEntity `json:"cash_flow,omitempty"`
+	CategoryId string          `json:"category_id"`
+	Category   string          `json:"category"`
+	Month      string          `json:"month,omitempty"` // of a spike, e.g. 2026-09
+	Amount     Money           `json:"amount"`
+	Expected   Money           `json:"expected"` // the usual amount it is compared with
+}
+
+// AnomalyReport lists the anomalies found from one day to another, most unusual first
+type AnomalyReport struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Items []Anomaly `json:"items"`
+}
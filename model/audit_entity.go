@@ -0,0 +1,79 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Operations recorded in the audit log
+const (
+	AuditOperationDump           = "dump"
+	AuditOperationBackup         = "backup"
+	AuditOperationRestore        = "restore"
+	AuditOperationImport         = "import"
+	AuditOperationReset          = "reset"
+	AuditOperationTruncate       = "truncate"
+	AuditOperationTrashPurge     = "trash_purge"
+	AuditOperationMigrate        = "migrate"
+	AuditOperationMigrateBackend = "migrate_backend"
+	AuditOperationMigrateMoney   = "migrate_money"
+	AuditOperationBackupPrune    = "backup_prune"
+)
+
+// Outcomes of an audited operation
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEntity is one administrative or destructive operation in the audit log
+// Parameters never hold secrets; see audit_service.Redact
+type AuditEntity struct {
+	Id         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Operation  string             `json:"operation" bson:"operation"`
+	Actor      string             `json:"actor" bson:"actor"`
+	Source     string             `json:"source" bson:"source"`
+	Parameters map[string]string  `json:"parameters" bson:"parameters"`
+	Affected   map[string]int     `json:"affected" bson:"affected"`
+	Outcome    string             `json:"outcome" bson:"outcome"`
+	Error      string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreateTime time.Time          `json:"create_time" bson:"create_time"`
+}
+
+// AuditFilter narrows an audit log query; empty fields match everything
+// From is inclusive and To exclusive
+type AuditFilter struct {
+	Operation string
+	Actor     string
+	Outcome   string
+	From      time.Time
+	To        time.Time
+}
+
+func (entity AuditEntity) ToString() string {
+	description := "[ " +
+		"Time: " + util.ToTimezone(entity.CreateTime).Format("2006-01-02 15:04:05") +
+		", Operation: " + entity.Operation +
+		", Actor: " + entity.Actor +
+		", Source: " + entity.Source +
+		", Outcome: " + entity.Outcome
+	if entity.Error != "" {
+		description += ", Error: " + entity.Error
+	}
+	return description + " ]"
+}
+
+// MarshalJSON converts the audit time to the configured timezone for display
+func (entity AuditEntity) MarshalJSON() ([]byte, error) {
+	type Alias AuditEntity
+	return json.Marshal(&struct {
+		CreateTime time.Time `json:"create_time"`
+		*Alias
+	}{
+		CreateTime: util.ToTimezone(entity.CreateTime),
+		Alias:      (*Alias)(&entity),
+	})
+}
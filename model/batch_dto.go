@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// Operations of a cash flow batch
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// BatchRequest is a list of cash flow operations applied in one call
+// An atomic batch is applied only when every operation passes, and undone when one of them fails to write
+type BatchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation creates, updates or deletes one cash flow
+// A create takes type (expense or income) and the fields of CashFlowDTO, an update takes id, if_match and a
+// JSON Merge Patch in patch, a delete takes id and an optional if_match
+type BatchOperation struct {
+	Op           string          `json:"op"`
+	Id           string          `json:"id,omitempty"`
+	IfMatch      string          `json:"if_match,omitempty"`
+	Type         string          `json:"type,omitempty"`
+	BelongsDate  string          `json:"belongs_date,omitempty"`
+	CategoryName string          `json:"category_name,omitempty"`
+	Amount       Money           `json:"amount,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	Patch        json.RawMessage `json:"patch,omitempty"`
+}
+
+// BatchResult is the outcome of one operation, in the order of the request
+// Status is the HTTP status the single endpoint would have answered with
+type BatchResult struct {
+	Index   int             `json:"index"`
+	Op      string          `json:"op"`
+	Status  int             `json:"status"`
+	Id      string          `json:"id,omitempty"`
+	Version int64           `json:"version,omitempty"`
+	Error   *util.ErrorInfo `json:"error,omitempty"`
+}
+
+// BatchResponse reports every operation of a batch
+// Applied is false when an atomic batch was refused or undone, in which case nothing was written
+type BatchResponse struct {
+	Atomic    bool          `json:"atomic"`
+	Applied   bool          `json:"applied"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
@@ -2,9 +2,7 @@ package model
 
 import (
 	"encoding/json"
-	"fmt"
 	"reflect"
-	"strconv"
 	"time"
 
 	"github.com/macar-x/cashlenx-server/util"
@@ -17,11 +15,18 @@ type CashFlowEntity struct {
 	CategoryId  primitive.ObjectID `json:"category_id" bson:"category_id"`
 	BelongsDate time.Time          `json:"belongs_date" bson:"belongs_date"`
 	FlowType    string             `json:"flow_type" bson:"flow_type"`
-	Amount      float64            `json:"amount" bson:"amount"`
+	Amount      Money              `json:"amount" bson:"amount"`
 	Description string             `json:"description" bson:"description"`
 	Remark      string             `json:"remark" bson:"remark"`
 	CreateTime  time.Time          `json:"create_time" bson:"create_time"`
 	ModifyTime  time.Time          `json:"modify_time" bson:"modify_time"`
+	DeletedAt   *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"` // set while the cash flow is in the trash
+	Version     int64              `json:"version" bson:"version"`                           // bumped by every update, served as the ETag
+}
+
+// IsDeleted reports whether the cash flow is in the trash
+func (entity CashFlowEntity) IsDeleted() bool {
+	return entity.DeletedAt != nil
 }
 
 func (entity CashFlowEntity) IsEmpty() bool {
@@ -33,7 +38,7 @@ func (entity CashFlowEntity) ToString() string {
 		"Id: " + entity.Id.Hex() +
 		", Date: " + util.FormatDateToStringWithoutDash(entity.BelongsDate) +
 		", Type: " + entity.FlowType +
-		", Amount: " + fmt.Sprintf("%.2f", entity.Amount) +
+		", Amount: " + entity.Amount.String() +
 		", Description: " + entity.Description +
 		" ]"
 }
@@ -55,7 +60,7 @@ func (entity CashFlowEntity) Build(fieldMap map[string]string) CashFlowEntity {
 		case "FlowType":
 			newEntity.FlowType = value
 		case "Amount":
-			amount, err := strconv.ParseFloat(value, 64)
+			amount, err := NewMoneyFromString(value)
 			if err != nil {
 				util.Logger.Warnln("build cash failed with err: " + err.Error())
 			}
@@ -76,18 +81,25 @@ func (entity CashFlowEntity) MarshalJSON() ([]byte, error) {
 	localBelongsDate := util.ToTimezone(entity.BelongsDate)
 	localCreateTime := util.ToTimezone(entity.CreateTime)
 	localModifyTime := util.ToTimezone(entity.ModifyTime)
+	var localDeletedAt *time.Time
+	if entity.DeletedAt != nil {
+		deletedAt := util.ToTimezone(*entity.DeletedAt)
+		localDeletedAt = &deletedAt
+	}
 
 	// Create a temporary struct with local timezone timestamps
 	type Alias CashFlowEntity
 	return json.Marshal(&struct {
-		BelongsDate time.Time `json:"belongs_date"`
-		CreateTime  time.Time `json:"create_time"`
-		ModifyTime  time.Time `json:"modify_time"`
+		BelongsDate time.Time  `json:"belongs_date"`
+		CreateTime  time.Time  `json:"create_time"`
+		ModifyTime  time.Time  `json:"modify_time"`
+		DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 		*Alias
 	}{
 		BelongsDate: localBelongsDate,
 		CreateTime:  localCreateTime,
 		ModifyTime:  localModifyTime,
+		DeletedAt:   localDeletedAt,
 		Alias:       (*Alias)(&entity),
 	})
 }
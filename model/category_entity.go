@@ -17,6 +17,7 @@ type CategoryEntity struct {
 	Remark     string             `json:"remark" bson:"remark"`
 	CreateTime time.Time          `json:"create_time" bson:"create_time"`
 	ModifyTime time.Time          `json:"modify_time" bson:"modify_time"`
+	Version    int64              `json:"version" bson:"version"` // bumped by every update, served as the ETag
 }
 
 func (entity CategoryEntity) IsEmpty() bool {
@@ -0,0 +1,45 @@
+package model
+
+// AmountDelta compares an amount of a period with the same amount of a baseline period
+type AmountDelta struct {
+	Current  Money    `json:"current"`
+	Baseline Money    `json:"baseline"`
+	Change   Money    `json:"change"`
+	Percent  *float64 `json:"percent"` // change relative to the baseline, nil when the baseline is zero
+}
+
+// CategoryDelta is the change of one category's income or expense
+type CategoryDelta struct {
+	Category string `json:"category"`
+	Type     string `json:"type"` // INCOME or OUTCOME
+	AmountDelta
+	Mover bool `json:"mover"` // among the biggest changes of the comparison
+}
+
+// PeriodTotals sums the cash flows of one period
+type PeriodTotals struct {
+	Name    string `json:"name"` // e.g. 2026-09-15, 2026-09 or 2026
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Income  Money  `json:"income"`
+	Expense Money  `json:"expense"`
+	Balance Money  `json:"balance"`
+	Count   int    `json:"count"`
+}
+
+// PeriodComparison compares a period with one baseline period
+type PeriodComparison struct {
+	Against    string          `json:"against"` // prev or yoy
+	Baseline   PeriodTotals    `json:"baseline"`
+	Income     AmountDelta     `json:"income"`
+	Expense    AmountDelta     `json:"expense"`
+	Balance    AmountDelta     `json:"balance"`
+	Categories []CategoryDelta `json:"categories"` // biggest change first
+}
+
+// ComparisonReport compares a day, month or year with the periods before it
+type ComparisonReport struct {
+	Period      string             `json:"period"`
+	Current     PeriodTotals       `json:"current"`
+	Comparisons []PeriodComparison `json:"comparisons"`
+}
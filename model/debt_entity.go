@@ -0,0 +1,107 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DebtEntity is a loan repaid in monthly installments falling due from a month after the start date.
+// Payments are the expenses of the payment category since the start date; as each one is recorded
+// its interest part moves to the interest category, leaving the principal part behind.
+type DebtEntity struct {
+	Id                 primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name               string             `json:"name" bson:"name"`
+	Principal          Money              `json:"principal" bson:"principal"`
+	AnnualRate         float64            `json:"annual_rate" bson:"annual_rate"` // percent a year, compounded monthly
+	TermMonths         int                `json:"term_months" bson:"term_months"`
+	StartDate          time.Time          `json:"start_date" bson:"start_date"`
+	PaymentCategoryId  primitive.ObjectID `json:"payment_category_id" bson:"payment_category_id"`
+	InterestCategoryId primitive.ObjectID `json:"interest_category_id" bson:"interest_category_id"`
+	Remark             string             `json:"remark" bson:"remark"`
+	CreateTime         time.Time          `json:"create_time" bson:"create_time"`
+	ModifyTime         time.Time          `json:"modify_time" bson:"modify_time"`
+	Version            int64              `json:"version" bson:"version"` // bumped by every update, served as the ETag
+}
+
+func (entity DebtEntity) IsEmpty() bool {
+	return reflect.DeepEqual(entity, DebtEntity{})
+}
+
+func (entity DebtEntity) ToString() string {
+	return "[ " +
+		"Id: " + entity.Id.Hex() +
+		", Name: " + entity.Name +
+		", Principal: " + entity.Principal.String() +
+		", AnnualRate: " + strconv.FormatFloat(entity.AnnualRate, 'f', -1, 64) +
+		", TermMonths: " + strconv.Itoa(entity.TermMonths) +
+		" ]"
+}
+
+// MarshalJSON renders the start date as a date and the timestamps in the configured timezone
+func (entity DebtEntity) MarshalJSON() ([]byte, error) {
+	type Alias DebtEntity
+	return json.Marshal(&struct {
+		StartDate  string    `json:"start_date"`
+		CreateTime time.Time `json:"create_time"`
+		ModifyTime time.Time `json:"modify_time"`
+		*Alias
+	}{
+		StartDate:  util.FormatDateToStringWithDash(entity.StartDate),
+		CreateTime: util.ToTimezone(entity.CreateTime),
+		ModifyTime: util.ToTimezone(entity.ModifyTime),
+		Alias:      (*Alias)(&entity),
+	})
+}
+
+// DebtDTO creates or replaces a debt. The start date takes a day, a month or a year, the first day of
+// it, and defaults to today. Each category is given by id or name. SplitExisting, only read on create,
+// also splits the payments recorded before the debt.
+type DebtDTO struct {
+	Name                 string  `json:"name"`
+	Principal            Money   `json:"principal"`
+	AnnualRate           float64 `json:"annual_rate"`
+	TermMonths           int     `json:"term_months"`
+	StartDate            string  `json:"start_date"`
+	PaymentCategoryId    string  `json:"payment_category_id"`
+	PaymentCategoryName  string  `json:"payment_category_name"`
+	InterestCategoryId   string  `json:"interest_category_id"`
+	InterestCategoryName string  `json:"interest_category_name"`
+	Remark               string  `json:"remark"`
+	SplitExisting        bool    `json:"split_existing"`
+}
+
+// AmortizationRow is one installment of a debt, either a recorded payment or a projected one
+type AmortizationRow struct {
+	Number     int    `json:"number"`
+	Date       string `json:"date"`
+	Payment    Money  `json:"payment"`
+	Principal  Money  `json:"principal"`
+	Interest   Money  `json:"interest"`
+	Balance    Money  `json:"balance"` // still owed after the installment
+	Paid       bool   `json:"paid"`
+	CashFlowId string `json:"cash_flow_id,omitempty"` // of the payment, its principal part
+}
+
+// DebtSummary is a debt with its recorded payments and the installments left to pay it off
+type DebtSummary struct {
+	Debt                DebtEntity        `json:"debt"`
+	PaymentCategory     string            `json:"payment_category"`
+	InterestCategory    string            `json:"interest_category"`
+	MonthlyPayment      Money             `json:"monthly_payment"` // repays the principal over the term
+	Balance             Money             `json:"balance"`
+	PrincipalPaid       Money             `json:"principal_paid"`
+	InterestPaid        Money             `json:"interest_paid"`
+	PaymentsMade        int               `json:"payments_made"`
+	RemainingPayments   int               `json:"remaining_payments"`
+	RemainingInterest   Money             `json:"remaining_interest"`
+	NextPaymentDate     string            `json:"next_payment_date"` // empty once paid off
+	PayoffDate          string            `json:"payoff_date"`       // of the last installment, paid or left
+	ScheduledPayoffDate string            `json:"scheduled_payoff_date"`
+	PaidOff             bool              `json:"paid_off"`
+	Schedule            []AmortizationRow `json:"schedule,omitempty"` // left out of lists
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/macar-x/cashlenx-server/errors"
+)
+
+// AnyVersion skips the version check of an update, for `If-Match: *` and the CLI
+const AnyVersion int64 = -1
+
+// FormatETag renders an entity version as a strong ETag
+func FormatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseIfMatch reads the version an update expects from an If-Match header
+// A missing header is refused, `*` gives AnyVersion and a weak `W/` prefix is accepted
+func ParseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, errors.NewPreconditionRequiredError("If-Match header is required, send the ETag of the last GET")
+	}
+	if header == "*" {
+		return AnyVersion, nil
+	}
+
+	etag := strings.TrimPrefix(header, "W/")
+	if len(etag) < 2 || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		return 0, errors.NewInvalidInputError("If-Match must be a single quoted ETag or *")
+	}
+	version, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	if err != nil || version < 0 {
+		return 0, errors.NewInvalidInputError("If-Match holds an unknown ETag " + header)
+	}
+	return version, nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/errors"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	cases := map[string]int64{
+		`"0"`:   0,
+		`"12"`:  12,
+		`W/"3"`: 3,
+		` "7" `: 7,
+		"*":     AnyVersion,
+	}
+	for header, want := range cases {
+		got, err := ParseIfMatch(header)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", header, err)
+		} else if got != want {
+			t.Errorf("%q: expected version %d, got %d", header, want, got)
+		}
+	}
+}
+
+func TestParseIfMatch_RoundTrip(t *testing.T) {
+	got, err := ParseIfMatch(FormatETag(42))
+	if err != nil || got != 42 {
+		t.Errorf("expected 42 back from %s, got %d (%v)", FormatETag(42), got, err)
+	}
+}
+
+func TestParseIfMatch_Rejects(t *testing.T) {
+	if _, err := ParseIfMatch(""); err == nil || err.(*errors.AppError).Code != errors.ErrPreconditionRequired {
+		t.Errorf("a missing If-Match should require a precondition, got %v", err)
+	}
+	for _, header := range []string{`3`, `"abc"`, `"-1"`, `"1", "2"`, `"`} {
+		if _, err := ParseIfMatch(header); err == nil {
+			t.Errorf("%q should be rejected", header)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// SelectFields answers only the given fields of an entity, encoded as the entity encodes them
+// Names match JSON keys ignoring case, so id selects the Id key of entities without a json tag on it
+func SelectFields(entity interface{}, fieldList []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	var valueMap map[string]json.RawMessage
+	if err := json.Unmarshal(data, &valueMap); err != nil {
+		return nil, err
+	}
+
+	selectedMap := make(map[string]json.RawMessage, len(fieldList))
+	for key, value := range valueMap {
+		for _, field := range fieldList {
+			if strings.EqualFold(key, field) {
+				selectedMap[key] = value
+			}
+		}
+	}
+	return selectedMap, nil
+}
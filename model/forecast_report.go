@@ -0,0 +1,39 @@
+package model
+
+// ForecastBand is an expected amount with a low and a high estimate around it
+type ForecastBand struct {
+	Expected Money `json:"expected"`
+	Low      Money `json:"low"`
+	High     Money `json:"high"`
+}
+
+// ForecastRecurringItem is a cash flow seen month after month, projected at its latest amount
+type ForecastRecurringItem struct {
+	Description string `json:"description"`
+	CategoryId  string `json:"category_id"`
+	Type        string `json:"type"` // INCOME or OUTCOME
+	Amount      Money  `json:"amount"`
+	Day         int    `json:"day"`    // day of the month it usually comes on
+	Months      int    `json:"months"` // how many recent months held it
+}
+
+// ForecastMonth projects the income, expense and end of month balance of one month
+type ForecastMonth struct {
+	Month     string       `json:"month"` // e.g. 2026-11
+	Income    ForecastBand `json:"income"`
+	Expense   ForecastBand `json:"expense"`
+	Recurring Money        `json:"recurring"` // net amount of the recurring items in the month
+	Balance   ForecastBand `json:"balance"`   // at the end of the month
+	Negative  bool         `json:"negative"`  // the expected balance is below zero
+}
+
+// ForecastReport projects the balance forward month by month, starting with the current month
+type ForecastReport struct {
+	AsOf        string                  `json:"as_of"`
+	Horizon     int                     `json:"horizon"`
+	Balance     Money                   `json:"balance"` // all income minus all expense up to as_of
+	HistoryFrom string                  `json:"history_from"`
+	HistoryTo   string                  `json:"history_to"`
+	Recurring   []ForecastRecurringItem `json:"recurring"`
+	Months      []ForecastMonth         `json:"months"`
+}
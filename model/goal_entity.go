@@ -0,0 +1,98 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Where a goal stands, see GoalProgress
+const (
+	GoalStatusAchieved = "achieved" // saved reached the target
+	GoalStatusOnTrack  = "on_track" // saving as much a month as the deadline needs
+	GoalStatusBehind   = "behind"   // saving less a month than the deadline needs
+	GoalStatusOverdue  = "overdue"  // the deadline passed short of the target
+)
+
+// GoalEntity is a savings target to reach by a deadline. Progress is measured from the cash flows
+// of the linked category and the categories below it since the start date, or of all cash flows
+// when no category is linked.
+type GoalEntity struct {
+	Id           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name         string             `json:"name" bson:"name"`
+	TargetAmount Money              `json:"target_amount" bson:"target_amount"`
+	StartDate    time.Time          `json:"start_date" bson:"start_date"`
+	Deadline     time.Time          `json:"deadline" bson:"deadline"`
+	CategoryId   primitive.ObjectID `json:"category_id" bson:"category_id"`
+	Remark       string             `json:"remark" bson:"remark"`
+	CreateTime   time.Time          `json:"create_time" bson:"create_time"`
+	ModifyTime   time.Time          `json:"modify_time" bson:"modify_time"`
+	Version      int64              `json:"version" bson:"version"` // bumped by every update, served as the ETag
+}
+
+func (entity GoalEntity) IsEmpty() bool {
+	return reflect.DeepEqual(entity, GoalEntity{})
+}
+
+func (entity GoalEntity) ToString() string {
+	return "[ " +
+		"Id: " + entity.Id.Hex() +
+		", Name: " + entity.Name +
+		", TargetAmount: " + entity.TargetAmount.String() +
+		", Deadline: " + util.FormatDateToStringWithDash(entity.Deadline) +
+		" ]"
+}
+
+// MarshalJSON renders the start date and the deadline as dates, the timestamps in the
+// configured timezone and no category id for goals over all cash flows
+func (entity GoalEntity) MarshalJSON() ([]byte, error) {
+	categoryId := ""
+	if !entity.CategoryId.IsZero() {
+		categoryId = entity.CategoryId.Hex()
+	}
+	type Alias GoalEntity
+	return json.Marshal(&struct {
+		StartDate  string    `json:"start_date"`
+		Deadline   string    `json:"deadline"`
+		CategoryId string    `json:"category_id"`
+		CreateTime time.Time `json:"create_time"`
+		ModifyTime time.Time `json:"modify_time"`
+		*Alias
+	}{
+		StartDate:  util.FormatDateToStringWithDash(entity.StartDate),
+		Deadline:   util.FormatDateToStringWithDash(entity.Deadline),
+		CategoryId: categoryId,
+		CreateTime: util.ToTimezone(entity.CreateTime),
+		ModifyTime: util.ToTimezone(entity.ModifyTime),
+		Alias:      (*Alias)(&entity),
+	})
+}
+
+// GoalDTO creates or replaces a goal. Dates take a day, a month or a year: the deadline is the last
+// day of it and the start date the first, today when left out. The category is given by id or name.
+type GoalDTO struct {
+	Name         string `json:"name"`
+	TargetAmount Money  `json:"target_amount"`
+	StartDate    string `json:"start_date"`
+	Deadline     string `json:"deadline"`
+	CategoryId   string `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Remark       string `json:"remark"`
+}
+
+// GoalProgress is a goal with what the cash flows saved towards it up to AsOf
+type GoalProgress struct {
+	Goal            GoalEntity `json:"goal"`
+	Category        string     `json:"category"` // name of the linked category, empty without one
+	AsOf            string     `json:"as_of"`
+	Saved           Money      `json:"saved"`
+	Remaining       Money      `json:"remaining"` // zero once achieved
+	Percent         float64    `json:"percent"`
+	MonthsLeft      int        `json:"months_left"`      // the current month and the deadline's included
+	RequiredMonthly Money      `json:"required_monthly"` // to put aside each month left to reach the target
+	AverageMonthly  Money      `json:"average_monthly"`  // saved per month since the start date
+	Status          string     `json:"status"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// IdempotencyEntity is the stored outcome of a request sent with an Idempotency-Key header
+// StatusCode is 0 while the first request with the key is still being handled
+type IdempotencyEntity struct {
+	Key         string    `json:"key" bson:"_id"`
+	Fingerprint string    `json:"fingerprint" bson:"fingerprint"` // sha256 of method, path and body
+	StatusCode  int       `json:"status_code" bson:"status_code"`
+	ContentType string    `json:"content_type" bson:"content_type"`
+	ETag        string    `json:"etag" bson:"etag"`         // ETag header of the response, replayed with it
+	Location    string    `json:"location" bson:"location"` // Location header of the response, replayed with it
+	Body        []byte    `json:"body" bson:"body"`
+	CreateTime  time.Time `json:"create_time" bson:"create_time"`
+	ExpireTime  time.Time `json:"expire_time" bson:"expire_time"`
+}
+
+func (entity IdempotencyEntity) IsEmpty() bool {
+	return entity.Key == ""
+}
+
+// IsCompleted tells whether the response of the first request has been stored
+func (entity IdempotencyEntity) IsCompleted() bool {
+	return entity.StatusCode != 0
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+
+	"github.com/macar-x/cashlenx-server/errors"
+)
+
+// MergePatchContentType is the media type of a JSON Merge Patch (RFC 7396)
+const MergePatchContentType = "application/merge-patch+json"
+
+// MergePatch holds the members of a JSON Merge Patch whose values are all scalars
+// A missing field stays as it is, a nil value clears the field and any other value replaces it
+type MergePatch map[string]*string
+
+// ParseMergePatch reads a merge patch body, keeping numbers and booleans as their JSON text
+func ParseMergePatch(body []byte) (MergePatch, error) {
+	var memberMap map[string]json.RawMessage
+	if err := json.Unmarshal(body, &memberMap); err != nil || memberMap == nil {
+		return nil, errors.NewInvalidInputError("merge patch must be a JSON object")
+	}
+
+	patch := make(MergePatch, len(memberMap))
+	for field, raw := range memberMap {
+		raw = bytes.TrimSpace(raw)
+		switch {
+		case bytes.Equal(raw, []byte("null")):
+			patch[field] = nil
+		case raw[0] == '"':
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, errors.NewInvalidInputError("field " + field + " holds an invalid string")
+			}
+			patch[field] = &value
+		case raw[0] == '{' || raw[0] == '[':
+			return nil, errors.NewInvalidInputError("field " + field + " must be a string, number, boolean or null")
+		default:
+			value := string(raw)
+			patch[field] = &value
+		}
+	}
+	return patch, nil
+}
+
+// IsMergePatchContentType tells whether a Content-Type header announces a merge patch
+// Plain JSON is taken as well, for clients that cannot set the media type
+func IsMergePatchContentType(header string) bool {
+	mediaType, _, err := mime.ParseMediaType(header)
+	return err == nil && (mediaType == MergePatchContentType || mediaType == "application/json")
+}
+
+// Has tells whether the patch touches the field
+func (patch MergePatch) Has(field string) bool {
+	_, ok := patch[field]
+	return ok
+}
+
+// Set replaces the field with a value
+func (patch MergePatch) Set(field, value string) {
+	patch[field] = &value
+}
+
+// Clear removes the field
+func (patch MergePatch) Clear(field string) {
+	patch[field] = nil
+}
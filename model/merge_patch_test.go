@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestParseMergePatch(t *testing.T) {
+	patch, err := ParseMergePatch([]byte(`{"amount": 0, "description": null, "remark": "kept \"quoted\"", "flag": true}`))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(patch) != 4 {
+		t.Fatalf("expected 4 fields, got %d", len(patch))
+	}
+	if value := patch["amount"]; value == nil || *value != "0" {
+		t.Errorf("expected amount 0, got %v", value)
+	}
+	if value, ok := patch["description"]; !ok || value != nil {
+		t.Errorf("expected description to be cleared, got %v", value)
+	}
+	if value := patch["remark"]; value == nil || *value != `kept "quoted"` {
+		t.Errorf("expected the unquoted remark, got %v", value)
+	}
+	if value := patch["flag"]; value == nil || *value != "true" {
+		t.Errorf("expected flag true, got %v", value)
+	}
+	if patch.Has("belongs_date") {
+		t.Error("expected a missing field to be left out")
+	}
+}
+
+func TestParseMergePatch_Invalid(t *testing.T) {
+	for _, body := range []string{``, `null`, `[]`, `"amount"`, `{"amount": {"value": 1}}`, `{"tags": ["a"]}`} {
+		if _, err := ParseMergePatch([]byte(body)); err == nil {
+			t.Errorf("%q: expected an error", body)
+		}
+	}
+}
+
+func TestIsMergePatchContentType(t *testing.T) {
+	cases := map[string]bool{
+		"application/merge-patch+json":                true,
+		"application/merge-patch+json; charset=utf-8": true,
+		"application/json":                            true,
+		"application/json-patch+json":                 false,
+		"text/plain":                                  false,
+		"":                                            false,
+	}
+	for header, want := range cases {
+		if got := IsMergePatchContentType(header); got != want {
+			t.Errorf("%q: expected %v, got %v", header, want, got)
+		}
+	}
+}
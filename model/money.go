@@ -0,0 +1,190 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// MoneyStorageScale is the number of decimal places kept in storage (DECIMAL(19,4))
+const MoneyStorageScale = 4
+
+// MoneyDisplayScale is the number of decimal places used for display and input rounding
+const MoneyDisplayScale = 2
+
+// Money is an exact monetary amount backed by decimal.Decimal
+// It is stored as Decimal128 in MongoDB and DECIMAL(19,4) in MySQL,
+// and serialized as a plain JSON number so existing clients keep working
+type Money struct {
+	amount decimal.Decimal
+}
+
+// NewMoney wraps a decimal value as Money
+func NewMoney(amount decimal.Decimal) Money {
+	return Money{amount: amount}
+}
+
+// NewMoneyFromFloat converts a float to Money using its shortest decimal representation
+func NewMoneyFromFloat(amount float64) Money {
+	return Money{amount: decimal.NewFromFloat(amount)}
+}
+
+// NewMoneyFromInt creates Money from a whole number of units
+func NewMoneyFromInt(amount int64) Money {
+	return Money{amount: decimal.NewFromInt(amount)}
+}
+
+// NewMoneyFromString parses a decimal string such as "12.34"
+func NewMoneyFromString(amount string) (Money, error) {
+	parsed, err := decimal.NewFromString(strings.TrimSpace(amount))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{amount: parsed}, nil
+}
+
+// Decimal returns the underlying decimal value
+func (m Money) Decimal() decimal.Decimal {
+	return m.amount
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{amount: m.amount.Add(other.amount)}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{amount: m.amount.Sub(other.amount)}
+}
+
+func (m Money) Neg() Money {
+	return Money{amount: m.amount.Neg()}
+}
+
+func (m Money) Abs() Money {
+	return Money{amount: m.amount.Abs()}
+}
+
+// Mul multiplies the amount by a plain decimal factor (e.g. an interest rate)
+func (m Money) Mul(factor decimal.Decimal) Money {
+	return Money{amount: m.amount.Mul(factor)}
+}
+
+// DivInt divides the amount by n, keeping the storage scale
+func (m Money) DivInt(n int64) Money {
+	if n == 0 {
+		return Money{}
+	}
+	return Money{amount: m.amount.DivRound(decimal.NewFromInt(n), MoneyStorageScale)}
+}
+
+// Round rounds the amount half away from zero to the given number of places
+func (m Money) Round(places int32) Money {
+	return Money{amount: m.amount.Round(places)}
+}
+
+func (m Money) Cmp(other Money) int {
+	return m.amount.Cmp(other.amount)
+}
+
+func (m Money) Equal(other Money) bool {
+	return m.amount.Equal(other.amount)
+}
+
+func (m Money) IsZero() bool {
+	return m.amount.IsZero()
+}
+
+func (m Money) IsPositive() bool {
+	return m.amount.IsPositive()
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount.IsNegative()
+}
+
+// Float64 returns the nearest float64, for presentation or statistics only
+func (m Money) Float64() float64 {
+	return m.amount.InexactFloat64()
+}
+
+// String formats the amount with two decimal places
+func (m Money) String() string {
+	return m.amount.StringFixed(MoneyDisplayScale)
+}
+
+// MarshalJSON writes the amount as an unquoted JSON number
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.amount.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number, a quoted decimal string or null
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if text == "null" || text == `""` {
+		*m = Money{}
+		return nil
+	}
+	text = strings.Trim(text, `"`)
+	parsed, err := decimal.NewFromString(text)
+	if err != nil {
+		return fmt.Errorf("invalid money amount %q: %w", text, err)
+	}
+	m.amount = parsed
+	return nil
+}
+
+// MarshalBSONValue stores the amount as Decimal128
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	value, err := primitive.ParseDecimal128(m.amount.String())
+	if err != nil {
+		return 0, nil, err
+	}
+	return bsontype.Decimal128, bsoncore.AppendDecimal128(nil, value), nil
+}
+
+// UnmarshalBSONValue reads Decimal128 and, for data written before the
+// decimal migration, legacy double/int/string amounts
+func (m *Money) UnmarshalBSONValue(valueType bsontype.Type, data []byte) error {
+	value := bsoncore.Value{Type: valueType, Data: data}
+	switch valueType {
+	case bsontype.Decimal128:
+		parsed, err := decimal.NewFromString(value.Decimal128().String())
+		if err != nil {
+			return err
+		}
+		m.amount = parsed
+	case bsontype.Double:
+		m.amount = decimal.NewFromFloat(value.Double())
+	case bsontype.Int32:
+		m.amount = decimal.NewFromInt32(value.Int32())
+	case bsontype.Int64:
+		m.amount = decimal.NewFromInt(value.Int64())
+	case bsontype.String:
+		parsed, err := decimal.NewFromString(value.StringValue())
+		if err != nil {
+			return err
+		}
+		m.amount = parsed
+	case bsontype.Null, bsontype.Undefined:
+		m.amount = decimal.Decimal{}
+	default:
+		return errors.New("unsupported bson type for money: " + valueType.String())
+	}
+	return nil
+}
+
+// Value implements driver.Valuer so amounts are bound as exact decimal strings
+func (m Money) Value() (driver.Value, error) {
+	return m.amount.String(), nil
+}
+
+// Scan implements sql.Scanner for DECIMAL columns
+func (m *Money) Scan(src interface{}) error {
+	return m.amount.Scan(src)
+}
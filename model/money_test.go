@@ -0,0 +1,98 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMoneySumDoesNotDrift(t *testing.T) {
+	// 0.1 added ten thousand times drifts with float64 but must be exact here
+	total := Money{}
+	step := NewMoneyFromFloat(0.1)
+	for i := 0; i < 10000; i++ {
+		total = total.Add(step)
+	}
+
+	expected := NewMoneyFromInt(1000)
+	if !total.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, total)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"Number", `12.34`, "12.34", false},
+		{"Quoted string", `"0.10"`, "0.10", false},
+		{"Integer", `5000`, "5000.00", false},
+		{"Null", `null`, "0.00", false},
+		{"Invalid", `"abc"`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var amount Money
+			err := json.Unmarshal([]byte(tt.input), &amount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && amount.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, amount.String())
+			}
+		})
+	}
+
+	// Amounts are written as plain JSON numbers
+	data, _ := json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{Amount: NewMoneyFromFloat(45.5)})
+	if string(data) != `{"amount":45.5}` {
+		t.Errorf("Unexpected JSON output: %s", data)
+	}
+}
+
+func TestMoneyBSONRoundTrip(t *testing.T) {
+	type document struct {
+		Amount Money `bson:"amount"`
+	}
+
+	original := document{Amount: NewMoneyFromFloat(19.99)}
+	data, err := bson.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	raw := bson.Raw(data)
+	if raw.Lookup("amount").Type != bson.TypeDecimal128 {
+		t.Errorf("Expected amount stored as Decimal128, got %v", raw.Lookup("amount").Type)
+	}
+
+	var decoded document
+	if err := bson.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !decoded.Amount.Equal(original.Amount) {
+		t.Errorf("Expected %s, got %s", original.Amount, decoded.Amount)
+	}
+}
+
+func TestMoneyBSONLegacyDouble(t *testing.T) {
+	// Documents written before the decimal migration hold a double
+	data, _ := bson.Marshal(bson.M{"amount": 45.5})
+
+	var decoded struct {
+		Amount Money `bson:"amount"`
+	}
+	if err := bson.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Amount.String() != "45.50" {
+		t.Errorf("Expected 45.50, got %s", decoded.Amount.String())
+	}
+}
@@ -0,0 +1,27 @@
+package model
+
+// PivotRow is one category of a pivot report; its values include every category below it
+type PivotRow struct {
+	CategoryId string  `json:"category_id"` // empty for cash flows whose category is gone
+	Name       string  `json:"name"`
+	Path       string  `json:"path"`
+	Depth      int     `json:"depth"` // 0 for top level categories
+	Values     []Money `json:"values"`
+	Total      Money   `json:"total"`
+	Average    Money   `json:"average"` // per column
+	Count      int     `json:"count"`
+}
+
+// PivotReport sums cash flows of one type by category and period, one column per period
+type PivotReport struct {
+	Rows       string     `json:"rows"`
+	Cols       string     `json:"cols"`
+	Type       string     `json:"type"`
+	From       string     `json:"from"`
+	To         string     `json:"to"`
+	Columns    []string   `json:"columns"`
+	Items      []PivotRow `json:"items"`
+	Totals     []Money    `json:"totals"`
+	GrandTotal Money      `json:"grand_total"`
+	Average    Money      `json:"average"` // grand total per column
+}
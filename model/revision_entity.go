@@ -0,0 +1,110 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Interfaces a change can come through
+const (
+	ChangeSourceCLI       = "cli"
+	ChangeSourceAPI       = "api"
+	ChangeSourceImport    = "import"
+	ChangeSourceScheduler = "scheduler" // background jobs of the api server
+)
+
+// SchedulerActor is the actor of changes made by background jobs of the api server
+const SchedulerActor = "server"
+
+// Entity types recorded in revisions, the same as in tombstones
+const (
+	RevisionTypeCategory = TombstoneTypeCategory
+	RevisionTypeCashFlow = TombstoneTypeCashFlow
+)
+
+// Actions recorded in the revision log
+const (
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionDelete  = "delete"
+	RevisionActionRestore = "restore"
+	RevisionActionRevert  = "revert"
+)
+
+// ChangeOrigin tells who made a change and through which interface
+type ChangeOrigin struct {
+	Actor  string
+	Source string
+}
+
+// NewChangeOrigin builds a ChangeOrigin for one of the ChangeSource interfaces
+func NewChangeOrigin(source, actor string) ChangeOrigin {
+	return ChangeOrigin{Actor: actor, Source: source}
+}
+
+// RevisionChange is one field of an entity before and after a change
+// Values are kept as text in the form the entity is read and written with (dates as YYYY-MM-DD)
+type RevisionChange struct {
+	Field  string `json:"field" bson:"field"`
+	Before string `json:"before" bson:"before"`
+	After  string `json:"after" bson:"after"`
+}
+
+// RevisionEntity is one append-only entry in the change history of a category or cash flow
+type RevisionEntity struct {
+	Id         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityType string             `json:"entity_type" bson:"entity_type"`
+	EntityId   primitive.ObjectID `json:"entity_id" bson:"entity_id"`
+	Action     string             `json:"action" bson:"action"`
+	Actor      string             `json:"actor" bson:"actor"`
+	Source     string             `json:"source" bson:"source"`
+	Changes    []RevisionChange   `json:"changes" bson:"changes"`
+	RevertedTo string             `json:"reverted_to,omitempty" bson:"reverted_to,omitempty"` // revision id a revert went back to
+	CreateTime time.Time          `json:"create_time" bson:"create_time"`
+}
+
+// NewRevision builds a revision for a change made now
+func NewRevision(entityType string, entityId primitive.ObjectID, action string,
+	changes []RevisionChange, origin ChangeOrigin) RevisionEntity {
+	if changes == nil {
+		changes = []RevisionChange{}
+	}
+	return RevisionEntity{
+		Id:         primitive.NewObjectID(),
+		EntityType: entityType,
+		EntityId:   entityId,
+		Action:     action,
+		Actor:      origin.Actor,
+		Source:     origin.Source,
+		Changes:    changes,
+		CreateTime: time.Now().UTC(),
+	}
+}
+
+func (entity RevisionEntity) ToString() string {
+	description := "[ " +
+		"Id: " + entity.Id.Hex() +
+		", Time: " + util.ToTimezone(entity.CreateTime).Format("2006-01-02 15:04:05") +
+		", Action: " + entity.Action +
+		", Actor: " + entity.Actor +
+		", Source: " + entity.Source
+	if entity.RevertedTo != "" {
+		description += ", RevertedTo: " + entity.RevertedTo
+	}
+	return description + " ]"
+}
+
+// MarshalJSON converts the revision time to the configured timezone for display
+func (entity RevisionEntity) MarshalJSON() ([]byte, error) {
+	type Alias RevisionEntity
+	return json.Marshal(&struct {
+		CreateTime time.Time `json:"create_time"`
+		*Alias
+	}{
+		CreateTime: util.ToTimezone(entity.CreateTime),
+		Alias:      (*Alias)(&entity),
+	})
+}
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// SchemaMigrationEntity records one applied schema migration
+type SchemaMigrationEntity struct {
+	Version   int       `json:"version" bson:"_id"`
+	Name      string    `json:"name" bson:"name"`
+	AppliedAt time.Time `json:"applied_at" bson:"applied_at"`
+}
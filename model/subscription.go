@@ -0,0 +1,39 @@
+package model
+
+const (
+	SubscriptionCadenceWeekly  = "weekly"
+	SubscriptionCadenceMonthly = "monthly"
+	SubscriptionCadenceYearly  = "yearly"
+)
+
+// PriceChange is a subscription charged a different amount than the time before
+type PriceChange struct {
+	Date    string   `json:"date"`
+	From    Money    `json:"from"`
+	To      Money    `json:"to"`
+	Percent *float64 `json:"percent"` // of the change; nil when it was free before
+}
+
+// Subscription is an expense found to come back at a steady cadence with a similar amount
+type Subscription struct {
+	Description   string        `json:"description"` // of the latest charge
+	CategoryId    string        `json:"category_id"`
+	Category      string        `json:"category"`
+	Cadence       string        `json:"cadence"` // weekly, monthly or yearly
+	Occurrences   int           `json:"occurrences"`
+	AverageAmount Money         `json:"average_amount"`
+	LatestAmount  Money         `json:"latest_amount"`
+	MonthlyCost   Money         `json:"monthly_cost"` // the latest amount spread over a month
+	FirstDate     string        `json:"first_date"`
+	LastDate      string        `json:"last_date"`
+	NextDate      string        `json:"next_date"` // expected
+	Active        bool          `json:"active"`    // the next charge is not overdue yet
+	PriceChanges  []PriceChange `json:"price_changes"`
+}
+
+// SubscriptionReport lists the subscriptions found in the history, active ones first
+type SubscriptionReport struct {
+	AsOf        string         `json:"as_of"`
+	MonthlyCost Money          `json:"monthly_cost"` // of the active subscriptions
+	Items       []Subscription `json:"items"`
+}
@@ -0,0 +1,9 @@
+package model
+
+// TextSearchHit is a cash flow found by full-text search, with its relevance and the matching words marked
+// Score only orders the hits of one search; it means nothing across searches or databases
+type TextSearchHit struct {
+	CashFlow   CashFlowEntity    `json:"cash_flow"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Entity types recorded in tombstones
+const (
+	TombstoneTypeCategory = "category"
+	TombstoneTypeCashFlow = "cash_flow"
+	TombstoneTypeGoal     = "goal"
+	TombstoneTypeDebt     = "debt"
+)
+
+// TombstoneEntity records a deleted row so incremental backups can replay the deletion
+type TombstoneEntity struct {
+	Id         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityType string             `json:"entity_type" bson:"entity_type"`
+	EntityId   primitive.ObjectID `json:"entity_id" bson:"entity_id"`
+	DeleteTime time.Time          `json:"delete_time" bson:"delete_time"`
+}
+
+// NewTombstone builds a tombstone for an entity deleted now
+func NewTombstone(entityType string, entityId primitive.ObjectID) TombstoneEntity {
+	return TombstoneEntity{
+		Id:         primitive.NewObjectID(),
+		EntityType: entityType,
+		EntityId:   entityId,
+		DeleteTime: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,119 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+)
+
+// Fields a Term can compare; FieldText is a bare word, matched against description and remark
+const (
+	FieldAmount      = "amount"
+	FieldDate        = "date"
+	FieldCategory    = "category"
+	FieldDescription = "desc"
+	FieldRemark      = "remark"
+	FieldType        = "type"
+	FieldTag         = "tag"
+	FieldText        = "text"
+)
+
+// Operators of a Term after parsing; != and ranges are rewritten into Not and And
+const (
+	OpEqual        = "="
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpContains     = "~"
+)
+
+// Node is a parsed search query: an *And, *Or, *Not or *Term
+type Node interface {
+	String() string
+}
+
+// And matches when every child matches
+type And struct {
+	Children []Node
+}
+
+// Or matches when any child matches
+type Or struct {
+	Children []Node
+}
+
+// Not matches when its child does not
+type Not struct {
+	Child Node
+}
+
+// Term compares one field of a cash flow with a value
+// Value is a model.Money for amount, a time.Time for date and a string for the other fields
+type Term struct {
+	Field string
+	Op    string
+	Value interface{}
+
+	// CategoryIdList holds the ids a category pattern stands for; set by the caller before translating
+	CategoryIdList []string
+}
+
+func (node *And) String() string {
+	return joinNodes("AND", node.Children)
+}
+
+func (node *Or) String() string {
+	return joinNodes("OR", node.Children)
+}
+
+func (node *Not) String() string {
+	return "(NOT " + node.Child.String() + ")"
+}
+
+func (node *Term) String() string {
+	switch value := node.Value.(type) {
+	case model.Money:
+		return node.Field + node.Op + value.String()
+	case time.Time:
+		return node.Field + node.Op + value.Format("2006-01-02")
+	case string:
+		return node.Field + node.Op + strconv.Quote(value)
+	default:
+		return node.Field + node.Op + "?"
+	}
+}
+
+func joinNodes(operator string, children []Node) string {
+	partList := make([]string, 0, len(children))
+	for _, child := range children {
+		partList = append(partList, child.String())
+	}
+	return "(" + operator + " " + strings.Join(partList, " ") + ")"
+}
+
+// Terms lists every Term of the query, so callers can fill in what the parser cannot know
+func Terms(node Node) []*Term {
+	switch typed := node.(type) {
+	case *And:
+		return childTerms(typed.Children)
+	case *Or:
+		return childTerms(typed.Children)
+	case *Not:
+		return Terms(typed.Child)
+	case *Term:
+		return []*Term{typed}
+	default:
+		return nil
+	}
+}
+
+func childTerms(children []Node) []*Term {
+	var termList []*Term
+	for _, child := range children {
+		termList = append(termList, Terms(child)...)
+	}
+	return termList
+}
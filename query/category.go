@@ -0,0 +1,37 @@
+package query
+
+import (
+	"path"
+	"strings"
+)
+
+// CategoryPatternMatches reports whether a category pattern of a query matches the full path of a category
+// The path joins the names from the top category down with /, as in Food/Coffee. Matching ignores case and
+// follows path.Match, so * stands for any part of one name. A pattern without / also matches the name alone,
+// and a pattern ending in /* matches the category before it as well as everything below it
+func CategoryPatternMatches(pattern, fullPath string) bool {
+	pattern = strings.ToLower(pattern)
+	fullPath = strings.ToLower(fullPath)
+
+	if matched, _ := path.Match(pattern, fullPath); matched {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		name := fullPath[strings.LastIndex(fullPath, "/")+1:]
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}
+	if base, ok := strings.CutSuffix(pattern, "/*"); ok {
+		for ancestor := fullPath; ancestor != ""; {
+			if matched, _ := path.Match(base, ancestor); matched {
+				return true
+			}
+			index := strings.LastIndex(ancestor, "/")
+			if index < 0 {
+				break
+			}
+			ancestor = ancestor[:index]
+		}
+	}
+	return false
+}
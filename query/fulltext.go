@@ -0,0 +1,149 @@
+package query
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// MaxTextTerms bounds the words of a full-text search
+const MaxTextTerms = 20
+
+// TextTerms splits a full-text search into its words, lowercased and without duplicates
+// Punctuation separates words, so operators of the databases' own syntax never reach them
+func TextTerms(text string) []string {
+	var termList []string
+	seenMap := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isWordSeparator) {
+		if seenMap[word] || len(termList) == MaxTextTerms {
+			continue
+		}
+		seenMap[word] = true
+		termList = append(termList, word)
+	}
+	return termList
+}
+
+func isWordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// Stem reduces an English word to a stem shared by its inflections, so coffees and coffee, or paid and
+// paying, find each other. It follows the first and last steps of the Porter stemmer, enough for the short
+// texts of descriptions and remarks; MongoDB text indexes stem on their own
+func Stem(word string) string {
+	word = strings.ToLower(word)
+	if len(word) <= 3 {
+		return word
+	}
+
+	// Plurals
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ies"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"):
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "us"):
+		word = word[:len(word)-1]
+	}
+
+	// Past and progressive forms, as long as a vowel is left
+	for _, suffix := range []string{"ing", "ed"} {
+		stem, ok := strings.CutSuffix(word, suffix)
+		if !ok || len(stem) < 3 || !strings.ContainsAny(stem, "aeiouy") {
+			continue
+		}
+		word = stem
+		last := word[len(word)-1]
+		if last == word[len(word)-2] && !strings.ContainsRune("aeiouylsz", rune(last)) {
+			word = word[:len(word)-1]
+		}
+		break
+	}
+
+	// Final y and e, so buy and buys, or price and prices, end alike
+	if strings.HasSuffix(word, "y") && len(word) > 3 {
+		word = word[:len(word)-1] + "i"
+	}
+	if strings.HasSuffix(word, "e") && len(word) > 4 {
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+// StemTerms returns the stems of the words of a full-text search, without duplicates
+func StemTerms(termList []string) []string {
+	var stemList []string
+	seenMap := map[string]bool{}
+	for _, term := range termList {
+		stem := Stem(term)
+		if !seenMap[stem] {
+			seenMap[stem] = true
+			stemList = append(stemList, stem)
+		}
+	}
+	return stemList
+}
+
+// MySqlBooleanQuery builds the AGAINST text of a FULLTEXT search in boolean mode from the stems
+// Each stem is a prefix, so a row matches when any word starts with one of them, and rows matching more rank higher
+func MySqlBooleanQuery(stemList []string) string {
+	partList := make([]string, 0, len(stemList))
+	for _, stem := range stemList {
+		partList = append(partList, stem+"*")
+	}
+	return strings.Join(partList, " ")
+}
+
+// Highlight wraps the words of text starting with one of the stems in open and close
+// With escapeHTML the rest of the text is escaped, so the result is safe to show as HTML
+func Highlight(text string, stemList []string, open, close string, escapeHTML bool) (string, bool) {
+	escape := func(part string) string {
+		if escapeHTML {
+			return html.EscapeString(part)
+		}
+		return part
+	}
+
+	var highlighted strings.Builder
+	matched := false
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		if wordMatches(word, stemList) {
+			matched = true
+			highlighted.WriteString(open + escape(word) + close)
+		} else {
+			highlighted.WriteString(escape(word))
+		}
+		start = -1
+	}
+	for index, r := range text {
+		if !isWordSeparator(r) {
+			if start < 0 {
+				start = index
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(index)
+		}
+		highlighted.WriteString(escape(string(r)))
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+	return highlighted.String(), matched
+}
+
+func wordMatches(word string, stemList []string) bool {
+	lowerWord := strings.ToLower(word)
+	wordStem := Stem(lowerWord)
+	for _, stem := range stemList {
+		if wordStem == stem || strings.HasPrefix(lowerWord, stem) {
+			return true
+		}
+	}
+	return false
+}
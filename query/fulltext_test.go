@@ -0,0 +1,62 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTextTerms(t *testing.T) {
+	termList := TextTerms(`Coffee, "beans" -coffee +café`)
+	expected := []string{"coffee", "beans", "café"}
+	if !reflect.DeepEqual(termList, expected) {
+		t.Errorf("expected %v, got %v", expected, termList)
+	}
+	if len(TextTerms("-- ,; ()")) != 0 {
+		t.Error("expected no terms without words")
+	}
+}
+
+func TestStem(t *testing.T) {
+	for _, group := range [][]string{
+		{"coffee", "coffees", "Coffee"},
+		{"rent", "rented", "renting", "rents"},
+		{"shop", "shopping", "shops"},
+		{"grocery", "groceries"},
+		{"tax", "taxes"},
+		{"lunch", "lunches"},
+		{"price", "prices"},
+	} {
+		for _, word := range group[1:] {
+			if Stem(word) != Stem(group[0]) {
+				t.Errorf("expected %q and %q to share a stem, got %q and %q", word, group[0], Stem(word), Stem(group[0]))
+			}
+		}
+	}
+	for _, word := range []string{"bus", "glass", "tea"} {
+		if Stem(word) != word {
+			t.Errorf("expected %q to stay, got %q", word, Stem(word))
+		}
+	}
+}
+
+func TestMySqlBooleanQuery(t *testing.T) {
+	if query := MySqlBooleanQuery(StemTerms([]string{"coffees", "coffee", "beans"})); query != "coffe* bean*" {
+		t.Errorf("expected the stems as prefixes, got %q", query)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	stemList := StemTerms([]string{"coffees"})
+	highlighted, matched := Highlight(`Coffee & <b>coffeehouse</b> tea`, stemList, "<mark>", "</mark>", true)
+	if !matched {
+		t.Fatal("expected a match")
+	}
+	expected := `<mark>Coffee</mark> &amp; &lt;b&gt;<mark>coffeehouse</mark>&lt;/b&gt; tea`
+	if highlighted != expected {
+		t.Errorf("expected %s, got %s", expected, highlighted)
+	}
+
+	if highlighted, matched := Highlight("green tea", stemList, "[", "]", false); matched || highlighted != "green tea" {
+		t.Errorf("expected no match, got %q", highlighted)
+	}
+}
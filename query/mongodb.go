@@ -0,0 +1,144 @@
+package query
+
+import (
+	"regexp"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ToMongoDbFilter translates a query into a filter on the cash_flows collection
+// Category terms need their CategoryIdList filled in first
+func ToMongoDbFilter(node Node) (bson.D, error) {
+	switch typed := node.(type) {
+	case *And:
+		childList, err := mongoDbChildFilters(typed.Children)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{primitive.E{Key: "$and", Value: childList}}, nil
+	case *Or:
+		childList, err := mongoDbChildFilters(typed.Children)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{primitive.E{Key: "$or", Value: childList}}, nil
+	case *Not:
+		child, err := ToMongoDbFilter(typed.Child)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{primitive.E{Key: "$nor", Value: bson.A{child}}}, nil
+	case *Term:
+		return mongoDbTermFilter(typed)
+	default:
+		return nil, errors.NewInvalidInputError("unknown query node")
+	}
+}
+
+func mongoDbChildFilters(children []Node) (bson.A, error) {
+	childList := make(bson.A, 0, len(children))
+	for _, child := range children {
+		filter, err := ToMongoDbFilter(child)
+		if err != nil {
+			return nil, err
+		}
+		childList = append(childList, filter)
+	}
+	return childList, nil
+}
+
+var mongoDbOperatorMap = map[string]string{
+	OpEqual:        "$eq",
+	OpGreater:      "$gt",
+	OpGreaterEqual: "$gte",
+	OpLess:         "$lt",
+	OpLessEqual:    "$lte",
+}
+
+func mongoDbTermFilter(term *Term) (bson.D, error) {
+	switch term.Field {
+	case FieldAmount, FieldDate:
+		key := map[string]string{FieldAmount: "amount", FieldDate: "belongs_date"}[term.Field]
+		return bson.D{primitive.E{Key: key, Value: bson.M{mongoDbOperatorMap[term.Op]: term.Value}}}, nil
+	case FieldCategory:
+		objectIdList := make([]primitive.ObjectID, 0, len(term.CategoryIdList))
+		for _, plainId := range term.CategoryIdList {
+			objectIdList = append(objectIdList, util.Convert2ObjectId(plainId))
+		}
+		return bson.D{primitive.E{Key: "category_id", Value: bson.M{"$in": objectIdList}}}, nil
+	case FieldType:
+		// Expenses are stored as EXPENSE or OUTCOME, so anything but INCOME counts as one
+		if term.Value == "income" {
+			return bson.D{primitive.E{Key: "flow_type", Value: "INCOME"}}, nil
+		}
+		return bson.D{primitive.E{Key: "flow_type", Value: bson.M{"$ne": "INCOME"}}}, nil
+	case FieldTag:
+		pattern := primitive.Regex{Pattern: `(^|\s)#` + regexp.QuoteMeta(term.Value.(string)) + `(\s|$)`, Options: "i"}
+		return bson.D{primitive.E{Key: "$or", Value: bson.A{
+			bson.D{primitive.E{Key: "description", Value: pattern}},
+			bson.D{primitive.E{Key: "remark", Value: pattern}},
+		}}}, nil
+	case FieldText:
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(term.Value.(string)), Options: "i"}
+		return bson.D{primitive.E{Key: "$or", Value: bson.A{
+			bson.D{primitive.E{Key: "description", Value: pattern}},
+			bson.D{primitive.E{Key: "remark", Value: pattern}},
+		}}}, nil
+	case FieldDescription, FieldRemark:
+		key := map[string]string{FieldDescription: "description", FieldRemark: "remark"}[term.Field]
+		value := term.Value.(string)
+		if term.Op == OpContains {
+			return bson.D{primitive.E{Key: key, Value: primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}}}, nil
+		}
+		if value == "" {
+			// Older documents may not hold the field at all
+			return bson.D{primitive.E{Key: key, Value: bson.M{"$in": bson.A{"", nil}}}}, nil
+		}
+		return bson.D{primitive.E{Key: key, Value: value}}, nil
+	default:
+		return nil, errors.NewInvalidInputError("unknown query field " + term.Field)
+	}
+}
+
+// mongoDbValue converts a compared value for MongoDB, where ids are ObjectIDs
+func mongoDbValue(value keysetValue) interface{} {
+	if value.field.Kind == KindId {
+		return util.Convert2ObjectId(value.value.(string))
+	}
+	return value.value
+}
+
+// MongoDbPage translates the sort and cursor of a page into a sort and a filter for the rows past the cursor
+// The filter is nil without a cursor; a backward page is sorted the other way round
+func MongoDbPage(request PageRequest) (bson.D, bson.D) {
+	sort := bson.D{}
+	for _, field := range request.Sort {
+		direction := 1
+		if field.Descending != request.IsBackward() {
+			direction = -1
+		}
+		sort = append(sort, primitive.E{Key: field.Key, Value: direction})
+	}
+
+	stepList := keysetSteps(request)
+	if len(stepList) == 0 {
+		return sort, nil
+	}
+	alternativeList := make(bson.A, 0, len(stepList))
+	for _, step := range stepList {
+		alternative := bson.D{}
+		for _, equal := range step.equalList {
+			alternative = append(alternative, primitive.E{Key: equal.field.Key, Value: mongoDbValue(equal)})
+		}
+		operator := "$lt"
+		if step.greater {
+			operator = "$gt"
+		}
+		alternative = append(alternative, primitive.E{Key: step.beyond.field.Key, Value: bson.M{operator: mongoDbValue(step.beyond)}})
+		alternativeList = append(alternativeList, alternative)
+	}
+	return sort, bson.D{primitive.E{Key: "$or", Value: alternativeList}}
+}
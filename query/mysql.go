@@ -0,0 +1,118 @@
+package query
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// ToMySqlWhere translates a query into a condition on the cash_flows table and the arguments it binds
+// Every value is passed as an argument, never written into the SQL; category terms need their
+// CategoryIdList filled in first
+func ToMySqlWhere(node Node) (string, []interface{}, error) {
+	switch typed := node.(type) {
+	case *And:
+		return mySqlJoin(" AND ", typed.Children)
+	case *Or:
+		return mySqlJoin(" OR ", typed.Children)
+	case *Not:
+		condition, argList, err := ToMySqlWhere(typed.Child)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT (" + condition + ")", argList, nil
+	case *Term:
+		return mySqlTermWhere(typed)
+	default:
+		return "", nil, errors.NewInvalidInputError("unknown query node")
+	}
+}
+
+func mySqlJoin(separator string, children []Node) (string, []interface{}, error) {
+	conditionList := make([]string, 0, len(children))
+	var argList []interface{}
+	for _, child := range children {
+		condition, childArgList, err := ToMySqlWhere(child)
+		if err != nil {
+			return "", nil, err
+		}
+		conditionList = append(conditionList, "("+condition+")")
+		argList = append(argList, childArgList...)
+	}
+	return strings.Join(conditionList, separator), argList, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func mySqlTermWhere(term *Term) (string, []interface{}, error) {
+	switch term.Field {
+	case FieldAmount:
+		return "AMOUNT " + term.Op + " ?", []interface{}{term.Value}, nil
+	case FieldDate:
+		return "BELONGS_DATE " + term.Op + " ?", []interface{}{util.FormatDateToStringWithDash(term.Value.(time.Time))}, nil
+	case FieldCategory:
+		if len(term.CategoryIdList) == 0 {
+			return "1 = 0", nil, nil
+		}
+		argList := make([]interface{}, 0, len(term.CategoryIdList))
+		for _, plainId := range term.CategoryIdList {
+			argList = append(argList, plainId)
+		}
+		return "CATEGORY_ID IN (?" + strings.Repeat(", ?", len(argList)-1) + ")", argList, nil
+	case FieldType:
+		// Expenses are stored as EXPENSE or OUTCOME, so anything but INCOME counts as one
+		if term.Value == "income" {
+			return "FLOW_TYPE = ?", []interface{}{"INCOME"}, nil
+		}
+		return "FLOW_TYPE <> ?", []interface{}{"INCOME"}, nil
+	case FieldTag:
+		pattern := "(^|[[:space:]])#" + regexp.QuoteMeta(term.Value.(string)) + "([[:space:]]|$)"
+		return "COALESCE(DESCRIPTION, '') REGEXP ? OR COALESCE(REMARK, '') REGEXP ?", []interface{}{pattern, pattern}, nil
+	case FieldText:
+		pattern := "%" + likeEscaper.Replace(term.Value.(string)) + "%"
+		return "COALESCE(DESCRIPTION, '') LIKE ? OR COALESCE(REMARK, '') LIKE ?", []interface{}{pattern, pattern}, nil
+	case FieldDescription, FieldRemark:
+		column := "COALESCE(" + map[string]string{FieldDescription: "DESCRIPTION", FieldRemark: "REMARK"}[term.Field] + ", '')"
+		value := term.Value.(string)
+		if term.Op == OpContains {
+			return column + " LIKE ?", []interface{}{"%" + likeEscaper.Replace(value) + "%"}, nil
+		}
+		return column + " = ?", []interface{}{value}, nil
+	default:
+		return "", nil, errors.NewInvalidInputError("unknown query field " + term.Field)
+	}
+}
+
+// MySqlPage translates the sort and cursor of a page into an ORDER BY and a condition for the rows past
+// the cursor with its arguments; the condition is empty without a cursor
+func MySqlPage(request PageRequest) (string, string, []interface{}) {
+	orderList := make([]string, 0, len(request.Sort))
+	for _, field := range request.Sort {
+		if field.Descending != request.IsBackward() {
+			orderList = append(orderList, field.Column+" DESC")
+		} else {
+			orderList = append(orderList, field.Column+" ASC")
+		}
+	}
+
+	var alternativeList []string
+	var argList []interface{}
+	for _, step := range keysetSteps(request) {
+		partList := make([]string, 0, len(step.equalList)+1)
+		for _, equal := range step.equalList {
+			partList = append(partList, equal.field.Column+" = ?")
+			argList = append(argList, equal.value)
+		}
+		operator := " < ?"
+		if step.greater {
+			operator = " > ?"
+		}
+		partList = append(partList, step.beyond.field.Column+operator)
+		argList = append(argList, step.beyond.value)
+		alternativeList = append(alternativeList, "("+strings.Join(partList, " AND ")+")")
+	}
+	return strings.Join(orderList, ", "), strings.Join(alternativeList, " OR "), argList
+}
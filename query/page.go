@@ -0,0 +1,398 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of values a list can be sorted by, deciding how cursor values are read back
+const (
+	KindId     = "id"
+	KindString = "string"
+	KindTime   = "time"
+	KindMoney  = "money"
+)
+
+// PageField is a field a list can be sorted by, with where each database keeps it
+type PageField struct {
+	Name   string // as used in sort and fields parameters
+	Key    string // MongoDB document key
+	Column string // MySQL column
+	Kind   string
+}
+
+// CashFlowSortFieldList lists the fields cash flows can be sorted by
+var CashFlowSortFieldList = []PageField{
+	{Name: "id", Key: "_id", Column: "ID", Kind: KindId},
+	{Name: "belongs_date", Key: "belongs_date", Column: "BELONGS_DATE", Kind: KindTime},
+	{Name: "amount", Key: "amount", Column: "AMOUNT", Kind: KindMoney},
+	{Name: "flow_type", Key: "flow_type", Column: "FLOW_TYPE", Kind: KindString},
+	{Name: "description", Key: "description", Column: "DESCRIPTION", Kind: KindString},
+	{Name: "create_time", Key: "create_time", Column: "CREATE_TIME", Kind: KindTime},
+	{Name: "modify_time", Key: "modify_time", Column: "MODIFY_TIME", Kind: KindTime},
+}
+
+// CashFlowFieldList lists the fields a cash flow list can be narrowed to
+var CashFlowFieldList = []string{
+	"id", "category_id", "belongs_date", "flow_type", "amount", "description", "remark",
+	"create_time", "modify_time", "version",
+}
+
+// CategorySortFieldList lists the fields categories can be sorted by
+var CategorySortFieldList = []PageField{
+	{Name: "id", Key: "_id", Column: "ID", Kind: KindId},
+	{Name: "name", Key: "name", Column: "NAME", Kind: KindString},
+	{Name: "type", Key: "type", Column: "TYPE", Kind: KindString},
+	{Name: "create_time", Key: "create_time", Column: "CREATE_TIME", Kind: KindTime},
+	{Name: "modify_time", Key: "modify_time", Column: "MODIFY_TIME", Kind: KindTime},
+}
+
+// CategoryFieldList lists the fields a category list can be narrowed to
+var CategoryFieldList = []string{"id", "parent_id", "name", "type", "remark", "create_time", "modify_time", "version"}
+
+// SortField is one key of a sort order
+type SortField struct {
+	PageField
+	Descending bool
+}
+
+// PageRequest is one page of a sorted list, found by offset or, when After is set, by cursor
+type PageRequest struct {
+	Sort   []SortField // always ends with id, so the order is total
+	Limit  int
+	Offset int
+	After  *Cursor
+	Fields []string // fields to answer with, all when empty
+}
+
+// Cursor points between two rows of a sorted list; the rows after it, or before it when Backward
+// It holds the sort it was made for and the sort values and id of the row it follows
+type Cursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// IsBackward reports whether the page is read towards the start of the list
+func (request PageRequest) IsBackward() bool {
+	return request.After != nil && request.After.Backward
+}
+
+// FetchLimit is the number of rows to read: one more than the page, to learn whether another page follows
+func (request PageRequest) FetchLimit() int {
+	return request.Limit + 1
+}
+
+// Skip is the number of rows to skip; a cursor replaces the offset
+func (request PageRequest) Skip() int {
+	if request.After != nil {
+		return 0
+	}
+	return request.Offset
+}
+
+// SortText renders the sort like the sort parameter, id included
+func (request PageRequest) SortText() string {
+	partList := make([]string, 0, len(request.Sort))
+	for _, field := range request.Sort {
+		if field.Descending {
+			partList = append(partList, "-"+field.Name)
+		} else {
+			partList = append(partList, field.Name)
+		}
+	}
+	return strings.Join(partList, ",")
+}
+
+// ParsePageRequest reads the sort, fields, after, limit, page and offset parameters of a list endpoint
+// sort is a comma separated list of fields, - in front for descending; id is added last to break ties.
+// after takes next_cursor or prev_cursor of an earlier page, made for the same sort
+func ParsePageRequest(values url.Values, sortFieldList []PageField, fieldList []string, defaultSort string, defaultLimit int) (PageRequest, error) {
+	request := PageRequest{Limit: defaultLimit}
+	if limit, err := strconv.Atoi(values.Get("limit")); err == nil && limit > 0 {
+		request.Limit = limit
+	}
+	if page, err := strconv.Atoi(values.Get("page")); err == nil && page > 0 {
+		request.Offset = (page - 1) * request.Limit
+	} else if offset, err := strconv.Atoi(values.Get("offset")); err == nil && offset >= 0 {
+		request.Offset = offset
+	}
+
+	sortText := values.Get("sort")
+	if sortText == "" {
+		sortText = defaultSort
+	}
+	sortList, err := ParseSort(sortText, sortFieldList)
+	if err != nil {
+		return PageRequest{}, err
+	}
+	request.Sort = sortList
+
+	if request.Fields, err = ParseFields(values.Get("fields"), fieldList); err != nil {
+		return PageRequest{}, err
+	}
+
+	if after := values.Get("after"); after != "" {
+		cursor, err := DecodeCursor(after, request)
+		if err != nil {
+			return PageRequest{}, err
+		}
+		request.After = &cursor
+	}
+	return request, nil
+}
+
+// ParseSort reads a sort parameter such as -belongs_date,amount, adding id to break ties
+// The tie breaker follows the direction of the last field, so reversing the sort reverses the list
+func ParseSort(text string, sortFieldList []PageField) ([]SortField, error) {
+	var sortList []SortField
+	seenMap := map[string]bool{}
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		descending := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")
+		field, ok := findPageField(sortFieldList, name)
+		if !ok {
+			return nil, errors.NewFieldValidationError("sort", "cannot sort by "+strconv.Quote(name)+", use "+pageFieldNames(sortFieldList))
+		}
+		if seenMap[name] {
+			return nil, errors.NewFieldValidationError("sort", name+" appears twice")
+		}
+		seenMap[name] = true
+		sortList = append(sortList, SortField{PageField: field, Descending: descending})
+	}
+	if !seenMap["id"] {
+		idField, _ := findPageField(sortFieldList, "id")
+		sortList = append(sortList, SortField{PageField: idField, Descending: sortList[len(sortList)-1].Descending})
+	}
+	return sortList, nil
+}
+
+// ParseFields reads a fields parameter such as id,amount,description; empty means every field
+func ParseFields(text string, fieldList []string) ([]string, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	var selectedList []string
+	for _, name := range strings.Split(text, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(fieldList, name) {
+			return nil, errors.NewFieldValidationError("fields", "unknown field "+strconv.Quote(name)+", use "+strings.Join(fieldList, ", "))
+		}
+		if !slices.Contains(selectedList, name) {
+			selectedList = append(selectedList, name)
+		}
+	}
+	return selectedList, nil
+}
+
+// NewCursor makes the cursor pointing after entity, or before it when backward, in the sort of request
+func NewCursor(request PageRequest, entity interface{}, backward bool) (string, error) {
+	data, err := bson.Marshal(entity)
+	if err != nil {
+		return "", err
+	}
+	raw := bson.Raw(data)
+	cursor := Cursor{Sort: request.SortText(), Backward: backward}
+	for _, field := range request.Sort {
+		value, err := raw.LookupErr(field.Key)
+		if err != nil {
+			return "", err
+		}
+		switch field.Kind {
+		case KindId:
+			cursor.Values = append(cursor.Values, value.ObjectID().Hex())
+		case KindTime:
+			cursor.Values = append(cursor.Values, value.Time().UTC().Format(time.RFC3339Nano))
+		case KindMoney:
+			cursor.Values = append(cursor.Values, value.Decimal128().String())
+		default:
+			cursor.Values = append(cursor.Values, value.StringValue())
+		}
+	}
+	encoded, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// DecodeCursor reads a cursor, which has to be made for the sort of request
+func DecodeCursor(text string, request PageRequest) (Cursor, error) {
+	invalid := errors.NewFieldValidationError("after", "invalid cursor, use next_cursor or prev_cursor of an earlier page")
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || len(cursor.Values) != len(request.Sort) {
+		return Cursor{}, invalid
+	}
+	if cursor.Sort != request.SortText() {
+		return Cursor{}, errors.NewFieldValidationError("after", "the cursor was made for sort "+cursor.Sort+", not "+request.SortText())
+	}
+	for index, field := range request.Sort {
+		if _, err := cursorValue(field, cursor.Values[index]); err != nil {
+			return Cursor{}, invalid
+		}
+	}
+	return cursor, nil
+}
+
+// cursorValue reads a value of a cursor back into the type the databases compare
+// Ids stay strings here; the MongoDB translation turns them into ObjectIDs
+func cursorValue(field SortField, text string) (interface{}, error) {
+	switch field.Kind {
+	case KindId:
+		if _, err := primitive.ObjectIDFromHex(text); err != nil {
+			return nil, err
+		}
+		return text, nil
+	case KindTime:
+		return time.Parse(time.RFC3339Nano, text)
+	case KindMoney:
+		return model.NewMoneyFromString(text)
+	default:
+		return text, nil
+	}
+}
+
+// keysetSteps lists the comparisons selecting the rows past the cursor: the first field beyond its value,
+// or equal to it and the second field beyond, and so on. Each step holds its equal fields and the last compared
+type keysetStep struct {
+	equalList []keysetValue
+	beyond    keysetValue
+	greater   bool
+}
+
+type keysetValue struct {
+	field SortField
+	value interface{}
+}
+
+func keysetSteps(request PageRequest) []keysetStep {
+	if request.After == nil {
+		return nil
+	}
+	valueList := make([]keysetValue, 0, len(request.Sort))
+	for index, field := range request.Sort {
+		value, _ := cursorValue(field, request.After.Values[index])
+		valueList = append(valueList, keysetValue{field: field, value: value})
+	}
+	stepList := make([]keysetStep, 0, len(valueList))
+	for index, value := range valueList {
+		// Ascending fields continue with greater values, unless reading backward
+		greater := value.field.Descending == request.IsBackward()
+		stepList = append(stepList, keysetStep{equalList: valueList[:index], beyond: value, greater: greater})
+	}
+	return stepList
+}
+
+func findPageField(fieldList []PageField, name string) (PageField, bool) {
+	for _, field := range fieldList {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return PageField{}, false
+}
+
+func pageFieldNames(fieldList []PageField) string {
+	nameList := make([]string, 0, len(fieldList))
+	for _, field := range fieldList {
+		nameList = append(nameList, field.Name)
+	}
+	return strings.Join(nameList, ", ")
+}
+
+// PageCursors works out the cursors of a page read with request, from its first and last row in list order
+// hasMore tells whether a row beyond the page was read, in the direction of reading
+func PageCursors(request PageRequest, first, last interface{}, hasMore bool) (string, string, error) {
+	var nextCursor, prevCursor string
+	var err error
+	backward := request.IsBackward()
+	if hasMore || backward {
+		if nextCursor, err = NewCursor(request, last, false); err != nil {
+			return "", "", err
+		}
+	}
+	if (backward && hasMore) || (!backward && (request.After != nil || request.Offset > 0)) {
+		if prevCursor, err = NewCursor(request, first, true); err != nil {
+			return "", "", err
+		}
+	}
+	return nextCursor, prevCursor, nil
+}
+
+// Page is one page of a list, its rows in list order and narrowed to the requested fields
+type Page struct {
+	Data       []interface{}
+	Total      int64
+	NextCursor string
+	PrevCursor string
+}
+
+// NewPage makes the page of rows read for request, which may hold one row more than the page
+// Rows of a backward page come in reverse and are put back in list order
+func NewPage(request PageRequest, rowList []interface{}, total int64) (Page, error) {
+	hasMore := len(rowList) > request.Limit
+	if hasMore {
+		rowList = rowList[:request.Limit]
+	}
+	if request.IsBackward() {
+		slices.Reverse(rowList)
+	}
+
+	page := Page{Data: rowList, Total: total}
+	if len(rowList) > 0 {
+		var err error
+		page.NextCursor, page.PrevCursor, err = PageCursors(request, rowList[0], rowList[len(rowList)-1], hasMore)
+		if err != nil {
+			return Page{}, err
+		}
+	}
+
+	if len(request.Fields) > 0 {
+		for index, row := range rowList {
+			selected, err := model.SelectFields(row, request.Fields)
+			if err != nil {
+				return Page{}, err
+			}
+			page.Data[index] = selected
+		}
+	}
+	if page.Data == nil {
+		page.Data = []interface{}{}
+	}
+	return page, nil
+}
+
+// Meta describes the page for the meta of a list response; page is left out when reading by cursor
+func (page Page) Meta(request PageRequest) map[string]interface{} {
+	meta := map[string]interface{}{
+		"total":       page.Total,
+		"limit":       int64(request.Limit),
+		"next_cursor": nil,
+		"prev_cursor": nil,
+	}
+	if request.After == nil {
+		meta["page"] = int64(request.Offset/request.Limit + 1)
+	}
+	if page.NextCursor != "" {
+		meta["next_cursor"] = page.NextCursor
+	}
+	if page.PrevCursor != "" {
+		meta["prev_cursor"] = page.PrevCursor
+	}
+	return meta
+}
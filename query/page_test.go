@@ -0,0 +1,203 @@
+package query
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func parsePage(t *testing.T, rawQuery string) PageRequest {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	request, err := ParsePageRequest(values, CashFlowSortFieldList, CashFlowFieldList, "-belongs_date", 20)
+	if err != nil {
+		t.Fatalf("%q: unexpected error %v", rawQuery, err)
+	}
+	return request
+}
+
+func newCashFlow(plainId, date string, amount int64) model.CashFlowEntity {
+	belongsDate, _ := time.Parse("2006-01-02", date)
+	return model.CashFlowEntity{
+		Id:          util.Convert2ObjectId(plainId),
+		BelongsDate: belongsDate,
+		Amount:      model.NewMoneyFromInt(amount),
+		Description: "lunch",
+	}
+}
+
+func TestParsePageRequest(t *testing.T) {
+	request := parsePage(t, "sort=-belongs_date,amount&fields=id,amount&limit=5&page=3")
+	if request.SortText() != "-belongs_date,amount,id" {
+		t.Errorf("expected id to break ties in the last direction, got %s", request.SortText())
+	}
+	if request.Limit != 5 || request.Offset != 10 {
+		t.Errorf("expected limit 5 and offset 10, got %d and %d", request.Limit, request.Offset)
+	}
+	if !reflect.DeepEqual(request.Fields, []string{"id", "amount"}) {
+		t.Errorf("expected the selected fields, got %v", request.Fields)
+	}
+	if parsePage(t, "").SortText() != "-belongs_date,-id" {
+		t.Error("expected the default sort")
+	}
+
+	for _, rawQuery := range []string{"sort=remark", "sort=amount,amount", "sort=", "fields=id,secret", "after=abc"} {
+		values, _ := url.ParseQuery(rawQuery)
+		if rawQuery == "sort=" {
+			values.Set("sort", ",")
+		}
+		if _, err := ParsePageRequest(values, CashFlowSortFieldList, CashFlowFieldList, "-belongs_date", 20); err == nil {
+			t.Errorf("%q: expected an error", rawQuery)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	request := parsePage(t, "sort=-belongs_date,amount")
+	cashFlow := newCashFlow("65a000000000000000000001", "2026-01-15", 12)
+	cursor, err := NewCursor(request, cashFlow, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	next := parsePage(t, "sort=-belongs_date,amount&after="+cursor)
+	if next.After == nil || next.After.Backward {
+		t.Fatal("expected a forward cursor")
+	}
+	expectedValues := []string{"2026-01-15T00:00:00Z", "12", "65a000000000000000000001"}
+	if !reflect.DeepEqual(next.After.Values, expectedValues) {
+		t.Errorf("expected values %v, got %v", expectedValues, next.After.Values)
+	}
+
+	values := url.Values{"sort": {"amount"}, "after": {cursor}}
+	if _, err := ParsePageRequest(values, CashFlowSortFieldList, CashFlowFieldList, "-belongs_date", 20); err == nil {
+		t.Error("expected a cursor of another sort to be refused")
+	}
+}
+
+func TestMySqlPage(t *testing.T) {
+	request := parsePage(t, "sort=-belongs_date,amount")
+	orderBy, condition, argList := MySqlPage(request)
+	if orderBy != "BELONGS_DATE DESC, AMOUNT ASC, ID ASC" || condition != "" || len(argList) != 0 {
+		t.Errorf("unexpected first page %s, %s, %v", orderBy, condition, argList)
+	}
+
+	cursor, _ := NewCursor(request, newCashFlow("65a000000000000000000001", "2026-01-15", 12), true)
+	request = parsePage(t, "sort=-belongs_date,amount&after="+cursor)
+	orderBy, condition, argList = MySqlPage(request)
+	if orderBy != "BELONGS_DATE ASC, AMOUNT DESC, ID DESC" {
+		t.Errorf("expected the reversed order for a backward page, got %s", orderBy)
+	}
+	expectedCondition := "(BELONGS_DATE > ?) OR (BELONGS_DATE = ? AND AMOUNT < ?) OR (BELONGS_DATE = ? AND AMOUNT = ? AND ID < ?)"
+	if condition != expectedCondition {
+		t.Errorf("expected condition\n%s\ngot\n%s", expectedCondition, condition)
+	}
+	if len(argList) != 6 || argList[5] != "65a000000000000000000001" {
+		t.Errorf("unexpected arguments %v", argList)
+	}
+}
+
+func TestMongoDbPage(t *testing.T) {
+	request := parsePage(t, "sort=amount")
+	cursor, _ := NewCursor(request, newCashFlow("65a000000000000000000001", "2026-01-15", 12), false)
+	request = parsePage(t, "sort=amount&after="+cursor)
+
+	sort, filter := MongoDbPage(request)
+	if !reflect.DeepEqual(sort, bson.D{{Key: "amount", Value: 1}, {Key: "_id", Value: 1}}) {
+		t.Errorf("unexpected sort %v", sort)
+	}
+	expected := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "amount", Value: bson.M{"$gt": model.NewMoneyFromInt(12)}}},
+		bson.D{{Key: "amount", Value: model.NewMoneyFromInt(12)},
+			{Key: "_id", Value: bson.M{"$gt": util.Convert2ObjectId("65a000000000000000000001")}}},
+	}}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("expected %v, got %v", expected, filter)
+	}
+	if _, err := bson.Marshal(filter); err != nil {
+		t.Errorf("expected the filter to encode, got %v", err)
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	rowList := func(plainIdList ...string) []interface{} {
+		var list []interface{}
+		for _, plainId := range plainIdList {
+			list = append(list, newCashFlow(plainId, "2026-01-15", 1))
+		}
+		return list
+	}
+	idOf := func(row interface{}) string {
+		return row.(model.CashFlowEntity).Id.Hex()
+	}
+
+	// First page, one row more than the limit read
+	request := parsePage(t, "limit=2")
+	page, err := NewPage(request, rowList("65a000000000000000000003", "65a000000000000000000002", "65a000000000000000000001"), 3)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(page.Data) != 2 || page.NextCursor == "" || page.PrevCursor != "" {
+		t.Fatalf("expected two rows and only a next cursor, got %d rows, %q and %q", len(page.Data), page.NextCursor, page.PrevCursor)
+	}
+
+	// Backward page read in reverse, with more rows before it
+	request = parsePage(t, "limit=2&after="+page.NextCursor)
+	backwardCursor, _ := NewCursor(request, newCashFlow("65a000000000000000000001", "2026-01-15", 1), true)
+	request = parsePage(t, "limit=2&after="+backwardCursor)
+	page, err = NewPage(request, rowList("65a000000000000000000002", "65a000000000000000000003", "65a000000000000000000004"), 4)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if idOf(page.Data[0]) != "65a000000000000000000003" || idOf(page.Data[1]) != "65a000000000000000000002" {
+		t.Errorf("expected the rows back in list order, got %s and %s", idOf(page.Data[0]), idOf(page.Data[1]))
+	}
+	if page.NextCursor == "" || page.PrevCursor == "" {
+		t.Error("expected both cursors on a middle page")
+	}
+	meta := page.Meta(request)
+	if _, ok := meta["page"]; ok {
+		t.Error("expected no page number when reading by cursor")
+	}
+
+	// Sparse fields
+	request = parsePage(t, "fields=id,amount")
+	page, err = NewPage(request, rowList("65a000000000000000000001"), 1)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	selected := page.Data[0].(map[string]json.RawMessage)
+	if _, ok := selected["amount"]; len(selected) != 2 || !ok {
+		t.Errorf("expected only id and amount, got %v", selected)
+	}
+	if page.Meta(request)["next_cursor"] != nil {
+		t.Error("expected no next cursor on the last page")
+	}
+}
+
+func TestCursorOfCategory(t *testing.T) {
+	values := url.Values{"sort": {"-name"}}
+	request, err := ParsePageRequest(values, CategorySortFieldList, CategoryFieldList, "name", 50)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	category := model.CategoryEntity{Id: primitive.NewObjectID(), Name: "Food"}
+	cursor, err := NewCursor(request, category, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	values.Set("after", cursor)
+	request, err = ParsePageRequest(values, CategorySortFieldList, CategoryFieldList, "name", 50)
+	if err != nil || request.After.Values[0] != "Food" {
+		t.Errorf("expected the name in the cursor, got %v, %v", request.After, err)
+	}
+}
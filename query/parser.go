@@ -0,0 +1,513 @@
+package query
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// MaxQueryLength bounds the text of a query, so a request cannot make the parser work without end
+const MaxQueryLength = 1000
+
+// fieldAliasMap maps the names a query may use to the field they stand for
+var fieldAliasMap = map[string]string{
+	"amount":       FieldAmount,
+	"amt":          FieldAmount,
+	"date":         FieldDate,
+	"belongs_date": FieldDate,
+	"category":     FieldCategory,
+	"cat":          FieldCategory,
+	"desc":         FieldDescription,
+	"description":  FieldDescription,
+	"remark":       FieldRemark,
+	"type":         FieldType,
+	"tag":          FieldTag,
+}
+
+// operatorList holds the operators a term may use, two character ones first so they win
+var operatorList = []string{">=", "<=", "!=", ":", "=", ">", "<", "~"}
+
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+var monthPattern = regexp.MustCompile(`^(\d{4})[-/]?(\d{2})$`)
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenOpen
+	tokenClose
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenTerm
+)
+
+type token struct {
+	kind     tokenKind
+	position int
+	field    string
+	operator string
+	value    string
+}
+
+// Parse reads a search query into its syntax tree
+//
+// Terms are separated by spaces and all have to match; OR, parentheses and a leading - or NOT combine them
+// differently. A term is field, operator and value, as in amount>50, category:Food/*, desc~"coffee" or
+// date:2026-01..2026-03; a bare word matches the description or remark. Values with spaces are quoted
+func Parse(text string) (Node, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.NewFieldValidationError("q", "query cannot be empty")
+	}
+	if len(text) > MaxQueryLength {
+		return nil, errors.NewFieldValidationError("q", "query too long (max "+strconv.Itoa(MaxQueryLength)+" characters)")
+	}
+
+	tokenList, err := scan(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokenList: tokenList}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEnd {
+		return nil, syntaxError(next.position, "unexpected )")
+	}
+	return node, nil
+}
+
+func syntaxError(position int, message string) error {
+	return errors.NewFieldValidationError("q", message+" at position "+strconv.Itoa(position+1))
+}
+
+// scan splits the query into tokens
+func scan(text string) ([]token, error) {
+	var tokenList []token
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokenList = append(tokenList, token{kind: tokenOpen, position: i})
+			i++
+		case c == ')':
+			tokenList = append(tokenList, token{kind: tokenClose, position: i})
+			i++
+		case c == '-' && i+1 < len(text) && !isDelimiter(text[i+1]):
+			tokenList = append(tokenList, token{kind: tokenNot, position: i})
+			i++
+		case c == '"':
+			value, next, err := scanQuoted(text, i)
+			if err != nil {
+				return nil, err
+			}
+			tokenList = append(tokenList, token{kind: tokenTerm, position: i, value: value})
+			i = next
+		default:
+			next, scanned, err := scanWord(text, i)
+			if err != nil {
+				return nil, err
+			}
+			tokenList = append(tokenList, scanned)
+			i = next
+		}
+	}
+	return append(tokenList, token{kind: tokenEnd, position: len(text)}), nil
+}
+
+// scanWord reads a field with its operator and value, a keyword or a bare word
+func scanWord(text string, start int) (int, token, error) {
+	end := start
+	for end < len(text) && (isLetter(text[end]) || text[end] == '_') {
+		end++
+	}
+	if end > start && end < len(text) {
+		for _, operator := range operatorList {
+			if !strings.HasPrefix(text[end:], operator) {
+				continue
+			}
+			valueStart := end + len(operator)
+			if valueStart < len(text) && text[valueStart] == '"' {
+				value, next, err := scanQuoted(text, valueStart)
+				if err != nil {
+					return 0, token{}, err
+				}
+				return next, token{kind: tokenTerm, position: start, field: text[start:end], operator: operator, value: value}, nil
+			}
+			valueEnd := valueStart
+			for valueEnd < len(text) && !isDelimiter(text[valueEnd]) {
+				valueEnd++
+			}
+			if valueEnd == valueStart {
+				return 0, token{}, syntaxError(start, "missing value after "+text[start:valueStart])
+			}
+			return valueEnd, token{kind: tokenTerm, position: start, field: text[start:end], operator: operator, value: text[valueStart:valueEnd]}, nil
+		}
+	}
+
+	end = start
+	for end < len(text) && !isDelimiter(text[end]) {
+		end++
+	}
+	word := text[start:end]
+	switch word {
+	case "AND":
+		return end, token{kind: tokenAnd, position: start}, nil
+	case "OR":
+		return end, token{kind: tokenOr, position: start}, nil
+	case "NOT":
+		return end, token{kind: tokenNot, position: start}, nil
+	}
+	return end, token{kind: tokenTerm, position: start, value: word}, nil
+}
+
+// scanQuoted reads a double quoted value, where \" and \\ stand for " and \
+func scanQuoted(text string, start int) (string, int, error) {
+	var value strings.Builder
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if i+1 < len(text) && (text[i+1] == '"' || text[i+1] == '\\') {
+				i++
+			}
+			value.WriteByte(text[i])
+		case '"':
+			return value.String(), i + 1, nil
+		default:
+			value.WriteByte(text[i])
+		}
+	}
+	return "", 0, syntaxError(start, "unterminated quote")
+}
+
+func isDelimiter(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+type parser struct {
+	tokenList []token
+	index     int
+}
+
+func (p *parser) peek() token {
+	return p.tokenList[p.index]
+}
+
+func (p *parser) next() token {
+	current := p.tokenList[p.index]
+	if current.kind != tokenEnd {
+		p.index++
+	}
+	return current
+}
+
+// parseOr reads terms joined by OR, which binds looser than the implicit AND
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	childList := []Node{first}
+	for p.peek().kind == tokenOr {
+		p.next()
+		child, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		childList = append(childList, child)
+	}
+	if len(childList) == 1 {
+		return first, nil
+	}
+	return &Or{Children: childList}, nil
+}
+
+// parseAnd reads terms next to each other, with or without AND between them
+func (p *parser) parseAnd() (Node, error) {
+	var childList []Node
+	for {
+		switch p.peek().kind {
+		case tokenEnd, tokenClose, tokenOr:
+			if len(childList) == 0 {
+				return nil, syntaxError(p.peek().position, "expected a search term")
+			}
+			if len(childList) == 1 {
+				return childList[0], nil
+			}
+			return &And{Children: childList}, nil
+		case tokenAnd:
+			if len(childList) == 0 {
+				return nil, syntaxError(p.peek().position, "expected a search term before AND")
+			}
+			p.next()
+			if kind := p.peek().kind; kind == tokenEnd || kind == tokenClose || kind == tokenOr {
+				return nil, syntaxError(p.peek().position, "expected a search term after AND")
+			}
+		default:
+			child, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			childList = append(childList, child)
+		}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negate(child), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	current := p.next()
+	switch current.kind {
+	case tokenOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenClose {
+			return nil, syntaxError(current.position, "unclosed (")
+		}
+		return node, nil
+	case tokenTerm:
+		return buildTerm(current)
+	default:
+		return nil, syntaxError(current.position, "expected a search term")
+	}
+}
+
+func negate(node Node) Node {
+	if not, ok := node.(*Not); ok {
+		return not.Child
+	}
+	return &Not{Child: node}
+}
+
+// buildTerm checks the field, operator and value of a term and rewrites != and ranges with Not and And
+func buildTerm(current token) (Node, error) {
+	if current.field == "" {
+		if current.value == "" {
+			return nil, syntaxError(current.position, "empty search term")
+		}
+		return &Term{Field: FieldText, Op: OpContains, Value: current.value}, nil
+	}
+
+	field, ok := fieldAliasMap[strings.ToLower(current.field)]
+	if !ok {
+		return nil, syntaxError(current.position,
+			"unknown field "+current.field+", use one of amount, date, category, desc, remark, type or tag")
+	}
+
+	operator := current.operator
+	if operator == "!=" {
+		positive := current
+		positive.operator = "="
+		node, err := buildTerm(positive)
+		if err != nil {
+			return nil, err
+		}
+		return negate(node), nil
+	}
+
+	unsupported := func() (Node, error) {
+		return nil, syntaxError(current.position, "operator "+operator+" does not apply to "+field)
+	}
+
+	switch field {
+	case FieldAmount:
+		return buildAmountTerm(current, operator, unsupported)
+	case FieldDate:
+		return buildDateTerm(current, operator, unsupported)
+	case FieldCategory:
+		if operator != ":" && operator != "=" {
+			return unsupported()
+		}
+		if current.value == "" {
+			return nil, syntaxError(current.position, "empty category")
+		}
+		return &Term{Field: field, Op: OpEqual, Value: current.value}, nil
+	case FieldDescription, FieldRemark:
+		switch operator {
+		case ":", "~":
+			return &Term{Field: field, Op: OpContains, Value: current.value}, nil
+		case "=":
+			return &Term{Field: field, Op: OpEqual, Value: current.value}, nil
+		}
+		return unsupported()
+	case FieldType:
+		if operator != ":" && operator != "=" {
+			return unsupported()
+		}
+		flowType := strings.ToLower(current.value)
+		if flowType != "income" && flowType != "expense" {
+			return nil, syntaxError(current.position, "type must be income or expense")
+		}
+		return &Term{Field: field, Op: OpEqual, Value: flowType}, nil
+	default: // FieldTag
+		if operator != ":" && operator != "=" {
+			return unsupported()
+		}
+		tag := strings.TrimPrefix(current.value, "#")
+		if !tagPattern.MatchString(tag) {
+			return nil, syntaxError(current.position, "tag may only hold letters, digits, - and _")
+		}
+		return &Term{Field: field, Op: OpEqual, Value: tag}, nil
+	}
+}
+
+// buildAmountTerm reads amount>50, amount:12.5 or the inclusive range amount:10..50, either end left open
+func buildAmountTerm(current token, operator string, unsupported func() (Node, error)) (Node, error) {
+	parseAmount := func(text string) (model.Money, error) {
+		amount, err := model.NewMoneyFromString(text)
+		if err != nil {
+			return model.Money{}, syntaxError(current.position, "invalid amount "+text)
+		}
+		return amount, nil
+	}
+
+	switch operator {
+	case ">", ">=", "<", "<=":
+		amount, err := parseAmount(current.value)
+		if err != nil {
+			return nil, err
+		}
+		return &Term{Field: FieldAmount, Op: operator, Value: amount}, nil
+	case ":", "=":
+		lower, upper, isRange := strings.Cut(current.value, "..")
+		if !isRange {
+			amount, err := parseAmount(current.value)
+			if err != nil {
+				return nil, err
+			}
+			return &Term{Field: FieldAmount, Op: OpEqual, Value: amount}, nil
+		}
+		if lower == "" && upper == "" {
+			return nil, syntaxError(current.position, "range needs at least one end")
+		}
+		var boundList []Node
+		if lower != "" {
+			amount, err := parseAmount(lower)
+			if err != nil {
+				return nil, err
+			}
+			boundList = append(boundList, &Term{Field: FieldAmount, Op: OpGreaterEqual, Value: amount})
+		}
+		if upper != "" {
+			amount, err := parseAmount(upper)
+			if err != nil {
+				return nil, err
+			}
+			boundList = append(boundList, &Term{Field: FieldAmount, Op: OpLessEqual, Value: amount})
+		}
+		return joinBounds(boundList), nil
+	}
+	return unsupported()
+}
+
+// buildDateTerm reads a day, month or year, or an inclusive range of them, into bounds on the belongs date
+// date:2026-01 is the whole of January, date>2026-01 starts in February and date:2026-01..2026-03 ends with March
+func buildDateTerm(current token, operator string, unsupported func() (Node, error)) (Node, error) {
+	parsePeriod := func(text string) (time.Time, time.Time, error) {
+		start, end, ok := ParsePeriod(text)
+		if !ok {
+			return time.Time{}, time.Time{}, syntaxError(current.position,
+				"invalid date "+text+", use a day like 2026-01-15, a month like 2026-01 or a year like 2026")
+		}
+		return start, end, nil
+	}
+
+	switch operator {
+	case ">", ">=", "<", "<=":
+		start, end, err := parsePeriod(current.value)
+		if err != nil {
+			return nil, err
+		}
+		bound := map[string]*Term{
+			">":  {Field: FieldDate, Op: OpGreaterEqual, Value: end},
+			">=": {Field: FieldDate, Op: OpGreaterEqual, Value: start},
+			"<":  {Field: FieldDate, Op: OpLess, Value: start},
+			"<=": {Field: FieldDate, Op: OpLess, Value: end},
+		}[operator]
+		return bound, nil
+	case ":", "=":
+		lower, upper, isRange := strings.Cut(current.value, "..")
+		if !isRange {
+			upper = lower
+		}
+		if lower == "" && upper == "" {
+			return nil, syntaxError(current.position, "range needs at least one end")
+		}
+		var boundList []Node
+		if lower != "" {
+			start, _, err := parsePeriod(lower)
+			if err != nil {
+				return nil, err
+			}
+			boundList = append(boundList, &Term{Field: FieldDate, Op: OpGreaterEqual, Value: start})
+		}
+		if upper != "" {
+			_, end, err := parsePeriod(upper)
+			if err != nil {
+				return nil, err
+			}
+			boundList = append(boundList, &Term{Field: FieldDate, Op: OpLess, Value: end})
+		}
+		return joinBounds(boundList), nil
+	}
+	return unsupported()
+}
+
+func joinBounds(boundList []Node) Node {
+	if len(boundList) == 1 {
+		return boundList[0]
+	}
+	return &And{Children: boundList}
+}
+
+// ParsePeriod reads a year (2026), a month (2026-01, 2026/01 or 202601) or a day in any format of util.ParseDate
+// Returns the first day of the period and the first day after it, at midnight UTC like stored belongs dates
+func ParsePeriod(text string) (time.Time, time.Time, bool) {
+	if len(text) == 4 {
+		year, err := strconv.Atoi(text)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, 0), true
+	}
+	if matches := monthPattern.FindStringSubmatch(text); matches != nil {
+		year, _ := strconv.Atoi(matches[1])
+		month, _ := strconv.Atoi(matches[2])
+		if month < 1 || month > 12 {
+			return time.Time{}, time.Time{}, false
+		}
+		start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), true
+	}
+	day, err := util.ParseDate(text)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1), true
+}
@@ -0,0 +1,78 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		`amount>50`:             `amount>50.00`,
+		`amt:10..50`:            `(AND amount>=10.00 amount<=50.00)`,
+		`amount:..50`:           `amount<=50.00`,
+		`coffee`:                `text~"coffee"`,
+		`desc~"coffee"`:         `desc~"coffee"`,
+		`desc="a \"b\""`:        `desc="a \"b\""`,
+		`remark:x`:              `remark~"x"`,
+		`category:Food/*`:       `category="Food/*"`,
+		`type:Income`:           `type="income"`,
+		`-tag:work`:             `(NOT tag="work")`,
+		`tag:#work`:             `tag="work"`,
+		`NOT -tag:work`:         `tag="work"`,
+		`amount!=5`:             `(NOT amount=5.00)`,
+		`date:2026-01..2026-03`: `(AND date>=2026-01-01 date<2026-04-01)`,
+		`date:2026`:             `(AND date>=2026-01-01 date<2027-01-01)`,
+		`date:20260115`:         `(AND date>=2026-01-15 date<2026-01-16)`,
+		`date>2026-01`:          `date>=2026-02-01`,
+		`date<=2026/01`:         `date<2026-02-01`,
+		`date:2026-02..`:        `date>=2026-02-01`,
+		`a b OR c`:              `(OR (AND text~"a" text~"b") text~"c")`,
+		`a AND (b OR c)`:        `(AND text~"a" (OR text~"b" text~"c"))`,
+		`amount>50 category:Food/* desc~"coffee" date:2026-01..2026-03 -tag:work`: `(AND amount>50.00 category="Food/*" desc~"coffee" ` +
+			`(AND date>=2026-01-01 date<2026-04-01) (NOT tag="work"))`,
+	}
+	for text, expected := range cases {
+		node, err := Parse(text)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", text, err)
+			continue
+		}
+		if node.String() != expected {
+			t.Errorf("%q: expected %s, got %s", text, expected, node.String())
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, text := range []string{
+		``, `   `, `amount>abc`, `amount~5`, `unknown:1`, `date:2026-13`, `date:yesterday`, `type:gift`,
+		`tag:"two words"`, `desc:"open`, `(a b`, `a b)`, `a OR`, `AND a`, `()`, `amount:..`, `category>Food`,
+	} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
+
+func TestCategoryPatternMatches(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		fullPath string
+		expected bool
+	}{
+		{"Food", "Food", true},
+		{"coffee", "Food/Coffee", true},
+		{"Food", "Food/Coffee", false},
+		{"Food/*", "Food", true},
+		{"Food/*", "Food/Coffee", true},
+		{"Food/*", "Food/Coffee/Beans", true},
+		{"Food/*", "Fun", false},
+		{"F*", "Fun", true},
+		{"Food/C*", "Food/Coffee", true},
+		{"Food/C*", "Food/Tea", false},
+	}
+	for _, c := range cases {
+		if actual := CategoryPatternMatches(c.pattern, c.fullPath); actual != c.expected {
+			t.Errorf("%q on %q: expected %v, got %v", c.pattern, c.fullPath, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func parseWithCategories(t *testing.T, text string, categoryIdList ...string) Node {
+	node, err := Parse(text)
+	if err != nil {
+		t.Fatalf("%q: unexpected error %v", text, err)
+	}
+	for _, term := range Terms(node) {
+		if term.Field == FieldCategory {
+			term.CategoryIdList = categoryIdList
+		}
+	}
+	return node
+}
+
+func TestToMySqlWhere(t *testing.T) {
+	node := parseWithCategories(t, `amount>50 category:Food/* desc~"50%_off" date:2026-01 -tag:work`,
+		"65a000000000000000000001", "65a000000000000000000002")
+	condition, argList, err := ToMySqlWhere(node)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expectedCondition := "(AMOUNT > ?) AND (CATEGORY_ID IN (?, ?)) AND (COALESCE(DESCRIPTION, '') LIKE ?) AND " +
+		"((BELONGS_DATE >= ?) AND (BELONGS_DATE < ?)) AND " +
+		"(NOT (COALESCE(DESCRIPTION, '') REGEXP ? OR COALESCE(REMARK, '') REGEXP ?))"
+	if condition != expectedCondition {
+		t.Errorf("expected condition\n%s\ngot\n%s", expectedCondition, condition)
+	}
+	if len(argList) != 8 {
+		t.Fatalf("expected 8 arguments, got %d", len(argList))
+	}
+	if argList[3] != `%50\%\_off%` {
+		t.Errorf("expected the escaped like pattern, got %v", argList[3])
+	}
+	if argList[4] != "2026-01-01" || argList[5] != "2026-02-01" {
+		t.Errorf("expected the bounds of January, got %v and %v", argList[4], argList[5])
+	}
+	if argList[6] != "(^|[[:space:]])#work([[:space:]]|$)" {
+		t.Errorf("expected the tag pattern, got %v", argList[6])
+	}
+}
+
+func TestToMySqlWhere_NoCategory(t *testing.T) {
+	condition, argList, err := ToMySqlWhere(parseWithCategories(t, `category:Nothing`))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if condition != "1 = 0" || len(argList) != 0 {
+		t.Errorf("expected a condition matching nothing, got %s with %v", condition, argList)
+	}
+}
+
+func TestToMongoDbFilter(t *testing.T) {
+	filter, err := ToMongoDbFilter(parseWithCategories(t, `type:expense OR -remark=""`))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "flow_type", Value: bson.M{"$ne": "INCOME"}}},
+		bson.D{{Key: "$nor", Value: bson.A{
+			bson.D{{Key: "remark", Value: bson.M{"$in": bson.A{"", nil}}}},
+		}}},
+	}}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("expected %v, got %v", expected, filter)
+	}
+
+	// Every filter has to encode, amounts as Decimal128 and dates as datetimes
+	filter, err = ToMongoDbFilter(parseWithCategories(t, `amount:10..20 date:2026 category:Food coffee tag:work`,
+		"65a000000000000000000001"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := bson.Marshal(filter); err != nil {
+		t.Errorf("expected the filter to encode, got %v", err)
+	}
+}
@@ -10,7 +10,7 @@ CREATE TABLE `cash_flows`
     `category_id`  VARCHAR(24)  NOT NULL,
     `belongs_date` TIMESTAMP    NOT NULL,
     `flow_type`    VARCHAR(10)  NOT NULL COMMENT 'INCOME/OUTCOME',
-    `amount`       DECIMAL      NOT NULL,
+    `amount`       DECIMAL(19, 4) NOT NULL,
     `description`  VARCHAR(200) NOT NULL,
     `remark`       VARCHAR(200)          DEFAULT NULL COMMENT 'KEEP EMPTY',
     `create_time`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP(),
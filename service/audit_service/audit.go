@@ -0,0 +1,115 @@
+package audit_service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/audit_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RedactedValue replaces the value of a secret parameter in the audit log
+const RedactedValue = "[REDACTED]"
+
+// secretParameterList holds the words that mark a parameter name as a secret
+var secretParameterList = []string{"passphrase", "password", "token", "secret"}
+
+// Redact returns a copy of the parameters with the value of every secret replaced by RedactedValue
+// An empty secret stays empty, so the log still tells whether one was given
+func Redact(parameters map[string]string) map[string]string {
+	redacted := make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		if value != "" && isSecretParameter(name) {
+			value = RedactedValue
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+func isSecretParameter(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range secretParameterList {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record appends an operation to the audit log, failed when err is not nil
+// A failure to record is logged and does not fail the operation itself
+func Record(operation string, origin model.ChangeOrigin, parameters map[string]string, affected map[string]int, err error) {
+	if affected == nil {
+		affected = map[string]int{}
+	}
+	entity := model.AuditEntity{
+		Id:         primitive.NewObjectID(),
+		Operation:  operation,
+		Actor:      origin.Actor,
+		Source:     origin.Source,
+		Parameters: Redact(parameters),
+		Affected:   affected,
+		Outcome:    model.AuditOutcomeSuccess,
+		CreateTime: time.Now().UTC(),
+	}
+	if err != nil {
+		entity.Outcome = model.AuditOutcomeFailure
+		entity.Error = err.Error()
+	}
+
+	if insertErr := audit_mapper.INSTANCE.InsertAudit(entity); insertErr != nil {
+		util.Logger.Errorw("record audit failed", "operation", operation, "error", insertErr)
+	}
+}
+
+// ParseAuditFilter builds a filter from the query values of the API and CLI
+// Dates are days in the configured timezone, both ends inclusive
+func ParseAuditFilter(operation, actor, outcome, fromDate, toDate string) (model.AuditFilter, error) {
+	filter := model.AuditFilter{Operation: operation, Actor: actor, Outcome: outcome}
+	if outcome != "" && outcome != model.AuditOutcomeSuccess && outcome != model.AuditOutcomeFailure {
+		return model.AuditFilter{}, errors.NewInvalidInputError("outcome must be success or failure")
+	}
+	if fromDate != "" {
+		from, err := parseDay(fromDate)
+		if err != nil {
+			return model.AuditFilter{}, err
+		}
+		filter.From = from
+	}
+	if toDate != "" {
+		to, err := parseDay(toDate)
+		if err != nil {
+			return model.AuditFilter{}, err
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	return filter, nil
+}
+
+func parseDay(value string) (time.Time, error) {
+	date, err := util.ParseDate(value)
+	if err != nil {
+		return time.Time{}, errors.NewInvalidInputError("invalid date " + value + ", try format like 19700101 or 1970-01-01")
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, util.GetTimezone()), nil
+}
+
+// QueryAuditLog returns a page of the audit log, newest first, and the number of entries matching the filter
+func QueryAuditLog(filter model.AuditFilter, limit, offset int) ([]model.AuditEntity, int64, error) {
+	auditList, err := audit_mapper.INSTANCE.GetAudits(filter, limit, offset)
+	if err != nil {
+		return nil, 0, errors.NewDatabaseError("query audit log failed", err)
+	}
+	if auditList == nil {
+		auditList = []model.AuditEntity{}
+	}
+	totalCount, err := audit_mapper.INSTANCE.CountAudits(filter)
+	if err != nil {
+		return nil, 0, errors.NewDatabaseError("count audit log failed", err)
+	}
+	return auditList, totalCount, nil
+}
@@ -0,0 +1,47 @@
+package audit_service
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	parameters := map[string]string{
+		"passphrase":    "correct horse",
+		"admin_token":   "s3cr3t",
+		"DB_PASSWORD":   "hunter2",
+		"compression":   "zstd",
+		"file":          "backup.ndjson",
+		"client_secret": "",
+	}
+
+	redacted := Redact(parameters)
+	for _, name := range []string{"passphrase", "admin_token", "DB_PASSWORD"} {
+		if redacted[name] != RedactedValue {
+			t.Errorf("%s should be redacted, got %q", name, redacted[name])
+		}
+	}
+	if redacted["compression"] != "zstd" || redacted["file"] != "backup.ndjson" {
+		t.Errorf("plain parameters should be kept, got %v", redacted)
+	}
+	if redacted["client_secret"] != "" {
+		t.Errorf("an empty secret should stay empty, got %q", redacted["client_secret"])
+	}
+	if parameters["passphrase"] != "correct horse" {
+		t.Error("Redact should not modify its argument")
+	}
+}
+
+func TestParseAuditFilter(t *testing.T) {
+	filter, err := ParseAuditFilter("restore", "", "failure", "2024-05-01", "20240501")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.To.Sub(filter.From).Hours() != 24 {
+		t.Errorf("a single day should span 24 hours, got %v to %v", filter.From, filter.To)
+	}
+
+	if _, err := ParseAuditFilter("", "", "maybe", "", ""); err == nil {
+		t.Error("an unknown outcome should be rejected")
+	}
+	if _, err := ParseAuditFilter("", "", "", "yesterday", ""); err == nil {
+		t.Error("an invalid date should be rejected")
+	}
+}
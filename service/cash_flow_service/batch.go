@@ -0,0 +1,319 @@
+package cash_flow_service
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+)
+
+const defaultBatchMaxSize = 500
+
+// preparedOperation is a batch operation that passed validation, ready to write
+type preparedOperation struct {
+	index  int
+	op     string
+	before model.CashFlowEntity // as read, for updates and deletes
+	after  model.CashFlowEntity // to insert or the patched entity to update
+}
+
+// GetBatchMaxSize reads BATCH_MAX_SIZE, the most operations a batch may hold
+func GetBatchMaxSize() int {
+	maxSize, err := strconv.Atoi(util.GetConfigByKey("batch.max.size"))
+	if err != nil || maxSize <= 0 {
+		util.Logger.Warnw("invalid batch max size, using default", "key", "batch.max.size", "default", defaultBatchMaxSize)
+		return defaultBatchMaxSize
+	}
+	return maxSize
+}
+
+// ExecuteBatch applies a list of creates, updates and deletes, validating each like the single endpoints do
+// Every operation is checked before anything is written. An atomic batch stops there when one fails, and undoes
+// what it wrote when a write fails; otherwise the valid operations are applied and the others reported
+func ExecuteBatch(request model.BatchRequest, origin model.ChangeOrigin) (model.BatchResponse, error) {
+	if len(request.Operations) == 0 {
+		return model.BatchResponse{}, errors.NewInvalidInputError("batch holds no operations")
+	}
+	if maxSize := GetBatchMaxSize(); len(request.Operations) > maxSize {
+		return model.BatchResponse{}, errors.NewInvalidInputError(
+			"batch holds " + strconv.Itoa(len(request.Operations)) + " operations, at most " + strconv.Itoa(maxSize) + " are allowed")
+	}
+
+	response := model.BatchResponse{Atomic: request.Atomic, Results: make([]model.BatchResult, len(request.Operations))}
+	preparedList := make([]preparedOperation, 0, len(request.Operations))
+	seenIdMap := map[string]int{}
+	for index, operation := range request.Operations {
+		response.Results[index] = model.BatchResult{Index: index, Op: operation.Op}
+		prepared, err := prepareOperation(index, operation, seenIdMap)
+		if err != nil {
+			setFailure(&response.Results[index], err)
+			continue
+		}
+		preparedList = append(preparedList, prepared)
+	}
+
+	if request.Atomic && len(preparedList) < len(request.Operations) {
+		abortRemaining(&response, "not applied, another operation of the atomic batch is invalid")
+		return countResults(response), nil
+	}
+
+	appliedList, failed := applyOperations(preparedList, response.Results)
+	if request.Atomic && failed {
+		undoOperations(appliedList)
+		abortRemaining(&response, "undone, another operation of the atomic batch failed to write")
+		return countResults(response), nil
+	}
+
+	recordBatchChanges(appliedList, origin)
+	splitDebtPayments(appliedList, response.Results, origin)
+	response.Applied = len(appliedList) > 0
+	return countResults(response), nil
+}
+
+// prepareOperation validates one operation without writing anything
+// An id may only appear once in a batch, so operations never depend on each other
+func prepareOperation(index int, operation model.BatchOperation, seenIdMap map[string]int) (preparedOperation, error) {
+	if operation.Op != model.BatchOpCreate {
+		if err := validation.ValidateID(operation.Id); err != nil {
+			return preparedOperation{}, err
+		}
+		if firstIndex, ok := seenIdMap[operation.Id]; ok {
+			return preparedOperation{}, errors.NewInvalidInputError(
+				"cash_flow " + operation.Id + " is already handled by operation " + strconv.Itoa(firstIndex))
+		}
+		seenIdMap[operation.Id] = index
+	}
+
+	switch operation.Op {
+	case model.BatchOpCreate:
+		flowType := map[string]string{"expense": "EXPENSE", "income": "INCOME"}[operation.Type]
+		if flowType == "" {
+			return preparedOperation{}, validation.NewValidationError("type", "must be expense or income")
+		}
+		newEntity, err := buildCashFlow(flowType, operation.BelongsDate, operation.CategoryName, operation.Amount, operation.Description)
+		if err != nil {
+			return preparedOperation{}, err
+		}
+		return preparedOperation{index: index, op: operation.Op, after: newEntity}, nil
+
+	case model.BatchOpUpdate:
+		expectedVersion, err := model.ParseIfMatch(operation.IfMatch)
+		if err != nil {
+			return preparedOperation{}, err
+		}
+		patch, err := model.ParseMergePatch(operation.Patch)
+		if err != nil {
+			return preparedOperation{}, err
+		}
+		if err := checkPatchFields(patch); err != nil {
+			return preparedOperation{}, err
+		}
+		existingEntity, err := getExpectedCashFlow(operation.Id, expectedVersion)
+		if err != nil {
+			return preparedOperation{}, err
+		}
+		patchedEntity, err := applyCashFlowPatch(existingEntity, patch)
+		if err != nil {
+			return preparedOperation{}, err
+		}
+		return preparedOperation{index: index, op: operation.Op, before: existingEntity, after: patchedEntity}, nil
+
+	case model.BatchOpDelete:
+		expectedVersion := model.AnyVersion
+		if operation.IfMatch != "" {
+			var err error
+			if expectedVersion, err = model.ParseIfMatch(operation.IfMatch); err != nil {
+				return preparedOperation{}, err
+			}
+		}
+		existingEntity, err := getExpectedCashFlow(operation.Id, expectedVersion)
+		if err != nil {
+			return preparedOperation{}, err
+		}
+		return preparedOperation{index: index, op: operation.Op, before: existingEntity}, nil
+
+	default:
+		return preparedOperation{}, validation.NewValidationError("op", "must be create, update or delete")
+	}
+}
+
+func getExpectedCashFlow(plainId string, expectedVersion int64) (model.CashFlowEntity, error) {
+	existingEntity := cash_flow_mapper.INSTANCE.GetCashFlowByObjectId(plainId)
+	if existingEntity.IsEmpty() {
+		return model.CashFlowEntity{}, errors.NewNotFoundError("cash_flow not found")
+	}
+	if expectedVersion != model.AnyVersion && existingEntity.Version != expectedVersion {
+		return model.CashFlowEntity{}, errors.NewPreconditionFailedError(
+			"cash_flow was changed since version " + strconv.FormatInt(expectedVersion, 10) + ", fetch it again")
+	}
+	return existingEntity, nil
+}
+
+// applyOperations writes the prepared operations and fills in their results
+// Creates go in one bulk insert; returns the operations written, holding the stored entity in after
+func applyOperations(preparedList []preparedOperation, resultList []model.BatchResult) ([]preparedOperation, bool) {
+	appliedList := make([]preparedOperation, 0, len(preparedList))
+	failed := false
+
+	createList := make([]preparedOperation, 0, len(preparedList))
+	newEntityList := make([]model.CashFlowEntity, 0, len(preparedList))
+	for _, prepared := range preparedList {
+		if prepared.op == model.BatchOpCreate {
+			createList = append(createList, prepared)
+			newEntityList = append(newEntityList, prepared.after)
+		}
+	}
+	if len(createList) > 0 {
+		newIdList, err := cash_flow_mapper.INSTANCE.BulkInsertCashFlows(newEntityList)
+		if err != nil || len(newIdList) != len(createList) {
+			failed = true
+			for _, prepared := range createList {
+				setFailure(&resultList[prepared.index], errors.NewDatabaseError("cash_flow create failed", err))
+			}
+		} else {
+			for i, prepared := range createList {
+				prepared.after = cash_flow_mapper.INSTANCE.GetCashFlowByObjectId(newIdList[i])
+				if prepared.after.IsEmpty() {
+					prepared.after.Id = util.Convert2ObjectId(newIdList[i])
+				}
+				setSuccess(&resultList[prepared.index], http.StatusCreated, prepared.after)
+				appliedList = append(appliedList, prepared)
+			}
+		}
+	}
+
+	for _, prepared := range preparedList {
+		switch prepared.op {
+		case model.BatchOpUpdate:
+			if len(revision_service.Diff(revision_service.CashFlowFields(prepared.before), revision_service.CashFlowFields(prepared.after))) == 0 {
+				setSuccess(&resultList[prepared.index], http.StatusOK, prepared.before)
+				continue
+			}
+			prepared.after.ModifyTime = time.Now().UTC() // Store in UTC
+			updatedEntity := cash_flow_mapper.INSTANCE.UpdateCashFlowByEntity(prepared.before.Id.Hex(), prepared.after)
+			if updatedEntity.IsEmpty() {
+				failed = true
+				setFailure(&resultList[prepared.index], errors.NewPreconditionFailedError("cash_flow was changed or deleted meanwhile, fetch it again"))
+				continue
+			}
+			prepared.after = updatedEntity
+		case model.BatchOpDelete:
+			deletedEntity := cash_flow_mapper.INSTANCE.SoftDeleteCashFlowByObjectId(prepared.before.Id.Hex())
+			if deletedEntity.IsEmpty() {
+				failed = true
+				setFailure(&resultList[prepared.index], errors.NewNotFoundError("cash_flow was deleted meanwhile"))
+				continue
+			}
+			prepared.after = deletedEntity
+		default:
+			continue
+		}
+		setSuccess(&resultList[prepared.index], http.StatusOK, prepared.after)
+		appliedList = append(appliedList, prepared)
+	}
+	return appliedList, failed
+}
+
+// undoOperations reverts written operations of an atomic batch, newest first
+// Created cash flows are removed for good, updated ones get their fields back and deleted ones leave the trash
+func undoOperations(appliedList []preparedOperation) {
+	for i := len(appliedList) - 1; i >= 0; i-- {
+		applied := appliedList[i]
+		plainId := applied.after.Id.Hex()
+		undone := false
+		switch applied.op {
+		case model.BatchOpCreate:
+			undone = !cash_flow_mapper.INSTANCE.DeleteCashFlowByObjectId(plainId).IsEmpty()
+		case model.BatchOpUpdate:
+			restoredEntity := applied.before
+			restoredEntity.Version = applied.after.Version
+			undone = !cash_flow_mapper.INSTANCE.UpdateCashFlowByEntity(plainId, restoredEntity).IsEmpty()
+		case model.BatchOpDelete:
+			undone = !cash_flow_mapper.INSTANCE.RestoreDeletedCashFlowByObjectId(plainId).IsEmpty()
+		}
+		if !undone {
+			util.Logger.Errorw("undo of atomic batch operation failed", "op", applied.op, "id", plainId)
+		}
+	}
+}
+
+// recordBatchChanges writes the revisions and tombstones of a batch once it is kept
+func recordBatchChanges(appliedList []preparedOperation, origin model.ChangeOrigin) {
+	deletedList := make([]model.CashFlowEntity, 0)
+	for _, applied := range appliedList {
+		switch applied.op {
+		case model.BatchOpCreate:
+			revision_service.RecordChange(model.RevisionTypeCashFlow, applied.after.Id, model.RevisionActionCreate,
+				nil, revision_service.CashFlowFields(applied.after), origin)
+		case model.BatchOpUpdate:
+			revision_service.RecordChange(model.RevisionTypeCashFlow, applied.after.Id, model.RevisionActionUpdate,
+				revision_service.CashFlowFields(applied.before), revision_service.CashFlowFields(applied.after), origin)
+		case model.BatchOpDelete:
+			deletedList = append(deletedList, applied.after)
+		}
+	}
+	if len(deletedList) > 0 {
+		recordTombstones(deletedList)
+		recordDeletions(deletedList, origin)
+	}
+}
+
+// splitDebtPayments splits the created payments to debts like single creates do once a batch is kept,
+// oldest first whatever the order of the batch, so the results carry the version after the split
+func splitDebtPayments(appliedList []preparedOperation, resultList []model.BatchResult, origin model.ChangeOrigin) {
+	var createdList []preparedOperation
+	var cashFlowList []model.CashFlowEntity
+	for _, applied := range appliedList {
+		if applied.op == model.BatchOpCreate {
+			createdList = append(createdList, applied)
+			cashFlowList = append(cashFlowList, applied.after)
+		}
+	}
+	for i, splitEntity := range debt_service.SplitPayments(cashFlowList, origin) {
+		resultList[createdList[i].index].Version = splitEntity.Version
+	}
+}
+
+// abortRemaining marks every operation of an atomic batch that has not failed itself as aborted
+func abortRemaining(response *model.BatchResponse, message string) {
+	for index := range response.Results {
+		result := &response.Results[index]
+		if result.Error != nil {
+			continue
+		}
+		result.Id = ""
+		result.Version = 0
+		setFailure(result, errors.NewBatchAbortedError(message))
+	}
+	response.Applied = false
+}
+
+func setSuccess(result *model.BatchResult, status int, entity model.CashFlowEntity) {
+	result.Status = status
+	result.Id = entity.Id.Hex()
+	result.Version = entity.Version
+	result.Error = nil
+}
+
+func setFailure(result *model.BatchResult, err error) {
+	result.Status = util.GetErrorStatus(err)
+	result.Error = util.NewErrorInfo(err)
+}
+
+func countResults(response model.BatchResponse) model.BatchResponse {
+	for _, result := range response.Results {
+		if result.Error == nil {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	return response
+}
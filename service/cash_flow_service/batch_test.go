@@ -0,0 +1,70 @@
+package cash_flow_service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+func TestExecuteBatch_Size(t *testing.T) {
+	origin := model.NewChangeOrigin(model.ChangeSourceCLI, "tester")
+	if _, err := ExecuteBatch(model.BatchRequest{}, origin); err == nil {
+		t.Error("expected an empty batch to be refused")
+	}
+
+	tooMany := model.BatchRequest{Operations: make([]model.BatchOperation, GetBatchMaxSize()+1)}
+	if _, err := ExecuteBatch(tooMany, origin); util.GetErrorStatus(err) != http.StatusBadRequest {
+		t.Errorf("expected 400 for a batch over the limit, got %v", err)
+	}
+}
+
+func TestPrepareOperation_Invalid(t *testing.T) {
+	const plainId = "507f1f77bcf86cd799439011"
+	tests := []struct {
+		name      string
+		operation model.BatchOperation
+		seenIdMap map[string]int
+	}{
+		{"Unknown op", model.BatchOperation{Op: "upsert", Id: plainId}, map[string]int{}},
+		{"Missing id", model.BatchOperation{Op: model.BatchOpDelete}, map[string]int{}},
+		{"Invalid id", model.BatchOperation{Op: model.BatchOpUpdate, Id: "42"}, map[string]int{}},
+		{"Repeated id", model.BatchOperation{Op: model.BatchOpDelete, Id: plainId}, map[string]int{plainId: 0}},
+		{"Unknown create type", model.BatchOperation{Op: model.BatchOpCreate, Type: "transfer"}, map[string]int{}},
+		{"Update without if_match", model.BatchOperation{Op: model.BatchOpUpdate, Id: plainId}, map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := prepareOperation(1, tt.operation, tt.seenIdMap); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestAbortRemaining(t *testing.T) {
+	response := model.BatchResponse{Atomic: true, Applied: true, Results: []model.BatchResult{
+		{Index: 0, Op: model.BatchOpCreate, Status: http.StatusCreated, Id: "507f1f77bcf86cd799439011"},
+		{Index: 1, Op: model.BatchOpDelete},
+	}}
+	setFailure(&response.Results[1], errors.NewNotFoundError("cash_flow not found"))
+
+	abortRemaining(&response, "not applied")
+	response = countResults(response)
+
+	if response.Applied {
+		t.Error("expected an aborted batch not to be applied")
+	}
+	if response.Succeeded != 0 || response.Failed != 2 {
+		t.Errorf("expected 0 succeeded and 2 failed, got %d and %d", response.Succeeded, response.Failed)
+	}
+	if first := response.Results[0]; first.Status != http.StatusFailedDependency || first.Id != "" {
+		t.Errorf("expected the create to be aborted with 424, got %d %s", first.Status, first.Id)
+	}
+	if second := response.Results[1]; second.Status != http.StatusNotFound {
+		t.Errorf("expected the failed delete to keep its 404, got %d", second.Status)
+	}
+}
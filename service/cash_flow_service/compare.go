@@ -0,0 +1,201 @@
+package cash_flow_service
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// CompareAgainstPrevious compares with the period right before
+	CompareAgainstPrevious = "prev"
+	// CompareAgainstYearOverYear compares with the same period a year earlier
+	CompareAgainstYearOverYear = "yoy"
+
+	// MaxMovers is how many of the biggest category changes are flagged as movers
+	MaxMovers = 3
+)
+
+// comparePeriodMap takes the period names of the reports API and those of GetSummary
+var comparePeriodMap = map[string]string{
+	"day":     "daily",
+	"daily":   "daily",
+	"month":   "monthly",
+	"monthly": "monthly",
+	"year":    "yearly",
+	"yearly":  "yearly",
+}
+
+// reportPeriodMap names the periods of GetSummary the way the reports API does
+var reportPeriodMap = map[string]string{
+	"daily":   "day",
+	"monthly": "month",
+	"yearly":  "year",
+}
+
+// Compare summarizes a period and compares it with each baseline in againstList,
+// per category for income and expense. Period is day, month or year (or daily, monthly,
+// yearly), date takes the format GetSummary expects for it; againstList holds prev and yoy,
+// both when empty.
+func Compare(period, date string, againstList []string) (model.ComparisonReport, error) {
+	summaryPeriod, ok := comparePeriodMap[strings.ToLower(period)]
+	if !ok {
+		return model.ComparisonReport{}, errors.NewFieldValidationError("period", "period must be day, month or year")
+	}
+	againstList, err := normalizeAgainst(againstList)
+	if err != nil {
+		return model.ComparisonReport{}, err
+	}
+	fromDate, toDate, err := summaryRange(summaryPeriod, date)
+	if err != nil {
+		return model.ComparisonReport{}, err
+	}
+
+	categoryName := categoryNameLookup()
+	current := summarize(fromDate, toDate, categoryName)
+	report := model.ComparisonReport{
+		Period:      reportPeriodMap[summaryPeriod],
+		Current:     periodTotals(summaryPeriod, fromDate, toDate, current),
+		Comparisons: []model.PeriodComparison{},
+	}
+	for _, against := range againstList {
+		baselineFrom := baselineStart(summaryPeriod, fromDate, against)
+		baselineTo := nextPeriodStart(summaryPeriod, baselineFrom).AddDate(0, 0, -1)
+		baseline := summarize(baselineFrom, baselineTo, categoryName)
+		comparison := compareSummaries(current, baseline)
+		comparison.Against = against
+		comparison.Baseline = periodTotals(summaryPeriod, baselineFrom, baselineTo, baseline)
+		report.Comparisons = append(report.Comparisons, comparison)
+	}
+	return report, nil
+}
+
+// normalizeAgainst checks the baselines, dropping repeats and empty entries
+func normalizeAgainst(againstList []string) ([]string, error) {
+	var normalizedList []string
+	for _, against := range againstList {
+		against = strings.ToLower(strings.TrimSpace(against))
+		if against == "" {
+			continue
+		}
+		if against != CompareAgainstPrevious && against != CompareAgainstYearOverYear {
+			return nil, errors.NewFieldValidationError("against", "against must be prev or yoy, got "+against)
+		}
+		if !slices.Contains(normalizedList, against) {
+			normalizedList = append(normalizedList, against)
+		}
+	}
+	if len(normalizedList) == 0 {
+		return []string{CompareAgainstPrevious, CompareAgainstYearOverYear}, nil
+	}
+	return normalizedList, nil
+}
+
+// nextPeriodStart returns the first day of the period after the one starting at fromDate
+func nextPeriodStart(summaryPeriod string, fromDate time.Time) time.Time {
+	switch summaryPeriod {
+	case "daily":
+		return fromDate.AddDate(0, 0, 1)
+	case "monthly":
+		return fromDate.AddDate(0, 1, 0)
+	default:
+		return fromDate.AddDate(1, 0, 0)
+	}
+}
+
+// baselineStart returns the first day of the period to compare the one starting at fromDate with
+func baselineStart(summaryPeriod string, fromDate time.Time, against string) time.Time {
+	if against == CompareAgainstYearOverYear {
+		return fromDate.AddDate(-1, 0, 0)
+	}
+	switch summaryPeriod {
+	case "daily":
+		return fromDate.AddDate(0, 0, -1)
+	case "monthly":
+		return fromDate.AddDate(0, -1, 0)
+	default:
+		return fromDate.AddDate(-1, 0, 0)
+	}
+}
+
+func periodTotals(summaryPeriod string, fromDate, toDate time.Time, summary *Summary) model.PeriodTotals {
+	name := fromDate.Format("2006")
+	switch summaryPeriod {
+	case "daily":
+		name = util.FormatDateToStringWithDash(fromDate)
+	case "monthly":
+		name = fromDate.Format("2006-01")
+	}
+	return model.PeriodTotals{
+		Name:    name,
+		From:    util.FormatDateToStringWithDash(fromDate),
+		To:      util.FormatDateToStringWithDash(toDate),
+		Income:  summary.TotalIncome,
+		Expense: summary.TotalExpense,
+		Balance: summary.Balance,
+		Count:   summary.TransactionCount,
+	}
+}
+
+// compareSummaries returns the changes from baseline to current, with the categories of both
+// ordered by the size of their change and the biggest MaxMovers changes flagged
+func compareSummaries(current, baseline *Summary) model.PeriodComparison {
+	comparison := model.PeriodComparison{
+		Income:  newAmountDelta(current.TotalIncome, baseline.TotalIncome),
+		Expense: newAmountDelta(current.TotalExpense, baseline.TotalExpense),
+		Balance: newAmountDelta(current.Balance, baseline.Balance),
+	}
+	comparison.Categories = append(append([]model.CategoryDelta{},
+		categoryDeltas(model.FlowTypeIncome, current.IncomeBreakdown, baseline.IncomeBreakdown)...),
+		categoryDeltas(model.FlowTypeOutcome, current.ExpenseBreakdown, baseline.ExpenseBreakdown)...)
+
+	sort.SliceStable(comparison.Categories, func(i, j int) bool {
+		left, right := comparison.Categories[i], comparison.Categories[j]
+		if order := left.Change.Abs().Cmp(right.Change.Abs()); order != 0 {
+			return order > 0
+		}
+		if left.Type != right.Type {
+			return left.Type < right.Type
+		}
+		return left.Category < right.Category
+	})
+	for index := range comparison.Categories {
+		if index >= MaxMovers || comparison.Categories[index].Change.IsZero() {
+			break
+		}
+		comparison.Categories[index].Mover = true
+	}
+	return comparison
+}
+
+func categoryDeltas(flowType string, currentMap, baselineMap map[string]model.Money) []model.CategoryDelta {
+	var deltaList []model.CategoryDelta
+	for name, amount := range currentMap {
+		deltaList = append(deltaList, model.CategoryDelta{
+			Category: name, Type: flowType, AmountDelta: newAmountDelta(amount, baselineMap[name]),
+		})
+	}
+	for name, amount := range baselineMap {
+		if _, ok := currentMap[name]; !ok {
+			deltaList = append(deltaList, model.CategoryDelta{
+				Category: name, Type: flowType, AmountDelta: newAmountDelta(model.Money{}, amount),
+			})
+		}
+	}
+	return deltaList
+}
+
+func newAmountDelta(current, baseline model.Money) model.AmountDelta {
+	delta := model.AmountDelta{Current: current, Baseline: baseline, Change: current.Sub(baseline)}
+	if !baseline.IsZero() {
+		percent := delta.Change.Decimal().Div(baseline.Abs().Decimal()).Mul(decimal.NewFromInt(100)).Round(1).InexactFloat64()
+		delta.Percent = &percent
+	}
+	return delta
+}
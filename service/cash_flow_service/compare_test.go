@@ -0,0 +1,106 @@
+package cash_flow_service
+
+import (
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newSummary(nameMap map[primitive.ObjectID]string, cashFlowList ...model.CashFlowEntity) *Summary {
+	return summarizeCashFlows(cashFlowList, func(categoryId primitive.ObjectID) string {
+		return nameMap[categoryId]
+	})
+}
+
+func TestCompareSummaries(t *testing.T) {
+	food, rent, salary, gone := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	nameMap := map[primitive.ObjectID]string{food: "Food", rent: "Rent", salary: "Salary"}
+
+	current := newSummary(nameMap,
+		testutil.NewCashFlow(food, "2026-09-01", model.FlowTypeOutcome, "", 700),
+		testutil.NewCashFlow(rent, "2026-09-01", model.FlowTypeOutcome, "", 1000),
+		testutil.NewCashFlow(salary, "2026-09-01", model.FlowTypeIncome, "", 3000),
+		testutil.NewCashFlow(gone, "2026-09-01", model.FlowTypeOutcome, "", 5))
+	baseline := newSummary(nameMap,
+		testutil.NewCashFlow(food, "2026-09-01", model.FlowTypeOutcome, "", 500),
+		testutil.NewCashFlow(rent, "2026-09-01", model.FlowTypeOutcome, "", 1000),
+		testutil.NewCashFlow(salary, "2026-09-01", model.FlowTypeIncome, "", 2000),
+		testutil.NewCashFlow(food, "2026-09-01", model.FlowTypeIncome, "", 30))
+
+	if current.TotalExpense.String() != "1705.00" || len(current.ExpenseBreakdown) != 2 {
+		t.Fatalf("expected uncategorized cash flows in the totals only, got %s and %v", current.TotalExpense, current.ExpenseBreakdown)
+	}
+
+	comparison := compareSummaries(current, baseline)
+	if comparison.Income.Change.String() != "970.00" || *comparison.Income.Percent != 47.8 {
+		t.Errorf("unexpected income change %s, %v%%", comparison.Income.Change, *comparison.Income.Percent)
+	}
+	if comparison.Balance.Current.String() != "1295.00" || comparison.Balance.Baseline.String() != "530.00" {
+		t.Errorf("unexpected balance %+v", comparison.Balance)
+	}
+
+	expectedOrder := []string{"INCOME Salary", "OUTCOME Food", "INCOME Food", "OUTCOME Rent"}
+	if len(comparison.Categories) != len(expectedOrder) {
+		t.Fatalf("expected %d categories, got %+v", len(expectedOrder), comparison.Categories)
+	}
+	for index, category := range comparison.Categories {
+		if category.Type+" "+category.Category != expectedOrder[index] {
+			t.Errorf("expected %s at %d, got %s %s", expectedOrder[index], index, category.Type, category.Category)
+		}
+	}
+	if !comparison.Categories[0].Mover || !comparison.Categories[2].Mover || comparison.Categories[3].Mover {
+		t.Error("expected the three changed categories to be movers and the unchanged one not")
+	}
+	if *comparison.Categories[2].Percent != -100 || comparison.Categories[2].Current.String() != "0.00" {
+		t.Errorf("expected a category gone from the period to drop by 100%%, got %+v", comparison.Categories[2].AmountDelta)
+	}
+	if newAmountDelta(model.NewMoneyFromInt(5), model.Money{}).Percent != nil {
+		t.Error("expected no percentage against a zero baseline")
+	}
+}
+
+func TestBaselineStart(t *testing.T) {
+	testCases := []struct {
+		period, against, from, expected string
+	}{
+		{"monthly", CompareAgainstPrevious, "2026-09-01", "2026-08-01"},
+		{"monthly", CompareAgainstPrevious, "2026-01-01", "2025-12-01"},
+		{"monthly", CompareAgainstYearOverYear, "2026-09-01", "2025-09-01"},
+		{"daily", CompareAgainstPrevious, "2026-03-01", "2026-02-28"},
+		{"daily", CompareAgainstYearOverYear, "2026-03-01", "2025-03-01"},
+		{"yearly", CompareAgainstPrevious, "2026-01-01", "2025-01-01"},
+	}
+	for _, testCase := range testCases {
+		actual := baselineStart(testCase.period, testutil.Day(testCase.from), testCase.against)
+		if !actual.Equal(testutil.Day(testCase.expected)) {
+			t.Errorf("%s %s of %s: expected %s, got %s", testCase.period, testCase.against, testCase.from, testCase.expected, actual)
+		}
+	}
+	if end := nextPeriodStart("monthly", testutil.Day("2026-02-01")).AddDate(0, 0, -1); !end.Equal(testutil.Day("2026-02-28")) {
+		t.Errorf("expected the end of February, got %s", end)
+	}
+}
+
+func TestCompare_InvalidParameters(t *testing.T) {
+	testCases := []struct {
+		period, date string
+		againstList  []string
+	}{
+		{"week", "2026-09", nil},
+		{"month", "2026-09", []string{"prev", "lastyear"}},
+		{"month", "", nil},
+		{"year", "26", nil},
+	}
+	for _, testCase := range testCases {
+		if _, err := Compare(testCase.period, testCase.date, testCase.againstList); err == nil {
+			t.Errorf("%+v: expected an error", testCase)
+		}
+	}
+
+	againstList, err := normalizeAgainst([]string{" YOY", "", "yoy"})
+	if err != nil || len(againstList) != 1 || againstList[0] != CompareAgainstYearOverYear {
+		t.Errorf("expected only yoy, got %v, %v", againstList, err)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/tombstone_mapper"
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/macar-x/cashlenx-server/validation"
 )
@@ -32,7 +34,8 @@ func IsDeleteFieldsConflicted(plainId, belongsDate string) bool {
 	return !semiOptionalFieldFilledFlag
 }
 
-func DeleteById(plainId string) (model.CashFlowEntity, error) {
+// DeleteById moves a cash flow to the trash; see trash.go to list, restore or purge it
+func DeleteById(plainId string, origin model.ChangeOrigin) (model.CashFlowEntity, error) {
 	// Validate ID
 	if err := validation.ValidateID(plainId); err != nil {
 		return model.CashFlowEntity{}, err
@@ -43,14 +46,17 @@ func DeleteById(plainId string) (model.CashFlowEntity, error) {
 		return model.CashFlowEntity{}, errors.New("cash_flow not found")
 	}
 
-	existCashFlowEntity = cash_flow_mapper.INSTANCE.DeleteCashFlowByObjectId(plainId)
+	existCashFlowEntity = cash_flow_mapper.INSTANCE.SoftDeleteCashFlowByObjectId(plainId)
 	if existCashFlowEntity.IsEmpty() {
 		return model.CashFlowEntity{}, errors.New("cash_flow delete failed")
 	}
+	recordTombstones([]model.CashFlowEntity{existCashFlowEntity})
+	recordDeletions([]model.CashFlowEntity{existCashFlowEntity}, origin)
 	return existCashFlowEntity, nil
 }
 
-func DeleteByDate(belongsDate string) ([]model.CashFlowEntity, error) {
+// DeleteByDate moves every cash flow of a day to the trash
+func DeleteByDate(belongsDate string, origin model.ChangeOrigin) ([]model.CashFlowEntity, error) {
 	// Validate date
 	if err := validation.ValidateDate(belongsDate); err != nil {
 		return []model.CashFlowEntity{}, err
@@ -61,6 +67,27 @@ func DeleteByDate(belongsDate string) ([]model.CashFlowEntity, error) {
 		return []model.CashFlowEntity{}, errors.New("belongs_date error, try format like 19700101")
 	}
 
-	cashFlowList := cash_flow_mapper.INSTANCE.DeleteCashFlowByBelongsDate(deleteDate)
+	cashFlowList := cash_flow_mapper.INSTANCE.SoftDeleteCashFlowsByBelongsDate(deleteDate)
+	recordTombstones(cashFlowList)
+	recordDeletions(cashFlowList, origin)
 	return cashFlowList, nil
 }
+
+// recordTombstones remembers deleted cash flows so incremental backups can replay the deletion
+// Backups only hold cash flows outside the trash, so moving one to the trash counts as a deletion
+func recordTombstones(deletedList []model.CashFlowEntity) {
+	tombstoneList := make([]model.TombstoneEntity, 0, len(deletedList))
+	for _, entity := range deletedList {
+		tombstoneList = append(tombstoneList, model.NewTombstone(model.TombstoneTypeCashFlow, entity.Id))
+	}
+	if err := tombstone_mapper.INSTANCE.InsertTombstones(tombstoneList); err != nil {
+		util.Logger.Errorw("record cash_flow tombstones failed, take a full backup", "error", err)
+	}
+}
+
+// recordDeletions adds a delete revision to the history of each cash flow moved to the trash
+func recordDeletions(deletedList []model.CashFlowEntity, origin model.ChangeOrigin) {
+	for _, entity := range deletedList {
+		revision_service.RecordChange(model.RevisionTypeCashFlow, entity.Id, model.RevisionActionDelete, nil, nil, origin)
+	}
+}
@@ -0,0 +1,95 @@
+package cash_flow_service
+
+import (
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetHistory returns the revisions of a cash flow, oldest first
+// The history stays available while the cash flow is in the trash and after it is purged
+func GetHistory(plainId string) ([]model.RevisionEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return nil, err
+	}
+	return revision_service.GetHistory(model.RevisionTypeCashFlow, plainId)
+}
+
+// RevertToRevision sets the fields of a cash flow back to how they were right after the given revision
+// The revert is recorded as a revision of its own, so it can be reverted in turn
+func RevertToRevision(plainId, plainRevisionId string, origin model.ChangeOrigin) (model.CashFlowEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return model.CashFlowEntity{}, err
+	}
+	if err := validation.ValidateID(plainRevisionId); err != nil {
+		return model.CashFlowEntity{}, err
+	}
+
+	existingEntity := cash_flow_mapper.INSTANCE.GetCashFlowByObjectId(plainId)
+	if existingEntity.IsEmpty() {
+		if !cash_flow_mapper.INSTANCE.GetDeletedCashFlowByObjectId(plainId).IsEmpty() {
+			return model.CashFlowEntity{}, errors.NewInvalidInputError("cash_flow is in the trash, restore it before reverting")
+		}
+		return model.CashFlowEntity{}, errors.NewNotFoundError("cash_flow not found")
+	}
+
+	history, err := revision_service.GetHistory(model.RevisionTypeCashFlow, plainId)
+	if err != nil {
+		return model.CashFlowEntity{}, err
+	}
+	currentFields := revision_service.CashFlowFields(existingEntity)
+	targetFields, err := revision_service.StateAt(currentFields, history, plainRevisionId)
+	if err != nil {
+		return model.CashFlowEntity{}, err
+	}
+	if len(revision_service.Diff(currentFields, targetFields)) == 0 {
+		return existingEntity, nil
+	}
+
+	revertedEntity, err := applyCashFlowFields(existingEntity, targetFields)
+	if err != nil {
+		return model.CashFlowEntity{}, err
+	}
+	updatedEntity := cash_flow_mapper.INSTANCE.UpdateCashFlowByEntity(plainId, revertedEntity)
+	if updatedEntity.IsEmpty() {
+		return model.CashFlowEntity{}, errors.NewPreconditionFailedError("cash_flow was changed or deleted meanwhile, try the revert again")
+	}
+	revision_service.RecordRevert(model.RevisionTypeCashFlow, updatedEntity.Id, plainRevisionId,
+		currentFields, revision_service.CashFlowFields(updatedEntity), origin)
+	return updatedEntity, nil
+}
+
+// applyCashFlowFields is the reverse of revision_service.CashFlowFields
+func applyCashFlowFields(entity model.CashFlowEntity, fields map[string]string) (model.CashFlowEntity, error) {
+	belongsDate := util.FormatDateFromStringWithDash(fields["belongs_date"])
+	if util.IsDateTimeEmpty(belongsDate) {
+		return model.CashFlowEntity{}, errors.NewInvalidInputError("revision has an invalid belongs_date: " + fields["belongs_date"])
+	}
+
+	categoryId, err := primitive.ObjectIDFromHex(fields["category_id"])
+	if err != nil {
+		return model.CashFlowEntity{}, errors.NewInvalidInputError("revision has an invalid category_id: " + fields["category_id"])
+	}
+	if category_mapper.INSTANCE.GetCategoryByObjectId(categoryId.Hex()).IsEmpty() {
+		return model.CashFlowEntity{}, errors.NewInvalidInputError(
+			"category " + categoryId.Hex() + " no longer exists, recreate it before reverting")
+	}
+
+	amount, err := model.NewMoneyFromString(fields["amount"])
+	if err != nil {
+		return model.CashFlowEntity{}, errors.NewInvalidInputError("revision has an invalid amount: " + fields["amount"])
+	}
+
+	entity.BelongsDate = belongsDate
+	entity.CategoryId = categoryId
+	entity.FlowType = fields["flow_type"]
+	entity.Amount = amount
+	entity.Description = fields["description"]
+	entity.Remark = fields["remark"]
+	return entity, nil
+}
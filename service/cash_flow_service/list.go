@@ -4,9 +4,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/macar-x/cashlenx-server/errors"
 	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
 )
 
 // QueryAll queries all cash flows with optional filtering and pagination
@@ -87,3 +90,82 @@ func QueryAll(
 
 	return filteredResults, totalCount, nil
 }
+
+// ListPage lists one page of cash flows matching filter, or all of them when it is nil
+// The page is sorted, narrowed and found by cursor or offset as request says
+func ListPage(filter query.Node, request query.PageRequest) (query.Page, error) {
+	var totalCount int64
+	if filter == nil {
+		totalCount = cash_flow_mapper.INSTANCE.CountAllCashFlows()
+	} else {
+		var err error
+		if totalCount, err = cash_flow_mapper.INSTANCE.CountSearchCashFlows(filter); err != nil {
+			return query.Page{}, errors.NewDatabaseError("cash_flow count failed", err)
+		}
+	}
+
+	cashFlowList, err := cash_flow_mapper.INSTANCE.GetCashFlowsPage(filter, request)
+	if err != nil {
+		return query.Page{}, errors.NewDatabaseError("cash_flow query failed", err)
+	}
+	rowList := make([]interface{}, 0, len(cashFlowList))
+	for _, cashFlow := range cashFlowList {
+		rowList = append(rowList, cashFlow)
+	}
+	return query.NewPage(request, rowList, totalCount)
+}
+
+// BuildListFilter turns the filter parameters of the cash flow list into a query, nil when none is set
+// cashType takes income, expense or the stored INCOME and OUTCOME; dates include both ends
+func BuildListFilter(cashType, categoryId, description, exactDescription, fromDateStr, toDateStr string) (query.Node, error) {
+	var termList []query.Node
+	if cashType != "" {
+		switch strings.ToLower(cashType) {
+		case "income":
+			termList = append(termList, &query.Term{Field: query.FieldType, Op: query.OpEqual, Value: "income"})
+		case "expense", "outcome":
+			termList = append(termList, &query.Term{Field: query.FieldType, Op: query.OpEqual, Value: "expense"})
+		default:
+			return nil, errors.NewFieldValidationError("type", "must be income or expense")
+		}
+	}
+	if categoryId != "" {
+		if err := validation.ValidateID(categoryId); err != nil {
+			return nil, err
+		}
+		termList = append(termList, &query.Term{Field: query.FieldCategory, Op: query.OpEqual, Value: categoryId,
+			CategoryIdList: []string{categoryId}})
+	}
+	if exactDescription != "" {
+		termList = append(termList, &query.Term{Field: query.FieldDescription, Op: query.OpEqual, Value: exactDescription})
+	} else if description != "" {
+		termList = append(termList, &query.Term{Field: query.FieldDescription, Op: query.OpContains, Value: description})
+	}
+	for _, bound := range []struct {
+		text  string
+		field string
+		op    string
+	}{{fromDateStr, "from_date", query.OpGreaterEqual}, {toDateStr, "to_date", query.OpLess}} {
+		if bound.text == "" {
+			continue
+		}
+		parsedDate, err := util.ParseDate(bound.text)
+		if err != nil {
+			return nil, errors.NewFieldValidationError(bound.field, "try format like 19700101, 1970-01-01, or 1970/01/01")
+		}
+		day := time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), 0, 0, 0, 0, time.UTC)
+		if bound.op == query.OpLess {
+			day = day.AddDate(0, 0, 1)
+		}
+		termList = append(termList, &query.Term{Field: query.FieldDate, Op: bound.op, Value: day})
+	}
+
+	switch len(termList) {
+	case 0:
+		return nil, nil
+	case 1:
+		return termList[0], nil
+	default:
+		return &query.And{Children: termList}, nil
+	}
+}
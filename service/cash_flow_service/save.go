@@ -0,0 +1,88 @@
+package cash_flow_service
+
+import (
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+)
+
+// saveCashFlow validates and inserts a new cash flow of the given flow type
+// A payment to a debt comes back as its principal part, the interest split off
+func saveCashFlow(flowType, belongsDate, categoryName string, amount model.Money, description string, origin model.ChangeOrigin) (model.CashFlowEntity, error) {
+	newEntity, err := buildCashFlow(flowType, belongsDate, categoryName, amount, description)
+	if err != nil {
+		return model.CashFlowEntity{}, err
+	}
+
+	newCashFlowId := cash_flow_mapper.INSTANCE.InsertCashFlowByEntity(newEntity)
+	if newCashFlowId == "" {
+		return model.CashFlowEntity{}, errors.NewDatabaseError("cash_flow create failed", nil)
+	}
+
+	newCashFlow := cash_flow_mapper.INSTANCE.GetCashFlowByObjectId(newCashFlowId)
+	revision_service.RecordChange(model.RevisionTypeCashFlow, newCashFlow.Id, model.RevisionActionCreate,
+		nil, revision_service.CashFlowFields(newCashFlow), origin)
+	return debt_service.SplitPayment(newCashFlow, origin), nil
+}
+
+// buildCashFlow validates the fields of a new cash flow and returns it ready to insert
+func buildCashFlow(flowType, belongsDate, categoryName string, amount model.Money, description string) (model.CashFlowEntity, error) {
+	// Validate inputs
+	if err := validation.ValidateCategoryName(categoryName); err != nil {
+		return model.CashFlowEntity{}, err
+	}
+
+	if err := validation.ValidateMoney(amount); err != nil {
+		return model.CashFlowEntity{}, err
+	}
+
+	if belongsDate != "" {
+		if err := validation.ValidateDate(belongsDate); err != nil {
+			return model.CashFlowEntity{}, err
+		}
+	}
+
+	if err := validation.ValidateDescription(description); err != nil {
+		return model.CashFlowEntity{}, err
+	}
+
+	// Round to 2 decimal places
+	amount = amount.Round(model.MoneyDisplayScale)
+
+	// Required parameter: category
+	categoryEntity := category_mapper.INSTANCE.GetCategoryByName(categoryName)
+	if categoryEntity.IsEmpty() {
+		return model.CashFlowEntity{}, errors.NewInvalidInputError("category does not exist")
+	}
+
+	// Optional parameter: date (default to today)
+	var date time.Time
+	if belongsDate != "" {
+		// Parse the provided date using our multi-format parser
+		parsedDate, err := util.ParseDate(belongsDate)
+		if err != nil {
+			return model.CashFlowEntity{}, errors.NewInvalidInputError("belongs_date error, try format like 19700101, 1970-01-01, or 1970/01/01")
+		}
+		// Use UTC time for consistent storage
+		date = time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), 0, 0, 0, 0, time.UTC)
+	} else {
+		// Use today's date in UTC
+		today := time.Now().UTC()
+		date = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	return model.CashFlowEntity{
+		CategoryId:  categoryEntity.Id,
+		BelongsDate: date,
+		FlowType:    flowType,
+		Amount:      amount,
+		Description: description,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package cash_flow_service
+
+import (
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
+)
+
+// Search returns the cash flows matching a query, newest first, with the number of matches in total
+// See query.Parse for the syntax; category patterns are resolved to the ids of the categories they match
+func Search(text string, limit, offset int) ([]model.CashFlowEntity, int64, error) {
+	node, err := query.Parse(text)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := resolveCategoryTerms(node); err != nil {
+		return nil, 0, err
+	}
+
+	totalCount, err := cash_flow_mapper.INSTANCE.CountSearchCashFlows(node)
+	if err != nil {
+		return nil, 0, errors.NewDatabaseError("cash_flow search failed", err)
+	}
+	cashFlowList, err := cash_flow_mapper.INSTANCE.SearchCashFlows(node, limit, offset)
+	if err != nil {
+		return nil, 0, errors.NewDatabaseError("cash_flow search failed", err)
+	}
+	if cashFlowList == nil {
+		cashFlowList = []model.CashFlowEntity{}
+	}
+	return cashFlowList, totalCount, nil
+}
+
+// resolveCategoryTerms fills in the category ids of every category term, failing on a pattern matching none
+func resolveCategoryTerms(node query.Node) error {
+	var categoryTermList []*query.Term
+	for _, term := range query.Terms(node) {
+		if term.Field == query.FieldCategory {
+			categoryTermList = append(categoryTermList, term)
+		}
+	}
+	if len(categoryTermList) == 0 {
+		return nil
+	}
+
+	fullPathMap := categoryFullPaths(category_mapper.INSTANCE.GetAllCategories(0, 0))
+	for _, term := range categoryTermList {
+		pattern := term.Value.(string)
+		for plainId, fullPath := range fullPathMap {
+			if query.CategoryPatternMatches(pattern, fullPath) {
+				term.CategoryIdList = append(term.CategoryIdList, plainId)
+			}
+		}
+		if len(term.CategoryIdList) == 0 {
+			return errors.NewFieldValidationError("q", "no category matches "+pattern)
+		}
+	}
+	return nil
+}
+
+// categoryFullPaths maps the id of every category to its names from the top down, joined with /
+func categoryFullPaths(categoryList []model.CategoryEntity) map[string]string {
+	categoryMap := make(map[string]model.CategoryEntity, len(categoryList))
+	for _, category := range categoryList {
+		categoryMap[category.Id.Hex()] = category
+	}
+
+	fullPathMap := make(map[string]string, len(categoryList))
+	for plainId, category := range categoryMap {
+		fullPath := category.Name
+		seenIdMap := map[string]bool{plainId: true}
+		for parent, ok := categoryMap[category.ParentId.Hex()]; ok && !seenIdMap[parent.Id.Hex()]; parent, ok = categoryMap[parent.ParentId.Hex()] {
+			seenIdMap[parent.Id.Hex()] = true
+			fullPath = parent.Name + "/" + fullPath
+		}
+		fullPathMap[plainId] = fullPath
+	}
+	return fullPathMap
+}
@@ -0,0 +1,48 @@
+package cash_flow_service
+
+import (
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+func TestCategoryFullPaths(t *testing.T) {
+	food := model.CategoryEntity{Id: util.Convert2ObjectId("65a000000000000000000001"), Name: "Food"}
+	coffee := model.CategoryEntity{Id: util.Convert2ObjectId("65a000000000000000000002"), ParentId: food.Id, Name: "Coffee"}
+	beans := model.CategoryEntity{Id: util.Convert2ObjectId("65a000000000000000000003"), ParentId: coffee.Id, Name: "Beans"}
+
+	fullPathMap := categoryFullPaths([]model.CategoryEntity{beans, food, coffee})
+	expected := map[string]string{
+		food.Id.Hex():   "Food",
+		coffee.Id.Hex(): "Food/Coffee",
+		beans.Id.Hex():  "Food/Coffee/Beans",
+	}
+	for plainId, fullPath := range expected {
+		if fullPathMap[plainId] != fullPath {
+			t.Errorf("%s: expected %q, got %q", plainId, fullPath, fullPathMap[plainId])
+		}
+	}
+}
+
+func TestSearch_InvalidQuery(t *testing.T) {
+	for _, text := range []string{"", "amount>abc", "(coffee"} {
+		if _, _, err := Search(text, 20, 0); err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
+
+func TestTextSearch_NoWords(t *testing.T) {
+	if _, err := TextSearch(" -- ", 20); err == nil {
+		t.Error("expected an error without words")
+	}
+}
+
+func TestHighlightCashFlow(t *testing.T) {
+	entity := model.CashFlowEntity{Description: "Two coffees", Remark: "with friends"}
+	highlightMap := highlightCashFlow(entity, []string{"coffe"}, "<mark>", "</mark>", true)
+	if len(highlightMap) != 1 || highlightMap["description"] != "Two <mark>coffees</mark>" {
+		t.Errorf("expected only the description highlighted, got %v", highlightMap)
+	}
+}
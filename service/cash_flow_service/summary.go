@@ -1,37 +1,40 @@
 package cash_flow_service
 
 import (
-	"errors"
 	"strings"
 	"time"
 
+	"github.com/macar-x/cashlenx-server/errors"
 	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
 	"github.com/macar-x/cashlenx-server/model"
 	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Summary represents financial summary data
 type Summary struct {
-	TotalIncome       float64
-	TotalExpense      float64
-	Balance           float64
+	TotalIncome       model.Money
+	TotalExpense      model.Money
+	Balance           model.Money
 	TransactionCount  int
-	CategoryBreakdown map[string]float64
+	CategoryBreakdown map[string]model.Money
+	// The breakdown split by flow type, so a category name can hold both
+	IncomeBreakdown  map[string]model.Money
+	ExpenseBreakdown map[string]model.Money
 }
 
 // GetSummary returns financial summary for a given period
 func GetSummary(period, date string) (*Summary, error) {
-	validPeriods := map[string]bool{
-		"daily":   true,
-		"monthly": true,
-		"yearly":  true,
-	}
-
-	if !validPeriods[period] {
-		return nil, errors.New("invalid period: must be daily, monthly, or yearly")
+	fromDate, toDate, err := summaryRange(period, date)
+	if err != nil {
+		return nil, err
 	}
+	return summarize(fromDate, toDate, categoryNameLookup()), nil
+}
 
+// summaryRange returns the first and last day of a daily, monthly or yearly period
+func summaryRange(period, date string) (time.Time, time.Time, error) {
 	var fromDate, toDate time.Time
 	var err error
 
@@ -41,58 +44,83 @@ func GetSummary(period, date string) (*Summary, error) {
 		// Date format: YYYY-MM-DD
 		fromDate = util.FormatDateFromStringWithoutDash(date)
 		if fromDate.IsZero() {
-			return nil, errors.New("invalid date format for daily, use YYYY-MM-DD")
+			return fromDate, toDate, errors.NewFieldValidationError("date", "invalid date format for daily, use YYYY-MM-DD")
 		}
 		toDate = fromDate
 	case "monthly":
 		// Date format: YYYY-MM
 		parts := strings.Split(date, "-")
 		if len(parts) != 2 {
-			return nil, errors.New("invalid date format for monthly, use YYYY-MM")
+			return fromDate, toDate, errors.NewFieldValidationError("date", "invalid date format for monthly, use YYYY-MM")
 		}
 		fromDate, err = time.Parse("2006-01", date)
 		if err != nil {
-			return nil, errors.New("invalid date format for monthly, use YYYY-MM")
+			return fromDate, toDate, errors.NewFieldValidationError("date", "invalid date format for monthly, use YYYY-MM")
 		}
 		toDate = fromDate.AddDate(0, 1, -1) // Last day of month
 	case "yearly":
 		// Date format: YYYY
 		fromDate, err = time.Parse("2006", date)
 		if err != nil {
-			return nil, errors.New("invalid date format for yearly, use YYYY")
+			return fromDate, toDate, errors.NewFieldValidationError("date", "invalid date format for yearly, use YYYY")
 		}
 		toDate = fromDate.AddDate(1, 0, -1) // Last day of year
+	default:
+		return fromDate, toDate, errors.NewFieldValidationError("period", "invalid period: must be daily, monthly, or yearly")
 	}
+	return fromDate, toDate, nil
+}
 
-	// Query transactions for period
-	summary := &Summary{
-		CategoryBreakdown: make(map[string]float64),
+// summarize adds up the cash flows from fromDate to toDate, both included
+func summarize(fromDate, toDate time.Time, categoryName func(primitive.ObjectID) string) *Summary {
+	return summarizeCashFlows(cash_flow_mapper.INSTANCE.GetCashFlowsByDateRange(fromDate, toDate), categoryName)
+}
+
+// categoryNameLookup returns a function naming categories by id, reading each category once
+func categoryNameLookup() func(primitive.ObjectID) string {
+	nameMap := make(map[primitive.ObjectID]string)
+	return func(categoryId primitive.ObjectID) string {
+		name, ok := nameMap[categoryId]
+		if !ok {
+			category := category_mapper.INSTANCE.GetCategoryByObjectId(categoryId.Hex())
+			if !category.IsEmpty() {
+				name = category.Name
+			}
+			nameMap[categoryId] = name
+		}
+		return name
 	}
+}
 
-	currentDate := fromDate
-	for !currentDate.After(toDate) {
-		dayResults := cash_flow_mapper.INSTANCE.GetCashFlowsByBelongsDate(currentDate)
+func summarizeCashFlows(cashFlowList []model.CashFlowEntity, categoryName func(primitive.ObjectID) string) *Summary {
+	summary := &Summary{
+		CategoryBreakdown: make(map[string]model.Money),
+		IncomeBreakdown:   make(map[string]model.Money),
+		ExpenseBreakdown:  make(map[string]model.Money),
+	}
 
-		for _, cashFlow := range dayResults {
-			summary.TransactionCount++
+	for _, cashFlow := range cashFlowList {
+		summary.TransactionCount++
 
-			if cashFlow.FlowType == model.FlowTypeIncome {
-				summary.TotalIncome += cashFlow.Amount
-			} else {
-				summary.TotalExpense += cashFlow.Amount
+		// Get category name for breakdown
+		name := categoryName(cashFlow.CategoryId)
+		if cashFlow.FlowType == model.FlowTypeIncome {
+			summary.TotalIncome = summary.TotalIncome.Add(cashFlow.Amount)
+			if name != "" {
+				summary.IncomeBreakdown[name] = summary.IncomeBreakdown[name].Add(cashFlow.Amount)
 			}
-
-			// Get category name for breakdown
-			category := category_mapper.INSTANCE.GetCategoryByObjectId(cashFlow.CategoryId.Hex())
-			if !category.IsEmpty() {
-				summary.CategoryBreakdown[category.Name] += cashFlow.Amount
+		} else {
+			summary.TotalExpense = summary.TotalExpense.Add(cashFlow.Amount)
+			if name != "" {
+				summary.ExpenseBreakdown[name] = summary.ExpenseBreakdown[name].Add(cashFlow.Amount)
 			}
 		}
-
-		currentDate = currentDate.AddDate(0, 0, 1)
+		if name != "" {
+			summary.CategoryBreakdown[name] = summary.CategoryBreakdown[name].Add(cashFlow.Amount)
+		}
 	}
 
-	summary.Balance = summary.TotalIncome - summary.TotalExpense
+	summary.Balance = summary.TotalIncome.Sub(summary.TotalExpense)
 
-	return summary, nil
+	return summary
 }
@@ -0,0 +1,56 @@
+package cash_flow_service
+
+import (
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
+)
+
+const maxTextSearchLimit = 100
+
+// Highlight markers of TextSearch; the text around them is HTML escaped
+const (
+	HighlightOpen  = "<mark>"
+	HighlightClose = "</mark>"
+)
+
+// TextSearch ranks cash flows by how well their description and remark match the words of text
+// Words match their inflections, so coffees finds coffee; hits carry the matching words wrapped in <mark>
+func TextSearch(text string, limit int) ([]model.TextSearchHit, error) {
+	termList := query.TextTerms(text)
+	if len(termList) == 0 {
+		return nil, errors.NewFieldValidationError("q", "enter at least one word to search for")
+	}
+	if limit <= 0 || limit > maxTextSearchLimit {
+		limit = maxTextSearchLimit
+	}
+
+	hitList, err := cash_flow_mapper.INSTANCE.TextSearchCashFlows(termList, limit)
+	if err == cash_flow_mapper.ErrTextIndexMissing {
+		return nil, errors.NewDatabaseError(err.Error(), nil)
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError("cash_flow text search failed", err)
+	}
+
+	stemList := query.StemTerms(termList)
+	for index := range hitList {
+		hitList[index].Highlights = highlightCashFlow(hitList[index].CashFlow, stemList, HighlightOpen, HighlightClose, true)
+	}
+	if hitList == nil {
+		hitList = []model.TextSearchHit{}
+	}
+	return hitList, nil
+}
+
+// highlightCashFlow marks the matching words of the description and remark, leaving out fields without any
+func highlightCashFlow(entity model.CashFlowEntity, stemList []string, open, close string, escapeHTML bool) map[string]string {
+	highlightMap := map[string]string{}
+	for field, text := range map[string]string{"description": entity.Description, "remark": entity.Remark} {
+		if highlighted, matched := query.Highlight(text, stemList, open, close, escapeHTML); matched {
+			highlightMap[field] = highlighted
+		}
+	}
+	return highlightMap
+}
@@ -0,0 +1,114 @@
+package cash_flow_service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+)
+
+// trashPurgeInterval is how often the server purges expired cash flows from the trash
+const trashPurgeInterval = time.Hour
+
+// ListTrash returns a page of the trash, most recently deleted first, and the number of cash flows in it
+func ListTrash(limit, offset int) ([]model.CashFlowEntity, int64) {
+	cashFlowList := cash_flow_mapper.INSTANCE.GetDeletedCashFlows(limit, offset)
+	if cashFlowList == nil {
+		cashFlowList = []model.CashFlowEntity{}
+	}
+	return cashFlowList, cash_flow_mapper.INSTANCE.CountDeletedCashFlows()
+}
+
+// RestoreFromTrash brings a deleted cash flow back
+// Refused when its category has been deleted since, as the cash flow would point at nothing
+func RestoreFromTrash(plainId string, origin model.ChangeOrigin) (model.CashFlowEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return model.CashFlowEntity{}, err
+	}
+
+	deletedEntity := cash_flow_mapper.INSTANCE.GetDeletedCashFlowByObjectId(plainId)
+	if deletedEntity.IsEmpty() {
+		return model.CashFlowEntity{}, errors.NewNotFoundError("cash_flow not found in trash")
+	}
+	if category_mapper.INSTANCE.GetCategoryByObjectId(deletedEntity.CategoryId.Hex()).IsEmpty() {
+		return model.CashFlowEntity{}, errors.NewInvalidInputError(
+			"category " + deletedEntity.CategoryId.Hex() + " no longer exists, recreate it before restoring")
+	}
+
+	restoredEntity := cash_flow_mapper.INSTANCE.RestoreDeletedCashFlowByObjectId(plainId)
+	if restoredEntity.IsEmpty() {
+		return model.CashFlowEntity{}, errors.NewDatabaseError("cash_flow restore failed", nil)
+	}
+	revision_service.RecordChange(model.RevisionTypeCashFlow, restoredEntity.Id, model.RevisionActionRestore, nil, nil, origin)
+	return restoredEntity, nil
+}
+
+// PurgeFromTrash permanently deletes one cash flow from the trash
+func PurgeFromTrash(plainId string) (model.CashFlowEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return model.CashFlowEntity{}, err
+	}
+
+	purgedEntity := cash_flow_mapper.INSTANCE.PurgeDeletedCashFlowByObjectId(plainId)
+	if purgedEntity.IsEmpty() {
+		return model.CashFlowEntity{}, errors.NewNotFoundError("cash_flow not found in trash")
+	}
+	return purgedEntity, nil
+}
+
+// PurgeTrash permanently deletes the cash flows that have been in the trash longer than olderThan
+// An olderThan of zero empties the whole trash
+func PurgeTrash(olderThan time.Duration) (int64, error) {
+	return cash_flow_mapper.INSTANCE.PurgeDeletedCashFlows(time.Now().UTC().Add(-olderThan))
+}
+
+// GetTrashRetention reads TRASH_RETENTION_DAYS; zero means the trash is never purged automatically
+func GetTrashRetention() time.Duration {
+	days, err := strconv.Atoi(util.GetConfigByKey("trash.retention.days"))
+	if err != nil || days < 0 {
+		util.Logger.Warnw("invalid trash retention, automatic purge disabled", "key", "trash.retention.days")
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// StartTrashPurger purges expired cash flows from the trash now and then every trashPurgeInterval
+// Returns false when TRASH_RETENTION_DAYS is 0; the purger stops when ctx is done
+func StartTrashPurger(ctx context.Context) bool {
+	retention := GetTrashRetention()
+	if retention == 0 {
+		return false
+	}
+
+	go func() {
+		for {
+			purgedCount, err := PurgeTrash(retention)
+			if err != nil {
+				util.Logger.Errorw("trash purge failed", "error", err)
+			} else if purgedCount > 0 {
+				util.Logger.Infow("trash purged", "count", purgedCount)
+			}
+			// Only runs that removed something or failed are worth an audit entry
+			if err != nil || purgedCount > 0 {
+				audit_service.Record(model.AuditOperationTrashPurge,
+					model.NewChangeOrigin(model.ChangeSourceScheduler, model.SchedulerActor),
+					map[string]string{"older_than": strconv.Itoa(int(retention.Hours() / 24))},
+					map[string]int{"cash_flows": int(purgedCount)}, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(trashPurgeInterval):
+			}
+		}
+	}()
+	return true
+}
@@ -1,90 +1,176 @@
 package cash_flow_service
 
 import (
-	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/macar-x/cashlenx-server/errors"
 	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/macar-x/cashlenx-server/validation"
-	"github.com/shopspring/decimal"
 )
 
-// UpdateById updates a cash flow record by ID
-func UpdateById(plainId, belongsDate, categoryName string, amount float64, description string) (model.CashFlowEntity, error) {
-	// Validate ID
-	if err := validation.ValidateID(plainId); err != nil {
-		return model.CashFlowEntity{}, err
-	}
+// patchableFieldList holds the fields of a cash flow a merge patch may touch
+// category_id and category_name are two ways to pick the category
+var patchableFieldList = []string{"belongs_date", "category_id", "category_name", "flow_type", "amount", "description", "remark"}
+
+// clearableFieldList holds the fields a merge patch may set to null
+var clearableFieldList = []string{"description", "remark"}
 
-	// Validate optional fields if provided
+// UpdateById updates a cash flow record by ID
+// Empty strings and a zero amount leave the field as it is, use PatchById to clear a field or set a zero amount
+// expectedVersion is the version the caller last read, or model.AnyVersion to overwrite whatever is stored
+func UpdateById(plainId, belongsDate, categoryName string, amount model.Money, description string, expectedVersion int64, origin model.ChangeOrigin) (model.CashFlowEntity, error) {
+	patch := model.MergePatch{}
 	if belongsDate != "" {
-		if err := validation.ValidateDate(belongsDate); err != nil {
-			return model.CashFlowEntity{}, err
-		}
+		patch.Set("belongs_date", belongsDate)
 	}
-
 	if categoryName != "" {
-		if err := validation.ValidateCategoryName(categoryName); err != nil {
-			return model.CashFlowEntity{}, err
-		}
+		patch.Set("category_name", categoryName)
 	}
-
-	if amount != 0 {
-		if err := validation.ValidateAmount(amount); err != nil {
+	if !amount.IsZero() {
+		if err := validation.ValidateMoney(amount); err != nil {
 			return model.CashFlowEntity{}, err
 		}
+		patch.Set("amount", amount.String())
 	}
-
 	if description != "" {
-		if err := validation.ValidateDescription(description); err != nil {
-			return model.CashFlowEntity{}, err
-		}
+		patch.Set("description", description)
+	}
+	return PatchById(plainId, patch, expectedVersion, origin)
+}
+
+// PatchById applies a JSON Merge Patch (RFC 7396) to a cash flow record
+// A field missing from the patch stays as it is, null clears description or remark and is refused for the others
+// expectedVersion is the version the caller last read, or model.AnyVersion to overwrite whatever is stored
+func PatchById(plainId string, patch model.MergePatch, expectedVersion int64, origin model.ChangeOrigin) (model.CashFlowEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return model.CashFlowEntity{}, err
+	}
+	if err := checkPatchFields(patch); err != nil {
+		return model.CashFlowEntity{}, err
 	}
 
 	// Query existing record
 	existingEntity := cash_flow_mapper.INSTANCE.GetCashFlowByObjectId(plainId)
 	if existingEntity.IsEmpty() {
-		return model.CashFlowEntity{}, errors.New("cash_flow not found")
-	}
-
-	// Update fields that are provided
-	if belongsDate != "" {
-		date := util.FormatDateFromStringWithoutDash(belongsDate)
-		if date.IsZero() {
-			return model.CashFlowEntity{}, errors.New("invalid date format")
-		}
-		existingEntity.BelongsDate = date
+		return model.CashFlowEntity{}, errors.NewNotFoundError("cash_flow not found")
 	}
-
-	if categoryName != "" {
-		categoryEntity := category_mapper.INSTANCE.GetCategoryByName(categoryName)
-		if categoryEntity.IsEmpty() {
-			return model.CashFlowEntity{}, errors.New("category does not exist")
-		}
-		existingEntity.CategoryId = categoryEntity.Id
+	if expectedVersion != model.AnyVersion && existingEntity.Version != expectedVersion {
+		return model.CashFlowEntity{}, errors.NewPreconditionFailedError(
+			"cash_flow was changed since version " + strconv.FormatInt(expectedVersion, 10) + ", fetch it again")
 	}
+	beforeFields := revision_service.CashFlowFields(existingEntity)
 
-	if amount != 0 {
-		// Round to 2 decimal places
-		amount, _ = decimal.NewFromFloat(amount).Round(2).Float64()
-		existingEntity.Amount = amount
+	patchedEntity, err := applyCashFlowPatch(existingEntity, patch)
+	if err != nil {
+		return model.CashFlowEntity{}, err
 	}
-
-	if description != "" {
-		existingEntity.Description = description
+	if len(revision_service.Diff(beforeFields, revision_service.CashFlowFields(patchedEntity))) == 0 {
+		return existingEntity, nil
 	}
 
 	// Update modify time
-	existingEntity.ModifyTime = time.Now().UTC() // Store in UTC
+	patchedEntity.ModifyTime = time.Now().UTC() // Store in UTC
 
-	// Call mapper to update the record
-	updatedEntity := cash_flow_mapper.INSTANCE.UpdateCashFlowByEntity(plainId, existingEntity)
+	// Call mapper to update the record; it refuses when another update got in since the read above
+	updatedEntity := cash_flow_mapper.INSTANCE.UpdateCashFlowByEntity(plainId, patchedEntity)
 	if updatedEntity.IsEmpty() {
-		return model.CashFlowEntity{}, errors.New("failed to update cash_flow")
+		return model.CashFlowEntity{}, errors.NewPreconditionFailedError("cash_flow was changed or deleted meanwhile, fetch it again")
 	}
+	revision_service.RecordChange(model.RevisionTypeCashFlow, updatedEntity.Id, model.RevisionActionUpdate,
+		beforeFields, revision_service.CashFlowFields(updatedEntity), origin)
 
 	return updatedEntity, nil
 }
+
+// checkPatchFields refuses unknown fields and nulls on required fields before anything is read
+func checkPatchFields(patch model.MergePatch) error {
+	for field, value := range patch {
+		if !slices.Contains(patchableFieldList, field) {
+			return validation.NewValidationError(field, "is not a field of a cash flow, use one of "+strings.Join(patchableFieldList, ", "))
+		}
+		if value == nil && !slices.Contains(clearableFieldList, field) {
+			return validation.NewValidationError(field, "is required and cannot be cleared")
+		}
+	}
+	if patch.Has("category_id") && patch.Has("category_name") {
+		return validation.NewValidationError("category", "give either category_id or category_name")
+	}
+	return nil
+}
+
+// applyCashFlowPatch validates the patched fields and sets them on a copy of the entity
+func applyCashFlowPatch(entity model.CashFlowEntity, patch model.MergePatch) (model.CashFlowEntity, error) {
+	fieldList := make([]string, 0, len(patch))
+	for field := range patch {
+		fieldList = append(fieldList, field)
+	}
+	// A fixed order keeps the reported error the same for the same patch
+	sort.Strings(fieldList)
+
+	for _, field := range fieldList {
+		value := ""
+		if patch[field] != nil {
+			value = *patch[field]
+		}
+
+		switch field {
+		case "belongs_date":
+			if err := validation.ValidateDate(value); err != nil {
+				return model.CashFlowEntity{}, err
+			}
+			entity.BelongsDate = util.FormatDateFromStringWithoutDash(value)
+		case "category_id":
+			if err := validation.ValidateID(value); err != nil {
+				return model.CashFlowEntity{}, err
+			}
+			categoryEntity := category_mapper.INSTANCE.GetCategoryByObjectId(value)
+			if categoryEntity.IsEmpty() {
+				return model.CashFlowEntity{}, errors.NewInvalidInputError("category does not exist")
+			}
+			entity.CategoryId = categoryEntity.Id
+		case "category_name":
+			if err := validation.ValidateCategoryName(value); err != nil {
+				return model.CashFlowEntity{}, err
+			}
+			categoryEntity := category_mapper.INSTANCE.GetCategoryByName(value)
+			if categoryEntity.IsEmpty() {
+				return model.CashFlowEntity{}, errors.NewInvalidInputError("category does not exist")
+			}
+			entity.CategoryId = categoryEntity.Id
+		case "flow_type":
+			if err := validation.ValidateFlowType(value); err != nil {
+				return model.CashFlowEntity{}, err
+			}
+			entity.FlowType = value
+		case "amount":
+			amount, err := model.NewMoneyFromString(value)
+			if err != nil {
+				return model.CashFlowEntity{}, validation.NewValidationError("amount", "must be a number")
+			}
+			if err := validation.ValidateMoneyAllowZero(amount); err != nil {
+				return model.CashFlowEntity{}, err
+			}
+			// Round to 2 decimal places
+			entity.Amount = amount.Round(model.MoneyDisplayScale)
+		case "description":
+			if err := validation.ValidateDescription(value); err != nil {
+				return model.CashFlowEntity{}, err
+			}
+			entity.Description = value
+		case "remark":
+			if err := validation.ValidateRemark(value); err != nil {
+				return model.CashFlowEntity{}, err
+			}
+			entity.Remark = value
+		}
+	}
+	return entity, nil
+}
@@ -6,12 +6,13 @@ import (
 
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/macar-x/cashlenx-server/validation"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func CreateService(parentPlainId, categoryName, categoryType string) (string, error) {
+func CreateService(parentPlainId, categoryName, categoryType string, origin model.ChangeOrigin) (string, error) {
 	// Validate category name
 	if err := validation.ValidateCategoryName(categoryName); err != nil {
 		return "", err
@@ -55,6 +56,8 @@ func CreateService(parentPlainId, categoryName, categoryType string) (string, er
 	}
 
 	newCategoryEntity := category_mapper.INSTANCE.GetCategoryByObjectId(newCategoryPlainId)
+	revision_service.RecordChange(model.RevisionTypeCategory, newCategoryEntity.Id, model.RevisionActionCreate,
+		nil, revision_service.CategoryFields(newCategoryEntity), origin)
 	fmt.Println("category ", 0, ": ", newCategoryEntity.ToString())
 	return newCategoryPlainId, nil
 }
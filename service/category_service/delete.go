@@ -6,20 +6,24 @@ import (
 
 	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/tombstone_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
+	"github.com/macar-x/cashlenx-server/util"
 	"github.com/macar-x/cashlenx-server/validation"
 )
 
-func DeleteService(plainId, categoryName string) error {
+func DeleteService(plainId, categoryName string, origin model.ChangeOrigin) error {
 	if isDeleteFieldsConflicted(plainId, categoryName) {
 		return errors.New("should have one and only one delete type")
 	}
 
 	if plainId != "" {
-		return deleteById(plainId)
+		return deleteById(plainId, origin)
 	}
 
 	if categoryName != "" {
-		return deleteByName(categoryName)
+		return deleteByName(categoryName, origin)
 	}
 
 	return errors.New("not supported delete type")
@@ -46,7 +50,7 @@ func isDeleteFieldsConflicted(plainId, categoryName string) bool {
 	return !semiOptionalFieldFilledFlag
 }
 
-func deleteById(plainId string) error {
+func deleteById(plainId string, origin model.ChangeOrigin) error {
 	// Validate ID
 	if err := validation.ValidateID(plainId); err != nil {
 		return err
@@ -66,11 +70,14 @@ func deleteById(plainId string) error {
 	if existCategoryEntity.IsEmpty() {
 		return errors.New("category delete failed")
 	}
+	recordTombstone(existCategoryEntity)
+	revision_service.RecordChange(model.RevisionTypeCategory, existCategoryEntity.Id, model.RevisionActionDelete,
+		revision_service.CategoryFields(existCategoryEntity), nil, origin)
 	fmt.Println("category ", 0, ": ", existCategoryEntity.ToString())
 	return nil
 }
 
-func deleteByName(categoryName string) error {
+func deleteByName(categoryName string, origin model.ChangeOrigin) error {
 	// Validate category name
 	if err := validation.ValidateCategoryName(categoryName); err != nil {
 		return err
@@ -90,6 +97,17 @@ func deleteByName(categoryName string) error {
 	if existCategoryEntity.IsEmpty() {
 		return errors.New("category delete failed")
 	}
+	recordTombstone(existCategoryEntity)
+	revision_service.RecordChange(model.RevisionTypeCategory, existCategoryEntity.Id, model.RevisionActionDelete,
+		revision_service.CategoryFields(existCategoryEntity), nil, origin)
 	fmt.Println("category ", 0, ": ", existCategoryEntity.ToString())
 	return nil
 }
+
+// recordTombstone remembers a deleted category so incremental backups can replay the deletion
+func recordTombstone(deletedEntity model.CategoryEntity) {
+	tombstone := model.NewTombstone(model.TombstoneTypeCategory, deletedEntity.Id)
+	if err := tombstone_mapper.INSTANCE.InsertTombstones([]model.TombstoneEntity{tombstone}); err != nil {
+		util.Logger.Errorw("record category tombstone failed, take a full backup", "error", err)
+	}
+}
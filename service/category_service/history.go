@@ -0,0 +1,97 @@
+package category_service
+
+import (
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
+	"github.com/macar-x/cashlenx-server/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetHistory returns the revisions of a category, oldest first, including after it is deleted
+func GetHistory(plainId string) ([]model.RevisionEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return nil, err
+	}
+	return revision_service.GetHistory(model.RevisionTypeCategory, plainId)
+}
+
+// RevertToRevision sets the fields of a category back to how they were right after the given revision
+// The revert is recorded as a revision of its own, so it can be reverted in turn
+func RevertToRevision(plainId, plainRevisionId string, origin model.ChangeOrigin) (model.CategoryEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return model.CategoryEntity{}, err
+	}
+	if err := validation.ValidateID(plainRevisionId); err != nil {
+		return model.CategoryEntity{}, err
+	}
+
+	existingCategory := category_mapper.INSTANCE.GetCategoryByObjectId(plainId)
+	if existingCategory.IsEmpty() {
+		return model.CategoryEntity{}, errors.NewNotFoundError("category not found")
+	}
+
+	history, err := revision_service.GetHistory(model.RevisionTypeCategory, plainId)
+	if err != nil {
+		return model.CategoryEntity{}, err
+	}
+	currentFields := revision_service.CategoryFields(existingCategory)
+	targetFields, err := revision_service.StateAt(currentFields, history, plainRevisionId)
+	if err != nil {
+		return model.CategoryEntity{}, err
+	}
+	if len(revision_service.Diff(currentFields, targetFields)) == 0 {
+		return existingCategory, nil
+	}
+
+	revertedCategory, err := applyCategoryFields(existingCategory, targetFields)
+	if err != nil {
+		return model.CategoryEntity{}, err
+	}
+	updatedEntity := category_mapper.INSTANCE.UpdateCategoryByEntity(plainId, revertedCategory)
+	if updatedEntity.IsEmpty() {
+		return model.CategoryEntity{}, errors.NewPreconditionFailedError("category was changed or deleted meanwhile, try the revert again")
+	}
+	revision_service.RecordRevert(model.RevisionTypeCategory, updatedEntity.Id, plainRevisionId,
+		currentFields, revision_service.CategoryFields(updatedEntity), origin)
+	return updatedEntity, nil
+}
+
+// applyCategoryFields is the reverse of revision_service.CategoryFields
+// The same rules as UpdateService apply: the parent must exist with the same type and the name must stay unique
+func applyCategoryFields(category model.CategoryEntity, fields map[string]string) (model.CategoryEntity, error) {
+	parentId, err := primitive.ObjectIDFromHex(fields["parent_id"])
+	if err != nil {
+		return model.CategoryEntity{}, errors.NewInvalidInputError("revision has an invalid parent_id: " + fields["parent_id"])
+	}
+	if parentId != primitive.NilObjectID {
+		if parentId == category.Id {
+			return model.CategoryEntity{}, errors.NewInvalidInputError("category cannot be its own parent")
+		}
+		parentCategory := category_mapper.INSTANCE.GetCategoryByObjectId(parentId.Hex())
+		if parentCategory.IsEmpty() {
+			return model.CategoryEntity{}, errors.NewInvalidInputError(
+				"parent category " + parentId.Hex() + " no longer exists, recreate it before reverting")
+		}
+		if parentCategory.Type != fields["type"] {
+			return model.CategoryEntity{}, errors.NewInvalidInputError("parent and child categories must have the same type")
+		}
+	}
+
+	if fields["type"] != category.Type && len(category_mapper.INSTANCE.GetCategoryByParentId(category.Id.Hex())) > 0 {
+		return model.CategoryEntity{}, errors.NewInvalidInputError("cannot change type of a category with children")
+	}
+
+	if fields["name"] != category.Name {
+		if sameName := category_mapper.INSTANCE.GetCategoryByName(fields["name"]); !sameName.IsEmpty() && sameName.Id != category.Id {
+			return model.CategoryEntity{}, errors.NewAlreadyExistsError("category " + fields["name"] + " already exists")
+		}
+	}
+
+	category.ParentId = parentId
+	category.Name = fields["name"]
+	category.Type = fields["type"]
+	category.Remark = fields["remark"]
+	return category, nil
+}
@@ -1,8 +1,10 @@
 package category_service
 
 import (
+	"github.com/macar-x/cashlenx-server/errors"
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 )
 
 // ListAllService lists all categories with pagination
@@ -15,3 +17,17 @@ func ListAllService(limit, offset int) ([]model.CategoryEntity, int64, error) {
 
 	return categories, totalCount, nil
 }
+
+// ListPageService lists one page of categories, sorted, narrowed and found by cursor or offset as request says
+func ListPageService(request query.PageRequest) (query.Page, error) {
+	totalCount := category_mapper.INSTANCE.CountAllCategories()
+	categoryList, err := category_mapper.INSTANCE.GetCategoriesPage(request)
+	if err != nil {
+		return query.Page{}, errors.NewDatabaseError("category query failed", err)
+	}
+	rowList := make([]interface{}, 0, len(categoryList))
+	for _, category := range categoryList {
+		rowList = append(rowList, category)
+	}
+	return query.NewPage(request, rowList, totalCount)
+}
@@ -1,71 +1,173 @@
 package category_service
 
 import (
-	"errors"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/macar-x/cashlenx-server/errors"
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// patchableFieldList holds the fields of a category a merge patch may touch
+var patchableFieldList = []string{"parent_id", "name", "type", "remark"}
+
+// clearableFieldList holds the fields a merge patch may set to null; a null parent_id makes a top level category
+var clearableFieldList = []string{"parent_id", "remark"}
+
 // UpdateService updates a category by ID
-func UpdateService(plainId, parentPlainId, categoryName, categoryType string) error {
+// Empty strings leave the field as it is, use PatchService to remove the parent or clear the remark
+// expectedVersion is the version the caller last read, or model.AnyVersion to overwrite whatever is stored
+func UpdateService(plainId, parentPlainId, categoryName, categoryType string, expectedVersion int64, origin model.ChangeOrigin) error {
+	patch := model.MergePatch{}
+	if parentPlainId != "" {
+		patch.Set("parent_id", parentPlainId)
+	}
+	if categoryName != "" {
+		patch.Set("name", categoryName)
+	}
+	if categoryType != "" {
+		patch.Set("type", categoryType)
+	}
+	_, err := PatchService(plainId, patch, expectedVersion, origin)
+	return err
+}
+
+// PatchService applies a JSON Merge Patch (RFC 7396) to a category
+// A field missing from the patch stays as it is, null clears parent_id or remark and is refused for the others
+// expectedVersion is the version the caller last read, or model.AnyVersion to overwrite whatever is stored
+func PatchService(plainId string, patch model.MergePatch, expectedVersion int64, origin model.ChangeOrigin) (model.CategoryEntity, error) {
 	if plainId == "" {
-		return errors.New("id cannot be empty")
+		return model.CategoryEntity{}, errors.NewInvalidInputError("id cannot be empty")
+	}
+	if err := checkPatchFields(patch); err != nil {
+		return model.CategoryEntity{}, err
 	}
 
 	// Query existing category
 	existingCategory := category_mapper.INSTANCE.GetCategoryByObjectId(plainId)
 	if existingCategory.IsEmpty() {
-		return errors.New("category not found")
+		return model.CategoryEntity{}, errors.NewNotFoundError("category not found")
+	}
+	if expectedVersion != model.AnyVersion && existingCategory.Version != expectedVersion {
+		return model.CategoryEntity{}, errors.NewPreconditionFailedError(
+			"category was changed since version " + strconv.FormatInt(expectedVersion, 10) + ", fetch it again")
 	}
+	beforeFields := revision_service.CategoryFields(existingCategory)
 
-	// Check if category has children before allowing type change
-	categoryChildren := category_mapper.INSTANCE.GetCategoryByParentId(plainId)
+	patchedCategory, err := applyCategoryPatch(existingCategory, patch)
+	if err != nil {
+		return model.CategoryEntity{}, err
+	}
+	if len(revision_service.Diff(beforeFields, revision_service.CategoryFields(patchedCategory))) == 0 {
+		return existingCategory, nil
+	}
 
-	// Update parent ID if provided
-	if parentPlainId != "" {
-		parentCategory := category_mapper.INSTANCE.GetCategoryByObjectId(parentPlainId)
-		if parentCategory.IsEmpty() {
-			return errors.New("parent category does not exist")
+	// Call mapper to update the record
+	// The mapper refuses when another update got in since the read above
+	updatedEntity := category_mapper.INSTANCE.UpdateCategoryByEntity(plainId, patchedCategory)
+	if updatedEntity.IsEmpty() {
+		return model.CategoryEntity{}, errors.NewPreconditionFailedError("category was changed or deleted meanwhile, fetch it again")
+	}
+	revision_service.RecordChange(model.RevisionTypeCategory, updatedEntity.Id, model.RevisionActionUpdate,
+		beforeFields, revision_service.CategoryFields(updatedEntity), origin)
+
+	return updatedEntity, nil
+}
+
+// checkPatchFields refuses unknown fields and nulls on required fields before anything is read
+func checkPatchFields(patch model.MergePatch) error {
+	for field, value := range patch {
+		if !slices.Contains(patchableFieldList, field) {
+			return validation.NewValidationError(field, "is not a field of a category, use one of "+strings.Join(patchableFieldList, ", "))
+		}
+		if value == nil && !slices.Contains(clearableFieldList, field) {
+			return validation.NewValidationError(field, "is required and cannot be cleared")
 		}
-		// Prevent circular reference
-		if parentPlainId == plainId {
-			return errors.New("category cannot be its own parent")
+	}
+	return nil
+}
+
+// applyCategoryPatch validates the patched fields and sets them on a copy of the category
+// The parent must exist with the same type and not sit below the category, and the name must stay unique
+func applyCategoryPatch(category model.CategoryEntity, patch model.MergePatch) (model.CategoryEntity, error) {
+	patchedCategory := category
+	if value, ok := patch["name"]; ok {
+		if err := validation.ValidateCategoryName(*value); err != nil {
+			return model.CategoryEntity{}, err
 		}
-		// Ensure parent and child categories have the same type
-		// Use existing type or new type if being updated
-		childType := existingCategory.Type
-		if categoryType != "" {
-			childType = categoryType
+		patchedCategory.Name = *value
+	}
+	if value, ok := patch["type"]; ok {
+		if err := validation.ValidateCategoryType(*value); err != nil {
+			return model.CategoryEntity{}, err
 		}
-		if parentCategory.Type != childType {
-			return errors.New("parent and child categories must have the same type")
+		patchedCategory.Type = *value
+	}
+	if value, ok := patch["remark"]; ok {
+		patchedCategory.Remark = ""
+		if value != nil {
+			if err := validation.ValidateRemark(*value); err != nil {
+				return model.CategoryEntity{}, err
+			}
+			patchedCategory.Remark = *value
 		}
-		existingCategory.ParentId = parentCategory.Id
 	}
-
-	// Update category name if provided
-	if categoryName != "" {
-		existingCategory.Name = categoryName
+	if value, ok := patch["parent_id"]; ok {
+		patchedCategory.ParentId = primitive.NilObjectID
+		if value != nil {
+			if err := validation.ValidateID(*value); err != nil {
+				return model.CategoryEntity{}, err
+			}
+			patchedCategory.ParentId = util.Convert2ObjectId(*value)
+		}
 	}
 
-	// Update category type if provided
-	if categoryType != "" {
-		// Validate category type
-		if categoryType != "income" && categoryType != "expense" {
-			return errors.New("category type must be either 'income' or 'expense'")
+	if patchedCategory.ParentId != primitive.NilObjectID {
+		if patchedCategory.ParentId == category.Id {
+			return model.CategoryEntity{}, errors.NewInvalidInputError("category cannot be its own parent")
+		}
+		parentCategory := category_mapper.INSTANCE.GetCategoryByObjectId(patchedCategory.ParentId.Hex())
+		if parentCategory.IsEmpty() {
+			return model.CategoryEntity{}, errors.NewInvalidInputError("parent category does not exist")
 		}
-		// Prevent type change if category has children
-		if len(categoryChildren) > 0 && existingCategory.Type != categoryType {
-			return errors.New("cannot change type of a category with children")
+		if parentCategory.Type != patchedCategory.Type {
+			return model.CategoryEntity{}, errors.NewInvalidInputError("parent and child categories must have the same type")
+		}
+		if isDescendant(parentCategory, category.Id) {
+			return model.CategoryEntity{}, errors.NewInvalidInputError("parent category cannot be one of its own subcategories")
 		}
-		existingCategory.Type = categoryType
 	}
 
-	// Call mapper to update the record
-	updatedEntity := category_mapper.INSTANCE.UpdateCategoryByEntity(plainId, existingCategory)
-	if updatedEntity.IsEmpty() {
-		return errors.New("failed to update category")
+	if patchedCategory.Type != category.Type && len(category_mapper.INSTANCE.GetCategoryByParentId(category.Id.Hex())) > 0 {
+		return model.CategoryEntity{}, errors.NewInvalidInputError("cannot change type of a category with children")
 	}
 
-	return nil
+	if patchedCategory.Name != category.Name {
+		if sameName := category_mapper.INSTANCE.GetCategoryByName(patchedCategory.Name); !sameName.IsEmpty() && sameName.Id != category.Id {
+			return model.CategoryEntity{}, errors.NewAlreadyExistsError("category " + patchedCategory.Name + " already exists")
+		}
+	}
+	return patchedCategory, nil
+}
+
+// isDescendant walks up from the category and tells whether ancestorId is one of its ancestors
+func isDescendant(category model.CategoryEntity, ancestorId primitive.ObjectID) bool {
+	visitedIdMap := map[primitive.ObjectID]bool{}
+	for category.ParentId != primitive.NilObjectID && !visitedIdMap[category.ParentId] {
+		if category.ParentId == ancestorId {
+			return true
+		}
+		visitedIdMap[category.ParentId] = true
+		category = category_mapper.INSTANCE.GetCategoryByObjectId(category.ParentId.Hex())
+		if category.IsEmpty() {
+			return false
+		}
+	}
+	return false
 }
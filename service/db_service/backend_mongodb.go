@@ -0,0 +1,182 @@
+package db_service
+
+import (
+	"context"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoDbStore struct {
+	client   *mongo.Client
+	database *mongo.Database
+}
+
+func openMongoDbStore(uri, dbName string) (backendStore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, err
+	}
+	if err = client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, err
+	}
+	return &mongoDbStore{client: client, database: client.Database(dbName)}, nil
+}
+
+func (store *mongoDbStore) Name() string {
+	return "mongodb/" + store.database.Name()
+}
+
+func (store *mongoDbStore) EnsureSchema() error {
+	// Collections are created on first insert; indexes come from 'db migrate up'
+	return nil
+}
+
+func (store *mongoDbStore) CountCategories() (int64, error) {
+	return store.database.Collection(database.CategoryTableName).CountDocuments(context.TODO(), bson.D{})
+}
+
+func (store *mongoDbStore) CountCashFlows() (int64, error) {
+	return store.database.Collection(database.CashFlowTableName).CountDocuments(context.TODO(), bson.D{})
+}
+
+func (store *mongoDbStore) CountGoals() (int64, error) {
+	return store.database.Collection(database.GoalTableName).CountDocuments(context.TODO(), bson.D{})
+}
+
+func (store *mongoDbStore) CountDebts() (int64, error) {
+	return store.database.Collection(database.DebtTableName).CountDocuments(context.TODO(), bson.D{})
+}
+
+func (store *mongoDbStore) StreamCategories(afterId string, batchSize int, fn func([]model.CategoryEntity) error) error {
+	return streamMongoDbCollection(store.database.Collection(database.CategoryTableName), afterId, batchSize, fn)
+}
+
+func (store *mongoDbStore) StreamCashFlows(afterId string, batchSize int, fn func([]model.CashFlowEntity) error) error {
+	return streamMongoDbCollection(store.database.Collection(database.CashFlowTableName), afterId, batchSize, fn)
+}
+
+func (store *mongoDbStore) StreamGoals(afterId string, batchSize int, fn func([]model.GoalEntity) error) error {
+	return streamMongoDbCollection(store.database.Collection(database.GoalTableName), afterId, batchSize, fn)
+}
+
+func (store *mongoDbStore) StreamDebts(afterId string, batchSize int, fn func([]model.DebtEntity) error) error {
+	return streamMongoDbCollection(store.database.Collection(database.DebtTableName), afterId, batchSize, fn)
+}
+
+// streamMongoDbCollection decodes documents in _id order and hands them to fn in batches
+func streamMongoDbCollection[T any](collection *mongo.Collection, afterId string, batchSize int, fn func([]T) error) error {
+	filter := bson.D{}
+	if afterId != "" {
+		objectId, err := primitive.ObjectIDFromHex(afterId)
+		if err != nil {
+			return err
+		}
+		filter = bson.D{primitive.E{Key: "_id", Value: bson.M{"$gt": objectId}}}
+	}
+
+	ctx := context.Background()
+	findOptions := options.Find().
+		SetSort(bson.D{primitive.E{Key: "_id", Value: 1}}).
+		SetBatchSize(int32(batchSize))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		util.Logger.Errorw("stream collection failed", "collection", collection.Name(), "error", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	batch := make([]T, 0, batchSize)
+	for cursor.Next(ctx) {
+		var entity T
+		if err := cursor.Decode(&entity); err != nil {
+			return err
+		}
+		batch = append(batch, entity)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+func (store *mongoDbStore) UpsertCategories(entities []model.CategoryEntity) error {
+	writeModels := make([]mongo.WriteModel, len(entities))
+	for i, entity := range entities {
+		writeModels[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.D{primitive.E{Key: "_id", Value: entity.Id}}).
+			SetReplacement(entity).
+			SetUpsert(true)
+	}
+	return store.bulkWrite(database.CategoryTableName, writeModels)
+}
+
+func (store *mongoDbStore) UpsertCashFlows(entities []model.CashFlowEntity) error {
+	writeModels := make([]mongo.WriteModel, len(entities))
+	for i, entity := range entities {
+		writeModels[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.D{primitive.E{Key: "_id", Value: entity.Id}}).
+			SetReplacement(entity).
+			SetUpsert(true)
+	}
+	return store.bulkWrite(database.CashFlowTableName, writeModels)
+}
+
+func (store *mongoDbStore) UpsertGoals(entities []model.GoalEntity) error {
+	writeModels := make([]mongo.WriteModel, len(entities))
+	for i, entity := range entities {
+		writeModels[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.D{primitive.E{Key: "_id", Value: entity.Id}}).
+			SetReplacement(entity).
+			SetUpsert(true)
+	}
+	return store.bulkWrite(database.GoalTableName, writeModels)
+}
+
+func (store *mongoDbStore) UpsertDebts(entities []model.DebtEntity) error {
+	writeModels := make([]mongo.WriteModel, len(entities))
+	for i, entity := range entities {
+		writeModels[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.D{primitive.E{Key: "_id", Value: entity.Id}}).
+			SetReplacement(entity).
+			SetUpsert(true)
+	}
+	return store.bulkWrite(database.DebtTableName, writeModels)
+}
+
+func (store *mongoDbStore) bulkWrite(collectionName string, writeModels []mongo.WriteModel) error {
+	if len(writeModels) == 0 {
+		return nil
+	}
+	_, err := store.database.Collection(collectionName).
+		BulkWrite(context.TODO(), writeModels, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		util.Logger.Errorw("bulk upsert failed", "collection", collectionName, "error", err)
+	}
+	return err
+}
+
+func (store *mongoDbStore) Close() {
+	if err := store.client.Disconnect(context.Background()); err != nil {
+		util.Logger.Warnw("close mongodb store failed", "error", err)
+	}
+}
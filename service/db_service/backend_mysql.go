@@ -0,0 +1,361 @@
+package db_service
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+type mySqlStore struct {
+	connection *sql.DB
+	dbName     string
+}
+
+func openMySqlStore(dsn string) (backendStore, error) {
+	connection, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	connection.SetConnMaxLifetime(time.Minute * 3)
+	connection.SetMaxOpenConns(10)
+	connection.SetMaxIdleConns(10)
+
+	if err = connection.Ping(); err != nil {
+		_ = connection.Close()
+		return nil, err
+	}
+
+	var dbName string
+	_ = connection.QueryRow("SELECT DATABASE()").Scan(&dbName)
+	return &mySqlStore{connection: connection, dbName: dbName}, nil
+}
+
+func (store *mySqlStore) Name() string {
+	return "mysql/" + store.dbName
+}
+
+func (store *mySqlStore) EnsureSchema() error {
+	for _, tableName := range []string{database.CategoryTableName, database.CashFlowTableName, database.GoalTableName,
+		database.DebtTableName} {
+		// VERSION comes with migration 007 and is copied so ETags stay valid on the target
+		if _, err := store.connection.Exec("SELECT VERSION FROM " + tableName + " LIMIT 0"); err != nil {
+			return fmt.Errorf("table %s is missing or outdated on %s, run 'cashlenx db migrate up' against it first: %w",
+				tableName, store.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (store *mySqlStore) CountCategories() (int64, error) {
+	return store.count(database.CategoryTableName)
+}
+
+func (store *mySqlStore) CountCashFlows() (int64, error) {
+	return store.count(database.CashFlowTableName)
+}
+
+func (store *mySqlStore) CountGoals() (int64, error) {
+	return store.count(database.GoalTableName)
+}
+
+func (store *mySqlStore) CountDebts() (int64, error) {
+	return store.count(database.DebtTableName)
+}
+
+func (store *mySqlStore) count(tableName string) (int64, error) {
+	var count int64
+	err := store.connection.QueryRow("SELECT COUNT(1) FROM " + tableName).Scan(&count)
+	return count, err
+}
+
+func (store *mySqlStore) StreamCategories(afterId string, batchSize int, fn func([]model.CategoryEntity) error) error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, PARENT_ID, NAME, TYPE, REMARK, CREATE_TIME, MODIFY_TIME, VERSION FROM ")
+	sqlString.WriteString(database.CategoryTableName)
+	sqlString.WriteString(" WHERE ID > ? ORDER BY ID ASC LIMIT ? ")
+
+	for {
+		rows, err := store.connection.Query(sqlString.String(), afterId, batchSize)
+		if err != nil {
+			util.Logger.Errorw("stream categories failed", "error", err)
+			return err
+		}
+
+		var batch []model.CategoryEntity
+		for rows.Next() {
+			var id string
+			var parentId, remark sql.NullString
+			var entity model.CategoryEntity
+			err := rows.Scan(&id, &parentId, &entity.Name, &entity.Type, &remark, &entity.CreateTime, &entity.ModifyTime,
+				&entity.Version)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			entity.Id = util.Convert2ObjectId(id)
+			if parentId.String != "" {
+				entity.ParentId = util.Convert2ObjectId(parentId.String)
+			}
+			entity.Remark = remark.String
+			batch = append(batch, entity)
+		}
+		rows.Close()
+
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		afterId = batch[len(batch)-1].Id.Hex()
+	}
+}
+
+func (store *mySqlStore) StreamCashFlows(afterId string, batchSize int, fn func([]model.CashFlowEntity) error) error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, CATEGORY_ID, BELONGS_DATE, FLOW_TYPE, AMOUNT, DESCRIPTION, REMARK, CREATE_TIME, MODIFY_TIME, DELETED_AT, VERSION FROM ")
+	sqlString.WriteString(database.CashFlowTableName)
+	sqlString.WriteString(" WHERE ID > ? ORDER BY ID ASC LIMIT ? ")
+
+	for {
+		rows, err := store.connection.Query(sqlString.String(), afterId, batchSize)
+		if err != nil {
+			util.Logger.Errorw("stream cash flows failed", "error", err)
+			return err
+		}
+
+		var batch []model.CashFlowEntity
+		for rows.Next() {
+			var id, categoryId string
+			var remark sql.NullString
+			var deletedAt sql.NullTime
+			var entity model.CashFlowEntity
+			err := rows.Scan(&id, &categoryId, &entity.BelongsDate, &entity.FlowType, &entity.Amount,
+				&entity.Description, &remark, &entity.CreateTime, &entity.ModifyTime, &deletedAt, &entity.Version)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			entity.Id = util.Convert2ObjectId(id)
+			entity.CategoryId = util.Convert2ObjectId(categoryId)
+			entity.Remark = remark.String
+			if deletedAt.Valid {
+				entity.DeletedAt = &deletedAt.Time
+			}
+			batch = append(batch, entity)
+		}
+		rows.Close()
+
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		afterId = batch[len(batch)-1].Id.Hex()
+	}
+}
+
+func (store *mySqlStore) StreamGoals(afterId string, batchSize int, fn func([]model.GoalEntity) error) error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, NAME, TARGET_AMOUNT, START_DATE, DEADLINE, CATEGORY_ID, REMARK, CREATE_TIME, MODIFY_TIME, VERSION FROM ")
+	sqlString.WriteString(database.GoalTableName)
+	sqlString.WriteString(" WHERE ID > ? ORDER BY ID ASC LIMIT ? ")
+
+	for {
+		rows, err := store.connection.Query(sqlString.String(), afterId, batchSize)
+		if err != nil {
+			util.Logger.Errorw("stream goals failed", "error", err)
+			return err
+		}
+
+		var batch []model.GoalEntity
+		for rows.Next() {
+			var id string
+			var categoryId, remark sql.NullString
+			var entity model.GoalEntity
+			err := rows.Scan(&id, &entity.Name, &entity.TargetAmount, &entity.StartDate, &entity.Deadline, &categoryId,
+				&remark, &entity.CreateTime, &entity.ModifyTime, &entity.Version)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			entity.Id = util.Convert2ObjectId(id)
+			if categoryId.String != "" {
+				entity.CategoryId = util.Convert2ObjectId(categoryId.String)
+			}
+			entity.Remark = remark.String
+			batch = append(batch, entity)
+		}
+		rows.Close()
+
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		afterId = batch[len(batch)-1].Id.Hex()
+	}
+}
+
+func (store *mySqlStore) StreamDebts(afterId string, batchSize int, fn func([]model.DebtEntity) error) error {
+	var sqlString bytes.Buffer
+	sqlString.WriteString("SELECT ID, NAME, PRINCIPAL, ANNUAL_RATE, TERM_MONTHS, START_DATE, PAYMENT_CATEGORY_ID, INTEREST_CATEGORY_ID, REMARK, CREATE_TIME, MODIFY_TIME, VERSION FROM ")
+	sqlString.WriteString(database.DebtTableName)
+	sqlString.WriteString(" WHERE ID > ? ORDER BY ID ASC LIMIT ? ")
+
+	for {
+		rows, err := store.connection.Query(sqlString.String(), afterId, batchSize)
+		if err != nil {
+			util.Logger.Errorw("stream debts failed", "error", err)
+			return err
+		}
+
+		var batch []model.DebtEntity
+		for rows.Next() {
+			var id, paymentCategoryId, interestCategoryId string
+			var remark sql.NullString
+			var entity model.DebtEntity
+			err := rows.Scan(&id, &entity.Name, &entity.Principal, &entity.AnnualRate, &entity.TermMonths,
+				&entity.StartDate, &paymentCategoryId, &interestCategoryId, &remark, &entity.CreateTime,
+				&entity.ModifyTime, &entity.Version)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			entity.Id = util.Convert2ObjectId(id)
+			entity.PaymentCategoryId = util.Convert2ObjectId(paymentCategoryId)
+			entity.InterestCategoryId = util.Convert2ObjectId(interestCategoryId)
+			entity.Remark = remark.String
+			batch = append(batch, entity)
+		}
+		rows.Close()
+
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		afterId = batch[len(batch)-1].Id.Hex()
+	}
+}
+
+func (store *mySqlStore) UpsertCategories(entities []model.CategoryEntity) error {
+	columnList := []string{"ID", "PARENT_ID", "NAME", "TYPE", "REMARK", "CREATE_TIME", "MODIFY_TIME", "VERSION"}
+	values := make([]interface{}, 0, len(entities)*len(columnList))
+	for _, entity := range entities {
+		values = append(values, entity.Id.Hex(), entity.ParentId.Hex(), entity.Name, entity.Type, entity.Remark,
+			toMySqlTime(entity.CreateTime), toMySqlTime(entity.ModifyTime), entity.Version)
+	}
+	return store.upsert(database.CategoryTableName, columnList, len(entities), values)
+}
+
+func (store *mySqlStore) UpsertCashFlows(entities []model.CashFlowEntity) error {
+	columnList := []string{"ID", "CATEGORY_ID", "BELONGS_DATE", "FLOW_TYPE", "AMOUNT", "DESCRIPTION", "REMARK",
+		"CREATE_TIME", "MODIFY_TIME", "DELETED_AT", "VERSION"}
+	values := make([]interface{}, 0, len(entities)*len(columnList))
+	for _, entity := range entities {
+		var deletedAt interface{}
+		if entity.DeletedAt != nil {
+			deletedAt = toMySqlTime(*entity.DeletedAt)
+		}
+		values = append(values, entity.Id.Hex(), entity.CategoryId.Hex(), toMySqlTime(entity.BelongsDate), entity.FlowType,
+			entity.Amount, entity.Description, entity.Remark, toMySqlTime(entity.CreateTime), toMySqlTime(entity.ModifyTime),
+			deletedAt, entity.Version)
+	}
+	return store.upsert(database.CashFlowTableName, columnList, len(entities), values)
+}
+
+func (store *mySqlStore) UpsertGoals(entities []model.GoalEntity) error {
+	columnList := []string{"ID", "NAME", "TARGET_AMOUNT", "START_DATE", "DEADLINE", "CATEGORY_ID", "REMARK",
+		"CREATE_TIME", "MODIFY_TIME", "VERSION"}
+	values := make([]interface{}, 0, len(entities)*len(columnList))
+	for _, entity := range entities {
+		// A goal over all cash flows has no category; the column is NULL rather than a zero id
+		var categoryId interface{}
+		if !entity.CategoryId.IsZero() {
+			categoryId = entity.CategoryId.Hex()
+		}
+		values = append(values, entity.Id.Hex(), entity.Name, entity.TargetAmount, toMySqlTime(entity.StartDate),
+			toMySqlTime(entity.Deadline), categoryId, entity.Remark, toMySqlTime(entity.CreateTime),
+			toMySqlTime(entity.ModifyTime), entity.Version)
+	}
+	return store.upsert(database.GoalTableName, columnList, len(entities), values)
+}
+
+func (store *mySqlStore) UpsertDebts(entities []model.DebtEntity) error {
+	columnList := []string{"ID", "NAME", "PRINCIPAL", "ANNUAL_RATE", "TERM_MONTHS", "START_DATE", "PAYMENT_CATEGORY_ID",
+		"INTEREST_CATEGORY_ID", "REMARK", "CREATE_TIME", "MODIFY_TIME", "VERSION"}
+	values := make([]interface{}, 0, len(entities)*len(columnList))
+	for _, entity := range entities {
+		values = append(values, entity.Id.Hex(), entity.Name, entity.Principal, entity.AnnualRate, entity.TermMonths,
+			toMySqlTime(entity.StartDate), entity.PaymentCategoryId.Hex(), entity.InterestCategoryId.Hex(), entity.Remark,
+			toMySqlTime(entity.CreateTime), toMySqlTime(entity.ModifyTime), entity.Version)
+	}
+	return store.upsert(database.DebtTableName, columnList, len(entities), values)
+}
+
+// upsert writes rowCount rows in one INSERT ... ON DUPLICATE KEY UPDATE, so re-running a batch is harmless
+func (store *mySqlStore) upsert(tableName string, columnList []string, rowCount int, values []interface{}) error {
+	if rowCount == 0 {
+		return nil
+	}
+
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columnList)), ", ") + ")"
+	var updateList []string
+	for _, column := range columnList[1:] {
+		updateList = append(updateList, column+" = VALUES("+column+")")
+	}
+
+	var sqlString bytes.Buffer
+	sqlString.WriteString("INSERT INTO ")
+	sqlString.WriteString(tableName)
+	sqlString.WriteString(" (" + strings.Join(columnList, ", ") + ") VALUES ")
+	for i := 0; i < rowCount; i++ {
+		if i > 0 {
+			sqlString.WriteString(", ")
+		}
+		sqlString.WriteString(placeholder)
+	}
+	sqlString.WriteString(" ON DUPLICATE KEY UPDATE ")
+	sqlString.WriteString(strings.Join(updateList, ", "))
+
+	if _, err := store.connection.Exec(sqlString.String(), values...); err != nil {
+		util.Logger.Errorw("bulk upsert failed", "table", tableName, "error", err)
+		return err
+	}
+	return nil
+}
+
+// toMySqlTime truncates to whole seconds in UTC; MySQL would otherwise round fractional seconds up
+func toMySqlTime(value time.Time) time.Time {
+	return value.UTC().Truncate(time.Second)
+}
+
+func (store *mySqlStore) Close() {
+	if err := store.connection.Close(); err != nil {
+		util.Logger.Warnw("close mysql store failed", "error", err)
+	}
+}
@@ -0,0 +1,326 @@
+package db_service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+const defaultMigrateBatchSize = 500
+
+// BackendMigrationOptions configures a copy between two storage backends
+type BackendMigrationOptions struct {
+	FromUri        string
+	ToUri          string
+	DbName         string // used when a uri has no database path
+	BatchSize      int
+	CheckpointPath string
+	Restart        bool // ignore an existing checkpoint and copy from the beginning
+	AllowNonEmpty  bool // allow copying into a target that already holds data
+}
+
+// SectionResult reports the copy and verification of one entity type
+type SectionResult struct {
+	Copied         int64
+	SourceCount    int64
+	TargetCount    int64
+	SourceChecksum string
+	TargetChecksum string
+}
+
+func (result SectionResult) Verified() bool {
+	return result.SourceCount == result.TargetCount && result.SourceChecksum == result.TargetChecksum
+}
+
+// BackendMigrationResult is the outcome of MigrateBackend
+type BackendMigrationResult struct {
+	From       string
+	To         string
+	Resumed    bool
+	Categories SectionResult
+	CashFlows  SectionResult
+	Goals      SectionResult
+	Debts      SectionResult
+}
+
+// AffectedCounts flattens the copied rows for the audit log, leaving out zero counts
+func (result BackendMigrationResult) AffectedCounts() map[string]int {
+	counts := map[string]int{}
+	for name, section := range map[string]SectionResult{
+		"categories": result.Categories,
+		"cash_flows": result.CashFlows,
+		"goals":      result.Goals,
+		"debts":      result.Debts,
+	} {
+		if section.Copied != 0 {
+			counts[name] = int(section.Copied)
+		}
+	}
+	return counts
+}
+
+// migrationCheckpoint records progress so an interrupted copy can continue where it stopped
+type migrationCheckpoint struct {
+	From           string `json:"from"`
+	To             string `json:"to"`
+	CategoriesLast string `json:"categories_last_id"`
+	CategoriesDone bool   `json:"categories_done"`
+	CashFlowsLast  string `json:"cash_flows_last_id"`
+	CashFlowsDone  bool   `json:"cash_flows_done"`
+	GoalsLast      string `json:"goals_last_id"`
+	GoalsDone      bool   `json:"goals_done"`
+	DebtsLast      string `json:"debts_last_id"`
+	DebtsDone      bool   `json:"debts_done"`
+}
+
+// MigrateBackend streams categories, cash flows, goals and debts from one backend into another,
+// keeping ids, parent links and create/modify times, then verifies counts and checksums
+func MigrateBackend(migrationOptions BackendMigrationOptions) (*BackendMigrationResult, error) {
+	if migrationOptions.FromUri == "" || migrationOptions.ToUri == "" {
+		return nil, errors.New("both source and target uri are required")
+	}
+	if migrationOptions.BatchSize <= 0 {
+		migrationOptions.BatchSize = defaultMigrateBatchSize
+	}
+	if migrationOptions.DbName == "" {
+		migrationOptions.DbName = util.GetConfigByKey("db.name")
+	}
+
+	result := &BackendMigrationResult{
+		From: RedactUri(migrationOptions.FromUri),
+		To:   RedactUri(migrationOptions.ToUri),
+	}
+	if result.From == result.To {
+		return nil, errors.New("source and target must be different backends")
+	}
+
+	source, err := openBackendStore(migrationOptions.FromUri, migrationOptions.DbName)
+	if err != nil {
+		return nil, fmt.Errorf("open source failed: %w", err)
+	}
+	defer source.Close()
+
+	target, err := openBackendStore(migrationOptions.ToUri, migrationOptions.DbName)
+	if err != nil {
+		return nil, fmt.Errorf("open target failed: %w", err)
+	}
+	defer target.Close()
+
+	if err := target.EnsureSchema(); err != nil {
+		return nil, err
+	}
+
+	checkpoint := loadCheckpoint(migrationOptions, result)
+	result.Resumed = checkpoint.CategoriesLast != "" || checkpoint.CategoriesDone
+	if !result.Resumed && !migrationOptions.AllowNonEmpty {
+		if err := ensureEmptyTarget(target); err != nil {
+			return nil, err
+		}
+	}
+
+	util.Logger.Infow("backend migration started",
+		"from", result.From, "to", result.To, "resumed", result.Resumed)
+
+	if !checkpoint.CategoriesDone {
+		err = source.StreamCategories(checkpoint.CategoriesLast, migrationOptions.BatchSize,
+			func(entities []model.CategoryEntity) error {
+				if err := target.UpsertCategories(entities); err != nil {
+					return err
+				}
+				result.Categories.Copied += int64(len(entities))
+				checkpoint.CategoriesLast = entities[len(entities)-1].Id.Hex()
+				return saveCheckpoint(migrationOptions.CheckpointPath, checkpoint)
+			})
+		if err != nil {
+			return result, fmt.Errorf("copy categories failed (re-run to resume): %w", err)
+		}
+		checkpoint.CategoriesDone = true
+		if err := saveCheckpoint(migrationOptions.CheckpointPath, checkpoint); err != nil {
+			return result, err
+		}
+	}
+
+	if !checkpoint.CashFlowsDone {
+		err = source.StreamCashFlows(checkpoint.CashFlowsLast, migrationOptions.BatchSize,
+			func(entities []model.CashFlowEntity) error {
+				if err := target.UpsertCashFlows(entities); err != nil {
+					return err
+				}
+				result.CashFlows.Copied += int64(len(entities))
+				checkpoint.CashFlowsLast = entities[len(entities)-1].Id.Hex()
+				return saveCheckpoint(migrationOptions.CheckpointPath, checkpoint)
+			})
+		if err != nil {
+			return result, fmt.Errorf("copy cash flows failed (re-run to resume): %w", err)
+		}
+		checkpoint.CashFlowsDone = true
+		if err := saveCheckpoint(migrationOptions.CheckpointPath, checkpoint); err != nil {
+			return result, err
+		}
+	}
+
+	if !checkpoint.GoalsDone {
+		err = source.StreamGoals(checkpoint.GoalsLast, migrationOptions.BatchSize,
+			func(entities []model.GoalEntity) error {
+				if err := target.UpsertGoals(entities); err != nil {
+					return err
+				}
+				result.Goals.Copied += int64(len(entities))
+				checkpoint.GoalsLast = entities[len(entities)-1].Id.Hex()
+				return saveCheckpoint(migrationOptions.CheckpointPath, checkpoint)
+			})
+		if err != nil {
+			return result, fmt.Errorf("copy goals failed (re-run to resume): %w", err)
+		}
+		checkpoint.GoalsDone = true
+		if err := saveCheckpoint(migrationOptions.CheckpointPath, checkpoint); err != nil {
+			return result, err
+		}
+	}
+
+	if !checkpoint.DebtsDone {
+		err = source.StreamDebts(checkpoint.DebtsLast, migrationOptions.BatchSize,
+			func(entities []model.DebtEntity) error {
+				if err := target.UpsertDebts(entities); err != nil {
+					return err
+				}
+				result.Debts.Copied += int64(len(entities))
+				checkpoint.DebtsLast = entities[len(entities)-1].Id.Hex()
+				return saveCheckpoint(migrationOptions.CheckpointPath, checkpoint)
+			})
+		if err != nil {
+			return result, fmt.Errorf("copy debts failed (re-run to resume): %w", err)
+		}
+		checkpoint.DebtsDone = true
+		if err := saveCheckpoint(migrationOptions.CheckpointPath, checkpoint); err != nil {
+			return result, err
+		}
+	}
+
+	if err := verifyBackends(source, target, migrationOptions.BatchSize, result); err != nil {
+		return result, err
+	}
+
+	// Verified: the checkpoint is no longer needed
+	if migrationOptions.CheckpointPath != "" {
+		_ = os.Remove(migrationOptions.CheckpointPath)
+	}
+	util.Logger.Infow("backend migration completed",
+		"categories", result.Categories.Copied, "cash_flows", result.CashFlows.Copied, "goals", result.Goals.Copied,
+		"debts", result.Debts.Copied)
+	return result, nil
+}
+
+func verifyBackends(source, target backendStore, batchSize int, result *BackendMigrationResult) error {
+	var err error
+	if result.Categories.SourceCount, err = source.CountCategories(); err != nil {
+		return err
+	}
+	if result.Categories.TargetCount, err = target.CountCategories(); err != nil {
+		return err
+	}
+	if result.CashFlows.SourceCount, err = source.CountCashFlows(); err != nil {
+		return err
+	}
+	if result.CashFlows.TargetCount, err = target.CountCashFlows(); err != nil {
+		return err
+	}
+	if result.Goals.SourceCount, err = source.CountGoals(); err != nil {
+		return err
+	}
+	if result.Goals.TargetCount, err = target.CountGoals(); err != nil {
+		return err
+	}
+	if result.Debts.SourceCount, err = source.CountDebts(); err != nil {
+		return err
+	}
+	if result.Debts.TargetCount, err = target.CountDebts(); err != nil {
+		return err
+	}
+
+	sourceSums, err := checksumStore(source, batchSize)
+	if err != nil {
+		return err
+	}
+	targetSums, err := checksumStore(target, batchSize)
+	if err != nil {
+		return err
+	}
+	result.Categories.SourceChecksum, result.Categories.TargetChecksum = sourceSums.categories, targetSums.categories
+	result.CashFlows.SourceChecksum, result.CashFlows.TargetChecksum = sourceSums.cashFlows, targetSums.cashFlows
+	result.Goals.SourceChecksum, result.Goals.TargetChecksum = sourceSums.goals, targetSums.goals
+	result.Debts.SourceChecksum, result.Debts.TargetChecksum = sourceSums.debts, targetSums.debts
+
+	if !result.Categories.Verified() || !result.CashFlows.Verified() || !result.Goals.Verified() ||
+		!result.Debts.Verified() {
+		util.Logger.Errorw("backend migration verification failed", "categories", result.Categories,
+			"cash_flows", result.CashFlows, "goals", result.Goals, "debts", result.Debts)
+		return errors.New("verification failed: source and target differ (was the source written to during the copy?)")
+	}
+	return nil
+}
+
+func ensureEmptyTarget(target backendStore) error {
+	categoryCount, err := target.CountCategories()
+	if err != nil {
+		return err
+	}
+	cashFlowCount, err := target.CountCashFlows()
+	if err != nil {
+		return err
+	}
+	goalCount, err := target.CountGoals()
+	if err != nil {
+		return err
+	}
+	debtCount, err := target.CountDebts()
+	if err != nil {
+		return err
+	}
+	if categoryCount > 0 || cashFlowCount > 0 || goalCount > 0 || debtCount > 0 {
+		return fmt.Errorf("target %s is not empty (%d categories, %d cash flows, %d goals, %d debts)",
+			target.Name(), categoryCount, cashFlowCount, goalCount, debtCount)
+	}
+	return nil
+}
+
+// loadCheckpoint returns the saved progress for this source/target pair, or a fresh one
+func loadCheckpoint(migrationOptions BackendMigrationOptions, result *BackendMigrationResult) *migrationCheckpoint {
+	fresh := &migrationCheckpoint{From: result.From, To: result.To}
+	if migrationOptions.CheckpointPath == "" || migrationOptions.Restart {
+		return fresh
+	}
+
+	content, err := os.ReadFile(migrationOptions.CheckpointPath)
+	if err != nil {
+		return fresh
+	}
+
+	var saved migrationCheckpoint
+	if err := json.Unmarshal(content, &saved); err != nil || saved.From != result.From || saved.To != result.To {
+		util.Logger.Warnw("ignoring checkpoint for a different migration", "path", migrationOptions.CheckpointPath)
+		return fresh
+	}
+	return &saved
+}
+
+func saveCheckpoint(path string, checkpoint *migrationCheckpoint) error {
+	if path == "" {
+		return nil
+	}
+	content, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so an interruption never leaves a half-written checkpoint
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
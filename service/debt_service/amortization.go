@@ -0,0 +1,157 @@
+package debt_service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/shopspring/decimal"
+)
+
+// PaymentEarlyDays is how many days before a due date a payment still pays that installment
+const PaymentEarlyDays = 7
+
+// ledger is where a debt stands after some of its payments
+type ledger struct {
+	balance model.Money
+	covered int // installments whose interest a payment carried
+}
+
+// monthlyRate is the share of the balance charged as interest for one installment
+func monthlyRate(debtEntity model.DebtEntity) decimal.Decimal {
+	return decimal.NewFromFloat(debtEntity.AnnualRate).Div(decimal.NewFromInt(1200))
+}
+
+// monthlyPayment is the installment repaying the principal in equal payments over the term
+func monthlyPayment(debtEntity model.DebtEntity) model.Money {
+	rate := monthlyRate(debtEntity)
+	if rate.IsZero() {
+		return debtEntity.Principal.DivInt(int64(debtEntity.TermMonths)).Round(model.MoneyDisplayScale)
+	}
+	growth := decimal.NewFromInt(1).Add(rate).Pow(decimal.NewFromInt(int64(debtEntity.TermMonths)))
+	return debtEntity.Principal.Mul(rate.Mul(growth).Div(growth.Sub(decimal.NewFromInt(1)))).Round(model.MoneyDisplayScale)
+}
+
+// dueDate returns when installment number falls due, number months after the start on the same
+// day of the month where the month has it
+func dueDate(startDate time.Time, number int) time.Time {
+	month := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, number, 0)
+	return month.AddDate(0, 0, min(startDate.Day(), month.AddDate(0, 1, -1).Day())-1)
+}
+
+// dueCount counts the installments falling due up to date
+func dueCount(startDate, date time.Time) int {
+	count := (date.Year()-startDate.Year())*12 + int(date.Month()) - int(startDate.Month())
+	if count > 0 && dueDate(startDate, count).After(date) {
+		count--
+	}
+	return max(count, 0)
+}
+
+// interestDue is the interest a payment on date carries: a month of interest on the balance for each
+// installment falling due up to PaymentEarlyDays after it that no earlier payment carried. It returns
+// the installments covered after the payment too.
+func (state ledger) interestDue(debtEntity model.DebtEntity, date time.Time) (model.Money, int) {
+	due := dueCount(debtEntity.StartDate, date.AddDate(0, 0, PaymentEarlyDays))
+	if due <= state.covered || !state.balance.IsPositive() {
+		return model.Money{}, state.covered
+	}
+	interest := state.balance.Mul(monthlyRate(debtEntity)).Mul(decimal.NewFromInt(int64(due - state.covered)))
+	return interest.Round(model.MoneyDisplayScale), due
+}
+
+// sortPayments orders payments by date, the ones recorded first first on the same day
+func sortPayments(paymentList []model.CashFlowEntity) {
+	sort.SliceStable(paymentList, func(i, j int) bool {
+		return paymentBefore(paymentList[i], paymentList[j])
+	})
+}
+
+func paymentBefore(left, right model.CashFlowEntity) bool {
+	if !left.BelongsDate.Equal(right.BelongsDate) {
+		return left.BelongsDate.Before(right.BelongsDate)
+	}
+	return left.Id.Hex() < right.Id.Hex()
+}
+
+// replayPayments applies payments, in order and already split, to a debt and returns where it stands
+func replayPayments(debtEntity model.DebtEntity, paymentList []model.CashFlowEntity) ledger {
+	state := ledger{balance: debtEntity.Principal}
+	for _, payment := range paymentList {
+		_, state.covered = state.interestDue(debtEntity, payment.BelongsDate)
+		state.balance = state.balance.Sub(payment.Amount)
+	}
+	return state
+}
+
+// amortize lists the payments of a debt, in order and already split so their amount is principal,
+// followed by the installments left. Those repay the balance with the monthly payment from the
+// installment after the last one paid, the installment ending the term settling what remains, so
+// paying extra leaves fewer of them.
+func amortize(debtEntity model.DebtEntity, paymentList []model.CashFlowEntity) model.DebtSummary {
+	summary := model.DebtSummary{
+		Debt:                debtEntity,
+		MonthlyPayment:      monthlyPayment(debtEntity),
+		ScheduledPayoffDate: util.FormatDateToStringWithDash(dueDate(debtEntity.StartDate, debtEntity.TermMonths)),
+		Schedule:            []model.AmortizationRow{},
+	}
+
+	state := ledger{balance: debtEntity.Principal}
+	for _, payment := range paymentList {
+		var interest model.Money
+		interest, state.covered = state.interestDue(debtEntity, payment.BelongsDate)
+		state.balance = state.balance.Sub(payment.Amount)
+		summary.PrincipalPaid = summary.PrincipalPaid.Add(payment.Amount)
+		summary.InterestPaid = summary.InterestPaid.Add(interest)
+		summary.Schedule = append(summary.Schedule, model.AmortizationRow{
+			Number:     len(summary.Schedule) + 1,
+			Date:       util.FormatDateToStringWithDash(payment.BelongsDate),
+			Payment:    payment.Amount.Add(interest),
+			Principal:  payment.Amount,
+			Interest:   interest,
+			Balance:    nonNegative(state.balance),
+			Paid:       true,
+			CashFlowId: payment.Id.Hex(),
+		})
+	}
+	summary.PaymentsMade = len(paymentList)
+	summary.Balance = nonNegative(state.balance)
+
+	rate := monthlyRate(debtEntity)
+	for number := state.covered + 1; state.balance.IsPositive(); number++ {
+		interest := state.balance.Mul(rate).Round(model.MoneyDisplayScale)
+		principal := summary.MonthlyPayment.Sub(interest)
+		if number >= debtEntity.TermMonths || principal.Cmp(state.balance) > 0 {
+			principal = state.balance
+		}
+		state.balance = state.balance.Sub(principal)
+		date := util.FormatDateToStringWithDash(dueDate(debtEntity.StartDate, number))
+		if summary.NextPaymentDate == "" {
+			summary.NextPaymentDate = date
+		}
+		summary.RemainingPayments++
+		summary.RemainingInterest = summary.RemainingInterest.Add(interest)
+		summary.Schedule = append(summary.Schedule, model.AmortizationRow{
+			Number:    len(summary.Schedule) + 1,
+			Date:      date,
+			Payment:   principal.Add(interest),
+			Principal: principal,
+			Interest:  interest,
+			Balance:   state.balance,
+		})
+	}
+
+	summary.PaidOff = summary.RemainingPayments == 0
+	if rowCount := len(summary.Schedule); rowCount > 0 {
+		summary.PayoffDate = summary.Schedule[rowCount-1].Date
+	}
+	return summary
+}
+
+func nonNegative(amount model.Money) model.Money {
+	if amount.IsNegative() {
+		return model.Money{}
+	}
+	return amount
+}
@@ -0,0 +1,191 @@
+package debt_service
+
+import (
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newPayment is an expense of an exact amount, which may have cents
+func newPayment(date string, amount string) model.CashFlowEntity {
+	payment := testutil.NewCashFlow(primitive.NilObjectID, date, model.FlowTypeOutcome, "", 0)
+	payment.Amount, _ = model.NewMoneyFromString(amount)
+	return payment
+}
+
+// carLoan is 12000 at 12% a year over 12 months from January 15th 2026, 1% a month
+func carLoan() model.DebtEntity {
+	return model.DebtEntity{
+		Name:       "Car loan",
+		Principal:  model.NewMoneyFromInt(12000),
+		AnnualRate: 12,
+		TermMonths: 12,
+		StartDate:  testutil.Day("2026-01-15"),
+	}
+}
+
+func TestMonthlyPayment(t *testing.T) {
+	testCases := []struct {
+		principal  int64
+		annualRate float64
+		termMonths int
+		expected   string
+	}{
+		{12000, 12, 12, "1066.19"},
+		{200000, 6, 360, "1199.10"},
+		{10000, 0, 3, "3333.33"},
+	}
+	for _, testCase := range testCases {
+		debtEntity := model.DebtEntity{
+			Principal:  model.NewMoneyFromInt(testCase.principal),
+			AnnualRate: testCase.annualRate,
+			TermMonths: testCase.termMonths,
+		}
+		if actual := monthlyPayment(debtEntity).String(); actual != testCase.expected {
+			t.Errorf("%+v: expected %s, got %s", testCase, testCase.expected, actual)
+		}
+	}
+}
+
+func TestDueDate(t *testing.T) {
+	startDate := testutil.Day("2026-01-31")
+	if date := dueDate(startDate, 1); !date.Equal(testutil.Day("2026-02-28")) {
+		t.Errorf("expected the end of February, got %s", date)
+	}
+	if date := dueDate(startDate, 2); !date.Equal(testutil.Day("2026-03-31")) {
+		t.Errorf("expected the end of March, got %s", date)
+	}
+	if count := dueCount(startDate, testutil.Day("2026-02-27")); count != 0 {
+		t.Errorf("expected nothing due before February 28th, got %d", count)
+	}
+	if count := dueCount(startDate, testutil.Day("2026-02-28")); count != 1 {
+		t.Errorf("expected the first installment due on February 28th, got %d", count)
+	}
+	if count := dueCount(startDate, testutil.Day("2025-12-01")); count != 0 {
+		t.Errorf("expected nothing due before the start, got %d", count)
+	}
+}
+
+func TestAmortize_Schedule(t *testing.T) {
+	summary := amortize(carLoan(), nil)
+
+	if len(summary.Schedule) != 12 || summary.RemainingPayments != 12 || summary.PaymentsMade != 0 {
+		t.Fatalf("expected 12 installments, got %+v", summary)
+	}
+	first, last := summary.Schedule[0], summary.Schedule[11]
+	if first.Date != "2026-02-15" || first.Interest.String() != "120.00" || first.Principal.String() != "946.19" || first.Balance.String() != "11053.81" {
+		t.Errorf("unexpected first installment %+v", first)
+	}
+	if last.Date != "2027-01-15" || last.Principal.String() != "1055.58" || last.Payment.String() != "1066.14" || !last.Balance.IsZero() {
+		t.Errorf("expected the last installment to settle the rest, got %+v", last)
+	}
+	if summary.RemainingInterest.String() != "794.23" || summary.NextPaymentDate != "2026-02-15" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if summary.PayoffDate != "2027-01-15" || summary.ScheduledPayoffDate != "2027-01-15" || summary.PaidOff {
+		t.Errorf("expected to pay off at the end of the term, got %s", summary.PayoffDate)
+	}
+}
+
+func TestAmortize_ExtraPayment(t *testing.T) {
+	paymentList := []model.CashFlowEntity{
+		newPayment("2026-02-15", "946.19"),
+		newPayment("2026-03-01", "2000"),
+		newPayment("2026-03-13", "975.65"),
+	}
+	summary := amortize(carLoan(), paymentList)
+
+	if summary.PaymentsMade != 3 || summary.PrincipalPaid.String() != "3921.84" || summary.InterestPaid.String() != "210.54" {
+		t.Errorf("unexpected payments %+v", summary)
+	}
+	extra, regular := summary.Schedule[1], summary.Schedule[2]
+	if !extra.Paid || !extra.Interest.IsZero() || extra.CashFlowId != paymentList[1].Id.Hex() {
+		t.Errorf("expected the extra payment to be all principal, got %+v", extra)
+	}
+	if regular.Interest.String() != "90.54" || regular.Payment.String() != "1066.19" || regular.Balance.String() != "8078.16" {
+		t.Errorf("expected a payment a few days early to pay the March installment, got %+v", regular)
+	}
+
+	if summary.Balance.String() != "8078.16" || summary.RemainingPayments != 8 || summary.NextPaymentDate != "2026-04-15" {
+		t.Errorf("expected 8 installments left from April, got %+v", summary)
+	}
+	if summary.PayoffDate != "2026-11-15" || summary.RemainingInterest.String() != "364.75" {
+		t.Errorf("expected the extra payment to pay off in November, got %s with %s interest", summary.PayoffDate, summary.RemainingInterest)
+	}
+	if last := summary.Schedule[len(summary.Schedule)-1]; last.Payment.String() != "979.58" || last.Paid {
+		t.Errorf("unexpected last installment %+v", last)
+	}
+}
+
+func TestAmortize_PaidOff(t *testing.T) {
+	paymentList := []model.CashFlowEntity{
+		newPayment("2026-02-15", "946.19"),
+		newPayment("2026-03-15", "11500"),
+	}
+	summary := amortize(carLoan(), paymentList)
+	if !summary.PaidOff || !summary.Balance.IsZero() || summary.NextPaymentDate != "" || summary.PayoffDate != "2026-03-15" {
+		t.Errorf("expected the loan to be paid off in March, got %+v", summary)
+	}
+	if len(summary.Schedule) != 2 || summary.RemainingPayments != 0 {
+		t.Errorf("expected no installments left, got %+v", summary.Schedule)
+	}
+}
+
+func TestInterestDue(t *testing.T) {
+	debtEntity := carLoan()
+	testCases := []struct {
+		name        string
+		paymentList []model.CashFlowEntity
+		date        string
+		expected    string
+		covered     int
+	}{
+		{"on the due date", nil, "2026-02-15", "120.00", 1},
+		{"a week early", nil, "2026-02-08", "120.00", 1},
+		{"before the first installment", nil, "2026-02-01", "0.00", 0},
+		{"two installments late", nil, "2026-03-20", "240.00", 2},
+		{"extra within the month", []model.CashFlowEntity{newPayment("2026-02-15", "946.19")}, "2026-02-20", "0.00", 1},
+		{"next installment", []model.CashFlowEntity{newPayment("2026-02-15", "946.19")}, "2026-03-15", "110.54", 2},
+		{"after paying off", []model.CashFlowEntity{newPayment("2026-02-15", "12000")}, "2026-03-15", "0.00", 1},
+	}
+	for _, testCase := range testCases {
+		interest, covered := replayPayments(debtEntity, testCase.paymentList).interestDue(debtEntity, testutil.Day(testCase.date))
+		if interest.String() != testCase.expected || covered != testCase.covered {
+			t.Errorf("%s: expected %s covering %d, got %s covering %d", testCase.name, testCase.expected, testCase.covered, interest, covered)
+		}
+	}
+}
+
+func TestSortPayments(t *testing.T) {
+	first, second, third := newPayment("2026-03-01", "1"), newPayment("2026-03-01", "2"), newPayment("2026-02-01", "3")
+	paymentList := []model.CashFlowEntity{second, first, third}
+	sortPayments(paymentList)
+	if paymentList[0].Id != third.Id || paymentList[1].Id != first.Id || paymentList[2].Id != second.Id {
+		t.Errorf("expected the date, then the order of recording, got %+v", paymentList)
+	}
+}
+
+func TestBuildDebtEntity(t *testing.T) {
+	testCases := []struct {
+		field   string
+		debtDTO model.DebtDTO
+	}{
+		{"name", model.DebtDTO{Name: " ", Principal: model.NewMoneyFromInt(1000), AnnualRate: 5, TermMonths: 12}},
+		{"principal", model.DebtDTO{Name: "Loan", Principal: model.NewMoneyFromInt(0), AnnualRate: 5, TermMonths: 12}},
+		{"annual_rate", model.DebtDTO{Name: "Loan", Principal: model.NewMoneyFromInt(1000), AnnualRate: -1, TermMonths: 12}},
+		{"term_months", model.DebtDTO{Name: "Loan", Principal: model.NewMoneyFromInt(1000), AnnualRate: 5, TermMonths: 0}},
+		{"term_months", model.DebtDTO{Name: "Loan", Principal: model.NewMoneyFromInt(1000), AnnualRate: 5, TermMonths: 601}},
+		{"start_date", model.DebtDTO{Name: "Loan", Principal: model.NewMoneyFromInt(1000), AnnualRate: 5, TermMonths: 12, StartDate: "2026-13"}},
+		{"payment_category_name", model.DebtDTO{Name: "Loan", Principal: model.NewMoneyFromInt(1000), AnnualRate: 5, TermMonths: 12}},
+		{"payment_category_id", model.DebtDTO{Name: "Loan", Principal: model.NewMoneyFromInt(1000), AnnualRate: 5, TermMonths: 12, PaymentCategoryId: "nope"}},
+	}
+	for _, testCase := range testCases {
+		_, err := buildDebtEntity(testCase.debtDTO, testutil.Day("2026-10-19"))
+		if appError, ok := err.(*errors.AppError); !ok || appError.Field != testCase.field {
+			t.Errorf("%+v: expected an error on %s, got %v", testCase.debtDTO, testCase.field, err)
+		}
+	}
+}
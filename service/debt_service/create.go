@@ -0,0 +1,101 @@
+package debt_service
+
+import (
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/debt_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxTermMonths is the longest term a debt may run, 50 years
+const MaxTermMonths = 600
+
+// CreateService stores a new debt starting today unless the DTO says otherwise; with SplitExisting
+// the payments already recorded since its start are split too
+func CreateService(debtDTO model.DebtDTO, origin model.ChangeOrigin) (model.DebtEntity, error) {
+	debtEntity, err := buildDebtEntity(debtDTO, util.Today())
+	if err != nil {
+		return model.DebtEntity{}, err
+	}
+	if err := checkPaymentCategory(debtEntity, primitive.NilObjectID); err != nil {
+		return model.DebtEntity{}, err
+	}
+
+	plainId, err := debt_mapper.INSTANCE.InsertDebt(debtEntity)
+	if err != nil {
+		return model.DebtEntity{}, errors.NewDatabaseError("debt create failed", err)
+	}
+	newDebtEntity, err := debt_mapper.INSTANCE.GetDebtByObjectId(plainId)
+	if err != nil {
+		return model.DebtEntity{}, errors.NewDatabaseError("debt create failed", err)
+	}
+	util.Logger.Infow("debt created", "debt", newDebtEntity.ToString())
+
+	if debtDTO.SplitExisting {
+		splitCount := splitExisting(newDebtEntity, origin)
+		util.Logger.Infow("existing debt payments split", "debt", newDebtEntity.Name, "count", splitCount)
+	}
+	return newDebtEntity, nil
+}
+
+// buildDebtEntity checks a DTO and turns it into a debt; the start date defaults to defaultStart
+func buildDebtEntity(debtDTO model.DebtDTO, defaultStart time.Time) (model.DebtEntity, error) {
+	name, err := validation.ValidateNameAndRemark(debtDTO.Name, debtDTO.Remark)
+	if err != nil {
+		return model.DebtEntity{}, err
+	}
+	if err := validation.ValidateMoney(debtDTO.Principal); err != nil {
+		return model.DebtEntity{}, errors.NewFieldValidationError("principal", "must be positive and within the maximum amount")
+	}
+	if debtDTO.AnnualRate < 0 || debtDTO.AnnualRate > 100 {
+		return model.DebtEntity{}, errors.NewFieldValidationError("annual_rate", "must be a percentage between 0 and 100")
+	}
+	if debtDTO.TermMonths < 1 || debtDTO.TermMonths > MaxTermMonths {
+		return model.DebtEntity{}, errors.NewFieldValidationError("term_months", "must be between 1 and 600")
+	}
+	startDate, err := validation.ValidateStartDate(debtDTO.StartDate, defaultStart)
+	if err != nil {
+		return model.DebtEntity{}, err
+	}
+
+	paymentCategoryId, err := validation.ResolveCategory("payment_category", debtDTO.PaymentCategoryId, debtDTO.PaymentCategoryName, true)
+	if err != nil {
+		return model.DebtEntity{}, err
+	}
+	interestCategoryId, err := validation.ResolveCategory("interest_category", debtDTO.InterestCategoryId, debtDTO.InterestCategoryName, true)
+	if err != nil {
+		return model.DebtEntity{}, err
+	}
+	if paymentCategoryId == interestCategoryId {
+		return model.DebtEntity{}, errors.NewFieldValidationError("interest_category", "must differ from the payment category")
+	}
+	return model.DebtEntity{
+		Name:               name,
+		Principal:          debtDTO.Principal,
+		AnnualRate:         debtDTO.AnnualRate,
+		TermMonths:         debtDTO.TermMonths,
+		StartDate:          startDate,
+		PaymentCategoryId:  paymentCategoryId,
+		InterestCategoryId: interestCategoryId,
+		Remark:             debtDTO.Remark,
+	}, nil
+}
+
+// checkPaymentCategory refuses a payment category another debt than debtId already takes its
+// payments from, as a payment could not tell which debt it repays
+func checkPaymentCategory(debtEntity model.DebtEntity, debtId primitive.ObjectID) error {
+	debtList, err := debt_mapper.INSTANCE.GetAllDebts()
+	if err != nil {
+		return errors.NewDatabaseError("debt query failed", err)
+	}
+	for _, otherDebt := range debtList {
+		if otherDebt.Id != debtId && otherDebt.PaymentCategoryId == debtEntity.PaymentCategoryId {
+			return errors.NewAlreadyExistsError("payment category already holds the payments of debt " + otherDebt.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package debt_service
+
+import (
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/debt_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/tombstone_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+)
+
+// DeleteService removes a debt and returns it; its payments and their split stay
+func DeleteService(plainId string) (model.DebtEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return model.DebtEntity{}, err
+	}
+	deletedEntity, err := debt_mapper.INSTANCE.DeleteDebtByObjectId(plainId)
+	if err != nil {
+		return model.DebtEntity{}, errors.NewDatabaseError("debt delete failed", err)
+	}
+	if deletedEntity.IsEmpty() {
+		return model.DebtEntity{}, errors.NewNotFoundError("debt not found")
+	}
+	recordTombstone(deletedEntity)
+	util.Logger.Infow("debt deleted", "debt", deletedEntity.ToString())
+	return deletedEntity, nil
+}
+
+// recordTombstone remembers a deleted debt so incremental backups can replay the deletion
+func recordTombstone(deletedEntity model.DebtEntity) {
+	tombstone := model.NewTombstone(model.TombstoneTypeDebt, deletedEntity.Id)
+	if err := tombstone_mapper.INSTANCE.InsertTombstones([]model.TombstoneEntity{tombstone}); err != nil {
+		util.Logger.Errorw("record debt tombstone failed, take a full backup", "error", err)
+	}
+}
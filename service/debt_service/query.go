@@ -0,0 +1,40 @@
+package debt_service
+
+import (
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/debt_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+)
+
+// QueryService returns a debt with its balance and its amortization table
+func QueryService(plainId string) (model.DebtSummary, error) {
+	debtEntity, err := getDebt(plainId)
+	if err != nil {
+		return model.DebtSummary{}, err
+	}
+	return summarizeDebt(debtEntity), nil
+}
+
+// ListService returns every debt with its balance, without the amortization tables
+func ListService() ([]model.DebtSummary, error) {
+	debtList, err := debt_mapper.INSTANCE.GetAllDebts()
+	if err != nil {
+		return nil, errors.NewDatabaseError("debt query failed", err)
+	}
+	summaryList := []model.DebtSummary{}
+	for _, debtEntity := range debtList {
+		summary := summarizeDebt(debtEntity)
+		summary.Schedule = nil
+		summaryList = append(summaryList, summary)
+	}
+	return summaryList, nil
+}
+
+// summarizeDebt reads the payments of a debt and the names of its categories
+func summarizeDebt(debtEntity model.DebtEntity) model.DebtSummary {
+	summary := amortize(debtEntity, debtPayments(debtEntity))
+	summary.PaymentCategory = category_mapper.INSTANCE.GetCategoryByObjectId(debtEntity.PaymentCategoryId.Hex()).Name
+	summary.InterestCategory = category_mapper.INSTANCE.GetCategoryByObjectId(debtEntity.InterestCategoryId.Hex()).Name
+	return summary
+}
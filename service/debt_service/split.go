@@ -0,0 +1,158 @@
+package debt_service
+
+import (
+	"sort"
+
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/debt_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// splitRemarkPrefix starts the remark of the interest split off a payment, followed by the payment's id;
+// it marks the payment as split, so splitting it again does nothing
+const splitRemarkPrefix = "split from "
+
+// SplitPayment moves the interest part of a new debt payment to the debt's interest category, as an
+// expense of its own on the same day, and returns the payment holding what is left: its principal part.
+// Cash flows that are no payment come back unchanged, as do payments that carry no interest or fail
+// to split.
+func SplitPayment(cashFlow model.CashFlowEntity, origin model.ChangeOrigin) model.CashFlowEntity {
+	if cashFlow.FlowType == model.FlowTypeIncome || cashFlow.Id.IsZero() {
+		return cashFlow
+	}
+	debtList, err := debt_mapper.INSTANCE.GetAllDebts()
+	if err != nil {
+		return cashFlow
+	}
+	for _, debtEntity := range debtList {
+		if debtEntity.PaymentCategoryId == cashFlow.CategoryId && !cashFlow.BelongsDate.Before(debtEntity.StartDate) {
+			return splitPayment(debtEntity, cashFlow, origin)
+		}
+	}
+	return cashFlow
+}
+
+// SplitPayments splits new cash flows like SplitPayment, oldest first, so each payment is charged
+// the interest left after the earlier ones; the results come back in the order given
+func SplitPayments(cashFlowList []model.CashFlowEntity, origin model.ChangeOrigin) []model.CashFlowEntity {
+	orderList := make([]int, len(cashFlowList))
+	for index := range orderList {
+		orderList[index] = index
+	}
+	sort.SliceStable(orderList, func(i, j int) bool {
+		return paymentBefore(cashFlowList[orderList[i]], cashFlowList[orderList[j]])
+	})
+
+	resultList := make([]model.CashFlowEntity, len(cashFlowList))
+	for _, index := range orderList {
+		resultList[index] = SplitPayment(cashFlowList[index], origin)
+	}
+	return resultList
+}
+
+// splitExisting splits every payment recorded since the start of a debt, oldest first, and returns
+// how many carried interest
+func splitExisting(debtEntity model.DebtEntity, origin model.ChangeOrigin) int {
+	splitCount := 0
+	for _, payment := range debtPayments(debtEntity) {
+		if splitPayment(debtEntity, payment, origin).Version != payment.Version {
+			splitCount++
+		}
+	}
+	return splitCount
+}
+
+// debtPayments reads the expenses of the payment category since the start of a debt, in order
+func debtPayments(debtEntity model.DebtEntity) []model.CashFlowEntity {
+	var paymentList []model.CashFlowEntity
+	for _, cashFlow := range cash_flow_mapper.INSTANCE.GetCashFlowsByCategoryId(debtEntity.PaymentCategoryId.Hex()) {
+		if cashFlow.FlowType != model.FlowTypeIncome && !cashFlow.BelongsDate.Before(debtEntity.StartDate) {
+			paymentList = append(paymentList, cashFlow)
+		}
+	}
+	sortPayments(paymentList)
+	return paymentList
+}
+
+// isSplit reports whether a payment was split before, looking at the cash flows of its day
+func isSplit(debtEntity model.DebtEntity, payment model.CashFlowEntity) bool {
+	return splitBefore(debtEntity, payment, cash_flow_mapper.INSTANCE.GetCashFlowsByBelongsDate(payment.BelongsDate))
+}
+
+// splitBefore decides whether a payment was split before from the cash flows of its day: an interest
+// cash flow links back to it, or the whole payment already moved to the interest category
+func splitBefore(debtEntity model.DebtEntity, payment model.CashFlowEntity, sameDayList []model.CashFlowEntity) bool {
+	if payment.CategoryId == debtEntity.InterestCategoryId {
+		return true
+	}
+	remark := splitRemark(payment.Id)
+	for _, cashFlow := range sameDayList {
+		if cashFlow.Remark == remark {
+			return true
+		}
+	}
+	return false
+}
+
+func splitRemark(paymentId primitive.ObjectID) string {
+	return splitRemarkPrefix + paymentId.Hex()
+}
+
+// splitPayment charges a payment the interest due after the payments before it. A payment not
+// covering the interest moves to the interest category whole and leaves the installment unpaid.
+// A payment split before, even for a debt since deleted, is left as it is.
+func splitPayment(debtEntity model.DebtEntity, payment model.CashFlowEntity, origin model.ChangeOrigin) model.CashFlowEntity {
+	if isSplit(debtEntity, payment) {
+		return payment
+	}
+	var earlierList []model.CashFlowEntity
+	for _, cashFlow := range debtPayments(debtEntity) {
+		if cashFlow.Id != payment.Id && paymentBefore(cashFlow, payment) {
+			earlierList = append(earlierList, cashFlow)
+		}
+	}
+	interest, _ := replayPayments(debtEntity, earlierList).interestDue(debtEntity, payment.BelongsDate)
+	if !interest.IsPositive() {
+		return payment
+	}
+
+	principalPart := payment
+	if interest.Cmp(payment.Amount) >= 0 {
+		principalPart.CategoryId = debtEntity.InterestCategoryId
+	} else {
+		principalPart.Amount = payment.Amount.Sub(interest)
+	}
+	updatedEntity := cash_flow_mapper.INSTANCE.UpdateCashFlowByEntity(payment.Id.Hex(), principalPart)
+	if updatedEntity.IsEmpty() {
+		util.Logger.Warnw("debt payment not split, it was changed meanwhile", "debt", debtEntity.Name, "cash_flow", payment.ToString())
+		return payment
+	}
+	revision_service.RecordChange(model.RevisionTypeCashFlow, updatedEntity.Id, model.RevisionActionUpdate,
+		revision_service.CashFlowFields(payment), revision_service.CashFlowFields(updatedEntity), origin)
+	if updatedEntity.CategoryId == debtEntity.InterestCategoryId {
+		util.Logger.Warnw("debt payment only covers interest", "debt", debtEntity.Name, "cash_flow", updatedEntity.ToString())
+		return updatedEntity
+	}
+
+	newPlainId := cash_flow_mapper.INSTANCE.InsertCashFlowByEntity(model.CashFlowEntity{
+		CategoryId:  debtEntity.InterestCategoryId,
+		BelongsDate: payment.BelongsDate,
+		FlowType:    payment.FlowType,
+		Amount:      interest,
+		Description: debtEntity.Name + " interest",
+		Remark:      splitRemark(payment.Id),
+	})
+	if newPlainId == "" {
+		util.Logger.Errorw("insert debt interest failed", "debt", debtEntity.Name, "cash_flow", updatedEntity.ToString(), "interest", interest.String())
+		return updatedEntity
+	}
+	interestCashFlow := cash_flow_mapper.INSTANCE.GetCashFlowByObjectId(newPlainId)
+	revision_service.RecordChange(model.RevisionTypeCashFlow, interestCashFlow.Id, model.RevisionActionCreate,
+		nil, revision_service.CashFlowFields(interestCashFlow), origin)
+	util.Logger.Infow("debt payment split", "debt", debtEntity.Name, "cash_flow", updatedEntity.Id.Hex(),
+		"principal", updatedEntity.Amount.String(), "interest", interest.String())
+	return updatedEntity
+}
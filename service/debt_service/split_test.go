@@ -0,0 +1,64 @@
+package debt_service
+
+import (
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// splitCarLoan is carLoan with its payment and interest categories
+func splitCarLoan() model.DebtEntity {
+	debtEntity := carLoan()
+	debtEntity.PaymentCategoryId = primitive.NewObjectID()
+	debtEntity.InterestCategoryId = primitive.NewObjectID()
+	return debtEntity
+}
+
+// splitInterest is the interest cash flow splitPayment inserts for a payment
+func splitInterest(debtEntity model.DebtEntity, payment model.CashFlowEntity, amount string) model.CashFlowEntity {
+	interest := testutil.NewCashFlow(debtEntity.InterestCategoryId, "2026-01-01", model.FlowTypeOutcome,
+		debtEntity.Name+" interest", 0)
+	interest.BelongsDate = payment.BelongsDate
+	interest.Amount, _ = model.NewMoneyFromString(amount)
+	interest.Remark = splitRemark(payment.Id)
+	return interest
+}
+
+func TestSplitBefore(t *testing.T) {
+	debtEntity := splitCarLoan()
+	payment := newPayment("2026-02-15", "946.19")
+	payment.CategoryId = debtEntity.PaymentCategoryId
+	interest := splitInterest(debtEntity, payment, "120.00")
+	otherPayment := newPayment("2026-02-15", "1066.19")
+	otherPayment.CategoryId = debtEntity.PaymentCategoryId
+
+	// An export holds the reduced payment and its interest; importing it keeps both ids
+	reimported := payment
+	reimported.Description = "imported"
+
+	// Too small to cover the interest, so the payment moved to the interest category whole
+	smallPayment := newPayment("2026-02-15", "80.00")
+	smallPayment.CategoryId = debtEntity.InterestCategoryId
+
+	testCases := []struct {
+		name        string
+		payment     model.CashFlowEntity
+		sameDayList []model.CashFlowEntity
+		expected    bool
+	}{
+		{"Unsplit payment", otherPayment, []model.CashFlowEntity{otherPayment}, false},
+		{"Imported with its interest", reimported, []model.CashFlowEntity{interest, reimported}, true},
+		{"Second split_existing run", payment, []model.CashFlowEntity{payment, interest, otherPayment}, true},
+		{"Interest of another payment", otherPayment, []model.CashFlowEntity{payment, interest, otherPayment}, false},
+		{"Payment smaller than the interest", smallPayment, []model.CashFlowEntity{smallPayment}, true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := splitBefore(debtEntity, testCase.payment, testCase.sameDayList); actual != testCase.expected {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package debt_service
+
+import (
+	"strconv"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/debt_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+)
+
+// UpdateService replaces a debt with the DTO; a missing start date keeps the stored one. Payments
+// split before keep their interest, the schedule follows the new terms.
+// expectedVersion is the version the caller last read, or model.AnyVersion to overwrite whatever is stored
+func UpdateService(plainId string, debtDTO model.DebtDTO, expectedVersion int64) (model.DebtEntity, error) {
+	existingDebt, err := getDebt(plainId)
+	if err != nil {
+		return model.DebtEntity{}, err
+	}
+	if expectedVersion != model.AnyVersion && existingDebt.Version != expectedVersion {
+		return model.DebtEntity{}, errors.NewPreconditionFailedError(
+			"debt was changed since version " + strconv.FormatInt(expectedVersion, 10) + ", fetch it again")
+	}
+
+	debtEntity, err := buildDebtEntity(debtDTO, existingDebt.StartDate)
+	if err != nil {
+		return model.DebtEntity{}, err
+	}
+	if err := checkPaymentCategory(debtEntity, existingDebt.Id); err != nil {
+		return model.DebtEntity{}, err
+	}
+	debtEntity.Version = existingDebt.Version
+
+	// The mapper refuses when another update got in since the read above
+	updatedEntity, err := debt_mapper.INSTANCE.UpdateDebtByEntity(plainId, debtEntity)
+	if err != nil {
+		return model.DebtEntity{}, errors.NewDatabaseError("debt update failed", err)
+	}
+	if updatedEntity.IsEmpty() {
+		return model.DebtEntity{}, errors.NewPreconditionFailedError("debt was changed or deleted meanwhile, fetch it again")
+	}
+	util.Logger.Infow("debt updated", "debt", updatedEntity.ToString())
+	return updatedEntity, nil
+}
+
+// getDebt reads a debt, refusing unknown and malformed ids
+func getDebt(plainId string) (model.DebtEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return model.DebtEntity{}, err
+	}
+	debtEntity, err := debt_mapper.INSTANCE.GetDebtByObjectId(plainId)
+	if err != nil {
+		return model.DebtEntity{}, errors.NewDatabaseError("debt query failed", err)
+	}
+	if debtEntity.IsEmpty() {
+		return model.DebtEntity{}, errors.NewNotFoundError("debt not found")
+	}
+	return debtEntity, nil
+}
@@ -0,0 +1,71 @@
+package goal_service
+
+import (
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/goal_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+)
+
+// CreateService stores a new goal starting today unless the DTO says otherwise
+func CreateService(goalDTO model.GoalDTO) (model.GoalEntity, error) {
+	goalEntity, err := buildGoalEntity(goalDTO, util.Today())
+	if err != nil {
+		return model.GoalEntity{}, err
+	}
+
+	plainId, err := goal_mapper.INSTANCE.InsertGoal(goalEntity)
+	if err != nil {
+		return model.GoalEntity{}, errors.NewDatabaseError("goal create failed", err)
+	}
+	newGoalEntity, err := goal_mapper.INSTANCE.GetGoalByObjectId(plainId)
+	if err != nil {
+		return model.GoalEntity{}, errors.NewDatabaseError("goal create failed", err)
+	}
+	util.Logger.Infow("goal created", "goal", newGoalEntity.ToString())
+	return newGoalEntity, nil
+}
+
+// buildGoalEntity checks a DTO and turns it into a goal; the start date defaults to defaultStart
+func buildGoalEntity(goalDTO model.GoalDTO, defaultStart time.Time) (model.GoalEntity, error) {
+	name, err := validation.ValidateNameAndRemark(goalDTO.Name, goalDTO.Remark)
+	if err != nil {
+		return model.GoalEntity{}, err
+	}
+	if err := validation.ValidateMoney(goalDTO.TargetAmount); err != nil {
+		return model.GoalEntity{}, err
+	}
+
+	if goalDTO.Deadline == "" {
+		return model.GoalEntity{}, errors.NewFieldValidationError("deadline", "cannot be empty")
+	}
+	_, deadlineEnd, ok := query.ParsePeriod(goalDTO.Deadline)
+	if !ok {
+		return model.GoalEntity{}, errors.NewFieldValidationError("deadline", "invalid date "+goalDTO.Deadline)
+	}
+	startDate, err := validation.ValidateStartDate(goalDTO.StartDate, defaultStart)
+	if err != nil {
+		return model.GoalEntity{}, err
+	}
+	deadline := deadlineEnd.AddDate(0, 0, -1)
+	if deadline.Before(startDate) {
+		return model.GoalEntity{}, errors.NewFieldValidationError("deadline", "deadline should not be before the start date")
+	}
+
+	categoryId, err := validation.ResolveCategory("category", goalDTO.CategoryId, goalDTO.CategoryName, false)
+	if err != nil {
+		return model.GoalEntity{}, err
+	}
+	return model.GoalEntity{
+		Name:         name,
+		TargetAmount: goalDTO.TargetAmount,
+		StartDate:    startDate,
+		Deadline:     deadline,
+		CategoryId:   categoryId,
+		Remark:       goalDTO.Remark,
+	}, nil
+}
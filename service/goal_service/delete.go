@@ -0,0 +1,35 @@
+package goal_service
+
+import (
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/goal_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/tombstone_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+)
+
+// DeleteService removes a goal and returns it; the cash flows it measured stay
+func DeleteService(plainId string) (model.GoalEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return model.GoalEntity{}, err
+	}
+	deletedEntity, err := goal_mapper.INSTANCE.DeleteGoalByObjectId(plainId)
+	if err != nil {
+		return model.GoalEntity{}, errors.NewDatabaseError("goal delete failed", err)
+	}
+	if deletedEntity.IsEmpty() {
+		return model.GoalEntity{}, errors.NewNotFoundError("goal not found")
+	}
+	recordTombstone(deletedEntity)
+	util.Logger.Infow("goal deleted", "goal", deletedEntity.ToString())
+	return deletedEntity, nil
+}
+
+// recordTombstone remembers a deleted goal so incremental backups can replay the deletion
+func recordTombstone(deletedEntity model.GoalEntity) {
+	tombstone := model.NewTombstone(model.TombstoneTypeGoal, deletedEntity.Id)
+	if err := tombstone_mapper.INSTANCE.InsertTombstones([]model.TombstoneEntity{tombstone}); err != nil {
+		util.Logger.Errorw("record goal tombstone failed, take a full backup", "error", err)
+	}
+}
@@ -0,0 +1,71 @@
+package goal_service
+
+import (
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// goalProgress sums what cashFlowList saved towards a goal up to asOf. Without categoryIdSet every
+// cash flow counts, income adding and expense taking away; with it only those of the categories,
+// income adding for an income category and expense adding for an expense category. The required
+// monthly amount spreads what remains over the months left, the current and the deadline's included.
+func goalProgress(goalEntity model.GoalEntity, asOf time.Time, cashFlowList []model.CashFlowEntity,
+	categoryName string, categoryIdSet map[primitive.ObjectID]bool, incomeAdds bool) model.GoalProgress {
+	progress := model.GoalProgress{
+		Goal:     goalEntity,
+		Category: categoryName,
+		AsOf:     util.FormatDateToStringWithDash(asOf),
+	}
+	for _, cashFlow := range cashFlowList {
+		if cashFlow.BelongsDate.Before(goalEntity.StartDate) || cashFlow.BelongsDate.After(asOf) {
+			continue
+		}
+		if categoryIdSet != nil && !categoryIdSet[cashFlow.CategoryId] {
+			continue
+		}
+		if (cashFlow.FlowType == model.FlowTypeIncome) == incomeAdds {
+			progress.Saved = progress.Saved.Add(cashFlow.Amount)
+		} else {
+			progress.Saved = progress.Saved.Sub(cashFlow.Amount)
+		}
+	}
+	progress.Saved = progress.Saved.Round(model.MoneyDisplayScale)
+
+	if progress.Saved.Cmp(goalEntity.TargetAmount) < 0 {
+		progress.Remaining = goalEntity.TargetAmount.Sub(progress.Saved).Round(model.MoneyDisplayScale)
+	}
+	progress.Percent = progress.Saved.Decimal().Div(goalEntity.TargetAmount.Decimal()).
+		Mul(decimal.NewFromInt(100)).Round(1).InexactFloat64()
+
+	if !asOf.After(goalEntity.Deadline) {
+		progress.MonthsLeft = monthsBetween(asOf, goalEntity.Deadline) + 1
+		progress.RequiredMonthly = progress.Remaining.DivInt(int64(progress.MonthsLeft)).Round(model.MoneyDisplayScale)
+	} else {
+		progress.RequiredMonthly = progress.Remaining
+	}
+	if !asOf.Before(goalEntity.StartDate) {
+		monthsSaving := monthsBetween(goalEntity.StartDate, asOf) + 1
+		progress.AverageMonthly = progress.Saved.DivInt(int64(monthsSaving)).Round(model.MoneyDisplayScale)
+	}
+
+	switch {
+	case progress.Remaining.IsZero():
+		progress.Status = model.GoalStatusAchieved
+	case progress.MonthsLeft == 0:
+		progress.Status = model.GoalStatusOverdue
+	case progress.AverageMonthly.Cmp(progress.RequiredMonthly) >= 0:
+		progress.Status = model.GoalStatusOnTrack
+	default:
+		progress.Status = model.GoalStatusBehind
+	}
+	return progress
+}
+
+// monthsBetween counts the months from the month of from to the month of to
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
@@ -0,0 +1,139 @@
+package goal_service
+
+import (
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newGoal(target int64, start, deadline string) model.GoalEntity {
+	return model.GoalEntity{
+		Name:         "Holiday",
+		TargetAmount: model.NewMoneyFromInt(target),
+		StartDate:    testutil.Day(start),
+		Deadline:     testutil.Day(deadline),
+	}
+}
+
+func TestGoalProgress_Ledger(t *testing.T) {
+	food, job := primitive.NewObjectID(), primitive.NewObjectID()
+	cashFlowList := []model.CashFlowEntity{
+		testutil.NewCashFlow(job, "2026-06-30", model.FlowTypeIncome, "", 5000),
+		testutil.NewCashFlow(job, "2026-07-25", model.FlowTypeIncome, "", 3000),
+		testutil.NewCashFlow(food, "2026-08-02", model.FlowTypeOutcome, "", 2000),
+		testutil.NewCashFlow(job, "2026-08-25", model.FlowTypeIncome, "", 3000),
+		testutil.NewCashFlow(food, "2026-09-02", model.FlowTypeOutcome, "", 2500),
+		testutil.NewCashFlow(job, "2026-10-25", model.FlowTypeIncome, "", 3000),
+	}
+	progress := goalProgress(newGoal(6000, "2026-07-01", "2027-01-31"), testutil.Day("2026-10-19"), cashFlowList, "", nil, true)
+
+	if progress.Saved.String() != "1500.00" || progress.Remaining.String() != "4500.00" || progress.Percent != 25 {
+		t.Errorf("expected the income and expense since July up to today, got %+v", progress)
+	}
+	if progress.MonthsLeft != 4 || progress.RequiredMonthly.String() != "1125.00" || progress.AverageMonthly.String() != "375.00" {
+		t.Errorf("expected October to January to spread what remains, got %+v", progress)
+	}
+	if progress.Status != model.GoalStatusBehind || progress.AsOf != "2026-10-19" {
+		t.Errorf("expected to be behind, got %+v", progress)
+	}
+}
+
+func TestGoalProgress_Category(t *testing.T) {
+	savings, holiday, food := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	categoryIdSet := map[primitive.ObjectID]bool{savings: true, holiday: true}
+	cashFlowList := []model.CashFlowEntity{
+		testutil.NewCashFlow(savings, "2026-09-01", model.FlowTypeOutcome, "", 400),
+		testutil.NewCashFlow(holiday, "2026-10-01", model.FlowTypeOutcome, "", 400),
+		testutil.NewCashFlow(holiday, "2026-10-10", model.FlowTypeIncome, "", 100),
+		testutil.NewCashFlow(food, "2026-10-12", model.FlowTypeOutcome, "", 900),
+	}
+	goal := newGoal(1500, "2026-09-01", "2026-12-31")
+
+	progress := goalProgress(goal, testutil.Day("2026-10-19"), cashFlowList, "Savings", categoryIdSet, false)
+	if progress.Saved.String() != "700.00" || progress.Category != "Savings" {
+		t.Errorf("expected the expenses of the category and its child less the refund, got %+v", progress)
+	}
+	if progress.MonthsLeft != 3 || progress.RequiredMonthly.String() != "266.67" || progress.Status != model.GoalStatusOnTrack {
+		t.Errorf("expected 350 a month to be on track, got %+v", progress)
+	}
+
+	progress = goalProgress(goal, testutil.Day("2026-10-19"), cashFlowList, "Savings", categoryIdSet, true)
+	if progress.Saved.String() != "-700.00" || progress.Remaining.String() != "2200.00" || progress.Status != model.GoalStatusBehind {
+		t.Errorf("expected expenses to take away from an income category, got %+v", progress)
+	}
+}
+
+func TestGoalProgress_Status(t *testing.T) {
+	savings := primitive.NewObjectID()
+	categoryIdSet := map[primitive.ObjectID]bool{savings: true}
+	cashFlowList := []model.CashFlowEntity{
+		testutil.NewCashFlow(savings, "2026-03-10", model.FlowTypeIncome, "", 600),
+		testutil.NewCashFlow(savings, "2026-04-10", model.FlowTypeIncome, "", 600),
+	}
+
+	achieved := goalProgress(newGoal(1000, "2026-03-01", "2026-12-31"), testutil.Day("2026-10-19"), cashFlowList, "Savings", categoryIdSet, true)
+	if achieved.Status != model.GoalStatusAchieved || !achieved.Remaining.IsZero() || achieved.Percent != 120 {
+		t.Errorf("expected the goal to be achieved, got %+v", achieved)
+	}
+	if !achieved.RequiredMonthly.IsZero() {
+		t.Errorf("expected nothing more to be needed, got %+v", achieved)
+	}
+
+	overdue := goalProgress(newGoal(2000, "2026-03-01", "2026-06-30"), testutil.Day("2026-10-19"), cashFlowList, "Savings", categoryIdSet, true)
+	if overdue.Status != model.GoalStatusOverdue || overdue.MonthsLeft != 0 || overdue.RequiredMonthly.String() != "800.00" {
+		t.Errorf("expected the goal to be overdue with everything remaining needed now, got %+v", overdue)
+	}
+
+	lastMonth := goalProgress(newGoal(2000, "2026-03-01", "2026-10-31"), testutil.Day("2026-10-31"), cashFlowList, "Savings", categoryIdSet, true)
+	if lastMonth.MonthsLeft != 1 || lastMonth.RequiredMonthly.String() != "800.00" || lastMonth.Status != model.GoalStatusBehind {
+		t.Errorf("expected the deadline day to still count its month, got %+v", lastMonth)
+	}
+
+	notStarted := goalProgress(newGoal(1000, "2026-11-01", "2027-04-30"), testutil.Day("2026-10-19"), nil, "Savings", categoryIdSet, true)
+	if notStarted.MonthsLeft != 7 || !notStarted.AverageMonthly.IsZero() || notStarted.Status != model.GoalStatusBehind {
+		t.Errorf("expected a goal starting next month to be behind, got %+v", notStarted)
+	}
+}
+
+func TestBuildGoalEntity(t *testing.T) {
+	goalEntity, err := buildGoalEntity(model.GoalDTO{
+		Name:         " Car ",
+		TargetAmount: model.NewMoneyFromInt(8000),
+		Deadline:     "2027-06",
+	}, testutil.Day("2026-10-19"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if goalEntity.Name != "Car" || !goalEntity.StartDate.Equal(testutil.Day("2026-10-19")) || !goalEntity.Deadline.Equal(testutil.Day("2027-06-30")) {
+		t.Errorf("expected the month deadline to end on its last day, got %+v", goalEntity)
+	}
+	if !goalEntity.CategoryId.IsZero() {
+		t.Errorf("expected no category, got %s", goalEntity.CategoryId.Hex())
+	}
+
+	goalEntity, err = buildGoalEntity(model.GoalDTO{
+		Name:         "Car",
+		TargetAmount: model.NewMoneyFromInt(8000),
+		StartDate:    "2026",
+		Deadline:     "2027",
+	}, testutil.Day("2026-10-19"))
+	if err != nil || !goalEntity.StartDate.Equal(testutil.Day("2026-01-01")) || !goalEntity.Deadline.Equal(testutil.Day("2027-12-31")) {
+		t.Errorf("expected the years to span 2026 and 2027, got %+v, %v", goalEntity, err)
+	}
+
+	testCases := []model.GoalDTO{
+		{Name: " ", TargetAmount: model.NewMoneyFromInt(100), Deadline: "2027"},
+		{Name: "Car", TargetAmount: model.NewMoneyFromInt(0), Deadline: "2027"},
+		{Name: "Car", TargetAmount: model.NewMoneyFromInt(100)},
+		{Name: "Car", TargetAmount: model.NewMoneyFromInt(100), Deadline: "next year"},
+		{Name: "Car", TargetAmount: model.NewMoneyFromInt(100), Deadline: "2027", StartDate: "2026-13"},
+		{Name: "Car", TargetAmount: model.NewMoneyFromInt(100), Deadline: "2026-09"},
+	}
+	for _, testCase := range testCases {
+		if _, err := buildGoalEntity(testCase, testutil.Day("2026-10-19")); err == nil {
+			t.Errorf("%+v: expected an error", testCase)
+		}
+	}
+}
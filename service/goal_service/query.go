@@ -0,0 +1,63 @@
+package goal_service
+
+import (
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/goal_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QueryService returns a goal with its progress up to today
+func QueryService(plainId string) (model.GoalProgress, error) {
+	goalEntity, err := getGoal(plainId)
+	if err != nil {
+		return model.GoalProgress{}, err
+	}
+	return measureGoal(goalEntity, util.Today()), nil
+}
+
+// ListService returns every goal with its progress, the nearest deadline first
+func ListService() ([]model.GoalProgress, error) {
+	goalList, err := goal_mapper.INSTANCE.GetAllGoals()
+	if err != nil {
+		return nil, errors.NewDatabaseError("goal query failed", err)
+	}
+	asOf := util.Today()
+	progressList := []model.GoalProgress{}
+	for _, goalEntity := range goalList {
+		progressList = append(progressList, measureGoal(goalEntity, asOf))
+	}
+	return progressList, nil
+}
+
+// measureGoal reads the cash flows of a goal from its start date up to asOf
+func measureGoal(goalEntity model.GoalEntity, asOf time.Time) model.GoalProgress {
+	var cashFlowList []model.CashFlowEntity
+	if !goalEntity.StartDate.After(asOf) {
+		cashFlowList = cash_flow_mapper.INSTANCE.GetCashFlowsByDateRange(goalEntity.StartDate, asOf)
+	}
+	if goalEntity.CategoryId.IsZero() {
+		return goalProgress(goalEntity, asOf, cashFlowList, "", nil, true)
+	}
+
+	// A deleted category still counts its own cash flows, as an expense category
+	categoryEntity := category_mapper.INSTANCE.GetCategoryByObjectId(goalEntity.CategoryId.Hex())
+	categoryIdSet := map[primitive.ObjectID]bool{goalEntity.CategoryId: true}
+	collectChildCategories(goalEntity.CategoryId, categoryIdSet)
+	return goalProgress(goalEntity, asOf, cashFlowList, categoryEntity.Name, categoryIdSet, categoryEntity.Type == "income")
+}
+
+// collectChildCategories adds every category below parentId to categoryIdSet
+func collectChildCategories(parentId primitive.ObjectID, categoryIdSet map[primitive.ObjectID]bool) {
+	for _, child := range category_mapper.INSTANCE.GetCategoryByParentId(parentId.Hex()) {
+		if !categoryIdSet[child.Id] {
+			categoryIdSet[child.Id] = true
+			collectChildCategories(child.Id, categoryIdSet)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package goal_service
+
+import (
+	"strconv"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/goal_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/validation"
+)
+
+// UpdateService replaces a goal with the DTO; a missing start date keeps the stored one
+// expectedVersion is the version the caller last read, or model.AnyVersion to overwrite whatever is stored
+func UpdateService(plainId string, goalDTO model.GoalDTO, expectedVersion int64) (model.GoalEntity, error) {
+	existingGoal, err := getGoal(plainId)
+	if err != nil {
+		return model.GoalEntity{}, err
+	}
+	if expectedVersion != model.AnyVersion && existingGoal.Version != expectedVersion {
+		return model.GoalEntity{}, errors.NewPreconditionFailedError(
+			"goal was changed since version " + strconv.FormatInt(expectedVersion, 10) + ", fetch it again")
+	}
+
+	goalEntity, err := buildGoalEntity(goalDTO, existingGoal.StartDate)
+	if err != nil {
+		return model.GoalEntity{}, err
+	}
+	goalEntity.Version = existingGoal.Version
+
+	// The mapper refuses when another update got in since the read above
+	updatedEntity, err := goal_mapper.INSTANCE.UpdateGoalByEntity(plainId, goalEntity)
+	if err != nil {
+		return model.GoalEntity{}, errors.NewDatabaseError("goal update failed", err)
+	}
+	if updatedEntity.IsEmpty() {
+		return model.GoalEntity{}, errors.NewPreconditionFailedError("goal was changed or deleted meanwhile, fetch it again")
+	}
+	util.Logger.Infow("goal updated", "goal", updatedEntity.ToString())
+	return updatedEntity, nil
+}
+
+// getGoal reads a goal, refusing unknown and malformed ids
+func getGoal(plainId string) (model.GoalEntity, error) {
+	if err := validation.ValidateID(plainId); err != nil {
+		return model.GoalEntity{}, err
+	}
+	goalEntity, err := goal_mapper.INSTANCE.GetGoalByObjectId(plainId)
+	if err != nil {
+		return model.GoalEntity{}, errors.NewDatabaseError("goal query failed", err)
+	}
+	if goalEntity.IsEmpty() {
+		return model.GoalEntity{}, errors.NewNotFoundError("goal not found")
+	}
+	return goalEntity, nil
+}
@@ -0,0 +1,149 @@
+package idempotency_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/idempotency_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// MaxKeyLength is the longest Idempotency-Key accepted, the size of the stored key
+const MaxKeyLength = 255
+
+// idempotencyPurgeInterval is how often the server removes expired keys
+const idempotencyPurgeInterval = time.Hour
+
+// Decisions for a request carrying an Idempotency-Key
+const (
+	DecisionProceed    = iota // handle the request, then Complete or Release the key
+	DecisionReplay            // answer with the stored response
+	DecisionInProgress        // the first request with the key has not finished yet
+	DecisionMismatch          // the key was first used for a different request
+)
+
+// GetWindow reads IDEMPOTENCY_WINDOW_HOURS; zero turns idempotency keys off
+func GetWindow() time.Duration {
+	hours, err := strconv.Atoi(util.GetConfigByKey("idempotency.window.hours"))
+	if err != nil || hours < 0 {
+		util.Logger.Warnw("invalid idempotency window, idempotency keys disabled", "key", "idempotency.window.hours")
+		return 0
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// ValidateKey accepts 1 to MaxKeyLength printable ASCII characters
+func ValidateKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return errors.NewInvalidInputError("Idempotency-Key must be 1 to " + strconv.Itoa(MaxKeyLength) + " characters")
+	}
+	for _, char := range key {
+		if char < 0x21 || char > 0x7e {
+			return errors.NewInvalidInputError("Idempotency-Key must only contain printable ASCII characters")
+		}
+	}
+	return nil
+}
+
+// Fingerprint identifies a request, so a key reused for another request is caught
+func Fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Decide tells what to do with a request given the entity already stored under its key
+func Decide(existing model.IdempotencyEntity, fingerprint string, now time.Time) int {
+	if existing.IsEmpty() || !now.Before(existing.ExpireTime) {
+		return DecisionProceed
+	}
+	if existing.Fingerprint != fingerprint {
+		return DecisionMismatch
+	}
+	if !existing.IsCompleted() {
+		return DecisionInProgress
+	}
+	return DecisionReplay
+}
+
+// Begin reserves the key for a new request, or returns the stored entity and what to do with it
+// Concurrent requests with the same key race on the reservation; only one proceeds
+func Begin(key, fingerprint string, window time.Duration) (model.IdempotencyEntity, int, error) {
+	now := time.Now().UTC()
+	existing, err := idempotency_mapper.INSTANCE.GetIdempotencyKey(key)
+	if err != nil {
+		return model.IdempotencyEntity{}, 0, errors.NewDatabaseError("idempotency key lookup failed", err)
+	}
+
+	decision := Decide(existing, fingerprint, now)
+	if decision != DecisionProceed {
+		return existing, decision, nil
+	}
+	if !existing.IsEmpty() {
+		// Expired, the key may be used again
+		if err := idempotency_mapper.INSTANCE.DeleteIdempotencyKey(key); err != nil {
+			return model.IdempotencyEntity{}, 0, errors.NewDatabaseError("idempotency key cleanup failed", err)
+		}
+	}
+
+	entity := model.IdempotencyEntity{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreateTime:  now,
+		ExpireTime:  now.Add(window),
+	}
+	reserved, err := idempotency_mapper.INSTANCE.ReserveIdempotencyKey(entity)
+	if err != nil {
+		return model.IdempotencyEntity{}, 0, errors.NewDatabaseError("idempotency key reservation failed", err)
+	}
+	if !reserved {
+		// Another request with the key got there first
+		return entity, DecisionInProgress, nil
+	}
+	return entity, DecisionProceed, nil
+}
+
+// Complete stores the response of the request that reserved the key
+func Complete(entity model.IdempotencyEntity) {
+	if err := idempotency_mapper.INSTANCE.CompleteIdempotencyKey(entity); err != nil {
+		util.Logger.Errorw("idempotency response not stored", "error", err)
+	}
+}
+
+// Release frees the key after a failure, so the client can retry with it
+func Release(key string) {
+	if err := idempotency_mapper.INSTANCE.DeleteIdempotencyKey(key); err != nil {
+		util.Logger.Errorw("idempotency key not released", "error", err)
+	}
+}
+
+// StartIdempotencyPurger removes expired keys every idempotencyPurgeInterval
+// Returns false when IDEMPOTENCY_WINDOW_HOURS is 0; the purger stops when ctx is done
+func StartIdempotencyPurger(ctx context.Context) bool {
+	if GetWindow() == 0 {
+		return false
+	}
+
+	go func() {
+		for {
+			purgedCount, err := idempotency_mapper.INSTANCE.DeleteExpiredIdempotencyKeys(time.Now().UTC())
+			if err != nil {
+				util.Logger.Errorw("idempotency key purge failed", "error", err)
+			} else if purgedCount > 0 {
+				util.Logger.Infow("idempotency keys purged", "count", purgedCount)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(idempotencyPurgeInterval):
+			}
+		}
+	}()
+	return true
+}
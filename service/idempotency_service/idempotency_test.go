@@ -0,0 +1,60 @@
+package idempotency_service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+)
+
+func TestDecide(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	fingerprint := Fingerprint("POST", "/api/cash/expense", []byte(`{"amount":"12.50"}`))
+	completed := model.IdempotencyEntity{Key: "k", Fingerprint: fingerprint, StatusCode: 200, ExpireTime: now.Add(time.Hour)}
+	pending := completed
+	pending.StatusCode = 0
+	expired := completed
+	expired.ExpireTime = now
+
+	cases := []struct {
+		name     string
+		existing model.IdempotencyEntity
+		request  string
+		want     int
+	}{
+		{"unknown key", model.IdempotencyEntity{}, fingerprint, DecisionProceed},
+		{"completed", completed, fingerprint, DecisionReplay},
+		{"pending", pending, fingerprint, DecisionInProgress},
+		{"other request", completed, Fingerprint("POST", "/api/cash/income", []byte(`{"amount":"12.50"}`)), DecisionMismatch},
+		{"expired", expired, "anything", DecisionProceed},
+	}
+	for _, c := range cases {
+		if got := Decide(c.existing, c.request, now); got != c.want {
+			t.Errorf("%s: expected decision %d, got %d", c.name, c.want, got)
+		}
+	}
+}
+
+func TestFingerprint_DependsOnBody(t *testing.T) {
+	first := Fingerprint("POST", "/api/cash/expense", []byte(`{"amount":"12.50"}`))
+	if first != Fingerprint("POST", "/api/cash/expense", []byte(`{"amount":"12.50"}`)) {
+		t.Error("the same request should have the same fingerprint")
+	}
+	if first == Fingerprint("POST", "/api/cash/expense", []byte(`{"amount":"12.51"}`)) {
+		t.Error("a different body should change the fingerprint")
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"a", "0b8f6c3e-6f1d-4c57-9f7e-2d3c1a5b9e01", strings.Repeat("x", MaxKeyLength)} {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("key %q should be valid: %v", key, err)
+		}
+	}
+	for _, key := range []string{"", "has space", "tab\tkey", strings.Repeat("x", MaxKeyLength+1)} {
+		if err := ValidateKey(key); err == nil {
+			t.Errorf("key %q should be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package insight_service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// AnomalyDefaultDays is how many days up to today are checked without from and to
+	AnomalyDefaultDays = 30
+
+	// An expense is an amount outlier when its robust z-score against the earlier expenses
+	// of its category reaches AmountOutlierScore; categories need AmountMinSamples of them
+	AmountOutlierScore = 3.5
+	AmountMinSamples   = 5
+
+	// NewPayeeMinHistory is how many expenses must come before the range for new payees to stand out
+	NewPayeeMinHistory = 20
+
+	// A category's month spikes when it is SpikeScore standard deviations and SpikeMinRatio times above
+	// the average of its SpikeLookbackMonths earlier months; categories need SpikeMinMonths of them
+	SpikeScore          = 3.0
+	SpikeMinRatio       = 1.5
+	SpikeLookbackMonths = 12
+	SpikeMinMonths      = 3
+)
+
+// DetectAnomalies flags unusual expenses from one day to another, both included.
+// From and to take a year, a month or a day; without them the last 30 days are checked.
+func DetectAnomalies(fromText, toText string) (model.AnomalyReport, error) {
+	from, to, err := parseAnomalyRange(fromText, toText, time.Now().In(util.GetTimezone()))
+	if err != nil {
+		return model.AnomalyReport{}, err
+	}
+	return DetectAnomaliesBetween(from, to), nil
+}
+
+// DetectAnomaliesBetween flags unusual expenses from from up to, but not including, to,
+// comparing them with every cash flow before
+func DetectAnomaliesBetween(from, to time.Time) model.AnomalyReport {
+	cashFlowList := cash_flow_mapper.INSTANCE.GetCashFlowsByDateRange(time.Time{}, to.AddDate(0, 0, -1))
+	report := detectAnomalies(from, to, cashFlowList)
+
+	categoryName := categoryNameLookup()
+	for index := range report.Items {
+		report.Items[index].Category = categoryName(report.Items[index].CategoryId)
+	}
+	return report
+}
+
+func parseAnomalyRange(fromText, toText string, now time.Time) (time.Time, time.Time, error) {
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	if toText != "" {
+		var ok bool
+		if _, to, ok = query.ParsePeriod(toText); !ok {
+			return time.Time{}, time.Time{}, errors.NewFieldValidationError("to", "invalid date "+toText)
+		}
+	}
+
+	from := to.AddDate(0, 0, -AnomalyDefaultDays)
+	if fromText != "" {
+		var ok bool
+		if from, _, ok = query.ParsePeriod(fromText); !ok {
+			return time.Time{}, time.Time{}, errors.NewFieldValidationError("from", "invalid date "+fromText)
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.NewFieldValidationError("from", "from should be before to")
+	}
+	return from, to, nil
+}
+
+// detectAnomalies runs the checks over the expenses of cashFlowList from from up to to.
+// Amounts and payees are judged against the expenses before each one, so a payee is only
+// new once; the months of a category against its earlier months.
+func detectAnomalies(from, to time.Time, cashFlowList []model.CashFlowEntity) model.AnomalyReport {
+	report := model.AnomalyReport{
+		From:  util.FormatDateToStringWithDash(from),
+		To:    util.FormatDateToStringWithDash(to.AddDate(0, 0, -1)),
+		Items: []model.Anomaly{},
+	}
+
+	var expenseList []model.CashFlowEntity
+	for _, cashFlow := range cashFlowList {
+		if cashFlow.FlowType != model.FlowTypeIncome && cashFlow.BelongsDate.Before(to) {
+			expenseList = append(expenseList, cashFlow)
+		}
+	}
+	sort.SliceStable(expenseList, func(i, j int) bool {
+		return expenseList[i].BelongsDate.Before(expenseList[j].BelongsDate)
+	})
+
+	amountMap := make(map[primitive.ObjectID][]float64)
+	payeeSet := make(map[string]bool)
+	var amountList []float64
+	historyCount := 0
+	for index := range expenseList {
+		cashFlow := expenseList[index]
+		amount := cashFlow.Amount.Float64()
+		payee := strings.ToLower(strings.TrimSpace(cashFlow.Description))
+		if cashFlow.BelongsDate.Before(from) {
+			historyCount++
+		} else {
+			if anomaly, ok := amountOutlier(&expenseList[index], amountMap[cashFlow.CategoryId]); ok {
+				report.Items = append(report.Items, anomaly)
+			}
+			if payee != "" && !payeeSet[payee] && historyCount >= NewPayeeMinHistory {
+				typical := median(amountList)
+				report.Items = append(report.Items, model.Anomaly{
+					Kind:       model.AnomalyKindNewPayee,
+					Reason:     fmt.Sprintf("first expense to %s", strings.TrimSpace(cashFlow.Description)),
+					Score:      roundScore(amount / typical),
+					CashFlow:   &expenseList[index],
+					CategoryId: categoryHex(cashFlow.CategoryId),
+					Amount:     cashFlow.Amount,
+					Expected:   newMoney(typical),
+				})
+			}
+		}
+		amountMap[cashFlow.CategoryId] = append(amountMap[cashFlow.CategoryId], amount)
+		amountList = append(amountList, amount)
+		if payee != "" {
+			payeeSet[payee] = true
+		}
+	}
+
+	report.Items = append(report.Items, categorySpikes(from, to, expenseList)...)
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		return report.Items[i].Score > report.Items[j].Score
+	})
+	return report
+}
+
+// amountOutlier scores an expense by how many robust standard deviations (1.4826 median absolute
+// deviations, at least a twentieth of the median) it lies above the median of its category
+func amountOutlier(cashFlow *model.CashFlowEntity, sampleList []float64) (model.Anomaly, bool) {
+	if len(sampleList) < AmountMinSamples {
+		return model.Anomaly{}, false
+	}
+	center := median(sampleList)
+	deviationList := make([]float64, len(sampleList))
+	for index, sample := range sampleList {
+		deviationList[index] = math.Abs(sample - center)
+	}
+	scale := math.Max(1.4826*median(deviationList), math.Abs(center)/20)
+	if scale == 0 {
+		return model.Anomaly{}, false
+	}
+
+	amount := cashFlow.Amount.Float64()
+	score := (amount - center) / scale
+	if score < AmountOutlierScore {
+		return model.Anomaly{}, false
+	}
+	reason := fmt.Sprintf("%s is far above the usual %s of its category", cashFlow.Amount, newMoney(center))
+	if center > 0 {
+		reason = fmt.Sprintf("%s is %.1f times the usual %s of its category", cashFlow.Amount, amount/center, newMoney(center))
+	}
+	return model.Anomaly{
+		Kind:       model.AnomalyKindAmountOutlier,
+		Reason:     reason,
+		Score:      roundScore(score),
+		CashFlow:   cashFlow,
+		CategoryId: categoryHex(cashFlow.CategoryId),
+		Amount:     cashFlow.Amount,
+		Expected:   newMoney(center),
+	}, true
+}
+
+// categorySpikes compares the monthly total of each category in the months of the range
+// with its earlier months, counting the months without expenses since its first one
+func categorySpikes(from, to time.Time, expenseList []model.CashFlowEntity) []model.Anomaly {
+	totalMap := make(map[primitive.ObjectID]map[time.Time]float64)
+	firstMonthMap := make(map[primitive.ObjectID]time.Time)
+	for _, cashFlow := range expenseList {
+		month := monthStart(cashFlow.BelongsDate)
+		if totalMap[cashFlow.CategoryId] == nil {
+			totalMap[cashFlow.CategoryId] = make(map[time.Time]float64)
+			firstMonthMap[cashFlow.CategoryId] = month
+		}
+		totalMap[cashFlow.CategoryId][month] += cashFlow.Amount.Float64()
+	}
+
+	var anomalyList []model.Anomaly
+	lastMonth := monthStart(to.AddDate(0, 0, -1))
+	for month := monthStart(from); !month.After(lastMonth); month = month.AddDate(0, 1, 0) {
+		for categoryId, monthMap := range totalMap {
+			total, ok := monthMap[month]
+			if !ok {
+				continue
+			}
+			start := month.AddDate(0, -SpikeLookbackMonths, 0)
+			if firstMonth := firstMonthMap[categoryId]; firstMonth.After(start) {
+				start = firstMonth
+			}
+			var earlierList []float64
+			for earlier := start; earlier.Before(month); earlier = earlier.AddDate(0, 1, 0) {
+				earlierList = append(earlierList, monthMap[earlier])
+			}
+			if len(earlierList) < SpikeMinMonths {
+				continue
+			}
+
+			average, deviation := meanDeviation(earlierList)
+			scale := math.Max(deviation, average/10)
+			if scale == 0 || total < average*SpikeMinRatio {
+				continue
+			}
+			score := (total - average) / scale
+			if score < SpikeScore {
+				continue
+			}
+			anomalyList = append(anomalyList, model.Anomaly{
+				Kind: model.AnomalyKindCategorySpike,
+				Reason: fmt.Sprintf("%s spent in %s, %.1f times the monthly average of %s",
+					newMoney(total), month.Format("2006-01"), total/average, newMoney(average)),
+				Score:      roundScore(score),
+				CategoryId: categoryHex(categoryId),
+				Month:      month.Format("2006-01"),
+				Amount:     newMoney(total),
+				Expected:   newMoney(average),
+			})
+		}
+	}
+	sort.SliceStable(anomalyList, func(i, j int) bool {
+		if anomalyList[i].Month != anomalyList[j].Month {
+			return anomalyList[i].Month < anomalyList[j].Month
+		}
+		return anomalyList[i].CategoryId < anomalyList[j].CategoryId
+	})
+	return anomalyList
+}
+
+// meanDeviation returns the mean and the population standard deviation
+func meanDeviation(valueList []float64) (float64, float64) {
+	total := 0.0
+	for _, value := range valueList {
+		total += value
+	}
+	mean := total / float64(len(valueList))
+	variance := 0.0
+	for _, value := range valueList {
+		variance += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(valueList)))
+}
+
+func roundScore(score float64) float64 {
+	if math.IsInf(score, 0) || math.IsNaN(score) {
+		return 0
+	}
+	return math.Round(score*10) / 10
+}
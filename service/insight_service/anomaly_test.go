@@ -0,0 +1,104 @@
+package insight_service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// anomalyHistory holds March to August 2026 with groceries of 55 to 65 every week, dining out
+// of 40 to 60 twice a month and a salary, then September with a new restaurant, an expensive
+// grocery trip and groceries bought every week at a second shop too
+func anomalyHistory(food, dining, job primitive.ObjectID) []model.CashFlowEntity {
+	var cashFlowList []model.CashFlowEntity
+	for month := 3; month <= 8; month++ {
+		prefix := fmt.Sprintf("2026-%02d-", month)
+		cashFlowList = append(cashFlowList,
+			testutil.NewCashFlow(job, prefix+"25", model.FlowTypeIncome, fmt.Sprintf("Bonus %d", month), 3000),
+			testutil.NewCashFlow(dining, prefix+"08", model.FlowTypeOutcome, "Pizzeria", 40+int64(month)),
+			testutil.NewCashFlow(dining, prefix+"22", model.FlowTypeOutcome, "Pizzeria", 60-int64(month)))
+		for index, dayOfMonth := range []string{"03", "10", "17", "24"} {
+			cashFlowList = append(cashFlowList,
+				testutil.NewCashFlow(food, prefix+dayOfMonth, model.FlowTypeOutcome, "Supermarket", 55+int64(index*3+month%2)))
+		}
+	}
+	return append(cashFlowList,
+		testutil.NewCashFlow(food, "2026-09-03", model.FlowTypeOutcome, "Supermarket", 60),
+		testutil.NewCashFlow(food, "2026-09-05", model.FlowTypeOutcome, "supermarket ", 480),
+		testutil.NewCashFlow(dining, "2026-09-12", model.FlowTypeOutcome, "Sushi Bar", 50),
+		testutil.NewCashFlow(dining, "2026-09-19", model.FlowTypeOutcome, "Sushi bar", 45),
+		testutil.NewCashFlow(food, "2026-09-10", model.FlowTypeOutcome, "Supermarket", 58),
+		testutil.NewCashFlow(food, "2026-09-17", model.FlowTypeOutcome, "Supermarket", 62),
+		testutil.NewCashFlow(food, "2026-09-24", model.FlowTypeOutcome, "Supermarket", 61),
+		testutil.NewCashFlow(job, "2026-09-25", model.FlowTypeIncome, "Bonus 9", 3000))
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	food, dining, job := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	report := detectAnomalies(testutil.Day("2026-09-01"), testutil.Day("2026-10-01"), anomalyHistory(food, dining, job))
+
+	if report.From != "2026-09-01" || report.To != "2026-09-30" {
+		t.Errorf("expected the range to end on its last day, got %s to %s", report.From, report.To)
+	}
+	if len(report.Items) != 3 {
+		t.Fatalf("expected an outlier, a new payee and a spike, got %+v", report.Items)
+	}
+
+	outlier := report.Items[0]
+	if outlier.Kind != model.AnomalyKindAmountOutlier || outlier.CashFlow == nil || outlier.CashFlow.Amount.String() != "480.00" {
+		t.Fatalf("expected the expensive grocery trip first, got %+v", outlier)
+	}
+	if outlier.Expected.String() != "60.00" || outlier.CategoryId != food.Hex() || outlier.Score < AmountOutlierScore {
+		t.Errorf("unexpected outlier %+v", outlier)
+	}
+	if outlier.Reason != "480.00 is 8.0 times the usual 60.00 of its category" {
+		t.Errorf("unexpected reason %q", outlier.Reason)
+	}
+
+	spike := report.Items[1]
+	if spike.Kind != model.AnomalyKindCategorySpike || spike.Month != "2026-09" || spike.CashFlow != nil {
+		t.Fatalf("expected the grocery month to spike, got %+v", spike)
+	}
+	if spike.Amount.String() != "721.00" || spike.CategoryId != food.Hex() {
+		t.Errorf("unexpected spike %+v", spike)
+	}
+
+	payee := report.Items[2]
+	if payee.Kind != model.AnomalyKindNewPayee || payee.CashFlow.BelongsDate != testutil.Day("2026-09-12") {
+		t.Fatalf("expected only the first visit of the sushi bar to be new, got %+v", payee)
+	}
+	if payee.Reason != "first expense to Sushi Bar" || payee.Score != 0.9 {
+		t.Errorf("unexpected new payee %+v", payee)
+	}
+}
+
+func TestDetectAnomalies_ShortHistory(t *testing.T) {
+	food := primitive.NewObjectID()
+	cashFlowList := []model.CashFlowEntity{
+		testutil.NewCashFlow(food, "2026-08-03", model.FlowTypeOutcome, "Supermarket", 60),
+		testutil.NewCashFlow(food, "2026-09-03", model.FlowTypeOutcome, "Corner shop", 900),
+	}
+	if report := detectAnomalies(testutil.Day("2026-09-01"), testutil.Day("2026-10-01"), cashFlowList); len(report.Items) != 0 {
+		t.Errorf("expected nothing to stand out without enough history, got %+v", report.Items)
+	}
+}
+
+func TestParseAnomalyRange(t *testing.T) {
+	from, to, err := parseAnomalyRange("", "", testutil.Day("2026-10-19").Add(15*time.Hour))
+	if err != nil || !from.Equal(testutil.Day("2026-09-20")) || !to.Equal(testutil.Day("2026-10-20")) {
+		t.Errorf("expected the last 30 days, got %s to %s, %v", from, to, err)
+	}
+	from, to, err = parseAnomalyRange("2026-08", "2026-09", testutil.Day("2026-10-19"))
+	if err != nil || !from.Equal(testutil.Day("2026-08-01")) || !to.Equal(testutil.Day("2026-10-01")) {
+		t.Errorf("expected August and September, got %s to %s, %v", from, to, err)
+	}
+	for _, testCase := range [][2]string{{"2026-13", ""}, {"", "yesterday"}, {"2026-09-10", "2026-09-01"}} {
+		if _, _, err := parseAnomalyRange(testCase[0], testCase[1], testutil.Day("2026-10-19")); err == nil {
+			t.Errorf("%v: expected an error", testCase)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package insight_service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/report_service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// categoryNameLookup names categories by id, once each; cash flows without one are uncategorized
+func categoryNameLookup() func(string) string {
+	nameMap := make(map[string]string)
+	return func(categoryId string) string {
+		if categoryId == "" {
+			return report_service.UncategorizedName
+		}
+		name, ok := nameMap[categoryId]
+		if !ok {
+			category := category_mapper.INSTANCE.GetCategoryByObjectId(categoryId)
+			if !category.IsEmpty() {
+				name = category.Name
+			}
+			nameMap[categoryId] = name
+		}
+		return name
+	}
+}
+
+func median(valueList []float64) float64 {
+	if len(valueList) == 0 {
+		return 0
+	}
+	sortedList := append([]float64{}, valueList...)
+	sort.Float64s(sortedList)
+	middle := len(sortedList) / 2
+	if len(sortedList)%2 == 0 {
+		return (sortedList[middle-1] + sortedList[middle]) / 2
+	}
+	return sortedList[middle]
+}
+
+func newMoney(amount float64) model.Money {
+	return model.NewMoneyFromFloat(amount).Round(model.MoneyDisplayScale)
+}
+
+func categoryHex(categoryId primitive.ObjectID) string {
+	if categoryId.IsZero() {
+		return ""
+	}
+	return categoryId.Hex()
+}
+
+func monthStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,232 @@
+package insight_service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// SubscriptionAmountTolerance is how far, as a share of the latest amount, a charge may lie from it
+	// to count for the same subscription; larger steps start another one
+	SubscriptionAmountTolerance = 0.25
+	// SubscriptionRegularShare is the share of intervals that must match the cadence
+	SubscriptionRegularShare = 0.75
+)
+
+// subscriptionCadence is how often a subscription comes and how exact it has to be about it
+type subscriptionCadence struct {
+	name           string
+	minDays        int // of an interval matching the cadence
+	maxDays        int
+	minOccurrences int
+	graceDays      int // a charge this late still leaves the subscription active
+}
+
+var subscriptionCadenceList = []subscriptionCadence{
+	{name: model.SubscriptionCadenceWeekly, minDays: 5, maxDays: 9, minOccurrences: 4, graceDays: 3},
+	{name: model.SubscriptionCadenceMonthly, minDays: 25, maxDays: 36, minOccurrences: 3, graceDays: 7},
+	{name: model.SubscriptionCadenceYearly, minDays: 350, maxDays: 380, minOccurrences: 2, graceDays: 30},
+}
+
+// descriptionNoisePattern matches what changes between charges of one payee, like dates and invoice numbers
+var descriptionNoisePattern = regexp.MustCompile(`[^\p{L}]+`)
+
+// DiscoverSubscriptions finds the expenses that come back weekly, monthly or yearly with a similar
+// amount in every cash flow up to today; subscriptions whose next charge is overdue are left out
+// unless includeEnded is set
+func DiscoverSubscriptions(includeEnded bool) model.SubscriptionReport {
+	now := time.Now().In(util.GetTimezone())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	cashFlowList := cash_flow_mapper.INSTANCE.GetCashFlowsByDateRange(time.Time{}, today)
+
+	report := model.SubscriptionReport{
+		AsOf:  util.FormatDateToStringWithDash(today),
+		Items: []model.Subscription{},
+	}
+	categoryName := categoryNameLookup()
+	for _, subscription := range discoverSubscriptions(today, cashFlowList) {
+		if subscription.Active {
+			report.MonthlyCost = report.MonthlyCost.Add(subscription.MonthlyCost)
+		} else if !includeEnded {
+			continue
+		}
+		subscription.Category = categoryName(subscription.CategoryId)
+		report.Items = append(report.Items, subscription)
+	}
+	return report
+}
+
+// discoverSubscriptions groups the expenses by payee, which is their description without digits and
+// punctuation or their category when they have none, and splits each payee by amount. A group is a
+// subscription when most of its intervals match a cadence and its amount changed at most once in
+// three charges. Active subscriptions come first, the most expensive per month first.
+func discoverSubscriptions(today time.Time, cashFlowList []model.CashFlowEntity) []model.Subscription {
+	var expenseList []model.CashFlowEntity
+	for _, cashFlow := range cashFlowList {
+		if cashFlow.FlowType != model.FlowTypeIncome && cashFlow.Amount.IsPositive() {
+			expenseList = append(expenseList, cashFlow)
+		}
+	}
+	sort.SliceStable(expenseList, func(i, j int) bool {
+		return expenseList[i].BelongsDate.Before(expenseList[j].BelongsDate)
+	})
+
+	var payeeList []string
+	groupMap := make(map[string][][]model.CashFlowEntity)
+	for _, cashFlow := range expenseList {
+		payee := payeeKey(cashFlow)
+		if _, ok := groupMap[payee]; !ok {
+			payeeList = append(payeeList, payee)
+		}
+		groupMap[payee] = addToAmountGroup(groupMap[payee], cashFlow)
+	}
+
+	subscriptionList := []model.Subscription{}
+	for _, payee := range payeeList {
+		for _, group := range groupMap[payee] {
+			if subscription, ok := newSubscription(today, group); ok {
+				subscriptionList = append(subscriptionList, subscription)
+			}
+		}
+	}
+	sort.SliceStable(subscriptionList, func(i, j int) bool {
+		left, right := subscriptionList[i], subscriptionList[j]
+		if left.Active != right.Active {
+			return left.Active
+		}
+		if order := left.MonthlyCost.Cmp(right.MonthlyCost); order != 0 {
+			return order > 0
+		}
+		return left.Description < right.Description
+	})
+	return subscriptionList
+}
+
+func payeeKey(cashFlow model.CashFlowEntity) string {
+	payee := strings.Join(strings.Fields(descriptionNoisePattern.ReplaceAllString(strings.ToLower(cashFlow.Description), " ")), " ")
+	if payee == "" {
+		return "#" + cashFlow.CategoryId.Hex()
+	}
+	return payee
+}
+
+// addToAmountGroup adds a charge to the group whose latest amount is closest to it within the tolerance
+func addToAmountGroup(groupList [][]model.CashFlowEntity, cashFlow model.CashFlowEntity) [][]model.CashFlowEntity {
+	amount := cashFlow.Amount.Float64()
+	closest, closestDistance := -1, 0.0
+	for index, group := range groupList {
+		latest := group[len(group)-1].Amount.Float64()
+		distance := amount - latest
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance <= latest*SubscriptionAmountTolerance && (closest < 0 || distance < closestDistance) {
+			closest, closestDistance = index, distance
+		}
+	}
+	if closest < 0 {
+		return append(groupList, []model.CashFlowEntity{cashFlow})
+	}
+	groupList[closest] = append(groupList[closest], cashFlow)
+	return groupList
+}
+
+// newSubscription checks the charges of a group, oldest first, for a cadence and a steady amount
+func newSubscription(today time.Time, group []model.CashFlowEntity) (model.Subscription, bool) {
+	if len(group) < 2 {
+		return model.Subscription{}, false
+	}
+	intervalList := make([]float64, len(group)-1)
+	for index := 1; index < len(group); index++ {
+		intervalList[index-1] = group[index].BelongsDate.Sub(group[index-1].BelongsDate).Hours() / 24
+	}
+	cadence, ok := matchCadence(intervalList)
+	if !ok || len(group) < cadence.minOccurrences {
+		return model.Subscription{}, false
+	}
+
+	latest := group[len(group)-1]
+	subscription := model.Subscription{
+		Description:  strings.TrimSpace(latest.Description),
+		CategoryId:   categoryHex(latest.CategoryId),
+		Cadence:      cadence.name,
+		Occurrences:  len(group),
+		LatestAmount: latest.Amount,
+		FirstDate:    util.FormatDateToStringWithDash(group[0].BelongsDate),
+		LastDate:     util.FormatDateToStringWithDash(latest.BelongsDate),
+		PriceChanges: []model.PriceChange{},
+	}
+	var total model.Money
+	for index, cashFlow := range group {
+		total = total.Add(cashFlow.Amount)
+		if index > 0 && cashFlow.Amount.Cmp(group[index-1].Amount) != 0 {
+			subscription.PriceChanges = append(subscription.PriceChanges,
+				newPriceChange(cashFlow.BelongsDate, group[index-1].Amount, cashFlow.Amount))
+		}
+	}
+	if len(subscription.PriceChanges)*3 > len(group) {
+		return model.Subscription{}, false
+	}
+	subscription.AverageAmount = total.DivInt(int64(len(group))).Round(model.MoneyDisplayScale)
+
+	nextDate := nextCharge(cadence.name, latest.BelongsDate)
+	subscription.NextDate = util.FormatDateToStringWithDash(nextDate)
+	subscription.Active = !today.After(nextDate.AddDate(0, 0, cadence.graceDays))
+	switch cadence.name {
+	case model.SubscriptionCadenceWeekly:
+		subscription.MonthlyCost = latest.Amount.Mul(decimal.NewFromInt(52)).DivInt(12)
+	case model.SubscriptionCadenceYearly:
+		subscription.MonthlyCost = latest.Amount.DivInt(12)
+	default:
+		subscription.MonthlyCost = latest.Amount
+	}
+	subscription.MonthlyCost = subscription.MonthlyCost.Round(model.MoneyDisplayScale)
+	return subscription, true
+}
+
+// matchCadence picks the cadence of the median interval, if enough intervals match it
+func matchCadence(intervalList []float64) (subscriptionCadence, bool) {
+	typical := median(intervalList)
+	for _, cadence := range subscriptionCadenceList {
+		if typical < float64(cadence.minDays) || typical > float64(cadence.maxDays) {
+			continue
+		}
+		matching := 0
+		for _, interval := range intervalList {
+			if interval >= float64(cadence.minDays) && interval <= float64(cadence.maxDays) {
+				matching++
+			}
+		}
+		return cadence, float64(matching) >= SubscriptionRegularShare*float64(len(intervalList))
+	}
+	return subscriptionCadence{}, false
+}
+
+// nextCharge returns when the charge after date is expected; monthly charges keep their day of the
+// month where the month has it
+func nextCharge(cadence string, date time.Time) time.Time {
+	switch cadence {
+	case model.SubscriptionCadenceWeekly:
+		return date.AddDate(0, 0, 7)
+	case model.SubscriptionCadenceYearly:
+		return date.AddDate(1, 0, 0)
+	}
+	next := monthStart(date).AddDate(0, 1, 0)
+	return next.AddDate(0, 0, min(date.Day(), next.AddDate(0, 1, -1).Day())-1)
+}
+
+func newPriceChange(date time.Time, from, to model.Money) model.PriceChange {
+	change := model.PriceChange{Date: util.FormatDateToStringWithDash(date), From: from, To: to}
+	if !from.IsZero() {
+		percent := to.Sub(from).Decimal().Div(from.Decimal()).Mul(decimal.NewFromInt(100)).Round(1).InexactFloat64()
+		change.Percent = &percent
+	}
+	return change
+}
@@ -0,0 +1,98 @@
+package insight_service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// subscriptionHistory holds a streaming service since January 2026 that went up in June, two plans
+// at one app store, a weekly newspaper, a yearly domain, a gym left in May, groceries every week
+// and a one-off purchase
+func subscriptionHistory(media, food, sport primitive.ObjectID) []model.CashFlowEntity {
+	var cashFlowList []model.CashFlowEntity
+	for month := 1; month <= 10; month++ {
+		amount := int64(12)
+		if month >= 6 {
+			amount = 14
+		}
+		cashFlowList = append(cashFlowList,
+			testutil.NewCashFlow(media, fmt.Sprintf("2026-%02d-15", month), model.FlowTypeOutcome, fmt.Sprintf("NETFLIX.COM %02d/26", month), amount),
+			testutil.NewCashFlow(media, fmt.Sprintf("2026-%02d-02", month), model.FlowTypeOutcome, "App Store", 3),
+			testutil.NewCashFlow(media, fmt.Sprintf("2026-%02d-03", month), model.FlowTypeOutcome, "App Store", 30))
+		if month <= 4 {
+			cashFlowList = append(cashFlowList,
+				testutil.NewCashFlow(sport, fmt.Sprintf("2026-%02d-05", month), model.FlowTypeOutcome, "Gym", 50))
+		}
+	}
+	for week := 0; week < 8; week++ {
+		date := testutil.Day("2026-08-24").AddDate(0, 0, 7*week).Format("2006-01-02")
+		cashFlowList = append(cashFlowList,
+			testutil.NewCashFlow(media, date, model.FlowTypeOutcome, "Daily Post", 5),
+			testutil.NewCashFlow(food, date, model.FlowTypeOutcome, "Supermarket", 40+int64(week*7)))
+	}
+	return append(cashFlowList,
+		testutil.NewCashFlow(media, "2024-11-20", model.FlowTypeOutcome, "example.org renewal", 24),
+		testutil.NewCashFlow(media, "2025-11-18", model.FlowTypeOutcome, "Example.org Renewal", 24),
+		testutil.NewCashFlow(food, "2026-09-09", model.FlowTypeOutcome, "Kitchen shop", 300),
+		testutil.NewCashFlow(food, "2026-09-10", model.FlowTypeIncome, "Refund", 12))
+}
+
+func TestDiscoverSubscriptions(t *testing.T) {
+	media, food, sport := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	subscriptionList := discoverSubscriptions(testutil.Day("2026-10-19"), subscriptionHistory(media, food, sport))
+
+	expectedList := []string{"App Store monthly", "Daily Post weekly", "NETFLIX.COM 10/26 monthly",
+		"App Store monthly", "Example.org Renewal yearly", "Gym monthly"}
+	if len(subscriptionList) != len(expectedList) {
+		t.Fatalf("expected %d subscriptions, got %+v", len(expectedList), subscriptionList)
+	}
+	for index, subscription := range subscriptionList {
+		if subscription.Description+" "+subscription.Cadence != expectedList[index] {
+			t.Errorf("expected %s at %d, got %s %s", expectedList[index], index, subscription.Description, subscription.Cadence)
+		}
+	}
+
+	streaming := subscriptionList[2]
+	if streaming.Occurrences != 10 || streaming.AverageAmount.String() != "13.00" || streaming.LatestAmount.String() != "14.00" {
+		t.Errorf("unexpected amounts %+v", streaming)
+	}
+	if streaming.LastDate != "2026-10-15" || streaming.NextDate != "2026-11-15" || !streaming.Active {
+		t.Errorf("unexpected dates %+v", streaming)
+	}
+	if len(streaming.PriceChanges) != 1 || streaming.PriceChanges[0].Date != "2026-06-15" || *streaming.PriceChanges[0].Percent != 16.7 {
+		t.Errorf("expected one price change in June, got %+v", streaming.PriceChanges)
+	}
+
+	newspaper := subscriptionList[1]
+	if newspaper.MonthlyCost.String() != "21.67" || newspaper.NextDate != "2026-10-19" {
+		t.Errorf("unexpected weekly subscription %+v", newspaper)
+	}
+	domain := subscriptionList[4]
+	if !domain.Active || domain.NextDate != "2026-11-18" || domain.MonthlyCost.String() != "2.00" {
+		t.Errorf("unexpected yearly subscription %+v", domain)
+	}
+	gym := subscriptionList[5]
+	if gym.Active || gym.CategoryId != sport.Hex() || gym.NextDate != "2026-05-05" {
+		t.Errorf("expected the gym to have ended, got %+v", gym)
+	}
+}
+
+func TestNextCharge(t *testing.T) {
+	testCases := []struct {
+		cadence, date, expected string
+	}{
+		{model.SubscriptionCadenceMonthly, "2026-01-31", "2026-02-28"},
+		{model.SubscriptionCadenceMonthly, "2026-12-15", "2027-01-15"},
+		{model.SubscriptionCadenceWeekly, "2026-12-29", "2027-01-05"},
+		{model.SubscriptionCadenceYearly, "2026-03-01", "2027-03-01"},
+	}
+	for _, testCase := range testCases {
+		if actual := nextCharge(testCase.cadence, testutil.Day(testCase.date)); !actual.Equal(testutil.Day(testCase.expected)) {
+			t.Errorf("%s after %s: expected %s, got %s", testCase.cadence, testCase.date, testCase.expected, actual)
+		}
+	}
+}
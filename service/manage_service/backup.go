@@ -1,22 +1,29 @@
 package manage_service
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/debt_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/goal_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/tombstone_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// BackupData represents the structure of backup data
-type BackupData struct {
-	Version    string                   `json:"version"`
-	Timestamp  string                   `json:"timestamp"`
-	CashFlows  []map[string]interface{} `json:"cash_flows"`
-	Categories []map[string]interface{} `json:"categories"`
-}
+// backupPageSize is the number of rows read per query while writing a backup
+const backupPageSize = 1000
+
+// incrementalOverlap widens the window of an incremental backup before its parent was taken.
+// Rows changed in that window are written again, which is harmless since restore upserts;
+// it covers MySQL rounding modify_time to whole seconds and rows written while the parent was read.
+const incrementalOverlap = 2 * time.Second
 
 // EntityStats represents statistics for a single entity type (cash_flows or categories)
 type EntityStats struct {
@@ -29,76 +36,292 @@ type EntityStats struct {
 type OperationStats struct {
 	CashFlows  EntityStats `json:"cash_flows"`
 	Categories EntityStats `json:"categories"`
+	Goals      EntityStats `json:"goals"`
+	Debts      EntityStats `json:"debts"`
+	Tombstones EntityStats `json:"tombstones"`
+	// Anomalies found among the imported cash flows, see ImportService
+	Anomalies []model.Anomaly `json:"anomalies,omitempty"`
 }
 
-// CreateBackup creates a backup of all database data
-func CreateBackup(filePath string) (OperationStats, error) {
-	stats := OperationStats{
-		CashFlows:  EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
-		Categories: EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+// AffectedCounts flattens the statistics for the audit log, leaving out zero counts
+func (stats OperationStats) AffectedCounts() map[string]int {
+	counts := map[string]int{}
+	addAffectedCount(counts, "categories", stats.Categories.Success)
+	addAffectedCount(counts, "categories_failed", stats.Categories.Failed)
+	addAffectedCount(counts, "cash_flows", stats.CashFlows.Success)
+	addAffectedCount(counts, "cash_flows_failed", stats.CashFlows.Failed)
+	addAffectedCount(counts, "goals", stats.Goals.Success)
+	addAffectedCount(counts, "goals_failed", stats.Goals.Failed)
+	addAffectedCount(counts, "debts", stats.Debts.Success)
+	addAffectedCount(counts, "debts_failed", stats.Debts.Failed)
+	addAffectedCount(counts, "tombstones", stats.Tombstones.Success)
+	addAffectedCount(counts, "tombstones_failed", stats.Tombstones.Failed)
+	return counts
+}
+
+func addAffectedCount(counts map[string]int, name string, count int) {
+	if count != 0 {
+		counts[name] = count
 	}
+}
+
+// BackupOptions controls how a backup stream is encoded
+type BackupOptions struct {
+	Compression string // none, gzip or zstd
+	Passphrase  string // encrypts with AES-256-GCM when not empty
+}
+
+// DefaultBackupOptions reads the configured compression and passphrase (BACKUP_COMPRESSION, BACKUP_PASSPHRASE)
+func DefaultBackupOptions() BackupOptions {
+	return BackupOptions{
+		Compression: util.GetConfigByKey("backup.compression"),
+		Passphrase:  util.GetConfigByKey("backup.passphrase"),
+	}
+}
 
+// CreateBackup writes a full backup of all database data to filePath (see docs/backup-format.md)
+func CreateBackup(filePath string, options BackupOptions) (OperationStats, error) {
+	return createBackupFile(filePath, func(w io.Writer) (OperationStats, error) {
+		return WriteBackup(w, options)
+	})
+}
+
+// CreateIncrementalBackup writes the rows modified and deleted since the backup at basePath was taken
+// The base may be a full or an incremental backup of format version 4 or later; it is read with the same passphrase
+func CreateIncrementalBackup(filePath, basePath string, options BackupOptions) (OperationStats, error) {
+	base, err := ReadBackupManifest(basePath, options.Passphrase)
+	if err != nil {
+		return newOperationStats(), fmt.Errorf("read base backup: %w", err)
+	}
+	return createBackupFile(filePath, func(w io.Writer) (OperationStats, error) {
+		return WriteIncrementalBackup(w, base, options)
+	})
+}
+
+func createBackupFile(filePath string, write func(w io.Writer) (OperationStats, error)) (OperationStats, error) {
 	if filePath == "" {
-		return stats, errors.New("file path cannot be empty")
-	}
-
-	// Get all categories (no pagination limit - get everything)
-	categories := category_mapper.INSTANCE.GetAllCategories(0, 0)
-	stats.Categories.Success = len(categories)
-
-	// Convert categories to map format for JSON serialization
-	categoryMaps := make([]map[string]interface{}, len(categories))
-	for i, cat := range categories {
-		categoryMaps[i] = map[string]interface{}{
-			"Id":         cat.Id.Hex(),
-			"Name":       cat.Name,
-			"ParentId":   cat.ParentId.Hex(),
-			"Remark":     cat.Remark,
-			"CreateTime": cat.CreateTime,
-			"ModifyTime": cat.ModifyTime,
+		return newOperationStats(), errors.New("file path cannot be empty")
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return newOperationStats(), err
+	}
+
+	stats, err := write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Never leave a partial backup behind that looks complete
+		_ = os.Remove(filePath)
+	}
+	return stats, err
+}
+
+// WriteBackup streams every category, cash flow, goal and debt to w, reading one page at a time
+func WriteBackup(w io.Writer, options BackupOptions) (OperationStats, error) {
+	return writeBackup(w, newBackupManifest(BackupKindFull), options)
+}
+
+// WriteIncrementalBackup streams the rows modified since base was taken, then a tombstone per deleted row
+func WriteIncrementalBackup(w io.Writer, base BackupManifest, options BackupOptions) (OperationStats, error) {
+	if base.BackupId == "" || base.CreatedAt.IsZero() {
+		return newOperationStats(), fmt.Errorf("base backup (format version %d) has no backup id, take a new full backup first",
+			base.Version)
+	}
+
+	manifest := newBackupManifest(BackupKindIncremental)
+	manifest.ParentId = base.BackupId
+	since := base.CreatedAt.UTC()
+	manifest.Since = &since
+	return writeBackup(w, manifest, options)
+}
+
+// ReadBackupManifest reads only the header of a backup file
+func ReadBackupManifest(filePath, passphrase string) (BackupManifest, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	defer file.Close()
+
+	stream, err := openBackupStream(file, passphrase)
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	defer stream.Close()
+	return stream.Manifest, nil
+}
+
+// newBackupManifest describes a backup taken now; CreatedAt is the snapshot time later incrementals start from
+func newBackupManifest(kind string) BackupManifest {
+	return BackupManifest{
+		Format:       BackupFormatName,
+		Version:      BackupFormatVersion,
+		CreatedAt:    time.Now().UTC(),
+		AppVersion:   model.Version,
+		SourceDbType: util.GetConfigByKey("db.type"),
+		BackupId:     primitive.NewObjectID().Hex(),
+		Kind:         kind,
+	}
+}
+
+func writeBackup(w io.Writer, manifest BackupManifest, options BackupOptions) (OperationStats, error) {
+	stats := newOperationStats()
+
+	// Full backups read every row, incremental ones only rows touched since the parent
+	getCategories := category_mapper.INSTANCE.GetCategoriesAfterId
+	getCashFlows := cash_flow_mapper.INSTANCE.GetCashFlowsAfterId
+	var since time.Time
+	if manifest.IsIncremental() {
+		since = manifest.Since.Add(-incrementalOverlap)
+		getCategories = func(afterPlainId string, limit int) ([]model.CategoryEntity, error) {
+			return category_mapper.INSTANCE.GetCategoriesModifiedSince(since, afterPlainId, limit)
+		}
+		getCashFlows = func(afterPlainId string, limit int) ([]model.CashFlowEntity, error) {
+			return cash_flow_mapper.INSTANCE.GetCashFlowsModifiedSince(since, afterPlainId, limit)
 		}
 	}
 
-	// Get all cash flows (no pagination limit - get everything)
-	cashFlows := cash_flow_mapper.INSTANCE.GetAllCashFlows(0, 0)
-	stats.CashFlows.Success = len(cashFlows)
-
-	// Convert cash flows to map format for JSON serialization
-	cashFlowMaps := make([]map[string]interface{}, len(cashFlows))
-	for i, cf := range cashFlows {
-		cashFlowMaps[i] = map[string]interface{}{
-			"Id":          cf.Id.Hex(),
-			"CategoryId":  cf.CategoryId.Hex(),
-			"BelongsDate": cf.BelongsDate,
-			"FlowType":    cf.FlowType,
-			"Amount":      cf.Amount,
-			"Description": cf.Description,
-			"Remark":      cf.Remark,
-			"CreateTime":  cf.CreateTime,
-			"ModifyTime":  cf.ModifyTime,
+	writer, err := newBackupWriter(w, manifest, options)
+	if err != nil {
+		return stats, err
+	}
+
+	// Categories first, so a reader knows every category before the cash flows referencing it
+	afterId := ""
+	for {
+		categories, err := getCategories(afterId, backupPageSize)
+		if err != nil {
+			return stats, err
 		}
+		for _, category := range categories {
+			if err := writer.writeCategory(newBackupCategory(category)); err != nil {
+				return stats, err
+			}
+			stats.Categories.Success++
+		}
+		if len(categories) < backupPageSize {
+			break
+		}
+		afterId = categories[len(categories)-1].Id.Hex()
 	}
 
-	// Create backup structure
-	backup := BackupData{
-		Version:    "1.0.0",
-		Timestamp:  time.Now().Format(time.RFC3339),
-		CashFlows:  cashFlowMaps,
-		Categories: categoryMaps,
+	afterId = ""
+	for {
+		cashFlows, err := getCashFlows(afterId, backupPageSize)
+		if err != nil {
+			return stats, err
+		}
+		for _, cashFlow := range cashFlows {
+			if err := writer.writeCashFlow(newBackupCashFlow(cashFlow)); err != nil {
+				return stats, err
+			}
+			stats.CashFlows.Success++
+		}
+		if len(cashFlows) < backupPageSize {
+			break
+		}
+		afterId = cashFlows[len(cashFlows)-1].Id.Hex()
 	}
 
-	// Write to file
-	file, err := os.Create(filePath)
+	// A ledger holds a handful of goals and debts, so they are read in one go
+	goals, err := goal_mapper.INSTANCE.GetAllGoals()
 	if err != nil {
 		return stats, err
 	}
-	defer file.Close()
+	for _, goal := range goals {
+		if manifest.IsIncremental() && goal.ModifyTime.Before(since) {
+			continue
+		}
+		if err := writer.writeGoal(newBackupGoal(goal)); err != nil {
+			return stats, err
+		}
+		stats.Goals.Success++
+	}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(backup); err != nil {
+	debts, err := debt_mapper.INSTANCE.GetAllDebts()
+	if err != nil {
 		return stats, err
 	}
+	for _, debt := range debts {
+		if manifest.IsIncremental() && debt.ModifyTime.Before(since) {
+			continue
+		}
+		if err := writer.writeDebt(newBackupDebt(debt)); err != nil {
+			return stats, err
+		}
+		stats.Debts.Success++
+	}
+
+	// Deletions last, so a restore removes rows only after every upsert of this backup
+	afterId = ""
+	for manifest.IsIncremental() {
+		tombstones, err := tombstone_mapper.INSTANCE.GetTombstonesSince(since, afterId, backupPageSize)
+		if err != nil {
+			return stats, err
+		}
+		for _, tombstone := range tombstones {
+			if err := writer.writeTombstone(newBackupTombstone(tombstone)); err != nil {
+				return stats, err
+			}
+			stats.Tombstones.Success++
+		}
+		if len(tombstones) < backupPageSize {
+			break
+		}
+		afterId = tombstones[len(tombstones)-1].Id.Hex()
+	}
 
+	if _, err := writer.Close(); err != nil {
+		return stats, err
+	}
 	return stats, nil
 }
+
+// VerifyBackup validates a backup file without touching the database
+// Older versions are upgraded in memory first, so the report reflects what a restore would use
+func VerifyBackup(filePath, passphrase string) (BackupVerifyReport, error) {
+	report := BackupVerifyReport{Errors: []string{}}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return report, err
+	}
+	defer file.Close()
+
+	stream, err := openBackupStream(file, passphrase)
+	if err != nil {
+		return report, err
+	}
+	defer stream.Close()
+
+	problemList, err := verifyBackupStream(stream)
+	if err != nil {
+		return report, err
+	}
+
+	report.Version = stream.Manifest.Version
+	report.UpgradedFrom = stream.Manifest.UpgradedFrom
+	report.CreatedAt = stream.Manifest.CreatedAt
+	report.BackupId = stream.Manifest.BackupId
+	report.Kind = stream.Manifest.Kind
+	if report.Kind == "" {
+		report.Kind = BackupKindFull
+	}
+	report.ParentId = stream.Manifest.ParentId
+	report.Sections = stream.Manifest.Sections
+	report.Errors = problemList
+	return report, nil
+}
+
+func newOperationStats() OperationStats {
+	return OperationStats{
+		CashFlows:  EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+		Categories: EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+		Goals:      EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+		Debts:      EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+		Tombstones: EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+	}
+}
@@ -0,0 +1,324 @@
+package manage_service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported backup compression codecs
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// Encrypted backups start with this magic, followed by a scrypt salt and a base nonce
+var encryptionMagic = []byte("CLXAES01")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+const (
+	encryptionSaltSize  = 16
+	encryptionChunkSize = 64 * 1024
+	encryptionFinalFlag = uint32(1) << 31
+)
+
+// ErrPassphraseRequired is returned when reading an encrypted backup without a passphrase
+var ErrPassphraseRequired = errors.New("backup is encrypted: a passphrase is required")
+
+// BackupFileExtension returns the conventional file suffix for the given options
+func BackupFileExtension(compression string, encrypted bool) string {
+	extension := ".ndjson"
+	switch compression {
+	case CompressionGzip:
+		extension += ".gz"
+	case CompressionZstd:
+		extension += ".zst"
+	}
+	if encrypted {
+		extension += ".enc"
+	}
+	return extension
+}
+
+// wrapBackupWriter layers compression and then encryption over w
+// Closing the returned writer flushes every layer but not w itself
+func wrapBackupWriter(w io.Writer, compression, passphrase string) (io.WriteCloser, error) {
+	var closerList []io.Closer
+	current := w
+
+	if passphrase != "" {
+		encryptWriter, err := newEncryptWriter(current, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		current = encryptWriter
+		closerList = append(closerList, encryptWriter)
+	}
+
+	switch compression {
+	case "", CompressionNone:
+	case CompressionGzip:
+		gzipWriter := gzip.NewWriter(current)
+		current = gzipWriter
+		closerList = append(closerList, gzipWriter)
+	case CompressionZstd:
+		zstdWriter, err := zstd.NewWriter(current)
+		if err != nil {
+			return nil, err
+		}
+		current = zstdWriter
+		closerList = append(closerList, zstdWriter)
+	default:
+		return nil, fmt.Errorf("unsupported compression %q (use none, gzip or zstd)", compression)
+	}
+
+	return &layeredWriter{Writer: current, closerList: closerList}, nil
+}
+
+// wrapBackupReader detects encryption and compression from the leading bytes and unwraps them
+func wrapBackupReader(r io.Reader, passphrase string) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	var closerList []io.Closer
+	var current io.Reader = buffered
+
+	if leading, _ := buffered.Peek(len(encryptionMagic)); bytes.Equal(leading, encryptionMagic) {
+		if passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		decryptReader, err := newDecryptReader(buffered, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		buffered = bufio.NewReader(decryptReader)
+		current = buffered
+	}
+
+	leading, _ := buffered.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(leading, gzipMagic):
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		current = gzipReader
+		closerList = append(closerList, gzipReader)
+	case bytes.HasPrefix(leading, zstdMagic):
+		zstdReader, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		current = zstdReader
+		closerList = append(closerList, zstdReader.IOReadCloser())
+	}
+
+	return &layeredReader{Reader: current, closerList: closerList}, nil
+}
+
+type layeredWriter struct {
+	io.Writer
+	closerList []io.Closer
+}
+
+// Close closes the innermost (last added) layer first so each flushes into the next
+func (writer *layeredWriter) Close() error {
+	for i := len(writer.closerList) - 1; i >= 0; i-- {
+		if err := writer.closerList[i].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type layeredReader struct {
+	io.Reader
+	closerList []io.Closer
+}
+
+func (reader *layeredReader) Close() error {
+	for i := len(reader.closerList) - 1; i >= 0; i-- {
+		_ = reader.closerList[i].Close()
+	}
+	return nil
+}
+
+// deriveEncryptionKey stretches a passphrase into an AES-256 key
+func deriveEncryptionKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce derives a unique nonce per chunk by xoring the counter into the base nonce
+func chunkNonce(baseNonce []byte, counter uint64) []byte {
+	nonce := make([]byte, len(baseNonce))
+	copy(nonce, baseNonce)
+	var counterBytes [8]byte
+	binary.BigEndian.PutUint64(counterBytes[:], counter)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-8+i] ^= counterBytes[i]
+	}
+	return nonce
+}
+
+// chunkAad binds the chunk length header, including the final flag, so truncation is detected
+func chunkAad(header uint32) []byte {
+	var aad [4]byte
+	binary.BigEndian.PutUint32(aad[:], header)
+	return aad[:]
+}
+
+// encryptWriter seals the stream in AES-256-GCM chunks of encryptionChunkSize bytes
+// Layout: magic | salt | base nonce | { uint32 length (high bit = final) | ciphertext }...
+type encryptWriter struct {
+	w         io.Writer
+	aead      cipher.AEAD
+	baseNonce []byte
+	counter   uint64
+	buffer    []byte
+}
+
+func newEncryptWriter(w io.Writer, passphrase string) (*encryptWriter, error) {
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := deriveEncryptionKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	baseNonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(baseNonce); err != nil {
+		return nil, err
+	}
+
+	for _, part := range [][]byte{encryptionMagic, salt, baseNonce} {
+		if _, err := w.Write(part); err != nil {
+			return nil, err
+		}
+	}
+	return &encryptWriter{w: w, aead: aead, baseNonce: baseNonce, buffer: make([]byte, 0, encryptionChunkSize)}, nil
+}
+
+func (writer *encryptWriter) Write(data []byte) (int, error) {
+	written := 0
+	for len(data) > 0 {
+		size := min(encryptionChunkSize-len(writer.buffer), len(data))
+		writer.buffer = append(writer.buffer, data[:size]...)
+		data = data[size:]
+		written += size
+		if len(writer.buffer) == encryptionChunkSize {
+			if err := writer.flushChunk(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (writer *encryptWriter) flushChunk(isFinal bool) error {
+	header := uint32(len(writer.buffer) + writer.aead.Overhead())
+	if isFinal {
+		header |= encryptionFinalFlag
+	}
+	sealed := writer.aead.Seal(nil, chunkNonce(writer.baseNonce, writer.counter), writer.buffer, chunkAad(header))
+	writer.counter++
+	writer.buffer = writer.buffer[:0]
+
+	var headerBytes [4]byte
+	binary.BigEndian.PutUint32(headerBytes[:], header)
+	if _, err := writer.w.Write(headerBytes[:]); err != nil {
+		return err
+	}
+	_, err := writer.w.Write(sealed)
+	return err
+}
+
+// Close writes the final chunk; without it a reader reports the backup as truncated
+func (writer *encryptWriter) Close() error {
+	return writer.flushChunk(true)
+}
+
+type decryptReader struct {
+	r         io.Reader
+	aead      cipher.AEAD
+	baseNonce []byte
+	counter   uint64
+	plain     []byte
+	finished  bool
+}
+
+func newDecryptReader(r io.Reader, passphrase string) (*decryptReader, error) {
+	header := make([]byte, len(encryptionMagic)+encryptionSaltSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("read encryption header failed: %w", err)
+	}
+	aead, err := deriveEncryptionKey(passphrase, header[len(encryptionMagic):])
+	if err != nil {
+		return nil, err
+	}
+	baseNonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(r, baseNonce); err != nil {
+		return nil, fmt.Errorf("read encryption header failed: %w", err)
+	}
+	return &decryptReader{r: r, aead: aead, baseNonce: baseNonce}, nil
+}
+
+func (reader *decryptReader) Read(data []byte) (int, error) {
+	for len(reader.plain) == 0 {
+		if reader.finished {
+			return 0, io.EOF
+		}
+		if err := reader.openChunk(); err != nil {
+			return 0, err
+		}
+	}
+	read := copy(data, reader.plain)
+	reader.plain = reader.plain[read:]
+	return read, nil
+}
+
+func (reader *decryptReader) openChunk() error {
+	var headerBytes [4]byte
+	if _, err := io.ReadFull(reader.r, headerBytes[:]); err != nil {
+		return errors.New("encrypted backup is truncated")
+	}
+	header := binary.BigEndian.Uint32(headerBytes[:])
+	size := header &^ encryptionFinalFlag
+	if size > encryptionChunkSize+uint32(reader.aead.Overhead()) {
+		return errors.New("encrypted backup is corrupted")
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(reader.r, sealed); err != nil {
+		return errors.New("encrypted backup is truncated")
+	}
+	plain, err := reader.aead.Open(nil, chunkNonce(reader.baseNonce, reader.counter), sealed, chunkAad(header))
+	if err != nil {
+		return errors.New("decrypt backup failed: wrong passphrase or corrupted file")
+	}
+	reader.counter++
+	reader.plain = plain
+	reader.finished = header&encryptionFinalFlag != 0
+	return nil
+}
@@ -0,0 +1,492 @@
+package manage_service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BackupFormatName identifies CashLenX backup files, see docs/backup-format.md
+const BackupFormatName = "cashlenx-backup"
+
+// BackupFormatVersion is the version written by CreateBackup
+// 1: legacy, unversioned-schema dump ("version": "1.0.0"), upgraded on read
+// 2: single JSON document with a manifest, per-section counts and checksums
+// 3: NDJSON stream (header, one line per record, trailer with checksums), optionally compressed/encrypted
+// 4: version 3 plus backup ids, incremental backups and tombstone records
+// 5: version 4 plus goal and debt records
+const BackupFormatVersion = 5
+
+const (
+	BackupSectionCategories = "categories"
+	BackupSectionCashFlows  = "cash_flows"
+	BackupSectionGoals      = "goals"
+	BackupSectionDebts      = "debts"
+	BackupSectionTombstones = "tombstones"
+)
+
+// Backup kinds: a full backup holds every row, an incremental one the rows modified and
+// deleted since its parent was taken
+const (
+	BackupKindFull        = "full"
+	BackupKindIncremental = "incremental"
+)
+
+// BackupFile is the on-disk layout of a version 2 backup, also used for upgraded version 1 files
+type BackupFile struct {
+	Manifest   BackupManifest   `json:"manifest"`
+	Categories []BackupCategory `json:"categories"`
+	CashFlows  []BackupCashFlow `json:"cash_flows"`
+}
+
+// BackupManifest describes a backup and the checksum of each section
+type BackupManifest struct {
+	Format       string                   `json:"format"`
+	Version      int                      `json:"version"`
+	CreatedAt    time.Time                `json:"created_at"`
+	AppVersion   string                   `json:"app_version"`
+	SourceDbType string                   `json:"source_db_type"`
+	UpgradedFrom int                      `json:"upgraded_from,omitempty"`
+	BackupId     string                   `json:"backup_id,omitempty"`
+	Kind         string                   `json:"kind,omitempty"`
+	ParentId     string                   `json:"parent_id,omitempty"`
+	Since        *time.Time               `json:"since,omitempty"`
+	Sections     map[string]BackupSection `json:"sections,omitempty"`
+}
+
+// IsIncremental reports whether the backup only holds changes since its parent
+func (manifest BackupManifest) IsIncremental() bool {
+	return manifest.Kind == BackupKindIncremental
+}
+
+// BackupSection holds the record count and SHA-256 of one section
+// The checksum covers the compact JSON of every record followed by "\n", in file order
+type BackupSection struct {
+	Count    int64  `json:"count"`
+	Checksum string `json:"sha256"`
+}
+
+// BackupCategory is one category record; ids are ObjectId hex strings
+type BackupCategory struct {
+	Id         string    `json:"id"`
+	ParentId   string    `json:"parent_id,omitempty"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Remark     string    `json:"remark"`
+	CreateTime time.Time `json:"create_time"`
+	ModifyTime time.Time `json:"modify_time"`
+}
+
+// BackupCashFlow is one cash flow record; amount is an exact JSON number
+type BackupCashFlow struct {
+	Id          string      `json:"id"`
+	CategoryId  string      `json:"category_id"`
+	BelongsDate time.Time   `json:"belongs_date"`
+	FlowType    string      `json:"flow_type"`
+	Amount      model.Money `json:"amount"`
+	Description string      `json:"description"`
+	Remark      string      `json:"remark"`
+	CreateTime  time.Time   `json:"create_time"`
+	ModifyTime  time.Time   `json:"modify_time"`
+}
+
+// BackupGoal is one goal record; category_id is left out for a goal over all cash flows
+type BackupGoal struct {
+	Id           string      `json:"id"`
+	Name         string      `json:"name"`
+	TargetAmount model.Money `json:"target_amount"`
+	StartDate    time.Time   `json:"start_date"`
+	Deadline     time.Time   `json:"deadline"`
+	CategoryId   string      `json:"category_id,omitempty"`
+	Remark       string      `json:"remark"`
+	CreateTime   time.Time   `json:"create_time"`
+	ModifyTime   time.Time   `json:"modify_time"`
+}
+
+// BackupDebt is one debt record
+type BackupDebt struct {
+	Id                 string      `json:"id"`
+	Name               string      `json:"name"`
+	Principal          model.Money `json:"principal"`
+	AnnualRate         float64     `json:"annual_rate"`
+	TermMonths         int         `json:"term_months"`
+	StartDate          time.Time   `json:"start_date"`
+	PaymentCategoryId  string      `json:"payment_category_id"`
+	InterestCategoryId string      `json:"interest_category_id"`
+	Remark             string      `json:"remark"`
+	CreateTime         time.Time   `json:"create_time"`
+	ModifyTime         time.Time   `json:"modify_time"`
+}
+
+// BackupTombstone records a row deleted since the parent of an incremental backup
+type BackupTombstone struct {
+	EntityType string    `json:"entity_type"`
+	Id         string    `json:"id"`
+	DeleteTime time.Time `json:"delete_time"`
+}
+
+// BackupVerifyReport is the result of checking a backup without touching the database
+type BackupVerifyReport struct {
+	Version      int                      `json:"version"`
+	UpgradedFrom int                      `json:"upgraded_from,omitempty"`
+	CreatedAt    time.Time                `json:"created_at"`
+	BackupId     string                   `json:"backup_id,omitempty"`
+	Kind         string                   `json:"kind"`
+	ParentId     string                   `json:"parent_id,omitempty"`
+	Sections     map[string]BackupSection `json:"sections"`
+	Errors       []string                 `json:"errors"`
+}
+
+func (report BackupVerifyReport) Valid() bool {
+	return len(report.Errors) == 0
+}
+
+func newBackupCategory(entity model.CategoryEntity) BackupCategory {
+	record := BackupCategory{
+		Id:         entity.Id.Hex(),
+		Name:       entity.Name,
+		Type:       entity.Type,
+		Remark:     entity.Remark,
+		CreateTime: entity.CreateTime.UTC(),
+		ModifyTime: entity.ModifyTime.UTC(),
+	}
+	if entity.ParentId != primitive.NilObjectID {
+		record.ParentId = entity.ParentId.Hex()
+	}
+	return record
+}
+
+func (record BackupCategory) toEntity() (model.CategoryEntity, error) {
+	id, err := primitive.ObjectIDFromHex(record.Id)
+	if err != nil {
+		return model.CategoryEntity{}, fmt.Errorf("category %q: invalid id", record.Id)
+	}
+	entity := model.CategoryEntity{
+		Id:         id,
+		Name:       record.Name,
+		Type:       record.Type,
+		Remark:     record.Remark,
+		CreateTime: record.CreateTime,
+		ModifyTime: record.ModifyTime,
+	}
+	if record.ParentId != "" {
+		if entity.ParentId, err = primitive.ObjectIDFromHex(record.ParentId); err != nil {
+			return model.CategoryEntity{}, fmt.Errorf("category %s: invalid parent_id", record.Id)
+		}
+	}
+	return entity, nil
+}
+
+func newBackupCashFlow(entity model.CashFlowEntity) BackupCashFlow {
+	return BackupCashFlow{
+		Id:          entity.Id.Hex(),
+		CategoryId:  entity.CategoryId.Hex(),
+		BelongsDate: entity.BelongsDate.UTC(),
+		FlowType:    entity.FlowType,
+		Amount:      entity.Amount,
+		Description: entity.Description,
+		Remark:      entity.Remark,
+		CreateTime:  entity.CreateTime.UTC(),
+		ModifyTime:  entity.ModifyTime.UTC(),
+	}
+}
+
+func (record BackupCashFlow) toEntity() (model.CashFlowEntity, error) {
+	id, err := primitive.ObjectIDFromHex(record.Id)
+	if err != nil {
+		return model.CashFlowEntity{}, fmt.Errorf("cash flow %q: invalid id", record.Id)
+	}
+	categoryId, err := primitive.ObjectIDFromHex(record.CategoryId)
+	if err != nil {
+		return model.CashFlowEntity{}, fmt.Errorf("cash flow %s: invalid category_id", record.Id)
+	}
+	return model.CashFlowEntity{
+		Id:          id,
+		CategoryId:  categoryId,
+		BelongsDate: record.BelongsDate,
+		FlowType:    record.FlowType,
+		Amount:      record.Amount,
+		Description: record.Description,
+		Remark:      record.Remark,
+		CreateTime:  record.CreateTime,
+		ModifyTime:  record.ModifyTime,
+	}, nil
+}
+
+func newBackupGoal(entity model.GoalEntity) BackupGoal {
+	record := BackupGoal{
+		Id:           entity.Id.Hex(),
+		Name:         entity.Name,
+		TargetAmount: entity.TargetAmount,
+		StartDate:    entity.StartDate.UTC(),
+		Deadline:     entity.Deadline.UTC(),
+		Remark:       entity.Remark,
+		CreateTime:   entity.CreateTime.UTC(),
+		ModifyTime:   entity.ModifyTime.UTC(),
+	}
+	if !entity.CategoryId.IsZero() {
+		record.CategoryId = entity.CategoryId.Hex()
+	}
+	return record
+}
+
+func (record BackupGoal) toEntity() (model.GoalEntity, error) {
+	id, err := primitive.ObjectIDFromHex(record.Id)
+	if err != nil {
+		return model.GoalEntity{}, fmt.Errorf("goal %q: invalid id", record.Id)
+	}
+	entity := model.GoalEntity{
+		Id:           id,
+		Name:         record.Name,
+		TargetAmount: record.TargetAmount,
+		StartDate:    record.StartDate,
+		Deadline:     record.Deadline,
+		Remark:       record.Remark,
+		CreateTime:   record.CreateTime,
+		ModifyTime:   record.ModifyTime,
+	}
+	if record.CategoryId != "" {
+		if entity.CategoryId, err = primitive.ObjectIDFromHex(record.CategoryId); err != nil {
+			return model.GoalEntity{}, fmt.Errorf("goal %s: invalid category_id", record.Id)
+		}
+	}
+	return entity, nil
+}
+
+func newBackupDebt(entity model.DebtEntity) BackupDebt {
+	return BackupDebt{
+		Id:                 entity.Id.Hex(),
+		Name:               entity.Name,
+		Principal:          entity.Principal,
+		AnnualRate:         entity.AnnualRate,
+		TermMonths:         entity.TermMonths,
+		StartDate:          entity.StartDate.UTC(),
+		PaymentCategoryId:  entity.PaymentCategoryId.Hex(),
+		InterestCategoryId: entity.InterestCategoryId.Hex(),
+		Remark:             entity.Remark,
+		CreateTime:         entity.CreateTime.UTC(),
+		ModifyTime:         entity.ModifyTime.UTC(),
+	}
+}
+
+func (record BackupDebt) toEntity() (model.DebtEntity, error) {
+	id, err := primitive.ObjectIDFromHex(record.Id)
+	if err != nil {
+		return model.DebtEntity{}, fmt.Errorf("debt %q: invalid id", record.Id)
+	}
+	paymentCategoryId, err := primitive.ObjectIDFromHex(record.PaymentCategoryId)
+	if err != nil {
+		return model.DebtEntity{}, fmt.Errorf("debt %s: invalid payment_category_id", record.Id)
+	}
+	interestCategoryId, err := primitive.ObjectIDFromHex(record.InterestCategoryId)
+	if err != nil {
+		return model.DebtEntity{}, fmt.Errorf("debt %s: invalid interest_category_id", record.Id)
+	}
+	return model.DebtEntity{
+		Id:                 id,
+		Name:               record.Name,
+		Principal:          record.Principal,
+		AnnualRate:         record.AnnualRate,
+		TermMonths:         record.TermMonths,
+		StartDate:          record.StartDate,
+		PaymentCategoryId:  paymentCategoryId,
+		InterestCategoryId: interestCategoryId,
+		Remark:             record.Remark,
+		CreateTime:         record.CreateTime,
+		ModifyTime:         record.ModifyTime,
+	}, nil
+}
+
+// backupTombstoneTypeSet holds the entity types a tombstone may name
+var backupTombstoneTypeSet = map[string]bool{
+	model.TombstoneTypeCategory: true,
+	model.TombstoneTypeCashFlow: true,
+	model.TombstoneTypeGoal:     true,
+	model.TombstoneTypeDebt:     true,
+}
+
+func newBackupTombstone(entity model.TombstoneEntity) BackupTombstone {
+	return BackupTombstone{
+		EntityType: entity.EntityType,
+		Id:         entity.EntityId.Hex(),
+		DeleteTime: entity.DeleteTime.UTC(),
+	}
+}
+
+// objectId validates the tombstone and returns the id of the deleted row
+func (record BackupTombstone) objectId() (primitive.ObjectID, error) {
+	if !backupTombstoneTypeSet[record.EntityType] {
+		return primitive.NilObjectID, fmt.Errorf("tombstone %s: unknown entity_type %q", record.Id, record.EntityType)
+	}
+	id, err := primitive.ObjectIDFromHex(record.Id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("tombstone %q: invalid id", record.Id)
+	}
+	return id, nil
+}
+
+// computeSection counts records and hashes their compact JSON, one per line
+func computeSection[T any](records []T) (BackupSection, error) {
+	section := newSectionDigest()
+	for _, record := range records {
+		compact, err := json.Marshal(record)
+		if err != nil {
+			return BackupSection{}, err
+		}
+		section.add(compact)
+	}
+	return section.result(), nil
+}
+
+// parseBackup decodes a single-document (version 1 or 2) backup into the version 2 layout
+func parseBackup(content []byte) (*BackupFile, error) {
+	var probe struct {
+		Version  json.RawMessage `json:"version"`
+		Manifest *struct {
+			Format  string `json:"format"`
+			Version int    `json:"version"`
+		} `json:"manifest"`
+	}
+	if err := json.Unmarshal(content, &probe); err != nil {
+		return nil, fmt.Errorf("not a valid backup file: %w", err)
+	}
+
+	switch {
+	case probe.Manifest != nil:
+		if probe.Manifest.Format != BackupFormatName {
+			return nil, fmt.Errorf("unknown backup format %q", probe.Manifest.Format)
+		}
+		if probe.Manifest.Version != 2 {
+			return nil, fmt.Errorf("unsupported single-document backup version %d", probe.Manifest.Version)
+		}
+		var backup BackupFile
+		if err := json.Unmarshal(content, &backup); err != nil {
+			return nil, fmt.Errorf("invalid backup content: %w", err)
+		}
+		return &backup, nil
+	case strings.HasPrefix(strings.Trim(string(probe.Version), `"`), "1."):
+		return upgradeBackupV1(content)
+	default:
+		return nil, errors.New("not a cashlenx backup: manifest missing")
+	}
+}
+
+// backupDataV1 is the legacy layout written before the versioned format
+type backupDataV1 struct {
+	Version    string                   `json:"version"`
+	Timestamp  string                   `json:"timestamp"`
+	CashFlows  []map[string]interface{} `json:"cash_flows"`
+	Categories []map[string]interface{} `json:"categories"`
+}
+
+// upgradeBackupV1 converts a legacy dump, keeping its ids and inferring the category type it never stored
+func upgradeBackupV1(content []byte) (*BackupFile, error) {
+	var legacy backupDataV1
+	decoder := json.NewDecoder(strings.NewReader(string(content)))
+	// Keep amounts as exact decimal strings instead of float64
+	decoder.UseNumber()
+	if err := decoder.Decode(&legacy); err != nil {
+		return nil, fmt.Errorf("invalid version 1 backup: %w", err)
+	}
+
+	backup := &BackupFile{
+		Manifest: BackupManifest{
+			Format:       BackupFormatName,
+			Version:      2,
+			UpgradedFrom: 1,
+		},
+	}
+	backup.Manifest.CreatedAt, _ = time.Parse(time.RFC3339, legacy.Timestamp)
+
+	// Category type was not written in version 1: infer it from the cash flows that use the category
+	inferredTypeMap := make(map[string]string)
+	for _, cashFlowMap := range legacy.CashFlows {
+		record := BackupCashFlow{
+			Id:          legacyString(cashFlowMap, "Id"),
+			CategoryId:  legacyString(cashFlowMap, "CategoryId"),
+			BelongsDate: legacyTime(cashFlowMap, "BelongsDate"),
+			FlowType:    legacyString(cashFlowMap, "FlowType"),
+			Description: legacyString(cashFlowMap, "Description"),
+			Remark:      legacyString(cashFlowMap, "Remark"),
+			CreateTime:  legacyTime(cashFlowMap, "CreateTime"),
+			ModifyTime:  legacyTime(cashFlowMap, "ModifyTime"),
+		}
+		if amount, isNumber := cashFlowMap["Amount"].(json.Number); isNumber {
+			record.Amount, _ = model.NewMoneyFromString(amount.String())
+		}
+		if _, isExist := inferredTypeMap[record.CategoryId]; !isExist {
+			inferredTypeMap[record.CategoryId] = map[string]string{"INCOME": "income"}[record.FlowType]
+		}
+		backup.CashFlows = append(backup.CashFlows, record)
+	}
+
+	for _, categoryMap := range legacy.Categories {
+		record := BackupCategory{
+			Id:         legacyString(categoryMap, "Id"),
+			ParentId:   legacyString(categoryMap, "ParentId"),
+			Name:       legacyString(categoryMap, "Name"),
+			Type:       legacyString(categoryMap, "Type"),
+			Remark:     legacyString(categoryMap, "Remark"),
+			CreateTime: legacyTime(categoryMap, "CreateTime"),
+			ModifyTime: legacyTime(categoryMap, "ModifyTime"),
+		}
+		if record.ParentId == primitive.NilObjectID.Hex() {
+			record.ParentId = ""
+		}
+		if record.Type == "" {
+			record.Type = inferredTypeMap[record.Id]
+		}
+		if record.Type == "" {
+			record.Type = "expense"
+		}
+		backup.Categories = append(backup.Categories, record)
+	}
+
+	// Children must share their parent's type
+	typeMap := make(map[string]string, len(backup.Categories))
+	for _, record := range backup.Categories {
+		typeMap[record.Id] = record.Type
+	}
+	for i, record := range backup.Categories {
+		if parentType, isExist := typeMap[record.ParentId]; isExist {
+			backup.Categories[i].Type = parentType
+		}
+	}
+
+	if err := backup.sealManifest(); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+func legacyString(fieldMap map[string]interface{}, key string) string {
+	value, _ := fieldMap[key].(string)
+	return value
+}
+
+func legacyTime(fieldMap map[string]interface{}, key string) time.Time {
+	parsedTime, _ := time.Parse(time.RFC3339Nano, legacyString(fieldMap, key))
+	return parsedTime.UTC()
+}
+
+// sealManifest recomputes the counts and checksums of every section
+func (backup *BackupFile) sealManifest() error {
+	categorySection, err := computeSection(backup.Categories)
+	if err != nil {
+		return err
+	}
+	cashFlowSection, err := computeSection(backup.CashFlows)
+	if err != nil {
+		return err
+	}
+	backup.Manifest.Sections = map[string]BackupSection{
+		BackupSectionCategories: categorySection,
+		BackupSectionCashFlows:  cashFlowSection,
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+package manage_service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestRecords() ([]BackupCategory, []BackupCashFlow) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	parentId, childId := primitive.NewObjectID(), primitive.NewObjectID()
+	amount, _ := model.NewMoneyFromString("19.99")
+
+	categories := []BackupCategory{
+		newBackupCategory(model.CategoryEntity{Id: parentId, Name: "Food", Type: "expense", CreateTime: now, ModifyTime: now}),
+		newBackupCategory(model.CategoryEntity{Id: childId, ParentId: parentId, Name: "Lunch", Type: "expense", CreateTime: now, ModifyTime: now}),
+	}
+	cashFlows := []BackupCashFlow{
+		newBackupCashFlow(model.CashFlowEntity{Id: primitive.NewObjectID(), CategoryId: childId, BelongsDate: now,
+			FlowType: "OUTCOME", Amount: amount, Description: "noodles", CreateTime: now, ModifyTime: now}),
+	}
+	return categories, cashFlows
+}
+
+func writeTestStream(t *testing.T, options BackupOptions) ([]byte, []BackupCategory, []BackupCashFlow) {
+	t.Helper()
+	categories, cashFlows := newTestRecords()
+
+	var buffer bytes.Buffer
+	writer, err := newBackupWriter(&buffer, BackupManifest{Format: BackupFormatName, Version: BackupFormatVersion}, options)
+	if err != nil {
+		t.Fatalf("newBackupWriter() error = %v", err)
+	}
+	for _, record := range categories {
+		if err := writer.writeCategory(record); err != nil {
+			t.Fatalf("writeCategory() error = %v", err)
+		}
+	}
+	for _, record := range cashFlows {
+		if err := writer.writeCashFlow(record); err != nil {
+			t.Fatalf("writeCashFlow() error = %v", err)
+		}
+	}
+	if _, err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buffer.Bytes(), categories, cashFlows
+}
+
+func verifyTestContent(t *testing.T, content []byte, passphrase string) (*backupStream, []string) {
+	t.Helper()
+	stream, err := openBackupStream(bytes.NewReader(content), passphrase)
+	if err != nil {
+		t.Fatalf("openBackupStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	problemList, err := verifyBackupStream(stream)
+	if err != nil {
+		t.Fatalf("verifyBackupStream() error = %v", err)
+	}
+	return stream, problemList
+}
+
+func TestBackupStreamRoundTrip(t *testing.T) {
+	for _, compression := range []string{CompressionNone, CompressionGzip, CompressionZstd} {
+		for _, passphrase := range []string{"", "correct horse"} {
+			t.Run(compression+"/encrypted="+map[bool]string{true: "yes", false: "no"}[passphrase != ""], func(t *testing.T) {
+				content, categories, cashFlows := writeTestStream(t, BackupOptions{Compression: compression, Passphrase: passphrase})
+				if passphrase != "" && bytes.Contains(content, []byte("noodles")) {
+					t.Fatal("Encrypted backup contains plain text")
+				}
+
+				stream, err := openBackupStream(bytes.NewReader(content), passphrase)
+				if err != nil {
+					t.Fatalf("openBackupStream() error = %v", err)
+				}
+				defer stream.Close()
+
+				var readCategories []BackupCategory
+				var readCashFlows []BackupCashFlow
+				err = stream.forEach(backupHandlers{
+					onCategory:  func(record BackupCategory) error { readCategories = append(readCategories, record); return nil },
+					onCashFlow:  func(record BackupCashFlow) error { readCashFlows = append(readCashFlows, record); return nil },
+					onGoal:      func(record BackupGoal) error { return nil },
+					onDebt:      func(record BackupDebt) error { return nil },
+					onTombstone: func(record BackupTombstone) error { return nil },
+				})
+				if err != nil {
+					t.Fatalf("forEach() error = %v", err)
+				}
+
+				if len(readCategories) != 2 || readCategories[1].ParentId != categories[0].Id {
+					t.Errorf("Parent link lost: %+v", readCategories)
+				}
+				if len(readCashFlows) != 1 || readCashFlows[0].CategoryId != cashFlows[0].CategoryId ||
+					readCashFlows[0].Amount.String() != "19.99" {
+					t.Errorf("Unexpected cash flows: %+v", readCashFlows)
+				}
+				if stream.Manifest.Sections[BackupSectionCashFlows].Count != 1 {
+					t.Errorf("Unexpected trailer: %+v", stream.Manifest.Sections)
+				}
+			})
+		}
+	}
+}
+
+func TestBackupStreamVerify(t *testing.T) {
+	content, _, _ := writeTestStream(t, BackupOptions{})
+	if _, problemList := verifyTestContent(t, content, ""); len(problemList) != 0 {
+		t.Fatalf("Expected valid backup, got %v", problemList)
+	}
+
+	tampered := bytes.Replace(content, []byte("noodles"), []byte("noodle!"), 1)
+	if _, problemList := verifyTestContent(t, tampered, ""); !strings.Contains(strings.Join(problemList, ";"), "checksum mismatch") {
+		t.Errorf("Expected checksum mismatch, got %v", problemList)
+	}
+
+	// Drop the trailer line
+	lineList := bytes.Split(bytes.TrimSpace(content), []byte("\n"))
+	truncated := bytes.Join(lineList[:len(lineList)-1], []byte("\n"))
+	stream, err := openBackupStream(bytes.NewReader(truncated), "")
+	if err != nil {
+		t.Fatalf("openBackupStream() error = %v", err)
+	}
+	if _, err := verifyBackupStream(stream); err == nil {
+		t.Error("Expected error for truncated backup")
+	}
+}
+
+func TestBackupStreamGoals(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	categories, _ := newTestRecords()
+	categoryId, _ := primitive.ObjectIDFromHex(categories[1].Id)
+	target, _ := model.NewMoneyFromString("1500.00")
+	newGoal := func(categoryId primitive.ObjectID) BackupGoal {
+		return newBackupGoal(model.GoalEntity{Id: primitive.NewObjectID(), Name: "Bike", TargetAmount: target,
+			StartDate: now, Deadline: now.AddDate(1, 0, 0), CategoryId: categoryId, CreateTime: now, ModifyTime: now})
+	}
+	writeGoals := func(version int, goals ...BackupGoal) []byte {
+		var buffer bytes.Buffer
+		writer, err := newBackupWriter(&buffer, BackupManifest{Format: BackupFormatName, Version: version}, BackupOptions{})
+		if err != nil {
+			t.Fatalf("newBackupWriter() error = %v", err)
+		}
+		for _, record := range categories {
+			if err := writer.writeCategory(record); err != nil {
+				t.Fatalf("writeCategory() error = %v", err)
+			}
+		}
+		for _, record := range goals {
+			if err := writer.writeGoal(record); err != nil {
+				t.Fatalf("writeGoal() error = %v", err)
+			}
+		}
+		if _, err := writer.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		return buffer.Bytes()
+	}
+
+	linked, overall := newGoal(categoryId), newGoal(primitive.NilObjectID)
+	if overall.CategoryId != "" {
+		t.Errorf("Goal without category wrote category_id %q", overall.CategoryId)
+	}
+	stream, problemList := verifyTestContent(t, writeGoals(BackupFormatVersion, linked, overall), "")
+	if len(problemList) != 0 {
+		t.Fatalf("Expected valid backup, got %v", problemList)
+	}
+	if stream.Manifest.Sections[BackupSectionGoals].Count != 2 {
+		t.Errorf("Unexpected trailer: %+v", stream.Manifest.Sections)
+	}
+	entity, err := linked.toEntity()
+	if err != nil || entity.CategoryId != categoryId || entity.TargetAmount.String() != "1500.00" {
+		t.Errorf("toEntity() = %+v, %v", entity, err)
+	}
+
+	_, problemList = verifyTestContent(t, writeGoals(BackupFormatVersion, newGoal(primitive.NewObjectID())), "")
+	if !strings.Contains(strings.Join(problemList, ";"), "not in backup") {
+		t.Errorf("Expected dangling category_id, got %v", problemList)
+	}
+
+	_, problemList = verifyTestContent(t, writeGoals(4, linked), "")
+	if !strings.Contains(strings.Join(problemList, ";"), "goals and debts are not allowed in version 4") {
+		t.Errorf("Expected goals refused in version 4, got %v", problemList)
+	}
+}
+
+func TestBackupStreamDebts(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	categories, _ := newTestRecords()
+	paymentId, _ := primitive.ObjectIDFromHex(categories[0].Id)
+	interestId, _ := primitive.ObjectIDFromHex(categories[1].Id)
+	principal, _ := model.NewMoneyFromString("12000.00")
+	newDebt := func(interestId primitive.ObjectID) BackupDebt {
+		return newBackupDebt(model.DebtEntity{Id: primitive.NewObjectID(), Name: "Car", Principal: principal,
+			AnnualRate: 4.5, TermMonths: 36, StartDate: now, PaymentCategoryId: paymentId, InterestCategoryId: interestId,
+			CreateTime: now, ModifyTime: now})
+	}
+	writeDebts := func(debts ...BackupDebt) []byte {
+		var buffer bytes.Buffer
+		writer, err := newBackupWriter(&buffer, BackupManifest{Format: BackupFormatName, Version: BackupFormatVersion}, BackupOptions{})
+		if err != nil {
+			t.Fatalf("newBackupWriter() error = %v", err)
+		}
+		for _, record := range categories {
+			if err := writer.writeCategory(record); err != nil {
+				t.Fatalf("writeCategory() error = %v", err)
+			}
+		}
+		for _, record := range debts {
+			if err := writer.writeDebt(record); err != nil {
+				t.Fatalf("writeDebt() error = %v", err)
+			}
+		}
+		if _, err := writer.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		return buffer.Bytes()
+	}
+
+	debt := newDebt(interestId)
+	stream, problemList := verifyTestContent(t, writeDebts(debt), "")
+	if len(problemList) != 0 {
+		t.Fatalf("Expected valid backup, got %v", problemList)
+	}
+	if stream.Manifest.Sections[BackupSectionDebts].Count != 1 {
+		t.Errorf("Unexpected trailer: %+v", stream.Manifest.Sections)
+	}
+	entity, err := debt.toEntity()
+	if err != nil || entity.InterestCategoryId != interestId || entity.AnnualRate != 4.5 || entity.TermMonths != 36 {
+		t.Errorf("toEntity() = %+v, %v", entity, err)
+	}
+
+	_, problemList = verifyTestContent(t, writeDebts(newDebt(primitive.NewObjectID())), "")
+	if !strings.Contains(strings.Join(problemList, ";"), "not in backup") {
+		t.Errorf("Expected dangling interest category, got %v", problemList)
+	}
+}
+
+func TestIncrementalBackupStreamVerify(t *testing.T) {
+	writeIncremental := func(manifest BackupManifest, cashFlows []BackupCashFlow, tombstones []BackupTombstone) []byte {
+		var buffer bytes.Buffer
+		writer, err := newBackupWriter(&buffer, manifest, BackupOptions{})
+		if err != nil {
+			t.Fatalf("newBackupWriter() error = %v", err)
+		}
+		for _, record := range cashFlows {
+			if err := writer.writeCashFlow(record); err != nil {
+				t.Fatalf("writeCashFlow() error = %v", err)
+			}
+		}
+		for _, record := range tombstones {
+			if err := writer.writeTombstone(record); err != nil {
+				t.Fatalf("writeTombstone() error = %v", err)
+			}
+		}
+		if _, err := writer.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		return buffer.Bytes()
+	}
+
+	// A cash flow whose category lives in the parent backup, and a deleted category
+	_, cashFlows := newTestRecords()
+	deleted := BackupTombstone{EntityType: model.TombstoneTypeCategory, Id: primitive.NewObjectID().Hex(),
+		DeleteTime: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)}
+
+	full := newBackupManifest(BackupKindFull)
+	incremental := newBackupManifest(BackupKindIncremental)
+	incremental.ParentId = full.BackupId
+	incremental.Since = &full.CreatedAt
+
+	content := writeIncremental(incremental, cashFlows, []BackupTombstone{deleted})
+	stream, problemList := verifyTestContent(t, content, "")
+	if len(problemList) != 0 {
+		t.Fatalf("Expected valid incremental backup, got %v", problemList)
+	}
+	if !stream.Manifest.IsIncremental() || stream.Manifest.ParentId != full.BackupId {
+		t.Errorf("Unexpected manifest %+v", stream.Manifest)
+	}
+	if stream.Manifest.Sections[BackupSectionTombstones].Count != 1 {
+		t.Errorf("Unexpected tombstone section %+v", stream.Manifest.Sections)
+	}
+
+	// The same content labelled as a full backup has a dangling reference and a stray tombstone
+	_, problemList = verifyTestContent(t, writeIncremental(full, cashFlows, []BackupTombstone{deleted}), "")
+	joined := strings.Join(problemList, "; ")
+	if !strings.Contains(joined, "not in backup") || !strings.Contains(joined, "in a full backup") {
+		t.Errorf("Expected reference and tombstone problems, got %v", problemList)
+	}
+
+	invalid := deleted
+	invalid.EntityType = "budget"
+	_, problemList = verifyTestContent(t, writeIncremental(incremental, nil, []BackupTombstone{invalid}), "")
+	if !strings.Contains(strings.Join(problemList, "; "), "unknown entity_type") {
+		t.Errorf("Expected entity type problem, got %v", problemList)
+	}
+}
+
+func TestWriteIncrementalBackupNeedsBackupId(t *testing.T) {
+	base := BackupManifest{Format: BackupFormatName, Version: 3, CreatedAt: time.Now()}
+	if _, err := WriteIncrementalBackup(&bytes.Buffer{}, base, BackupOptions{}); err == nil {
+		t.Error("Expected error for a base backup without backup id")
+	}
+}
+
+func TestBackupStreamPassphrase(t *testing.T) {
+	content, _, _ := writeTestStream(t, BackupOptions{Compression: CompressionGzip, Passphrase: "secret"})
+
+	if _, err := openBackupStream(bytes.NewReader(content), ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("Expected ErrPassphraseRequired, got %v", err)
+	}
+	if _, err := openBackupStream(bytes.NewReader(content), "wrong"); err == nil {
+		t.Error("Expected error for wrong passphrase")
+	}
+
+	stream, err := openBackupStream(bytes.NewReader(content[:len(content)-10]), "secret")
+	if err == nil {
+		_, err = verifyBackupStream(stream)
+	}
+	if err == nil {
+		t.Error("Expected error for truncated encrypted backup")
+	}
+}
+
+func TestVersion2BackupVerify(t *testing.T) {
+	categories, cashFlows := newTestRecords()
+	newVersion2 := func() *BackupFile {
+		backup := &BackupFile{
+			Manifest:   BackupManifest{Format: BackupFormatName, Version: 2},
+			Categories: append([]BackupCategory{}, categories...),
+			CashFlows:  append([]BackupCashFlow{}, cashFlows...),
+		}
+		_ = backup.sealManifest()
+		return backup
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*BackupFile)
+		expect string
+	}{
+		{"Valid", func(b *BackupFile) {}, ""},
+		{"Tampered record", func(b *BackupFile) { b.CashFlows[0].Description = "changed" }, "checksum mismatch"},
+		{"Missing record", func(b *BackupFile) { b.CashFlows = nil }, "manifest count"},
+		{"Dangling parent", func(b *BackupFile) {
+			b.Categories = b.Categories[1:]
+			_ = b.sealManifest()
+		}, "not in backup"},
+		{"Invalid id", func(b *BackupFile) {
+			b.CashFlows[0].Id = "xyz"
+			_ = b.sealManifest()
+		}, "invalid id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := newVersion2()
+			tt.modify(backup)
+			content, _ := json.MarshalIndent(backup, "", "  ")
+
+			_, problemList := verifyTestContent(t, content, "")
+			joined := strings.Join(problemList, "; ")
+			if tt.expect == "" && joined != "" {
+				t.Errorf("Expected no problem, got %v", problemList)
+			}
+			if !strings.Contains(joined, tt.expect) {
+				t.Errorf("Expected problem containing %q, got %v", tt.expect, problemList)
+			}
+		})
+	}
+}
+
+func TestVersion1BackupUpgrade(t *testing.T) {
+	categoryId := primitive.NewObjectID().Hex()
+	cashFlowId := primitive.NewObjectID().Hex()
+	legacy := `{
+		"version": "1.0.0",
+		"timestamp": "2024-01-02T03:04:05Z",
+		"categories": [{"Id": "` + categoryId + `", "Name": "Salary", "ParentId": "000000000000000000000000",
+			"Remark": "", "CreateTime": "2024-01-01T00:00:00Z", "ModifyTime": "2024-01-01T00:00:00Z"}],
+		"cash_flows": [{"Id": "` + cashFlowId + `", "CategoryId": "` + categoryId + `",
+			"BelongsDate": "2024-01-01T00:00:00Z", "FlowType": "INCOME", "Amount": 5000.10,
+			"Description": "pay", "Remark": "", "CreateTime": "2024-01-01T00:00:00Z", "ModifyTime": "2024-01-01T00:00:00Z"}]
+	}`
+
+	stream, problemList := verifyTestContent(t, []byte(legacy), "")
+	if len(problemList) != 0 {
+		t.Fatalf("Expected valid upgraded backup, got %v", problemList)
+	}
+	if stream.Manifest.UpgradedFrom != 1 {
+		t.Errorf("Unexpected manifest: %+v", stream.Manifest)
+	}
+
+	category := stream.legacy.Categories[0]
+	if category.Id != categoryId || category.ParentId != "" || category.Type != "income" {
+		t.Errorf("Unexpected upgraded category: %+v", category)
+	}
+	if stream.legacy.CashFlows[0].Id != cashFlowId || stream.legacy.CashFlows[0].Amount.String() != "5000.10" {
+		t.Errorf("Unexpected upgraded cash flow: %+v", stream.legacy.CashFlows[0])
+	}
+}
+
+func TestOpenBackupStreamRejectsUnknown(t *testing.T) {
+	for _, content := range []string{
+		`{"kind": "header", "data": {"format": "cashlenx-backup", "version": 99}}`,
+		`{"manifest": {"format": "cashlenx-backup", "version": 99}}`,
+		`{"manifest": {"format": "other", "version": 2}}`,
+		`{"hello": "world"}`,
+		`not json`,
+	} {
+		if _, err := openBackupStream(strings.NewReader(content), ""); err == nil {
+			t.Errorf("Expected error for %s", content)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package manage_service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// backupFileNamePattern matches files written by `manage backup`, `db dump` and the scheduler:
+// cashlenx_<backup|dump>_YYYYMMDD_HHMMSS.<ndjson|json>[.gz|.zst][.enc]
+var backupFileNamePattern = regexp.MustCompile(`^cashlenx_(?:backup|dump)_(\d{8}_\d{6})\.(?:ndjson|json)(?:\.gz|\.zst)?(?:\.enc)?$`)
+
+// incrementalFileNamePattern matches incremental backups written by `manage backup --base`;
+// they are never pruned, but the backups they build on are kept for them
+var incrementalFileNamePattern = regexp.MustCompile(`^cashlenx_incremental_\d{8}_\d{6}\.(?:ndjson|json)(?:\.gz|\.zst)?(?:\.enc)?$`)
+
+// backupFileTimeFormat is the timestamp layout used in backup file names
+const backupFileTimeFormat = "20060102_150405"
+
+// BackupFileInfo describes one backup file found in a backup directory
+type BackupFileInfo struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	KeptBy    []string  `json:"kept_by,omitempty"` // why this backup is kept: daily, weekly, monthly, latest or incremental
+}
+
+// RetentionPolicy is a grandfather-father-son policy: the newest backup of each of the last
+// Daily days, Weekly ISO weeks and Monthly months is kept
+type RetentionPolicy struct {
+	Daily   int `json:"daily"`
+	Weekly  int `json:"weekly"`
+	Monthly int `json:"monthly"`
+}
+
+// AuditParameters describes a prune of dir under the policy for the audit log
+func (policy RetentionPolicy) AuditParameters(dir string) map[string]string {
+	return map[string]string{
+		"dir":     dir,
+		"daily":   strconv.Itoa(policy.Daily),
+		"weekly":  strconv.Itoa(policy.Weekly),
+		"monthly": strconv.Itoa(policy.Monthly),
+	}
+}
+
+// DefaultRetentionPolicy reads BACKUP_KEEP_DAILY, BACKUP_KEEP_WEEKLY and BACKUP_KEEP_MONTHLY
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		Daily:   retentionConfig("backup.keep.daily"),
+		Weekly:  retentionConfig("backup.keep.weekly"),
+		Monthly: retentionConfig("backup.keep.monthly"),
+	}
+}
+
+func retentionConfig(configKey string) int {
+	value, err := strconv.Atoi(util.GetConfigByKey(configKey))
+	if err != nil || value < 0 {
+		util.Logger.Warnw("invalid backup retention, keeping none for this tier", "key", configKey)
+		return 0
+	}
+	return value
+}
+
+// IsEmpty reports whether the policy keeps nothing; pruning with it is refused
+func (policy RetentionPolicy) IsEmpty() bool {
+	return policy.Daily <= 0 && policy.Weekly <= 0 && policy.Monthly <= 0
+}
+
+// BackupFileName builds the timestamped name of a backup taken at the given time
+func BackupFileName(prefix string, at time.Time, options BackupOptions) string {
+	return fmt.Sprintf("cashlenx_%s_%s%s", prefix, at.Format(backupFileTimeFormat),
+		BackupFileExtension(options.Compression, options.Passphrase != ""))
+}
+
+// ListBackups returns the backup files in dir, newest first
+// The timestamp in the file name is read in the configured timezone; other files are ignored
+func ListBackups(dir string) ([]BackupFileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []BackupFileInfo{}, nil
+		}
+		return nil, err
+	}
+
+	backups := []BackupFileInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := backupFileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+		createdAt, err := time.ParseInLocation(backupFileTimeFormat, matches[1], util.GetTimezone())
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, BackupFileInfo{
+			Name:      entry.Name(),
+			Path:      filepath.Join(dir, entry.Name()),
+			Size:      info.Size(),
+			CreatedAt: createdAt,
+		})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].Name > backups[j].Name
+		}
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// PlanRetention splits backups into the ones the policy keeps and the ones to prune
+// Each tier walks the backups newest first and keeps the first one of every new day, ISO week
+// or month until it has kept its quota. The newest backup is always kept.
+// Both results stay newest first; KeptBy is filled for kept backups.
+func PlanRetention(backups []BackupFileInfo, policy RetentionPolicy) (keep, prune []BackupFileInfo) {
+	sorted := make([]BackupFileInfo, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	keptBy := make([][]string, len(sorted))
+	applyTier := func(tier string, quota int, period func(time.Time) string) {
+		seen := map[string]bool{}
+		for i, backup := range sorted {
+			if len(seen) >= quota {
+				return
+			}
+			key := period(backup.CreatedAt)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			keptBy[i] = append(keptBy[i], tier)
+		}
+	}
+	applyTier("daily", policy.Daily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	applyTier("weekly", policy.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	applyTier("monthly", policy.Monthly, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+	if len(sorted) > 0 && len(keptBy[0]) == 0 {
+		keptBy[0] = []string{"latest"}
+	}
+
+	keep, prune = []BackupFileInfo{}, []BackupFileInfo{}
+	for i, backup := range sorted {
+		if len(keptBy[i]) > 0 {
+			backup.KeptBy = keptBy[i]
+			keep = append(keep, backup)
+		} else {
+			prune = append(prune, backup)
+		}
+	}
+	return keep, prune
+}
+
+// PruneBackups deletes the backups in dir that the policy does not keep
+// A backup that an incremental backup in dir builds on is kept as well; manifests are read with passphrase.
+// With dryRun nothing is deleted; the returned lists show what would happen
+func PruneBackups(dir string, policy RetentionPolicy, passphrase string, dryRun bool) (keep, pruned []BackupFileInfo, err error) {
+	if policy.IsEmpty() {
+		return nil, nil, errors.New("retention policy keeps no backups; set at least one of daily, weekly or monthly")
+	}
+
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	keep, prune := PlanRetention(backups, policy)
+	if keep, prune, err = keepIncrementalParents(dir, keep, prune, passphrase); err != nil {
+		return nil, nil, err
+	}
+	if dryRun {
+		return keep, prune, nil
+	}
+
+	pruned = []BackupFileInfo{}
+	for _, backup := range prune {
+		if err := os.Remove(backup.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return keep, pruned, fmt.Errorf("failed to remove %s: %w", backup.Name, err)
+		}
+		pruned = append(pruned, backup)
+	}
+	return keep, pruned, nil
+}
+
+// keepIncrementalParents moves the backups that an incremental backup in dir names as its parent
+// from prune to keep. Incremental backups are never pruned, so every chain keeps its full backup.
+// A parent that is missing from dir is logged as an orphaned chain. When a backup cannot be read,
+// the prune is refused unless every parent has been found, since that backup may be one of them.
+func keepIncrementalParents(dir string, keep, prune []BackupFileInfo, passphrase string) ([]BackupFileInfo, []BackupFileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return keep, prune, nil
+		}
+		return nil, nil, err
+	}
+
+	// Parent backup id -> name of an incremental backup built on it
+	children := map[string]string{}
+	presentIds := map[string]bool{}
+	for _, entry := range entries {
+		if entry.IsDir() || !incrementalFileNamePattern.MatchString(entry.Name()) {
+			continue
+		}
+		manifest, err := ReadBackupManifest(filepath.Join(dir, entry.Name()), passphrase)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot read incremental backup %s to keep the backups it builds on: %w",
+				entry.Name(), err)
+		}
+		children[manifest.ParentId] = entry.Name()
+		presentIds[manifest.BackupId] = true
+	}
+	if len(children) == 0 {
+		return keep, prune, nil
+	}
+
+	backupIds := map[string]string{}
+	unreadable := []string{}
+	for _, backup := range append(append([]BackupFileInfo{}, keep...), prune...) {
+		manifest, err := ReadBackupManifest(backup.Path, passphrase)
+		if err != nil {
+			unreadable = append(unreadable, backup.Name)
+			continue
+		}
+		backupIds[backup.Path] = manifest.BackupId
+		presentIds[manifest.BackupId] = true
+	}
+	for parentId, child := range children {
+		if presentIds[parentId] {
+			continue
+		}
+		if len(unreadable) > 0 {
+			return nil, nil, fmt.Errorf("cannot find the parent of incremental backup %s, refusing to prune while %s cannot be read",
+				child, strings.Join(unreadable, ", "))
+		}
+		util.Logger.Warnw("incremental backup chain is orphaned, its parent backup is missing",
+			"dir", dir, "incremental", child, "parent_id", parentId)
+	}
+
+	remaining := []BackupFileInfo{}
+	for _, backup := range prune {
+		if backupId := backupIds[backup.Path]; backupId != "" && children[backupId] != "" {
+			backup.KeptBy = []string{"incremental"}
+			keep = append(keep, backup)
+		} else {
+			remaining = append(remaining, backup)
+		}
+	}
+	sort.SliceStable(keep, func(i, j int) bool {
+		return keep[i].CreatedAt.After(keep[j].CreatedAt)
+	})
+	return keep, remaining, nil
+}
@@ -0,0 +1,233 @@
+package manage_service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// newDailyBackups returns one backup per day at 02:30, newest first, ending at 2024-05-31 (a Friday)
+func newDailyBackups(days int) []BackupFileInfo {
+	last := time.Date(2024, 5, 31, 2, 30, 0, 0, util.GetTimezone())
+	backups := make([]BackupFileInfo, 0, days)
+	for i := 0; i < days; i++ {
+		createdAt := last.AddDate(0, 0, -i)
+		backups = append(backups, BackupFileInfo{
+			Name:      BackupFileName("backup", createdAt, BackupOptions{}),
+			CreatedAt: createdAt,
+		})
+	}
+	return backups
+}
+
+func keptNames(backups []BackupFileInfo) map[string][]string {
+	names := map[string][]string{}
+	for _, backup := range backups {
+		names[backup.CreatedAt.Format("2006-01-02")] = backup.KeptBy
+	}
+	return names
+}
+
+func TestPlanRetention_GrandfatherFatherSon(t *testing.T) {
+	backups := newDailyBackups(120)
+	keep, prune := PlanRetention(backups, RetentionPolicy{Daily: 7, Weekly: 4, Monthly: 3})
+
+	if len(keep)+len(prune) != len(backups) {
+		t.Fatalf("keep %d + prune %d != %d backups", len(keep), len(prune), len(backups))
+	}
+
+	kept := keptNames(keep)
+	// Daily: 05-25 .. 05-31
+	for day := 25; day <= 31; day++ {
+		date := time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+		if _, isExist := kept[date]; !isExist {
+			t.Errorf("daily backup %s should be kept", date)
+		}
+	}
+	// Weekly: newest of the last 4 ISO weeks (Sundays 05-26, 05-19, 05-12 and Friday 05-31)
+	for _, date := range []string{"2024-05-31", "2024-05-26", "2024-05-19", "2024-05-12"} {
+		if !containsTier(kept[date], "weekly") {
+			t.Errorf("backup %s should be kept by the weekly tier, got %v", date, kept[date])
+		}
+	}
+	// Monthly: newest of May, April and March
+	for _, date := range []string{"2024-05-31", "2024-04-30", "2024-03-31"} {
+		if !containsTier(kept[date], "monthly") {
+			t.Errorf("backup %s should be kept by the monthly tier, got %v", date, kept[date])
+		}
+	}
+	// 7 daily + 2 extra weekly (05-26 is also a daily) + 2 extra monthly
+	if len(keep) != 11 {
+		t.Errorf("expected 11 backups kept, got %d: %v", len(keep), kept)
+	}
+	if keep[0].Name != backups[0].Name {
+		t.Errorf("kept backups should stay newest first")
+	}
+}
+
+func TestPlanRetention_AlwaysKeepsNewest(t *testing.T) {
+	backups := newDailyBackups(3)
+	keep, prune := PlanRetention(backups, RetentionPolicy{})
+	if len(keep) != 1 || keep[0].Name != backups[0].Name || keep[0].KeptBy[0] != "latest" {
+		t.Errorf("expected only the newest backup kept as latest, got %v", keep)
+	}
+	if len(prune) != 2 {
+		t.Errorf("expected 2 backups pruned, got %d", len(prune))
+	}
+}
+
+func TestPlanRetention_SeveralPerDay(t *testing.T) {
+	day := time.Date(2024, 5, 31, 0, 0, 0, 0, util.GetTimezone())
+	backups := []BackupFileInfo{
+		{Name: "morning", CreatedAt: day.Add(6 * time.Hour)},
+		{Name: "evening", CreatedAt: day.Add(18 * time.Hour)},
+		{Name: "noon", CreatedAt: day.Add(12 * time.Hour)},
+	}
+	keep, prune := PlanRetention(backups, RetentionPolicy{Daily: 7})
+	if len(keep) != 1 || keep[0].Name != "evening" {
+		t.Errorf("expected only the newest backup of the day kept, got %v", keep)
+	}
+	if len(prune) != 2 {
+		t.Errorf("expected 2 backups pruned, got %d", len(prune))
+	}
+}
+
+func TestListAndPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	backups := newDailyBackups(10)
+	for _, backup := range backups {
+		if err := os.WriteFile(filepath.Join(dir, backup.Name), []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Files that are not backups are never listed or pruned
+	for _, name := range []string{"notes.txt", "cashlenx_backup_latest.ndjson", backupStatusFileName} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	encrypted := BackupFileName("dump", backups[0].CreatedAt.Add(-time.Hour), BackupOptions{Compression: CompressionZstd, Passphrase: "secret"})
+	if !strings.HasSuffix(encrypted, ".ndjson.zst.enc") {
+		t.Errorf("unexpected backup file name %s", encrypted)
+	}
+	if err := os.WriteFile(filepath.Join(dir, encrypted), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	listed, err := ListBackups(dir)
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	if len(listed) != 11 {
+		t.Fatalf("expected 11 backups listed, got %d", len(listed))
+	}
+	if listed[0].Name != backups[0].Name || listed[1].Name != encrypted {
+		t.Errorf("backups should be listed newest first, got %s, %s", listed[0].Name, listed[1].Name)
+	}
+
+	if _, _, err := PruneBackups(dir, RetentionPolicy{}, "", false); err == nil {
+		t.Error("pruning with an empty policy should be refused")
+	}
+
+	keep, wouldPrune, err := PruneBackups(dir, RetentionPolicy{Daily: 3}, "", true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if len(keep) != 3 || len(wouldPrune) != 8 {
+		t.Errorf("dry run: expected 3 kept and 8 pruned, got %d and %d", len(keep), len(wouldPrune))
+	}
+	if listed, _ := ListBackups(dir); len(listed) != 11 {
+		t.Errorf("dry run must not delete files, %d left", len(listed))
+	}
+
+	_, pruned, err := PruneBackups(dir, RetentionPolicy{Daily: 3}, "", false)
+	if err != nil {
+		t.Fatalf("PruneBackups failed: %v", err)
+	}
+	if len(pruned) != 8 {
+		t.Errorf("expected 8 backups pruned, got %d", len(pruned))
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 6 {
+		t.Errorf("expected 3 backups and 3 other files left, got %d entries", len(entries))
+	}
+
+	missing, err := ListBackups(filepath.Join(dir, "missing"))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("a missing directory should list no backups, got %v, %v", missing, err)
+	}
+}
+
+func containsTier(tiers []string, tier string) bool {
+	for _, candidate := range tiers {
+		if candidate == tier {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBackupHeader writes a backup file that holds only its manifest
+func writeBackupHeader(t *testing.T, path string, manifest BackupManifest, options BackupOptions) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer, err := newBackupWriter(file, manifest, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.output.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruneBackups_KeepsIncrementalChains(t *testing.T) {
+	dir := t.TempDir()
+	options := BackupOptions{Compression: CompressionGzip, Passphrase: "secret"}
+	backups := newDailyBackups(10)
+	for i := range backups {
+		backups[i].Name = BackupFileName("backup", backups[i].CreatedAt, options)
+		writeBackupHeader(t, filepath.Join(dir, backups[i].Name), newBackupManifest(BackupKindFull), options)
+	}
+
+	// A chain of two incremental backups on top of the full backup of 2024-05-26, outside the daily tier
+	base, err := ReadBackupManifest(filepath.Join(dir, backups[5].Name), options.Passphrase)
+	if err != nil {
+		t.Fatalf("ReadBackupManifest failed: %v", err)
+	}
+	previous := base
+	for i := 4; i >= 3; i-- {
+		manifest := newBackupManifest(BackupKindIncremental)
+		manifest.ParentId = previous.BackupId
+		writeBackupHeader(t, filepath.Join(dir, BackupFileName("incremental", backups[i].CreatedAt, options)), manifest, options)
+		previous = manifest
+	}
+
+	if _, _, err := PruneBackups(dir, RetentionPolicy{Daily: 3}, "wrong", false); err == nil {
+		t.Error("pruning without reading the incremental backups should be refused")
+	}
+
+	keep, pruned, err := PruneBackups(dir, RetentionPolicy{Daily: 3}, options.Passphrase, false)
+	if err != nil {
+		t.Fatalf("PruneBackups failed: %v", err)
+	}
+	if len(keep) != 4 || len(pruned) != 6 {
+		t.Fatalf("expected 4 kept and 6 pruned, got %d and %d", len(keep), len(pruned))
+	}
+	if kept := keptNames(keep); len(kept["2024-05-26"]) != 1 || kept["2024-05-26"][0] != "incremental" {
+		t.Errorf("the base of the incremental chain should be kept for it, got %v", kept)
+	}
+	if _, err := os.Stat(filepath.Join(dir, backups[5].Name)); err != nil {
+		t.Errorf("the base of the incremental chain was deleted: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 6 {
+		t.Errorf("expected 4 backups and 2 incremental backups left, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,204 @@
+package manage_service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/audit_service"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// backupStatusFileName keeps the last scheduled run across server restarts
+const backupStatusFileName = ".cashlenx_backup_status.json"
+
+// BackupRunResult is the outcome of one scheduled backup
+type BackupRunResult struct {
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Success    bool           `json:"success"`
+	File       string         `json:"file,omitempty"`
+	Stats      OperationStats `json:"stats"`
+	Pruned     []string       `json:"pruned,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// BackupScheduleStatus reports the scheduler configuration and its last runs
+type BackupScheduleStatus struct {
+	Enabled     bool             `json:"enabled"`
+	Schedule    string           `json:"schedule,omitempty"`
+	Directory   string           `json:"directory"`
+	Retention   RetentionPolicy  `json:"retention"`
+	Running     bool             `json:"running"`
+	NextRunAt   *time.Time       `json:"next_run_at,omitempty"`
+	LastRun     *BackupRunResult `json:"last_run,omitempty"`
+	LastSuccess *BackupRunResult `json:"last_success,omitempty"`
+	LastFailure *BackupRunResult `json:"last_failure,omitempty"`
+}
+
+var (
+	backupScheduleMutex  sync.Mutex
+	backupScheduleStatus = BackupScheduleStatus{}
+)
+
+// StartBackupScheduler starts taking backups on the BACKUP_SCHEDULE cron expression in the background
+// Returns false when no schedule is configured, and an error when the expression is invalid.
+// Runs never overlap: the next activation is computed only after the previous run finished.
+// The scheduler stops when ctx is done, letting a run in progress finish.
+func StartBackupScheduler(ctx context.Context) (bool, error) {
+	expression := util.GetConfigByKey("backup.schedule")
+	directory := util.GetConfigByKey("backup.dir")
+
+	backupScheduleMutex.Lock()
+	backupScheduleStatus.Directory = directory
+	backupScheduleStatus.Retention = DefaultRetentionPolicy()
+	backupScheduleMutex.Unlock()
+
+	if expression == "" {
+		return false, nil
+	}
+	schedule, err := util.ParseCronSchedule(expression)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return false, err
+	}
+
+	backupScheduleMutex.Lock()
+	backupScheduleStatus.Enabled = true
+	backupScheduleStatus.Schedule = expression
+	loadBackupStatus(directory)
+	backupScheduleMutex.Unlock()
+
+	go func() {
+		for {
+			next := schedule.Next(time.Now().In(util.GetTimezone()))
+			if next.IsZero() {
+				util.Logger.Errorw("backup schedule never matches, scheduler stopped", "schedule", expression)
+				return
+			}
+			backupScheduleMutex.Lock()
+			backupScheduleStatus.NextRunAt = &next
+			backupScheduleMutex.Unlock()
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				util.Logger.Infow("backup scheduler stopped")
+				return
+			case <-timer.C:
+			}
+			RunScheduledBackup(directory, DefaultBackupOptions(), DefaultRetentionPolicy())
+		}
+	}()
+
+	util.Logger.Infow("backup scheduler started", "schedule", expression, "directory", directory)
+	return true, nil
+}
+
+// RunScheduledBackup takes one timestamped backup into directory, prunes it with the policy
+// and records the outcome for GetBackupScheduleStatus
+func RunScheduledBackup(directory string, options BackupOptions, policy RetentionPolicy) BackupRunResult {
+	backupScheduleMutex.Lock()
+	backupScheduleStatus.Running = true
+	backupScheduleMutex.Unlock()
+
+	result := BackupRunResult{StartedAt: time.Now().UTC()}
+	filePath := filepath.Join(directory, BackupFileName("backup", time.Now().In(util.GetTimezone()), options))
+
+	stats, err := CreateBackup(filePath, options)
+	result.Stats = stats
+	if err == nil {
+		result.File = filepath.Base(filePath)
+		if !policy.IsEmpty() {
+			var pruned []BackupFileInfo
+			_, pruned, err = PruneBackups(directory, policy, options.Passphrase, false)
+			for _, backup := range pruned {
+				result.Pruned = append(result.Pruned, backup.Name)
+			}
+			audit_service.Record(model.AuditOperationBackupPrune,
+				model.NewChangeOrigin(model.ChangeSourceScheduler, model.SchedulerActor),
+				policy.AuditParameters(directory), map[string]int{"backups": len(pruned)}, err)
+		}
+	}
+	result.FinishedAt = time.Now().UTC()
+	result.Success = err == nil
+	if err != nil {
+		result.Error = err.Error()
+		util.Logger.Errorw("scheduled backup failed", "file", filePath, "error", err)
+	} else {
+		util.Logger.Infow("scheduled backup created", "file", filePath, "pruned", len(result.Pruned))
+	}
+	audit_service.Record(model.AuditOperationBackup, model.NewChangeOrigin(model.ChangeSourceScheduler, model.SchedulerActor),
+		map[string]string{"output": filePath, "compression": options.Compression, "passphrase": options.Passphrase},
+		stats.AffectedCounts(), err)
+
+	backupScheduleMutex.Lock()
+	defer backupScheduleMutex.Unlock()
+	backupScheduleStatus.Running = false
+	backupScheduleStatus.LastRun = &result
+	if result.Success {
+		backupScheduleStatus.LastSuccess = &result
+	} else {
+		backupScheduleStatus.LastFailure = &result
+	}
+	saveBackupStatus(directory)
+	return result
+}
+
+// GetBackupScheduleStatus returns a snapshot of the scheduler status
+func GetBackupScheduleStatus() BackupScheduleStatus {
+	backupScheduleMutex.Lock()
+	defer backupScheduleMutex.Unlock()
+
+	status := backupScheduleStatus
+	if status.Directory == "" {
+		status.Directory = util.GetConfigByKey("backup.dir")
+		status.Retention = DefaultRetentionPolicy()
+	}
+	return status
+}
+
+// persistedBackupStatus is the part of the status that survives a restart
+type persistedBackupStatus struct {
+	LastRun     *BackupRunResult `json:"last_run,omitempty"`
+	LastSuccess *BackupRunResult `json:"last_success,omitempty"`
+	LastFailure *BackupRunResult `json:"last_failure,omitempty"`
+}
+
+// loadBackupStatus restores the last runs; callers hold backupScheduleMutex
+func loadBackupStatus(directory string) {
+	data, err := os.ReadFile(filepath.Join(directory, backupStatusFileName))
+	if err != nil {
+		return
+	}
+	var persisted persistedBackupStatus
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		util.Logger.Warnw("ignoring unreadable backup status file", "error", err)
+		return
+	}
+	backupScheduleStatus.LastRun = persisted.LastRun
+	backupScheduleStatus.LastSuccess = persisted.LastSuccess
+	backupScheduleStatus.LastFailure = persisted.LastFailure
+}
+
+// saveBackupStatus writes the last runs next to the backups; callers hold backupScheduleMutex
+func saveBackupStatus(directory string) {
+	data, err := json.MarshalIndent(persistedBackupStatus{
+		LastRun:     backupScheduleStatus.LastRun,
+		LastSuccess: backupScheduleStatus.LastSuccess,
+		LastFailure: backupScheduleStatus.LastFailure,
+	}, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(filepath.Join(directory, backupStatusFileName), data, 0o644); err != nil {
+		util.Logger.Warnw("failed to save backup status", "error", err)
+	}
+}
@@ -0,0 +1,419 @@
+package manage_service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Line kinds of a version 3 (NDJSON) backup stream
+const (
+	backupLineHeader    = "header"
+	backupLineCategory  = "category"
+	backupLineCashFlow  = "cash_flow"
+	backupLineGoal      = "goal"
+	backupLineDebt      = "debt"
+	backupLineTombstone = "tombstone"
+	backupLineTrailer   = "trailer"
+)
+
+// backupLine is one line of a version 3 backup: {"kind": "...", "data": {...}}
+type backupLine struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// backupTrailer closes a version 3 backup with the counts and checksums of every section
+type backupTrailer struct {
+	Sections map[string]BackupSection `json:"sections"`
+}
+
+// sectionDigest accumulates the count and checksum of one section while streaming
+type sectionDigest struct {
+	count  int64
+	digest hash.Hash
+}
+
+func newSectionDigest() *sectionDigest {
+	return &sectionDigest{digest: sha256.New()}
+}
+
+// add hashes the compact JSON of a record followed by "\n", as defined in docs/backup-format.md
+func (section *sectionDigest) add(compact []byte) {
+	section.count++
+	section.digest.Write(compact)
+	section.digest.Write([]byte("\n"))
+}
+
+func (section *sectionDigest) result() BackupSection {
+	return BackupSection{Count: section.count, Checksum: hex.EncodeToString(section.digest.Sum(nil))}
+}
+
+// backupWriter writes a version 3 backup one record at a time
+type backupWriter struct {
+	output   io.WriteCloser
+	encoder  *json.Encoder
+	sections map[string]*sectionDigest
+}
+
+func newBackupWriter(w io.Writer, manifest BackupManifest, options BackupOptions) (*backupWriter, error) {
+	output, err := wrapBackupWriter(w, options.Compression, options.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &backupWriter{
+		output:  output,
+		encoder: json.NewEncoder(output),
+		sections: map[string]*sectionDigest{
+			BackupSectionCategories: newSectionDigest(),
+			BackupSectionCashFlows:  newSectionDigest(),
+			BackupSectionGoals:      newSectionDigest(),
+			BackupSectionDebts:      newSectionDigest(),
+			BackupSectionTombstones: newSectionDigest(),
+		},
+	}
+	if err := writer.writeLine(backupLineHeader, manifest); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (writer *backupWriter) writeLine(kind string, data interface{}) error {
+	compact, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case backupLineCategory:
+		writer.sections[BackupSectionCategories].add(compact)
+	case backupLineCashFlow:
+		writer.sections[BackupSectionCashFlows].add(compact)
+	case backupLineGoal:
+		writer.sections[BackupSectionGoals].add(compact)
+	case backupLineDebt:
+		writer.sections[BackupSectionDebts].add(compact)
+	case backupLineTombstone:
+		writer.sections[BackupSectionTombstones].add(compact)
+	}
+	// Encode appends the newline that makes this NDJSON
+	return writer.encoder.Encode(backupLine{Kind: kind, Data: compact})
+}
+
+func (writer *backupWriter) writeCategory(record BackupCategory) error {
+	return writer.writeLine(backupLineCategory, record)
+}
+
+func (writer *backupWriter) writeCashFlow(record BackupCashFlow) error {
+	return writer.writeLine(backupLineCashFlow, record)
+}
+
+func (writer *backupWriter) writeGoal(record BackupGoal) error {
+	return writer.writeLine(backupLineGoal, record)
+}
+
+func (writer *backupWriter) writeDebt(record BackupDebt) error {
+	return writer.writeLine(backupLineDebt, record)
+}
+
+func (writer *backupWriter) writeTombstone(record BackupTombstone) error {
+	return writer.writeLine(backupLineTombstone, record)
+}
+
+// Close writes the trailer and flushes compression and encryption
+func (writer *backupWriter) Close() (map[string]BackupSection, error) {
+	sections := map[string]BackupSection{}
+	for name, section := range writer.sections {
+		sections[name] = section.result()
+	}
+	if err := writer.writeLine(backupLineTrailer, backupTrailer{Sections: sections}); err != nil {
+		return nil, err
+	}
+	return sections, writer.output.Close()
+}
+
+// backupStream reads the records of a backup of any version
+// Versions 1 and 2 are single JSON documents and are loaded (and upgraded) in memory;
+// version 3 is read line by line with constant memory
+type backupStream struct {
+	Manifest BackupManifest
+	input    io.ReadCloser
+	decoder  *json.Decoder
+	legacy   *BackupFile
+}
+
+func openBackupStream(r io.Reader, passphrase string) (*backupStream, error) {
+	input, err := wrapBackupReader(r, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(input)
+	var first json.RawMessage
+	if err := decoder.Decode(&first); err != nil {
+		input.Close()
+		return nil, fmt.Errorf("not a valid backup file: %w", err)
+	}
+
+	var line backupLine
+	if json.Unmarshal(first, &line) == nil && line.Kind == backupLineHeader {
+		stream := &backupStream{input: input, decoder: decoder}
+		if err := json.Unmarshal(line.Data, &stream.Manifest); err != nil {
+			input.Close()
+			return nil, fmt.Errorf("invalid backup header: %w", err)
+		}
+		if stream.Manifest.Format != BackupFormatName {
+			input.Close()
+			return nil, fmt.Errorf("unknown backup format %q", stream.Manifest.Format)
+		}
+		if stream.Manifest.Version > BackupFormatVersion {
+			input.Close()
+			return nil, fmt.Errorf("backup version %d is newer than supported version %d, upgrade cashlenx",
+				stream.Manifest.Version, BackupFormatVersion)
+		}
+		return stream, nil
+	}
+
+	// Not a stream header: a single-document backup from version 1 or 2
+	legacy, err := parseBackup(first)
+	if err != nil {
+		input.Close()
+		return nil, err
+	}
+	return &backupStream{Manifest: legacy.Manifest, input: input, legacy: legacy}, nil
+}
+
+// backupHandlers receive the records of a backup, one handler per record kind
+type backupHandlers struct {
+	onCategory  func(BackupCategory) error
+	onCashFlow  func(BackupCashFlow) error
+	onGoal      func(BackupGoal) error
+	onDebt      func(BackupDebt) error
+	onTombstone func(BackupTombstone) error
+}
+
+// forEach calls the handlers for every record in file order and fills Manifest.Sections from the trailer
+// Categories come first, then cash flows, goals and debts; tombstones only occur in incremental backups, last
+func (stream *backupStream) forEach(handlers backupHandlers) error {
+	if stream.legacy != nil {
+		for _, record := range stream.legacy.Categories {
+			if err := handlers.onCategory(record); err != nil {
+				return err
+			}
+		}
+		for _, record := range stream.legacy.CashFlows {
+			if err := handlers.onCashFlow(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for {
+		var line backupLine
+		if err := stream.decoder.Decode(&line); err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("backup is truncated: trailer missing")
+			}
+			return fmt.Errorf("invalid backup line: %w", err)
+		}
+
+		switch line.Kind {
+		case backupLineCategory:
+			var record BackupCategory
+			if err := json.Unmarshal(line.Data, &record); err != nil {
+				return fmt.Errorf("invalid category record: %w", err)
+			}
+			if err := handlers.onCategory(record); err != nil {
+				return err
+			}
+		case backupLineCashFlow:
+			var record BackupCashFlow
+			if err := json.Unmarshal(line.Data, &record); err != nil {
+				return fmt.Errorf("invalid cash flow record: %w", err)
+			}
+			if err := handlers.onCashFlow(record); err != nil {
+				return err
+			}
+		case backupLineGoal:
+			var record BackupGoal
+			if err := json.Unmarshal(line.Data, &record); err != nil {
+				return fmt.Errorf("invalid goal record: %w", err)
+			}
+			if err := handlers.onGoal(record); err != nil {
+				return err
+			}
+		case backupLineDebt:
+			var record BackupDebt
+			if err := json.Unmarshal(line.Data, &record); err != nil {
+				return fmt.Errorf("invalid debt record: %w", err)
+			}
+			if err := handlers.onDebt(record); err != nil {
+				return err
+			}
+		case backupLineTombstone:
+			var record BackupTombstone
+			if err := json.Unmarshal(line.Data, &record); err != nil {
+				return fmt.Errorf("invalid tombstone record: %w", err)
+			}
+			if err := handlers.onTombstone(record); err != nil {
+				return err
+			}
+		case backupLineTrailer:
+			var trailer backupTrailer
+			if err := json.Unmarshal(line.Data, &trailer); err != nil {
+				return fmt.Errorf("invalid backup trailer: %w", err)
+			}
+			stream.Manifest.Sections = trailer.Sections
+			return nil
+		default:
+			return fmt.Errorf("unknown backup line kind %q", line.Kind)
+		}
+	}
+}
+
+func (stream *backupStream) Close() {
+	stream.input.Close()
+}
+
+// verifyBackupStream reads every record and returns all problems found:
+// section counts and checksums, id syntax, duplicate categories and dangling references.
+// References of an incremental backup may point at rows of its parent, so they are not checked.
+func verifyBackupStream(stream *backupStream) ([]string, error) {
+	problemList := []string{}
+	isIncremental := stream.Manifest.IsIncremental()
+	categoryDigest, cashFlowDigest, goalDigest := newSectionDigest(), newSectionDigest(), newSectionDigest()
+	debtDigest, tombstoneDigest := newSectionDigest(), newSectionDigest()
+	categoryIdMap := make(map[string]bool)
+	parentRefMap := make(map[string]string)
+
+	err := stream.forEach(backupHandlers{
+		onCategory: func(record BackupCategory) error {
+			compact, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			categoryDigest.add(compact)
+			if _, err := record.toEntity(); err != nil {
+				problemList = append(problemList, err.Error())
+			}
+			if categoryIdMap[record.Id] {
+				problemList = append(problemList, "duplicate category id "+record.Id)
+			}
+			categoryIdMap[record.Id] = true
+			if record.ParentId != "" {
+				parentRefMap[record.Id] = record.ParentId
+			}
+			return nil
+		},
+		onCashFlow: func(record BackupCashFlow) error {
+			compact, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			cashFlowDigest.add(compact)
+			if _, err := record.toEntity(); err != nil {
+				problemList = append(problemList, err.Error())
+			}
+			// Categories always precede cash flows, so the id set is complete here
+			if !isIncremental && !categoryIdMap[record.CategoryId] {
+				problemList = append(problemList,
+					fmt.Sprintf("cash flow %s: category %s not in backup", record.Id, record.CategoryId))
+			}
+			return nil
+		},
+		onGoal: func(record BackupGoal) error {
+			compact, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			goalDigest.add(compact)
+			if _, err := record.toEntity(); err != nil {
+				problemList = append(problemList, err.Error())
+			}
+			if !isIncremental && record.CategoryId != "" && !categoryIdMap[record.CategoryId] {
+				problemList = append(problemList,
+					fmt.Sprintf("goal %s: category %s not in backup", record.Id, record.CategoryId))
+			}
+			return nil
+		},
+		onDebt: func(record BackupDebt) error {
+			compact, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			debtDigest.add(compact)
+			if _, err := record.toEntity(); err != nil {
+				problemList = append(problemList, err.Error())
+			}
+			if isIncremental {
+				return nil
+			}
+			for _, categoryId := range []string{record.PaymentCategoryId, record.InterestCategoryId} {
+				if !categoryIdMap[categoryId] {
+					problemList = append(problemList,
+						fmt.Sprintf("debt %s: category %s not in backup", record.Id, categoryId))
+				}
+			}
+			return nil
+		},
+		onTombstone: func(record BackupTombstone) error {
+			compact, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			tombstoneDigest.add(compact)
+			if !isIncremental {
+				problemList = append(problemList, "tombstone "+record.Id+" in a full backup")
+			}
+			if _, err := record.objectId(); err != nil {
+				problemList = append(problemList, err.Error())
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for categoryId, parentId := range parentRefMap {
+		if !isIncremental && !categoryIdMap[parentId] {
+			problemList = append(problemList, fmt.Sprintf("category %s: parent %s not in backup", categoryId, parentId))
+		}
+	}
+
+	actualSections := map[string]BackupSection{
+		BackupSectionCategories: categoryDigest.result(),
+		BackupSectionCashFlows:  cashFlowDigest.result(),
+	}
+	if stream.Manifest.Version >= 4 {
+		actualSections[BackupSectionTombstones] = tombstoneDigest.result()
+	} else if tombstoneDigest.count > 0 {
+		problemList = append(problemList, fmt.Sprintf("tombstones are not allowed in version %d", stream.Manifest.Version))
+	}
+	if stream.Manifest.Version >= 5 {
+		actualSections[BackupSectionGoals] = goalDigest.result()
+		actualSections[BackupSectionDebts] = debtDigest.result()
+	} else if goalDigest.count > 0 || debtDigest.count > 0 {
+		problemList = append(problemList, fmt.Sprintf("goals and debts are not allowed in version %d", stream.Manifest.Version))
+	}
+	for name, actual := range actualSections {
+		expected, isExist := stream.Manifest.Sections[name]
+		if !isExist {
+			problemList = append(problemList, "manifest has no section "+name)
+			continue
+		}
+		if actual.Count != expected.Count {
+			problemList = append(problemList, fmt.Sprintf("%s: manifest count %d, found %d",
+				name, expected.Count, actual.Count))
+		}
+		if actual.Checksum != expected.Checksum {
+			problemList = append(problemList, name+": checksum mismatch")
+		}
+	}
+	return problemList, nil
+}
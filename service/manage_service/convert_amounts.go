@@ -0,0 +1,106 @@
+package manage_service
+
+import (
+	"context"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/macar-x/cashlenx-server/util/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConvertAmountsToDecimal converts stored cash flow amounts to the exact decimal representation
+// MongoDB: rewrites every non-Decimal128 amount as Decimal128
+// MySQL: widens the AMOUNT column to DECIMAL(19,4) so cents are no longer truncated
+func ConvertAmountsToDecimal() (OperationStats, error) {
+	stats := OperationStats{
+		CashFlows:  EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+		Categories: EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+	}
+
+	dbType := util.GetConfigByKey("db.type")
+	switch dbType {
+	case "mongodb":
+		return convertMongoDbAmounts(stats)
+	case "mysql":
+		return convertMySqlAmounts(stats)
+	default:
+		util.Logger.Errorw("unsupported database type", "type", dbType)
+		return stats, nil
+	}
+}
+
+func convertMongoDbAmounts(stats OperationStats) (OperationStats, error) {
+	collection := database.GetMongoCollection(database.CashFlowTableName)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	// Only touch documents that are not already stored as Decimal128
+	filter := bson.D{
+		primitive.E{Key: "amount", Value: bson.M{"$not": bson.M{"$type": "decimal"}}},
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		util.Logger.Errorw("query legacy amounts failed", "error", err)
+		return stats, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var document struct {
+			Id     primitive.ObjectID `bson:"_id"`
+			Amount model.Money        `bson:"amount"`
+		}
+		if err := cursor.Decode(&document); err != nil {
+			util.Logger.Errorw("decode legacy amount failed", "error", err)
+			stats.CashFlows.Failed++
+			continue
+		}
+
+		// Legacy values were rounded to cents on input, keep the storage scale here
+		amount := document.Amount.Round(model.MoneyStorageScale)
+		update := bson.D{
+			primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "amount", Value: amount}}},
+		}
+		if _, err := collection.UpdateByID(ctx, document.Id, update); err != nil {
+			util.Logger.Errorw("convert amount failed", "id", document.Id.Hex(), "error", err)
+			stats.CashFlows.Failed++
+			stats.CashFlows.FailedList = append(stats.CashFlows.FailedList, document.Id.Hex())
+			continue
+		}
+		stats.CashFlows.Success++
+	}
+
+	if err := cursor.Err(); err != nil {
+		return stats, err
+	}
+
+	util.Logger.Infow("cash flow amounts converted to Decimal128",
+		"success", stats.CashFlows.Success, "failed", stats.CashFlows.Failed)
+	return stats, nil
+}
+
+func convertMySqlAmounts(stats OperationStats) (OperationStats, error) {
+	connection := database.GetMySqlConnection()
+	defer database.CloseMySqlConnection()
+
+	_, err := connection.Exec("ALTER TABLE " + database.CashFlowTableName +
+		" MODIFY COLUMN AMOUNT DECIMAL(19, 4) NOT NULL")
+	if err != nil {
+		util.Logger.Errorw("alter amount column failed", "error", err)
+		return stats, err
+	}
+
+	var count int64
+	if err := connection.QueryRow("SELECT COUNT(1) FROM " + database.CashFlowTableName).Scan(&count); err != nil {
+		util.Logger.Errorw("count cash flows failed", "error", err)
+		return stats, err
+	}
+	stats.CashFlows.Success = int(count)
+
+	util.Logger.Infow("cash flow amount column converted to DECIMAL(19,4)", "rows", count)
+	return stats, nil
+}
@@ -133,7 +133,7 @@ func exportData(file *excelize.File, fromDateInString, toDateInString string) {
 					category_mapper.INSTANCE.GetCategoryByObjectId(cashFlow.CategoryId.Hex()).Name)
 				writeExcelRow(file, yearMonth, "D"+cashFlowIndexInString, dateStr)
 				writeExcelRow(file, yearMonth, "E"+cashFlowIndexInString, cashFlow.FlowType)
-				writeExcelRow(file, yearMonth, "F"+cashFlowIndexInString, cashFlow.Amount)
+				writeExcelRow(file, yearMonth, "F"+cashFlowIndexInString, cashFlow.Amount.Float64())
 				writeExcelRow(file, yearMonth, "G"+cashFlowIndexInString, cashFlow.Description)
 			}
 			util.Logger.Debugf("Exported %d records for %s", len(cashFlows), yearMonth)
@@ -179,7 +179,7 @@ func exportData(file *excelize.File, fromDateInString, toDateInString string) {
 					category_mapper.INSTANCE.GetCategoryByObjectId(cashFlow.CategoryId.Hex()).Name)
 				writeExcelRow(file, currentYearAndMonth, "D"+cashFlowIndexInString, queryDateCurrentInString)
 				writeExcelRow(file, currentYearAndMonth, "E"+cashFlowIndexInString, cashFlow.FlowType)
-				writeExcelRow(file, currentYearAndMonth, "F"+cashFlowIndexInString, cashFlow.Amount)
+				writeExcelRow(file, currentYearAndMonth, "F"+cashFlowIndexInString, cashFlow.Amount.Float64())
 				writeExcelRow(file, currentYearAndMonth, "G"+cashFlowIndexInString, cashFlow.Description)
 			}
 
@@ -9,6 +9,9 @@ import (
 	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
 	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/service/debt_service"
+	"github.com/macar-x/cashlenx-server/service/insight_service"
+	"github.com/macar-x/cashlenx-server/service/revision_service"
 	"github.com/macar-x/cashlenx-server/util"
 	"github.com/xuri/excelize/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -22,11 +25,36 @@ var (
 	importSucceedRowNumberList []int
 )
 
-func ImportService(filePath string) error {
+// importProgress holds the cash flows one import saved, and their first and last date for the anomaly detection
+type importProgress struct {
+	firstDate time.Time
+	lastDate  time.Time
+	savedList []model.CashFlowEntity
+}
+
+func (progress *importProgress) add(cashFlowEntity model.CashFlowEntity) {
+	date := cashFlowEntity.BelongsDate
+	if progress.firstDate.IsZero() || date.Before(progress.firstDate) {
+		progress.firstDate = date
+	}
+	if date.After(progress.lastDate) {
+		progress.lastDate = date
+	}
+	progress.savedList = append(progress.savedList, cashFlowEntity)
+}
+
+// ImportService imports cash flows from an Excel file; actor is recorded in their history
+// The statistics count imported and failed rows over all sheets; rows that already exist are ignored.
+// With insights.after.import they also hold the anomalies found from the first to the last imported date.
+func ImportService(filePath, actor string) (OperationStats, error) {
+	origin := model.NewChangeOrigin(model.ChangeSourceImport, actor)
+	stats := newOperationStats()
+	progress := &importProgress{}
+
 	// Open and read the target file
 	file := readExcelFile(filePath)
 	if file == nil {
-		return errors.New("can not read data from file")
+		return stats, errors.New("can not read data from file")
 	}
 	// Remember to close the file after execution
 	defer func() {
@@ -53,9 +81,9 @@ func ImportService(filePath string) error {
 			util.Logger.Errorw("read sheet rows failed", "error", err)
 		}
 		util.Logger.Infof("processing sheet %s", currentSheetName)
-		cashFlowMapByDate := readSheetData(rows)
+		cashFlowMapByDate := readSheetData(rows, origin)
 		for date, cashFlowMapByColumnList := range cashFlowMapByDate {
-			saveIntoDB(cashFlowMapByColumnList)
+			saveIntoDB(cashFlowMapByColumnList, origin, progress)
 			util.Logger.Debugf("%s of %s's flows imported", util.FormatDateToStringWithoutDash(date), currentSheetName)
 		}
 		util.Logger.Infow("sheet has been imported",
@@ -63,8 +91,23 @@ func ImportService(filePath string) error {
 			"succeed_row", importSucceedRowNumberList,
 			"ignored_row", importIgnoredRowNumberList,
 			"failed_row", importFailedRowNumberList)
+		stats.CashFlows.Success += len(importSucceedRowNumberList)
+		stats.CashFlows.Failed += len(importFailedRowNumberList)
 	}
-	return nil
+
+	// Debt payments are split once every row is in: the interest split off an exported payment
+	// comes back with it and marks it as split already
+	debt_service.SplitPayments(progress.savedList, origin)
+
+	if util.GetConfigByKey("insights.after.import") == "true" && stats.CashFlows.Success > 0 {
+		report := insight_service.DetectAnomaliesBetween(progress.firstDate, progress.lastDate.AddDate(0, 0, 1))
+		stats.Anomalies = report.Items
+		for _, anomaly := range report.Items {
+			util.Logger.Warnw("anomaly in imported cash flows",
+				"kind", anomaly.Kind, "reason", anomaly.Reason, "score", anomaly.Score)
+		}
+	}
+	return stats, nil
 }
 
 func readExcelFile(fileName string) *excelize.File {
@@ -78,7 +121,7 @@ func readExcelFile(fileName string) *excelize.File {
 /**
  * Read worksheet data and organize cashFlows with date as key
  */
-func readSheetData(sheetRowCursor *excelize.Rows) map[time.Time][]map[string]string {
+func readSheetData(sheetRowCursor *excelize.Rows, origin model.ChangeOrigin) map[time.Time][]map[string]string {
 	cashFlowMapByDate := make(map[time.Time][]map[string]string)
 
 	// First row is title row, verify if the format is correct
@@ -110,7 +153,7 @@ func readSheetData(sheetRowCursor *excelize.Rows) map[time.Time][]map[string]str
 		cashFlowMapByColumn[sheetRowNumberLabel] = strconv.Itoa(currentRowNumber)
 		// check category info and get the correct id
 		newCategoryId := handleCategoryInfo(
-			cashFlowMapByColumn["CategoryId"], cashFlowMapByColumn["CategoryName"])
+			cashFlowMapByColumn["CategoryId"], cashFlowMapByColumn["CategoryName"], origin)
 		if newCategoryId == "" {
 			fmt.Println("failed: row " + strconv.Itoa(currentRowNumber) + ": category not satisfied")
 			importFailedRowNumberList = append(importFailedRowNumberList, currentRowNumber)
@@ -157,7 +200,7 @@ func isRequiredFieldSatisfied(currentRowNumber int, columnCellMap map[string]str
 	return true
 }
 
-func handleCategoryInfo(categoryId, categoryName string) string {
+func handleCategoryInfo(categoryId, categoryName string, origin model.ChangeOrigin) string {
 	// use category id to fetch first
 	if categoryId != "" {
 		categoryEntity := category_mapper.INSTANCE.GetCategoryByObjectId(categoryId)
@@ -184,10 +227,15 @@ func handleCategoryInfo(categoryId, categoryName string) string {
 		Name:   categoryName,
 		Remark: "create by import",
 	})
+	if plainId != "" {
+		newCategoryEntity := category_mapper.INSTANCE.GetCategoryByObjectId(plainId)
+		revision_service.RecordChange(model.RevisionTypeCategory, newCategoryEntity.Id, model.RevisionActionCreate,
+			nil, revision_service.CategoryFields(newCategoryEntity), origin)
+	}
 	return plainId
 }
 
-func saveIntoDB(cashFlowMapByColumnList []map[string]string) {
+func saveIntoDB(cashFlowMapByColumnList []map[string]string, origin model.ChangeOrigin, progress *importProgress) {
 	for _, cashFlowMapByColumn := range cashFlowMapByColumnList {
 		cashFlowEntity := model.CashFlowEntity{}.Build(cashFlowMapByColumn)
 		if cashFlowEntity.Id != primitive.NilObjectID {
@@ -204,7 +252,12 @@ func saveIntoDB(cashFlowMapByColumnList []map[string]string) {
 		}
 		newPlainId := cash_flow_mapper.INSTANCE.InsertCashFlowByEntity(cashFlowEntity)
 		cashFlowEntity.Id = util.Convert2ObjectId(newPlainId)
+		if newPlainId != "" {
+			revision_service.RecordChange(model.RevisionTypeCashFlow, cashFlowEntity.Id, model.RevisionActionCreate,
+				nil, revision_service.CashFlowFields(cashFlowEntity), origin)
+		}
 		util.Logger.Debug("cash_flow inserted: " + cashFlowEntity.ToString())
+		progress.add(cashFlowEntity)
 		fmt.Println("succeed: row " + cashFlowMapByColumn[sheetRowNumberLabel] + ": cash_flow saved")
 		importSucceedRowNumberList = append(importSucceedRowNumberList,
 			util.ToInteger(cashFlowMapByColumn[sheetRowNumberLabel]))
@@ -83,6 +83,19 @@ func createMongoDBIndexes() error {
 	}
 	util.Logger.Info("✓ Created index: idx_category_id")
 
+	// Full-text index on description and remark, stemmed in English, descriptions weighing more
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "description", Value: "text"}, {Key: "remark", Value: "text"}},
+		Options: options.Index().SetName("idx_cash_flow_text").
+			SetWeights(bson.D{{Key: "description", Value: 3}, {Key: "remark", Value: 1}}).
+			SetDefaultLanguage("english"),
+	})
+	if err != nil {
+		util.Logger.Errorw("failed to create text index", "error", err)
+		return err
+	}
+	util.Logger.Info("✓ Created text index: idx_cash_flow_text")
+
 	// Category collection - unique index on name
 	database.CloseMongoDbConnection()
 	database.OpenMongoDbConnection(database.CategoryTableName)
@@ -110,7 +123,7 @@ func createMySQLIndexes() error {
 	defer database.CloseMySqlConnection()
 
 	// Index on belongs_date
-	_, err := connection.Exec("CREATE INDEX IF NOT EXISTS idx_belongs_date ON cash_flow(BELONGS_DATE)")
+	_, err := connection.Exec("CREATE INDEX IF NOT EXISTS idx_belongs_date ON " + database.CashFlowTableName + "(BELONGS_DATE)")
 	if err != nil {
 		util.Logger.Errorw("failed to create belongs_date index", "error", err)
 		return err
@@ -118,7 +131,7 @@ func createMySQLIndexes() error {
 	util.Logger.Info("✓ Created index: idx_belongs_date")
 
 	// Index on flow_type
-	_, err = connection.Exec("CREATE INDEX IF NOT EXISTS idx_flow_type ON cash_flow(FLOW_TYPE)")
+	_, err = connection.Exec("CREATE INDEX IF NOT EXISTS idx_flow_type ON " + database.CashFlowTableName + "(FLOW_TYPE)")
 	if err != nil {
 		util.Logger.Errorw("failed to create flow_type index", "error", err)
 		return err
@@ -126,7 +139,7 @@ func createMySQLIndexes() error {
 	util.Logger.Info("✓ Created index: idx_flow_type")
 
 	// Compound index on belongs_date and flow_type
-	_, err = connection.Exec("CREATE INDEX IF NOT EXISTS idx_belongs_date_flow_type ON cash_flow(BELONGS_DATE, FLOW_TYPE)")
+	_, err = connection.Exec("CREATE INDEX IF NOT EXISTS idx_belongs_date_flow_type ON " + database.CashFlowTableName + "(BELONGS_DATE, FLOW_TYPE)")
 	if err != nil {
 		util.Logger.Errorw("failed to create compound index", "error", err)
 		return err
@@ -134,7 +147,7 @@ func createMySQLIndexes() error {
 	util.Logger.Info("✓ Created index: idx_belongs_date_flow_type")
 
 	// Index on category_id
-	_, err = connection.Exec("CREATE INDEX IF NOT EXISTS idx_category_id ON cash_flow(CATEGORY_ID)")
+	_, err = connection.Exec("CREATE INDEX IF NOT EXISTS idx_category_id ON " + database.CashFlowTableName + "(CATEGORY_ID)")
 	if err != nil {
 		util.Logger.Errorw("failed to create category_id index", "error", err)
 		return err
@@ -142,13 +155,27 @@ func createMySQLIndexes() error {
 	util.Logger.Info("✓ Created index: idx_category_id")
 
 	// Unique index on category name
-	_, err = connection.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_category_name_unique ON category(NAME)")
+	_, err = connection.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_category_name_unique ON " + database.CategoryTableName + "(NAME)")
 	if err != nil {
 		util.Logger.Errorw("failed to create category name index", "error", err)
 		return err
 	}
 	util.Logger.Info("✓ Created unique index: idx_category_name_unique")
 
+	// Full-text index on description and remark; ADD FULLTEXT has no IF NOT EXISTS, so look it up first
+	var fullTextCount int
+	err = connection.QueryRow("SELECT COUNT(1) FROM INFORMATION_SCHEMA.STATISTICS "+
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?",
+		database.CashFlowTableName, "ft_cash_flows_text").Scan(&fullTextCount)
+	if err == nil && fullTextCount == 0 {
+		_, err = connection.Exec("ALTER TABLE " + database.CashFlowTableName + " ADD FULLTEXT INDEX ft_cash_flows_text (DESCRIPTION, REMARK)")
+	}
+	if err != nil {
+		util.Logger.Errorw("failed to create full-text index", "error", err)
+		return err
+	}
+	util.Logger.Info("✓ Created full-text index: ft_cash_flows_text")
+
 	util.Logger.Info("All indexes created successfully")
 	return nil
 }
@@ -10,7 +10,7 @@ import (
 )
 
 // InitializeDemoData initializes the database with demo categories and transactions
-func InitializeDemoData() error {
+func InitializeDemoData(origin model.ChangeOrigin) error {
 	// Create default categories with their types
 	categories := []struct {
 		name  string
@@ -28,7 +28,7 @@ func InitializeDemoData() error {
 
 	for _, cat := range categories {
 		// Check if category already exists
-		_, err := category_service.CreateService("", cat.name, cat.type_)
+		_, err := category_service.CreateService("", cat.name, cat.type_, origin)
 		if err != nil {
 			util.Logger.Warnw("category creation skipped", "category", cat.name, "error", err)
 		}
@@ -41,8 +41,9 @@ func InitializeDemoData() error {
 	_, _ = cash_flow_service.SaveIncome(
 		today.AddDate(0, 0, -7).Format(model.DateFormatYYYYMMDD),
 		"Salary",
-		5000.00,
+		model.NewMoneyFromFloat(5000.00),
 		"Monthly salary",
+		origin,
 	)
 
 	// Sample expenses
@@ -63,7 +64,7 @@ func InitializeDemoData() error {
 
 	for _, exp := range expenses {
 		date := today.AddDate(0, 0, -exp.daysAgo).Format(model.DateFormatYYYYMMDD)
-		_, _ = cash_flow_service.SaveExpense(date, exp.category, exp.amount, exp.description)
+		_, _ = cash_flow_service.SaveExpense(date, exp.category, model.NewMoneyFromFloat(exp.amount), exp.description, origin)
 	}
 
 	return nil
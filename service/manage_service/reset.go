@@ -3,6 +3,11 @@ package manage_service
 import (
 	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/debt_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/goal_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/revision_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/tombstone_mapper"
+	"github.com/macar-x/cashlenx-server/util"
 )
 
 // ResetDatabase clears all data from the database
@@ -10,11 +15,23 @@ func ResetDatabase() (OperationStats, error) {
 	stats := OperationStats{
 		CashFlows:  EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
 		Categories: EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+		Goals:      EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+		Debts:      EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
 	}
 
 	// Count items before truncation to provide accurate statistics
 	stats.CashFlows.Success = int(cash_flow_mapper.INSTANCE.CountAllCashFlows())
 	stats.Categories.Success = int(category_mapper.INSTANCE.CountAllCategories())
+	goals, err := goal_mapper.INSTANCE.GetAllGoals()
+	if err != nil {
+		return stats, err
+	}
+	stats.Goals.Success = len(goals)
+	debts, err := debt_mapper.INSTANCE.GetAllDebts()
+	if err != nil {
+		return stats, err
+	}
+	stats.Debts.Success = len(debts)
 
 	// This is a dangerous operation - truncate all data
 	// First truncate cash flows (dependent data)
@@ -25,6 +42,18 @@ func ResetDatabase() (OperationStats, error) {
 		return stats, err
 	}
 
+	// Goals and debts point at categories, so they go before categories
+	if err := goal_mapper.INSTANCE.TruncateGoals(); err != nil {
+		stats.Goals.Failed = stats.Goals.Success
+		stats.Goals.Success = 0
+		return stats, err
+	}
+	if err := debt_mapper.INSTANCE.TruncateDebts(); err != nil {
+		stats.Debts.Failed = stats.Debts.Success
+		stats.Debts.Success = 0
+		return stats, err
+	}
+
 	// Then truncate categories (parent data)
 	if err := category_mapper.INSTANCE.TruncateCategories(); err != nil {
 		// If truncation fails, set success to 0 and failed to the counted items
@@ -33,6 +62,17 @@ func ResetDatabase() (OperationStats, error) {
 		return stats, err
 	}
 
+	// Tombstones describe deletions relative to the data just cleared; an incremental chain
+	// cannot continue across a reset, so the next backup must be a full one
+	if err := tombstone_mapper.INSTANCE.TruncateTombstones(); err != nil {
+		util.Logger.Warnw("truncate tombstones failed", "error", err)
+	}
+
+	// The change history describes rows that no longer exist
+	if err := revision_mapper.INSTANCE.TruncateRevisions(); err != nil {
+		util.Logger.Warnw("truncate revisions failed", "error", err)
+	}
+
 	return stats, nil
 }
 
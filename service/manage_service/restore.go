@@ -1,105 +1,776 @@
 package manage_service
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
 	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/debt_mapper"
+	"github.com/macar-x/cashlenx-server/mapper/goal_mapper"
 	"github.com/macar-x/cashlenx-server/model"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// RestoreBackup restores database from a backup file
-func RestoreBackup(filePath string) (OperationStats, error) {
-	stats := OperationStats{
-		CashFlows:  EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
-		Categories: EntityStats{Success: 0, Failed: 0, FailedList: []string{}},
+// restoreBatchSize is the number of rows written per bulk upsert during restore
+const restoreBatchSize = 500
+
+// Restore modes decide what happens to rows already in the database
+const (
+	RestoreModeReplace        = "replace"          // clear the database, then load the backup
+	RestoreModeMerge          = "merge"            // insert new rows; on an id clash the newer modify_time wins
+	RestoreModeSkipExisting   = "skip-existing"    // insert new rows, never touch existing ones
+	RestoreModeFailOnConflict = "fail-on-conflict" // insert new rows, refuse the restore if an existing row differs
+)
+
+// RestoreOptions controls how a backup is restored
+type RestoreOptions struct {
+	Passphrase string // decrypts encrypted backups
+	Mode       string // one of the RestoreMode constants, replace when empty
+	DryRun     bool   // report what would change without writing anything
+}
+
+// RestoreEntityStats counts what a restore did, or would do, to one entity type
+// Success and Failed count backup records; the other fields describe their effect on the database
+type RestoreEntityStats struct {
+	EntityStats
+	Inserted     int      `json:"inserted"`
+	Updated      int      `json:"updated"`
+	Unchanged    int      `json:"unchanged"`
+	Skipped      int      `json:"skipped"` // existing rows kept: newer locally, or the mode never touches them
+	Deleted      int      `json:"deleted"` // rows removed by tombstones
+	Cleared      int      `json:"cleared"` // rows removed by replace before loading
+	Conflicts    int      `json:"conflicts"`
+	ConflictList []string `json:"conflict_list,omitempty"`
+}
+
+// RestoreResult is the outcome of a restore or a dry run
+type RestoreResult struct {
+	Mode       string             `json:"mode"`
+	DryRun     bool               `json:"dry_run"`
+	Categories RestoreEntityStats `json:"categories"`
+	CashFlows  RestoreEntityStats `json:"cash_flows"`
+	Goals      RestoreEntityStats `json:"goals"`
+	Debts      RestoreEntityStats `json:"debts"`
+	Tombstones EntityStats        `json:"tombstones"`
+}
+
+// AffectedCounts flattens the result for the audit log, leaving out zero counts
+func (result RestoreResult) AffectedCounts() map[string]int {
+	counts := map[string]int{}
+	for _, entity := range []struct {
+		name  string
+		stats RestoreEntityStats
+	}{
+		{"categories", result.Categories},
+		{"cash_flows", result.CashFlows},
+		{"goals", result.Goals},
+		{"debts", result.Debts},
+	} {
+		addAffectedCount(counts, entity.name+"_inserted", entity.stats.Inserted)
+		addAffectedCount(counts, entity.name+"_updated", entity.stats.Updated)
+		addAffectedCount(counts, entity.name+"_skipped", entity.stats.Skipped)
+		addAffectedCount(counts, entity.name+"_deleted", entity.stats.Deleted)
+		addAffectedCount(counts, entity.name+"_cleared", entity.stats.Cleared)
+		addAffectedCount(counts, entity.name+"_conflicts", entity.stats.Conflicts)
+		addAffectedCount(counts, entity.name+"_failed", entity.stats.Failed)
 	}
+	return counts
+}
 
-	if filePath == "" {
-		return stats, errors.New("file path cannot be empty")
+// ValidateRestoreMode checks a mode given by a user; empty means replace
+func ValidateRestoreMode(mode string) error {
+	switch mode {
+	case "", RestoreModeReplace, RestoreModeMerge, RestoreModeSkipExisting, RestoreModeFailOnConflict:
+		return nil
 	}
+	return fmt.Errorf("unknown restore mode %q, use replace, merge, skip-existing or fail-on-conflict", mode)
+}
 
-	// Read backup file
-	file, err := os.Open(filePath)
+func newRestoreResult(options RestoreOptions) RestoreResult {
+	return RestoreResult{
+		Mode:       options.Mode,
+		DryRun:     options.DryRun,
+		Categories: RestoreEntityStats{EntityStats: EntityStats{FailedList: []string{}}},
+		CashFlows:  RestoreEntityStats{EntityStats: EntityStats{FailedList: []string{}}},
+		Goals:      RestoreEntityStats{EntityStats: EntityStats{FailedList: []string{}}},
+		Debts:      RestoreEntityStats{EntityStats: EntityStats{FailedList: []string{}}},
+		Tombstones: EntityStats{FailedList: []string{}},
+	}
+}
+
+// RestoreBackup restores database from a single full backup file
+func RestoreBackup(filePath string, options RestoreOptions) (RestoreResult, error) {
+	return RestoreBackupChain([]string{filePath}, options)
+}
+
+// RestoreBackupChain restores a full backup followed by its incremental backups, oldest first
+// Every file is verified, and the chain checked link by link, before anything is written.
+// Ids, parent links and timestamps are kept as stored; older backup versions are upgraded first
+func RestoreBackupChain(filePathList []string, options RestoreOptions) (RestoreResult, error) {
+	if options.Mode == "" {
+		options.Mode = RestoreModeReplace
+	}
+	result := newRestoreResult(options)
+	if err := ValidateRestoreMode(options.Mode); err != nil {
+		return result, err
+	}
+	if len(filePathList) == 0 {
+		return result, errors.New("no backup file given")
+	}
+
+	// Pass 1: refuse a damaged backup or a broken chain before anything is written
+	previousId := ""
+	for i, filePath := range filePathList {
+		report, err := VerifyBackup(filePath, options.Passphrase)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", filePath, err)
+		}
+		if !report.Valid() {
+			return result, fmt.Errorf("%s: backup verification failed: %s", filePath, strings.Join(report.Errors, "; "))
+		}
+		switch {
+		case i == 0 && report.Kind == BackupKindIncremental:
+			return result, fmt.Errorf("%s is an incremental backup, the chain must start with a full backup", filePath)
+		case i > 0 && report.Kind != BackupKindIncremental:
+			return result, fmt.Errorf("%s is a full backup, only incremental backups may follow the first file", filePath)
+		case i > 0 && report.ParentId != previousId:
+			return result, fmt.Errorf("%s does not follow %s: parent %s, expected %s",
+				filePath, filePathList[i-1], report.ParentId, previousId)
+		}
+		previousId = report.BackupId
+
+		result.Categories.Failed += int(report.Sections[BackupSectionCategories].Count)
+		result.CashFlows.Failed += int(report.Sections[BackupSectionCashFlows].Count)
+		result.Goals.Failed += int(report.Sections[BackupSectionGoals].Count)
+		result.Debts.Failed += int(report.Sections[BackupSectionDebts].Count)
+		result.Tombstones.Failed += int(report.Sections[BackupSectionTombstones].Count)
+	}
+
+	// fail-on-conflict plans the whole restore first, so a conflict leaves the database untouched
+	if options.Mode == RestoreModeFailOnConflict && !options.DryRun {
+		planOptions := options
+		planOptions.DryRun = true
+		plan, err := RestoreBackupChain(filePathList, planOptions)
+		if err != nil {
+			return plan, err
+		}
+		conflicts := plan.Categories.Conflicts + plan.CashFlows.Conflicts + plan.Goals.Conflicts + plan.Debts.Conflicts
+		if conflicts > 0 {
+			return plan, fmt.Errorf("%d rows conflict with the database, nothing was restored", conflicts)
+		}
+	}
+
+	if options.Mode == RestoreModeReplace {
+		result.Categories.Cleared = int(category_mapper.INSTANCE.CountAllCategories())
+		result.CashFlows.Cleared = int(cash_flow_mapper.INSTANCE.CountAllCashFlows())
+		goalList, err := goal_mapper.INSTANCE.GetAllGoals()
+		if err != nil {
+			return result, err
+		}
+		result.Goals.Cleared = len(goalList)
+		debtList, err := debt_mapper.INSTANCE.GetAllDebts()
+		if err != nil {
+			return result, err
+		}
+		result.Debts.Cleared = len(debtList)
+		if !options.DryRun {
+			if _, err := ResetDatabase(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	// Pass 2: replay the full backup, then each incremental on top of it
+	restorer := newBackupRestorer(options, &result)
+	for _, filePath := range filePathList {
+		if err := restorer.applyBackupFile(filePath); err != nil {
+			return result, fmt.Errorf("%s: %w", filePath, err)
+		}
+	}
+	return result, nil
+}
+
+// restoreAction is what happens to one backup record or tombstone
+type restoreAction int
+
+const (
+	restoreInsert restoreAction = iota
+	restoreUpdate
+	restoreUnchanged
+	restoreSkip
+	restoreDelete
+	restoreConflict
+)
+
+// restoreRow is what a restore compares: the modify time and a digest of the whole row
+// A trashed row still exists; moving it to the trash counts as its latest modification
+type restoreRow struct {
+	modifyTime time.Time
+	digest     [sha256.Size]byte
+	deleted    bool
+	trashed    bool
+}
+
+// newRestoreRow digests a backup record with its times truncated to seconds,
+// so rows that went through MySQL compare equal to their original
+func newRestoreRow(record interface{}, modifyTime time.Time) restoreRow {
+	data, _ := json.Marshal(record)
+	return restoreRow{modifyTime: modifyTime.Truncate(time.Second), digest: sha256.Sum256(data)}
+}
+
+func newCategoryRestoreRow(record BackupCategory) restoreRow {
+	record.CreateTime = record.CreateTime.Truncate(time.Second)
+	record.ModifyTime = record.ModifyTime.Truncate(time.Second)
+	return newRestoreRow(record, record.ModifyTime)
+}
+
+// newExistingCashFlowRestoreRow is the row a restore meets for a cash flow in the database, trashed or not
+func newExistingCashFlowRestoreRow(entity model.CashFlowEntity) restoreRow {
+	row := newCashFlowRestoreRow(newBackupCashFlow(entity))
+	if entity.IsDeleted() {
+		row.trashed = true
+		if deletedAt := entity.DeletedAt.Truncate(time.Second); deletedAt.After(row.modifyTime) {
+			row.modifyTime = deletedAt
+		}
+	}
+	return row
+}
+
+func newCashFlowRestoreRow(record BackupCashFlow) restoreRow {
+	record.BelongsDate = record.BelongsDate.Truncate(time.Second)
+	record.CreateTime = record.CreateTime.Truncate(time.Second)
+	record.ModifyTime = record.ModifyTime.Truncate(time.Second)
+	return newRestoreRow(record, record.ModifyTime)
+}
+
+func newGoalRestoreRow(record BackupGoal) restoreRow {
+	record.StartDate = record.StartDate.Truncate(time.Second)
+	record.Deadline = record.Deadline.Truncate(time.Second)
+	record.CreateTime = record.CreateTime.Truncate(time.Second)
+	record.ModifyTime = record.ModifyTime.Truncate(time.Second)
+	return newRestoreRow(record, record.ModifyTime)
+}
+
+func newDebtRestoreRow(record BackupDebt) restoreRow {
+	record.StartDate = record.StartDate.Truncate(time.Second)
+	record.CreateTime = record.CreateTime.Truncate(time.Second)
+	record.ModifyTime = record.ModifyTime.Truncate(time.Second)
+	return newRestoreRow(record, record.ModifyTime)
+}
+
+// decideRestoreAction resolves a backup record against the row with the same id, if any
+// In merge mode the backup wins only when its modify_time is strictly newer
+func decideRestoreAction(mode string, incoming restoreRow, existing *restoreRow) restoreAction {
+	switch {
+	case existing == nil || existing.deleted:
+		return restoreInsert
+	case existing.digest == incoming.digest:
+		return restoreUnchanged
+	case mode == RestoreModeReplace:
+		return restoreUpdate
+	case mode == RestoreModeMerge && incoming.modifyTime.After(existing.modifyTime):
+		return restoreUpdate
+	case mode == RestoreModeFailOnConflict:
+		return restoreConflict
+	default:
+		return restoreSkip
+	}
+}
+
+// decideTombstoneAction resolves a backup deletion against the row with the same id, if any
+// A row modified after the deletion was brought back, e.g. restored from the trash, and is kept;
+// a row already in the trash is left there
+func decideTombstoneAction(mode string, deleteTime time.Time, existing *restoreRow) restoreAction {
+	switch {
+	case existing == nil || existing.deleted || existing.trashed:
+		return restoreUnchanged
+	case mode == RestoreModeReplace || mode == RestoreModeMerge:
+		if existing.modifyTime.After(deleteTime.Truncate(time.Second)) {
+			return restoreSkip
+		}
+		return restoreDelete
+	case mode == RestoreModeFailOnConflict:
+		return restoreConflict
+	default:
+		return restoreSkip
+	}
+}
+
+func (stats *RestoreEntityStats) count(action restoreAction, plainId string) {
+	switch action {
+	case restoreInsert:
+		stats.Inserted++
+	case restoreUpdate:
+		stats.Updated++
+	case restoreUnchanged:
+		stats.Unchanged++
+	case restoreSkip:
+		stats.Skipped++
+	case restoreDelete:
+		stats.Deleted++
+	case restoreConflict:
+		stats.Conflicts++
+		stats.ConflictList = append(stats.ConflictList, plainId)
+	}
+}
+
+// backupRestorer applies backup files to the database according to the restore mode
+// A dry run writes nothing; the rows it would write are remembered instead, so the
+// files of a chain are planned against each other as well as against the database
+type backupRestorer struct {
+	mode       string
+	dryRun     bool
+	passphrase string
+	result     *RestoreResult
+
+	categoryBatch []BackupCategory
+	cashFlowBatch []BackupCashFlow
+	goalBatch     []BackupGoal
+	debtBatch     []BackupDebt
+
+	plannedCategories map[string]restoreRow
+	plannedCashFlows  map[string]restoreRow
+	plannedGoals      map[string]restoreRow
+	plannedDebts      map[string]restoreRow
+}
+
+func newBackupRestorer(options RestoreOptions, result *RestoreResult) *backupRestorer {
+	return &backupRestorer{
+		mode:              options.Mode,
+		dryRun:            options.DryRun,
+		passphrase:        options.Passphrase,
+		result:            result,
+		categoryBatch:     make([]BackupCategory, 0, restoreBatchSize),
+		cashFlowBatch:     make([]BackupCashFlow, 0, restoreBatchSize),
+		goalBatch:         make([]BackupGoal, 0, restoreBatchSize),
+		debtBatch:         make([]BackupDebt, 0, restoreBatchSize),
+		plannedCategories: map[string]restoreRow{},
+		plannedCashFlows:  map[string]restoreRow{},
+		plannedGoals:      map[string]restoreRow{},
+		plannedDebts:      map[string]restoreRow{},
+	}
+}
+
+// readsDatabase reports whether existing rows are looked up in the database
+// A replace dry run leaves the database as it was, although a real run would have cleared it
+func (restorer *backupRestorer) readsDatabase() bool {
+	return restorer.mode != RestoreModeReplace || !restorer.dryRun
+}
+
+// existingCategories returns the rows a batch of ids would meet, from the plan first and then the database
+func (restorer *backupRestorer) existingCategories(plainIdList []string) (map[string]*restoreRow, error) {
+	rowMap := map[string]*restoreRow{}
+	var lookupList []string
+	for _, plainId := range plainIdList {
+		if row, isExist := restorer.plannedCategories[plainId]; isExist {
+			rowMap[plainId] = &row
+		} else if restorer.readsDatabase() {
+			lookupList = append(lookupList, plainId)
+		}
+	}
+	if len(lookupList) == 0 {
+		return rowMap, nil
+	}
+	entityList, err := category_mapper.INSTANCE.GetCategoriesByIdList(lookupList)
 	if err != nil {
-		return stats, err
+		return nil, err
+	}
+	for _, entity := range entityList {
+		row := newCategoryRestoreRow(newBackupCategory(entity))
+		rowMap[entity.Id.Hex()] = &row
+	}
+	return rowMap, nil
+}
+
+func (restorer *backupRestorer) existingCashFlows(plainIdList []string) (map[string]*restoreRow, error) {
+	rowMap := map[string]*restoreRow{}
+	var lookupList []string
+	for _, plainId := range plainIdList {
+		if row, isExist := restorer.plannedCashFlows[plainId]; isExist {
+			rowMap[plainId] = &row
+		} else if restorer.readsDatabase() {
+			lookupList = append(lookupList, plainId)
+		}
+	}
+	if len(lookupList) == 0 {
+		return rowMap, nil
+	}
+	// Trashed rows count as existing, so a restore never silently takes a row out of the trash
+	entityList, err := cash_flow_mapper.INSTANCE.GetCashFlowsByIdListWithDeleted(lookupList)
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range entityList {
+		row := newExistingCashFlowRestoreRow(entity)
+		rowMap[entity.Id.Hex()] = &row
+	}
+	return rowMap, nil
+}
+
+// existingGoals looks goals up one by one; a ledger holds a handful of them
+func (restorer *backupRestorer) existingGoals(plainIdList []string) (map[string]*restoreRow, error) {
+	rowMap := map[string]*restoreRow{}
+	for _, plainId := range plainIdList {
+		if row, isExist := restorer.plannedGoals[plainId]; isExist {
+			rowMap[plainId] = &row
+			continue
+		}
+		if !restorer.readsDatabase() {
+			continue
+		}
+		entity, err := goal_mapper.INSTANCE.GetGoalByObjectId(plainId)
+		if err != nil {
+			return nil, err
+		}
+		if !entity.IsEmpty() {
+			row := newGoalRestoreRow(newBackupGoal(entity))
+			rowMap[plainId] = &row
+		}
+	}
+	return rowMap, nil
+}
+
+// existingDebts looks debts up one by one, like existingGoals
+func (restorer *backupRestorer) existingDebts(plainIdList []string) (map[string]*restoreRow, error) {
+	rowMap := map[string]*restoreRow{}
+	for _, plainId := range plainIdList {
+		if row, isExist := restorer.plannedDebts[plainId]; isExist {
+			rowMap[plainId] = &row
+			continue
+		}
+		if !restorer.readsDatabase() {
+			continue
+		}
+		entity, err := debt_mapper.INSTANCE.GetDebtByObjectId(plainId)
+		if err != nil {
+			return nil, err
+		}
+		if !entity.IsEmpty() {
+			row := newDebtRestoreRow(newBackupDebt(entity))
+			rowMap[plainId] = &row
+		}
+	}
+	return rowMap, nil
+}
+
+func (restorer *backupRestorer) flushCategories() error {
+	batch := restorer.categoryBatch
+	if len(batch) == 0 {
+		return nil
+	}
+	stats := &restorer.result.Categories
+
+	plainIdList := make([]string, 0, len(batch))
+	for _, record := range batch {
+		plainIdList = append(plainIdList, record.Id)
+	}
+	existingMap, err := restorer.existingCategories(plainIdList)
+	if err != nil {
+		return err
+	}
+
+	writeList := make([]model.CategoryEntity, 0, len(batch))
+	for _, record := range batch {
+		incoming := newCategoryRestoreRow(record)
+		action := decideRestoreAction(restorer.mode, incoming, existingMap[record.Id])
+		stats.count(action, record.Id)
+		if action != restoreInsert && action != restoreUpdate {
+			continue
+		}
+		entity, _ := record.toEntity()
+		writeList = append(writeList, entity)
+		if restorer.dryRun {
+			restorer.plannedCategories[record.Id] = incoming
+		}
 	}
-	defer file.Close()
 
-	// Parse JSON
-	var backup BackupData
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&backup); err != nil {
-		return stats, err
+	if !restorer.dryRun && len(writeList) > 0 {
+		if err := category_mapper.INSTANCE.BulkUpsertCategories(writeList); err != nil {
+			for _, entity := range writeList {
+				stats.FailedList = append(stats.FailedList, entity.Id.Hex())
+			}
+			return err
+		}
 	}
+	stats.Success += len(batch)
+	stats.Failed -= len(batch)
+	restorer.categoryBatch = batch[:0]
+	return nil
+}
 
-	// Update total counts for stats
-	totalCategories := len(backup.Categories)
-	totalCashFlows := len(backup.CashFlows)
-	stats.Categories.Failed = totalCategories
-	stats.CashFlows.Failed = totalCashFlows
+func (restorer *backupRestorer) flushCashFlows() error {
+	batch := restorer.cashFlowBatch
+	if len(batch) == 0 {
+		return nil
+	}
+	stats := &restorer.result.CashFlows
 
-	// Step 1: Clear existing data
-	if _, err := ResetDatabase(); err != nil {
-		return stats, err
+	plainIdList := make([]string, 0, len(batch))
+	for _, record := range batch {
+		plainIdList = append(plainIdList, record.Id)
+	}
+	existingMap, err := restorer.existingCashFlows(plainIdList)
+	if err != nil {
+		return err
 	}
 
-	// Step 2: Insert categories from backup
-	for _, catMap := range backup.Categories {
-		// Create category entity from backup data
-		catEntity := model.CategoryEntity{
-			Name:   catMap["Name"].(string),
-			Remark: catMap["Remark"].(string),
+	writeList := make([]model.CashFlowEntity, 0, len(batch))
+	for _, record := range batch {
+		incoming := newCashFlowRestoreRow(record)
+		action := decideRestoreAction(restorer.mode, incoming, existingMap[record.Id])
+		stats.count(action, record.Id)
+		if action != restoreInsert && action != restoreUpdate {
+			continue
 		}
+		entity, _ := record.toEntity()
+		writeList = append(writeList, entity)
+		if restorer.dryRun {
+			restorer.plannedCashFlows[record.Id] = incoming
+		}
+	}
 
-		// Insert category
-		if id := category_mapper.INSTANCE.InsertCategoryByEntity(catEntity); id != "" {
-			stats.Categories.Success++
-			stats.Categories.Failed--
+	if !restorer.dryRun && len(writeList) > 0 {
+		if err := cash_flow_mapper.INSTANCE.BulkUpsertCashFlows(writeList); err != nil {
+			for _, entity := range writeList {
+				stats.FailedList = append(stats.FailedList, entity.Id.Hex())
+			}
+			return err
 		}
 	}
+	stats.Success += len(batch)
+	stats.Failed -= len(batch)
+	restorer.cashFlowBatch = batch[:0]
+	return nil
+}
 
-	// Step 3: Insert cash flows from backup
-	cashFlowEntities := make([]model.CashFlowEntity, totalCashFlows)
-	for i, cfMap := range backup.CashFlows {
-		// Parse belongs_date string to time.Time
-		belongsDate, _ := time.Parse(time.RFC3339, cfMap["BelongsDate"].(string))
+func (restorer *backupRestorer) flushGoals() error {
+	batch := restorer.goalBatch
+	if len(batch) == 0 {
+		return nil
+	}
+	stats := &restorer.result.Goals
 
-		// Parse CategoryId from backup data
-		categoryId, _ := primitive.ObjectIDFromHex(cfMap["CategoryId"].(string))
+	plainIdList := make([]string, 0, len(batch))
+	for _, record := range batch {
+		plainIdList = append(plainIdList, record.Id)
+	}
+	existingMap, err := restorer.existingGoals(plainIdList)
+	if err != nil {
+		return err
+	}
 
-		// Create cash flow entity from backup data
-		cfEntity := model.CashFlowEntity{
-			CategoryId:  categoryId,
-			BelongsDate: belongsDate,
-			FlowType:    cfMap["FlowType"].(string),
-			Amount:      cfMap["Amount"].(float64),
-			Description: cfMap["Description"].(string),
-			Remark:      cfMap["Remark"].(string),
+	writeList := make([]model.GoalEntity, 0, len(batch))
+	for _, record := range batch {
+		incoming := newGoalRestoreRow(record)
+		action := decideRestoreAction(restorer.mode, incoming, existingMap[record.Id])
+		stats.count(action, record.Id)
+		if action != restoreInsert && action != restoreUpdate {
+			continue
+		}
+		entity, _ := record.toEntity()
+		writeList = append(writeList, entity)
+		if restorer.dryRun {
+			restorer.plannedGoals[record.Id] = incoming
 		}
-		cashFlowEntities[i] = cfEntity
 	}
 
-	// Use bulk insert for cash flows if available
-	if len(cashFlowEntities) > 0 {
-		if ids, err := cash_flow_mapper.INSTANCE.BulkInsertCashFlows(cashFlowEntities); err != nil {
-			// If bulk insert fails, try individual inserts
-			for _, cfEntity := range cashFlowEntities {
-				if id := cash_flow_mapper.INSTANCE.InsertCashFlowByEntity(cfEntity); id != "" {
-					stats.CashFlows.Success++
-					stats.CashFlows.Failed--
-				}
+	if !restorer.dryRun && len(writeList) > 0 {
+		if err := goal_mapper.INSTANCE.BulkUpsertGoals(writeList); err != nil {
+			for _, entity := range writeList {
+				stats.FailedList = append(stats.FailedList, entity.Id.Hex())
 			}
-		} else {
-			// Bulk insert succeeded
-			stats.CashFlows.Success = len(ids)
-			stats.CashFlows.Failed = totalCashFlows - len(ids)
+			return err
 		}
 	}
+	stats.Success += len(batch)
+	stats.Failed -= len(batch)
+	restorer.goalBatch = batch[:0]
+	return nil
+}
+
+func (restorer *backupRestorer) flushDebts() error {
+	batch := restorer.debtBatch
+	if len(batch) == 0 {
+		return nil
+	}
+	stats := &restorer.result.Debts
 
-	return stats, nil
+	plainIdList := make([]string, 0, len(batch))
+	for _, record := range batch {
+		plainIdList = append(plainIdList, record.Id)
+	}
+	existingMap, err := restorer.existingDebts(plainIdList)
+	if err != nil {
+		return err
+	}
+
+	writeList := make([]model.DebtEntity, 0, len(batch))
+	for _, record := range batch {
+		incoming := newDebtRestoreRow(record)
+		action := decideRestoreAction(restorer.mode, incoming, existingMap[record.Id])
+		stats.count(action, record.Id)
+		if action != restoreInsert && action != restoreUpdate {
+			continue
+		}
+		entity, _ := record.toEntity()
+		writeList = append(writeList, entity)
+		if restorer.dryRun {
+			restorer.plannedDebts[record.Id] = incoming
+		}
+	}
+
+	if !restorer.dryRun && len(writeList) > 0 {
+		if err := debt_mapper.INSTANCE.BulkUpsertDebts(writeList); err != nil {
+			for _, entity := range writeList {
+				stats.FailedList = append(stats.FailedList, entity.Id.Hex())
+			}
+			return err
+		}
+	}
+	stats.Success += len(batch)
+	stats.Failed -= len(batch)
+	restorer.debtBatch = batch[:0]
+	return nil
+}
+
+// flushAll writes every pending batch, categories first so the other records find them
+func (restorer *backupRestorer) flushAll() error {
+	if err := restorer.flushCategories(); err != nil {
+		return err
+	}
+	if err := restorer.flushCashFlows(); err != nil {
+		return err
+	}
+	if err := restorer.flushGoals(); err != nil {
+		return err
+	}
+	return restorer.flushDebts()
+}
+
+// applyTombstone deletes the row named by a backup tombstone when the mode allows it
+// A row created and deleted between two backups was never restored; deleting nothing is fine
+func (restorer *backupRestorer) applyTombstone(record BackupTombstone) error {
+	id, _ := record.objectId()
+	plainId := id.Hex()
+
+	var stats *RestoreEntityStats
+	var planned map[string]restoreRow
+	var existingMap map[string]*restoreRow
+	var err error
+	switch record.EntityType {
+	case model.TombstoneTypeCashFlow:
+		stats, planned = &restorer.result.CashFlows, restorer.plannedCashFlows
+		existingMap, err = restorer.existingCashFlows([]string{plainId})
+	case model.TombstoneTypeCategory:
+		stats, planned = &restorer.result.Categories, restorer.plannedCategories
+		existingMap, err = restorer.existingCategories([]string{plainId})
+	case model.TombstoneTypeGoal:
+		stats, planned = &restorer.result.Goals, restorer.plannedGoals
+		existingMap, err = restorer.existingGoals([]string{plainId})
+	case model.TombstoneTypeDebt:
+		stats, planned = &restorer.result.Debts, restorer.plannedDebts
+		existingMap, err = restorer.existingDebts([]string{plainId})
+	}
+	if err != nil {
+		return err
+	}
+
+	action := decideTombstoneAction(restorer.mode, record.DeleteTime, existingMap[plainId])
+	stats.count(action, plainId)
+	if action == restoreDelete {
+		switch {
+		case restorer.dryRun:
+			planned[plainId] = restoreRow{deleted: true}
+		case record.EntityType == model.TombstoneTypeCashFlow:
+			cash_flow_mapper.INSTANCE.DeleteCashFlowByObjectId(plainId)
+		case record.EntityType == model.TombstoneTypeGoal:
+			if _, err := goal_mapper.INSTANCE.DeleteGoalByObjectId(plainId); err != nil {
+				return err
+			}
+		case record.EntityType == model.TombstoneTypeDebt:
+			if _, err := debt_mapper.INSTANCE.DeleteDebtByObjectId(plainId); err != nil {
+				return err
+			}
+		default:
+			category_mapper.INSTANCE.DeleteCategoryByObjectId(plainId)
+		}
+	}
+	restorer.result.Tombstones.Success++
+	restorer.result.Tombstones.Failed--
+	return nil
+}
+
+// applyBackupFile restores the records of one backup with their original ids, so parent links and
+// category references stay intact, then applies its tombstones
+func (restorer *backupRestorer) applyBackupFile(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stream, err := openBackupStream(file, restorer.passphrase)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	err = stream.forEach(backupHandlers{
+		onCategory: func(record BackupCategory) error {
+			restorer.categoryBatch = append(restorer.categoryBatch, record)
+			if len(restorer.categoryBatch) == restoreBatchSize {
+				return restorer.flushCategories()
+			}
+			return nil
+		},
+		onCashFlow: func(record BackupCashFlow) error {
+			// Categories are complete once cash flows start
+			if err := restorer.flushCategories(); err != nil {
+				return err
+			}
+			restorer.cashFlowBatch = append(restorer.cashFlowBatch, record)
+			if len(restorer.cashFlowBatch) == restoreBatchSize {
+				return restorer.flushCashFlows()
+			}
+			return nil
+		},
+		onGoal: func(record BackupGoal) error {
+			// A goal may point at any category of this backup
+			if err := restorer.flushCategories(); err != nil {
+				return err
+			}
+			restorer.goalBatch = append(restorer.goalBatch, record)
+			if len(restorer.goalBatch) == restoreBatchSize {
+				return restorer.flushGoals()
+			}
+			return nil
+		},
+		onDebt: func(record BackupDebt) error {
+			// A debt names its payment and interest categories
+			if err := restorer.flushCategories(); err != nil {
+				return err
+			}
+			restorer.debtBatch = append(restorer.debtBatch, record)
+			if len(restorer.debtBatch) == restoreBatchSize {
+				return restorer.flushDebts()
+			}
+			return nil
+		},
+		onTombstone: func(record BackupTombstone) error {
+			// Tombstones come last: every record of this backup must land before its deletions
+			if err := restorer.flushAll(); err != nil {
+				return err
+			}
+			return restorer.applyTombstone(record)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return restorer.flushAll()
 }
@@ -0,0 +1,159 @@
+package manage_service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/macar-x/cashlenx-server/model"
+)
+
+func TestDecideRestoreAction(t *testing.T) {
+	older := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	record := BackupCategory{Id: "663210f0a1b2c3d4e5f60717", Name: "Food", Type: "expense", CreateTime: older, ModifyTime: newer}
+	incoming := newCategoryRestoreRow(record)
+	same := newCategoryRestoreRow(record)
+
+	record.Name = "Groceries"
+	record.ModifyTime = older
+	localOlder := newCategoryRestoreRow(record)
+	record.ModifyTime = newer.Add(time.Hour)
+	localNewer := newCategoryRestoreRow(record)
+	deleted := restoreRow{deleted: true}
+
+	cases := []struct {
+		mode     string
+		existing *restoreRow
+		want     restoreAction
+	}{
+		{RestoreModeMerge, nil, restoreInsert},
+		{RestoreModeMerge, &deleted, restoreInsert},
+		{RestoreModeMerge, &same, restoreUnchanged},
+		{RestoreModeMerge, &localOlder, restoreUpdate},
+		{RestoreModeMerge, &localNewer, restoreSkip},
+		{RestoreModeSkipExisting, nil, restoreInsert},
+		{RestoreModeSkipExisting, &localOlder, restoreSkip},
+		{RestoreModeFailOnConflict, &same, restoreUnchanged},
+		{RestoreModeFailOnConflict, &localOlder, restoreConflict},
+		{RestoreModeReplace, &localNewer, restoreUpdate},
+	}
+	for _, c := range cases {
+		if got := decideRestoreAction(c.mode, incoming, c.existing); got != c.want {
+			t.Errorf("%s: expected action %d, got %d", c.mode, c.want, got)
+		}
+	}
+}
+
+func TestDecideRestoreAction_EqualModifyTimeKeepsLocal(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	incoming := newCategoryRestoreRow(BackupCategory{Id: "663210f0a1b2c3d4e5f60717", Name: "Food", ModifyTime: at})
+	local := newCategoryRestoreRow(BackupCategory{Id: "663210f0a1b2c3d4e5f60717", Name: "Groceries", ModifyTime: at})
+	if got := decideRestoreAction(RestoreModeMerge, incoming, &local); got != restoreSkip {
+		t.Errorf("a tie on modify_time should keep the local row, got %d", got)
+	}
+}
+
+func TestNewRestoreRow_IgnoresSubSecondTimes(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	record := BackupCashFlow{Id: "663210f0a1b2c3d4e5f60719", BelongsDate: at, CreateTime: at, ModifyTime: at}
+	original := newCashFlowRestoreRow(record)
+
+	record.CreateTime = at.Add(123 * time.Millisecond)
+	record.ModifyTime = at.Add(456 * time.Millisecond)
+	if newCashFlowRestoreRow(record) != original {
+		t.Error("rows differing only below one second should compare equal")
+	}
+
+	record.Description = "noodles"
+	if newCashFlowRestoreRow(record).digest == original.digest {
+		t.Error("rows with different content should not compare equal")
+	}
+}
+
+func TestDecideTombstoneAction(t *testing.T) {
+	deleteTime := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+	before := restoreRow{modifyTime: deleteTime.Add(-time.Hour)}
+	after := restoreRow{modifyTime: deleteTime.Add(time.Hour)}
+
+	cases := []struct {
+		mode     string
+		existing *restoreRow
+		want     restoreAction
+	}{
+		{RestoreModeMerge, nil, restoreUnchanged},
+		{RestoreModeMerge, &before, restoreDelete},
+		{RestoreModeMerge, &after, restoreSkip},
+		{RestoreModeReplace, &before, restoreDelete},
+		{RestoreModeReplace, &after, restoreSkip},
+		{RestoreModeSkipExisting, &before, restoreSkip},
+		{RestoreModeFailOnConflict, &before, restoreConflict},
+	}
+	for _, c := range cases {
+		if got := decideTombstoneAction(c.mode, deleteTime, c.existing); got != c.want {
+			t.Errorf("%s: expected action %d, got %d", c.mode, c.want, got)
+		}
+	}
+}
+
+func TestDecideRestoreAction_OverTrashedCashFlow(t *testing.T) {
+	modifyTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	deletedAt := modifyTime.Add(24 * time.Hour)
+	entity := model.CashFlowEntity{
+		Id:          primitive.NewObjectID(),
+		CategoryId:  primitive.NewObjectID(),
+		BelongsDate: modifyTime,
+		FlowType:    "expense",
+		Amount:      model.NewMoney(decimal.NewFromInt(12)),
+		Description: "lunch",
+		CreateTime:  modifyTime,
+		ModifyTime:  modifyTime,
+		DeletedAt:   &deletedAt,
+	}
+	trashed := newExistingCashFlowRestoreRow(entity)
+	if !trashed.trashed || !trashed.modifyTime.Equal(deletedAt) {
+		t.Fatalf("a trashed row should count as modified when it was trashed, got %+v", trashed)
+	}
+
+	same := newCashFlowRestoreRow(newBackupCashFlow(entity))
+	record := newBackupCashFlow(entity)
+	record.Description = "dinner"
+	record.ModifyTime = modifyTime.Add(time.Hour)
+	editedBeforeTrash := newCashFlowRestoreRow(record)
+	record.ModifyTime = deletedAt.Add(time.Hour)
+	editedAfterTrash := newCashFlowRestoreRow(record)
+
+	cases := []struct {
+		mode     string
+		incoming restoreRow
+		want     restoreAction
+	}{
+		{RestoreModeMerge, same, restoreUnchanged},
+		{RestoreModeMerge, editedBeforeTrash, restoreSkip},
+		{RestoreModeMerge, editedAfterTrash, restoreUpdate},
+		{RestoreModeSkipExisting, editedAfterTrash, restoreSkip},
+		{RestoreModeFailOnConflict, editedBeforeTrash, restoreConflict},
+	}
+	for _, c := range cases {
+		if got := decideRestoreAction(c.mode, c.incoming, &trashed); got != c.want {
+			t.Errorf("%s: expected action %d, got %d", c.mode, c.want, got)
+		}
+	}
+	if got := decideTombstoneAction(RestoreModeMerge, modifyTime, &trashed); got != restoreUnchanged {
+		t.Errorf("a tombstone should leave a trashed row in the trash, got %d", got)
+	}
+}
+
+func TestValidateRestoreMode(t *testing.T) {
+	for _, mode := range []string{"", RestoreModeReplace, RestoreModeMerge, RestoreModeSkipExisting, RestoreModeFailOnConflict} {
+		if err := ValidateRestoreMode(mode); err != nil {
+			t.Errorf("mode %q should be valid: %v", mode, err)
+		}
+	}
+	if err := ValidateRestoreMode("overwrite"); err == nil {
+		t.Error("unknown mode should be rejected")
+	}
+}
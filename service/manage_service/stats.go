@@ -1,14 +1,16 @@
 package manage_service
 
+import "github.com/macar-x/cashlenx-server/model"
+
 // DatabaseStats represents database statistics
 type DatabaseStats struct {
 	CashFlowCount int
 	IncomeCount   int
 	ExpenseCount  int
 	CategoryCount int
-	TotalIncome   float64
-	TotalExpense  float64
-	Balance       float64
+	TotalIncome   model.Money
+	TotalExpense  model.Money
+	Balance       model.Money
 	EarliestDate  string
 	LatestDate    string
 }
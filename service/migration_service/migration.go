@@ -0,0 +1,221 @@
+package migration_service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/mapper/schema_migration_mapper"
+	"github.com/macar-x/cashlenx-server/migrations"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+)
+
+// Migration is one versioned schema change with its up and down scripts
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a known migration has been applied
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.(sql|json)$`)
+
+// LoadMigrations returns the embedded migrations for the given database type, ordered by version
+func LoadMigrations(dbType string) ([]Migration, error) {
+	switch dbType {
+	case "mongodb", "mysql":
+		return loadMigrationsFromFS(migrations.FS, dbType)
+	default:
+		return nil, errors.New("database type not supported: " + dbType)
+	}
+}
+
+func loadMigrationsFromFS(fileSystem fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fileSystem, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationMap := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+		content, err := fs.ReadFile(fileSystem, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, isExist := migrationMap[version]
+		if !isExist {
+			migration = &Migration{Version: version, Name: matches[2]}
+			migrationMap[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d used by both %s and %s",
+				version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrationList []Migration
+	for _, migration := range migrationMap {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrationList = append(migrationList, *migration)
+	}
+	sort.Slice(migrationList, func(i, j int) bool {
+		return migrationList[i].Version < migrationList[j].Version
+	})
+	return migrationList, nil
+}
+
+// GetStatus lists every known migration and whether it has been applied
+func GetStatus() ([]MigrationStatus, error) {
+	migrationList, appliedMap, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	var statusList []MigrationStatus
+	for _, migration := range migrationList {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if applied, isExist := appliedMap[migration.Version]; isExist {
+			status.Applied = true
+			status.AppliedAt = applied.AppliedAt
+		}
+		statusList = append(statusList, status)
+	}
+	return statusList, nil
+}
+
+// MigrateUp applies pending migrations in version order; steps <= 0 applies all of them
+func MigrateUp(steps int) ([]Migration, error) {
+	migrationList, appliedMap, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	var appliedList []Migration
+	for _, migration := range migrationList {
+		if steps > 0 && len(appliedList) >= steps {
+			break
+		}
+		if _, isExist := appliedMap[migration.Version]; isExist {
+			continue
+		}
+
+		util.Logger.Infow("applying migration", "version", migration.Version, "name", migration.Name)
+		if err := schema_migration_mapper.INSTANCE.ExecuteMigrationScript(migration.Up); err != nil {
+			return appliedList, fmt.Errorf("migration %03d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		err := schema_migration_mapper.INSTANCE.InsertMigration(model.SchemaMigrationEntity{
+			Version: migration.Version,
+			Name:    migration.Name,
+		})
+		if err != nil {
+			return appliedList, err
+		}
+		appliedList = append(appliedList, migration)
+	}
+	return appliedList, nil
+}
+
+// MigrateDown reverts applied migrations newest first; steps <= 0 reverts only the latest one
+func MigrateDown(steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+
+	migrationList, appliedMap, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	var revertedList []Migration
+	for i := len(migrationList) - 1; i >= 0 && len(revertedList) < steps; i-- {
+		migration := migrationList[i]
+		if _, isExist := appliedMap[migration.Version]; !isExist {
+			continue
+		}
+		if migration.Down == "" {
+			return revertedList, fmt.Errorf("migration %03d_%s cannot be reverted: no down script",
+				migration.Version, migration.Name)
+		}
+
+		util.Logger.Infow("reverting migration", "version", migration.Version, "name", migration.Name)
+		if err := schema_migration_mapper.INSTANCE.ExecuteMigrationScript(migration.Down); err != nil {
+			return revertedList, fmt.Errorf("revert %03d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		if err := schema_migration_mapper.INSTANCE.DeleteMigrationByVersion(migration.Version); err != nil {
+			return revertedList, err
+		}
+		revertedList = append(revertedList, migration)
+	}
+	return revertedList, nil
+}
+
+// VerifySchemaVersion returns an error when the database is behind the migrations shipped with this binary
+func VerifySchemaVersion() error {
+	statusList, err := GetStatus()
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, status := range statusList {
+		if !status.Applied {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("database schema is out of date: %d pending migration(s), run 'cashlenx db migrate up'", pending)
+	}
+	return nil
+}
+
+func loadState() ([]Migration, map[int]model.SchemaMigrationEntity, error) {
+	migrationList, err := LoadMigrations(util.GetConfigByKey("db.type"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := schema_migration_mapper.INSTANCE.EnsureMigrationTable(); err != nil {
+		return nil, nil, err
+	}
+	appliedList, err := schema_migration_mapper.INSTANCE.GetAppliedMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appliedMap := make(map[int]model.SchemaMigrationEntity)
+	for _, applied := range appliedList {
+		appliedMap[applied.Version] = applied
+	}
+	return migrationList, appliedMap, nil
+}
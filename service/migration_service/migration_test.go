@@ -0,0 +1,68 @@
+package migration_service
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrationsFromFS(t *testing.T) {
+	fileSystem := fstest.MapFS{
+		"mysql/002_add_column.up.sql":      {Data: []byte("ALTER TABLE t ADD COLUMN c INT;")},
+		"mysql/002_add_column.down.sql":    {Data: []byte("ALTER TABLE t DROP COLUMN c;")},
+		"mysql/001_create_tables.up.sql":   {Data: []byte("CREATE TABLE t (id INT);")},
+		"mysql/001_create_tables.down.sql": {Data: []byte("DROP TABLE t;")},
+	}
+
+	migrationList, err := loadMigrationsFromFS(fileSystem, "mysql")
+	if err != nil {
+		t.Fatalf("loadMigrationsFromFS() error = %v", err)
+	}
+	if len(migrationList) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrationList))
+	}
+	if migrationList[0].Version != 1 || migrationList[0].Name != "create_tables" {
+		t.Errorf("Unexpected first migration: %+v", migrationList[0])
+	}
+	if migrationList[1].Down != "ALTER TABLE t DROP COLUMN c;" {
+		t.Errorf("Unexpected down script: %q", migrationList[1].Down)
+	}
+}
+
+func TestLoadMigrationsFromFSInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fs   fstest.MapFS
+	}{
+		{"Bad file name", fstest.MapFS{"mysql/create.sql": {Data: []byte("x")}}},
+		{"Missing up script", fstest.MapFS{"mysql/001_a.down.sql": {Data: []byte("x")}}},
+		{"Duplicate version", fstest.MapFS{
+			"mysql/001_a.up.sql": {Data: []byte("x")},
+			"mysql/001_b.up.sql": {Data: []byte("y")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadMigrationsFromFS(tt.fs, "mysql"); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	for _, dbType := range []string{"mysql", "mongodb"} {
+		migrationList, err := LoadMigrations(dbType)
+		if err != nil {
+			t.Fatalf("LoadMigrations(%s) error = %v", dbType, err)
+		}
+		if len(migrationList) == 0 {
+			t.Errorf("Expected embedded migrations for %s", dbType)
+		}
+		for _, migration := range migrationList {
+			if migration.Down == "" {
+				t.Errorf("%s migration %d has no down script", dbType, migration.Version)
+			}
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package report_service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ForecastMinMonths     = 3
+	ForecastMaxMonths     = 12
+	ForecastDefaultMonths = 6
+
+	// ForecastHistoryMonths is how many complete months the seasonal averages look back
+	ForecastHistoryMonths = 24
+	// RecurringLookbackMonths and RecurringMinMonths: an item is recurring when it came in at least
+	// RecurringMinMonths of the last RecurringLookbackMonths complete months, and lately
+	RecurringLookbackMonths = 6
+	RecurringMinMonths      = 3
+)
+
+// recurringKey identifies the cash flows of one recurring item
+type recurringKey struct {
+	flowType    string
+	categoryId  primitive.ObjectID
+	description string
+}
+
+func newRecurringKey(cashFlow model.CashFlowEntity) recurringKey {
+	flowType := model.FlowTypeOutcome
+	if cashFlow.FlowType == model.FlowTypeIncome {
+		flowType = model.FlowTypeIncome
+	}
+	return recurringKey{
+		flowType:    flowType,
+		categoryId:  cashFlow.CategoryId,
+		description: strings.ToLower(strings.TrimSpace(cashFlow.Description)),
+	}
+}
+
+// seasonalKey identifies the cash flows of one category and type outside the recurring items
+type seasonalKey struct {
+	flowType   string
+	categoryId primitive.ObjectID
+}
+
+// ForecastService projects the balance over the next months, the current one first, from every cash flow up to today
+func ForecastService(months int) (model.ForecastReport, error) {
+	if months == 0 {
+		months = ForecastDefaultMonths
+	}
+	if months < ForecastMinMonths || months > ForecastMaxMonths {
+		return model.ForecastReport{}, errors.NewFieldValidationError("months", "months must be between 3 and 12")
+	}
+
+	now := time.Now().In(util.GetTimezone())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	cashFlowList := cash_flow_mapper.INSTANCE.GetCashFlowsByDateRange(time.Time{}, today)
+	return buildForecast(today, months, cashFlowList), nil
+}
+
+// buildForecast combines the recurring items with seasonal averages of everything else, per category.
+// A category's month is expected at half the average of the same calendar month and half the average
+// month; the bands are one standard deviation of its months, growing over the months for the balance.
+// Recurring items count at their latest amount. In the current month only what is still to come counts.
+func buildForecast(today time.Time, horizon int, cashFlowList []model.CashFlowEntity) model.ForecastReport {
+	currentMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	report := model.ForecastReport{
+		AsOf:      util.FormatDateToStringWithDash(today),
+		Horizon:   horizon,
+		Recurring: []model.ForecastRecurringItem{},
+		Months:    []model.ForecastMonth{},
+	}
+
+	earliestMonth := currentMonth
+	for _, cashFlow := range cashFlowList {
+		if cashFlow.FlowType == model.FlowTypeIncome {
+			report.Balance = report.Balance.Add(cashFlow.Amount)
+		} else {
+			report.Balance = report.Balance.Sub(cashFlow.Amount)
+		}
+		if first := monthStart(cashFlow.BelongsDate); first.Before(earliestMonth) {
+			earliestMonth = first
+		}
+	}
+	// Months before the first cash flow would only water the averages down
+	historyStart := currentMonth.AddDate(0, -ForecastHistoryMonths, 0)
+	if earliestMonth.After(historyStart) {
+		historyStart = earliestMonth
+	}
+	historyMonths := monthsBetween(historyStart, currentMonth)
+	if historyMonths > 0 {
+		report.HistoryFrom = util.FormatDateToStringWithDash(historyStart)
+		report.HistoryTo = util.FormatDateToStringWithDash(currentMonth.AddDate(0, 0, -1))
+	}
+
+	recurringMap, seenThisMonth := findRecurringItems(currentMonth, cashFlowList)
+	for _, item := range recurringMap {
+		report.Recurring = append(report.Recurring, item)
+	}
+	sort.Slice(report.Recurring, func(i, j int) bool {
+		if report.Recurring[i].Day != report.Recurring[j].Day {
+			return report.Recurring[i].Day < report.Recurring[j].Day
+		}
+		return report.Recurring[i].Description < report.Recurring[j].Description
+	})
+
+	// Monthly totals of everything but the recurring items, per category and type
+	seriesMap := make(map[seasonalKey][]model.Money)
+	for _, cashFlow := range cashFlowList {
+		index := monthsBetween(historyStart, monthStart(cashFlow.BelongsDate))
+		if index < 0 || index >= historyMonths {
+			continue
+		}
+		if _, ok := recurringMap[newRecurringKey(cashFlow)]; ok {
+			continue
+		}
+		key := seasonalKey{flowType: newRecurringKey(cashFlow).flowType, categoryId: cashFlow.CategoryId}
+		if seriesMap[key] == nil {
+			seriesMap[key] = make([]model.Money, historyMonths)
+		}
+		seriesMap[key][index] = seriesMap[key][index].Add(cashFlow.Amount)
+	}
+
+	expectedBalance := report.Balance
+	balanceVariance := 0.0
+	for offset := 0; offset < horizon; offset++ {
+		start := currentMonth.AddDate(0, offset, 0)
+		// Share of the seasonal amounts still to come
+		share := 1.0
+		if offset == 0 {
+			daysInMonth := start.AddDate(0, 1, -1).Day()
+			share = float64(daysInMonth-today.Day()) / float64(daysInMonth)
+		}
+
+		month := model.ForecastMonth{Month: start.Format("2006-01")}
+		var income, expense model.Money
+		var incomeVariance, expenseVariance float64
+		for key, series := range seriesMap {
+			expected, deviation := seasonalEstimate(series, historyStart, start.Month())
+			expected = expected.Mul(decimal.NewFromFloat(share))
+			deviation *= share
+			if key.flowType == model.FlowTypeIncome {
+				income = income.Add(expected)
+				incomeVariance += deviation * deviation
+			} else {
+				expense = expense.Add(expected)
+				expenseVariance += deviation * deviation
+			}
+		}
+		for key, item := range recurringMap {
+			if offset == 0 && seenThisMonth[key] {
+				continue
+			}
+			if key.flowType == model.FlowTypeIncome {
+				income = income.Add(item.Amount)
+				month.Recurring = month.Recurring.Add(item.Amount)
+			} else {
+				expense = expense.Add(item.Amount)
+				month.Recurring = month.Recurring.Sub(item.Amount)
+			}
+		}
+
+		month.Income = newForecastBand(income, math.Sqrt(incomeVariance), true)
+		month.Expense = newForecastBand(expense, math.Sqrt(expenseVariance), true)
+		expectedBalance = expectedBalance.Add(income).Sub(expense)
+		balanceVariance += incomeVariance + expenseVariance
+		month.Balance = newForecastBand(expectedBalance, math.Sqrt(balanceVariance), false)
+		month.Negative = month.Balance.Expected.IsNegative()
+		report.Months = append(report.Months, month)
+	}
+	return report
+}
+
+// findRecurringItems returns the recurring items by key, and which of them already came this month
+func findRecurringItems(currentMonth time.Time, cashFlowList []model.CashFlowEntity) (map[recurringKey]model.ForecastRecurringItem, map[recurringKey]bool) {
+	lookbackStart := currentMonth.AddDate(0, -RecurringLookbackMonths, 0)
+	monthSetMap := make(map[recurringKey]map[time.Time]bool)
+	countMap := make(map[recurringKey]int)
+	latestMap := make(map[recurringKey]model.CashFlowEntity)
+	seenThisMonth := make(map[recurringKey]bool)
+	for _, cashFlow := range cashFlowList {
+		key := newRecurringKey(cashFlow)
+		if key.description == "" || cashFlow.BelongsDate.Before(lookbackStart) {
+			continue
+		}
+		first := monthStart(cashFlow.BelongsDate)
+		if first.Equal(currentMonth) {
+			seenThisMonth[key] = true
+		} else {
+			if monthSetMap[key] == nil {
+				monthSetMap[key] = make(map[time.Time]bool)
+			}
+			monthSetMap[key][first] = true
+			countMap[key]++
+		}
+		if latest, ok := latestMap[key]; !ok || !cashFlow.BelongsDate.Before(latest.BelongsDate) {
+			latestMap[key] = cashFlow
+		}
+	}
+
+	// Items that skipped the last two complete months have stopped, and what comes several times
+	// a month, like groceries at the same shop, is left to the seasonal averages
+	recentStart := currentMonth.AddDate(0, -2, 0)
+	recurringMap := make(map[recurringKey]model.ForecastRecurringItem)
+	for key, monthSet := range monthSetMap {
+		latest := latestMap[key]
+		if len(monthSet) < RecurringMinMonths || latest.BelongsDate.Before(recentStart) || countMap[key]*2 > len(monthSet)*3 {
+			continue
+		}
+		recurringMap[key] = model.ForecastRecurringItem{
+			Description: strings.TrimSpace(latest.Description),
+			CategoryId:  latest.CategoryId.Hex(),
+			Type:        key.flowType,
+			Amount:      latest.Amount,
+			Day:         latest.BelongsDate.Day(),
+			Months:      len(monthSet),
+		}
+	}
+	return recurringMap, seenThisMonth
+}
+
+// seasonalEstimate returns the expected amount of a category in a calendar month and its standard deviation
+func seasonalEstimate(series []model.Money, historyStart time.Time, month time.Month) (model.Money, float64) {
+	var total, sameMonthTotal model.Money
+	sameMonthCount := 0
+	for index, amount := range series {
+		total = total.Add(amount)
+		if historyStart.AddDate(0, index, 0).Month() == month {
+			sameMonthTotal = sameMonthTotal.Add(amount)
+			sameMonthCount++
+		}
+	}
+	average := total.DivInt(int64(len(series)))
+	expected := average
+	if sameMonthCount > 0 {
+		expected = sameMonthTotal.DivInt(int64(sameMonthCount)).Add(average).DivInt(2)
+	}
+
+	variance := 0.0
+	for _, amount := range series {
+		difference := amount.Float64() - average.Float64()
+		variance += difference * difference
+	}
+	return expected.Round(model.MoneyDisplayScale), math.Sqrt(variance / float64(len(series)))
+}
+
+// newForecastBand spreads deviation around expected; amounts flowing one way do not go below zero
+func newForecastBand(expected model.Money, deviation float64, floorAtZero bool) model.ForecastBand {
+	spread := model.NewMoneyFromFloat(deviation).Round(model.MoneyDisplayScale)
+	band := model.ForecastBand{
+		Expected: expected.Round(model.MoneyDisplayScale),
+		Low:      expected.Sub(spread).Round(model.MoneyDisplayScale),
+		High:     expected.Add(spread).Round(model.MoneyDisplayScale),
+	}
+	if floorAtZero && band.Low.IsNegative() {
+		band.Low = model.Money{}
+	}
+	return band
+}
+
+func monthStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// monthsBetween counts the months from the month of from to the month of to
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
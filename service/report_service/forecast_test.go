@@ -0,0 +1,111 @@
+package report_service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// forecastHistory holds January to September 2026 with a salary and rent every month, groceries four
+// times a month, a gym membership that stopped in June, and the rent of October already paid
+func forecastHistory() []model.CashFlowEntity {
+	housing, food, sport, job := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	var cashFlowList []model.CashFlowEntity
+	for month := 1; month <= 9; month++ {
+		prefix := fmt.Sprintf("2026-%02d-", month)
+		cashFlowList = append(cashFlowList,
+			testutil.NewCashFlow(job, prefix+"25", model.FlowTypeIncome, "ACME payroll", 3000),
+			testutil.NewCashFlow(housing, prefix+"01", model.FlowTypeOutcome, "Rent", 1000))
+		for _, dayOfMonth := range []string{"03", "10", "17", "24"} {
+			cashFlowList = append(cashFlowList, testutil.NewCashFlow(food, prefix+dayOfMonth, model.FlowTypeOutcome, "Supermarket", 100))
+		}
+		if month <= 6 {
+			cashFlowList = append(cashFlowList, testutil.NewCashFlow(sport, prefix+"05", model.FlowTypeOutcome, "Gym", 50))
+		}
+	}
+	return append(cashFlowList, testutil.NewCashFlow(housing, "2026-10-01", model.FlowTypeOutcome, " rent ", 1000))
+}
+
+func TestBuildForecast(t *testing.T) {
+	report := buildForecast(testutil.Day("2026-10-19"), 3, forecastHistory())
+
+	if report.Balance.String() != "13100.00" {
+		t.Errorf("expected the balance of all cash flows, got %s", report.Balance)
+	}
+	if report.HistoryFrom != "2026-01-01" || report.HistoryTo != "2026-09-30" {
+		t.Errorf("expected the history to start with the first cash flow, got %s to %s", report.HistoryFrom, report.HistoryTo)
+	}
+
+	if len(report.Recurring) != 2 || report.Recurring[0].Description != "rent" || report.Recurring[1].Description != "ACME payroll" {
+		t.Fatalf("expected rent and the salary to recur, got %+v", report.Recurring)
+	}
+	if report.Recurring[1].Day != 25 || report.Recurring[1].Months != 6 {
+		t.Errorf("unexpected salary item %+v", report.Recurring[1])
+	}
+
+	if len(report.Months) != 3 || report.Months[0].Month != "2026-10" || report.Months[2].Month != "2026-12" {
+		t.Fatalf("unexpected months %+v", report.Months)
+	}
+
+	// October: the rent is paid and 12 of 31 days are left for groceries and the gym
+	october := report.Months[0]
+	if october.Recurring.String() != "3000.00" || october.Income.Expected.String() != "3000.00" {
+		t.Errorf("expected only the salary to recur in October, got %s and %s", october.Recurring, october.Income.Expected)
+	}
+	if october.Expense.Expected.String() != "167.74" {
+		t.Errorf("expected the rest of October's seasonal expense, got %s", october.Expense.Expected)
+	}
+	if october.Balance.Expected.String() != "15932.26" {
+		t.Errorf("unexpected October balance %s", october.Balance.Expected)
+	}
+
+	november := report.Months[1]
+	if november.Recurring.String() != "2000.00" || november.Expense.Expected.String() != "1433.33" {
+		t.Errorf("expected rent, groceries and the average gym month, got %s and %s", november.Recurring, november.Expense.Expected)
+	}
+	if november.Expense.Low.String() != "1409.76" || november.Expense.High.String() != "1456.90" {
+		t.Errorf("expected one standard deviation of the gym, got %s to %s", november.Expense.Low, november.Expense.High)
+	}
+	if november.Balance.Expected.String() != "17498.93" || november.Negative {
+		t.Errorf("unexpected November balance %+v", november.Balance)
+	}
+	if !november.Balance.Low.IsPositive() || november.Balance.High.Cmp(november.Balance.Expected) <= 0 {
+		t.Errorf("expected a band around the balance, got %+v", november.Balance)
+	}
+}
+
+// A loan paid from savings made before the history the averages use
+func TestBuildForecast_Negative(t *testing.T) {
+	cashFlowList := []model.CashFlowEntity{}
+	for month := 7; month <= 9; month++ {
+		cashFlowList = append(cashFlowList, model.CashFlowEntity{
+			BelongsDate: testutil.Day(fmt.Sprintf("2026-%02d-01", month)),
+			FlowType:    model.FlowTypeOutcome,
+			Description: "Loan",
+			Amount:      model.NewMoneyFromInt(400),
+		})
+	}
+	cashFlowList = append(cashFlowList, model.CashFlowEntity{
+		BelongsDate: testutil.Day("2024-01-01"), FlowType: model.FlowTypeIncome, Description: "Savings", Amount: model.NewMoneyFromInt(2000),
+	})
+
+	report := buildForecast(testutil.Day("2026-10-19"), 4, cashFlowList)
+	for index, expectedBalance := range []string{"400.00", "0.00", "-400.00", "-800.00"} {
+		month := report.Months[index]
+		if month.Balance.Expected.String() != expectedBalance || month.Negative != (index >= 2) {
+			t.Errorf("%s: expected %s, got %+v", month.Month, expectedBalance, month)
+		}
+	}
+}
+
+func TestForecastService_InvalidMonths(t *testing.T) {
+	for _, months := range []int{-1, 2, 13} {
+		if _, err := ForecastService(months); err == nil {
+			t.Errorf("%d: expected an error", months)
+		}
+	}
+}
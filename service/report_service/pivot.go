@@ -0,0 +1,272 @@
+package report_service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/cash_flow_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
+	"github.com/macar-x/cashlenx-server/service/category_service"
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PivotRowsCategory = "category"
+
+	PivotColsMonth   = "month"
+	PivotColsQuarter = "quarter"
+	PivotColsYear    = "year"
+
+	// UncategorizedName labels the row of cash flows whose category no longer exists
+	UncategorizedName = "(uncategorized)"
+)
+
+// pivotCell holds the amounts of one category, one per column
+type pivotCell struct {
+	values []model.Money
+	count  int
+}
+
+// PivotService sums the cash flows of flowType between fromText and toText by category and period.
+// Rows can only be "category"; cols is "month", "quarter" or "year". Dates take the forms of
+// the date: search field, so from=2026-01&to=2026-06 covers January to June. Without them the
+// report covers the last 12 months.
+func PivotService(rows, cols, fromText, toText, flowType string) (model.PivotReport, error) {
+	if rows == "" {
+		rows = PivotRowsCategory
+	}
+	if rows != PivotRowsCategory {
+		return model.PivotReport{}, errors.NewFieldValidationError("rows", "rows must be category")
+	}
+	if cols == "" {
+		cols = PivotColsMonth
+	}
+	if cols != PivotColsMonth && cols != PivotColsQuarter && cols != PivotColsYear {
+		return model.PivotReport{}, errors.NewFieldValidationError("cols", "cols must be month, quarter or year")
+	}
+	flowType, err := normalizeFlowType(flowType)
+	if err != nil {
+		return model.PivotReport{}, err
+	}
+	from, to, err := parseReportRange(fromText, toText, time.Now().In(util.GetTimezone()))
+	if err != nil {
+		return model.PivotReport{}, err
+	}
+
+	// The mapper includes both days
+	cashFlowList := cash_flow_mapper.INSTANCE.GetCashFlowsByDateRange(from, to.AddDate(0, 0, -1))
+	categoryTree, err := category_service.TreeService(0, "")
+	if err != nil {
+		return model.PivotReport{}, err
+	}
+	return buildPivot(cols, flowType, from, to, cashFlowList, categoryTree), nil
+}
+
+// normalizeFlowType accepts INCOME, or OUTCOME and its alias EXPENSE, in any case; OUTCOME by default
+func normalizeFlowType(flowType string) (string, error) {
+	switch strings.ToUpper(flowType) {
+	case "", model.FlowTypeOutcome, "EXPENSE":
+		return model.FlowTypeOutcome, nil
+	case model.FlowTypeIncome:
+		return model.FlowTypeIncome, nil
+	default:
+		return "", errors.NewFieldValidationError("type", "type must be INCOME or OUTCOME")
+	}
+}
+
+// parseReportRange returns the first day of fromText and the day after toText. A missing end is
+// the period holding now, and a missing start is 11 months before the month of the end.
+func parseReportRange(fromText, toText string, now time.Time) (time.Time, time.Time, error) {
+	var to time.Time
+	if toText == "" {
+		to = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	} else {
+		var ok bool
+		if _, to, ok = query.ParsePeriod(toText); !ok {
+			return time.Time{}, time.Time{}, errors.NewFieldValidationError("to", "invalid date "+toText)
+		}
+	}
+
+	var from time.Time
+	if fromText == "" {
+		last := to.AddDate(0, 0, -1)
+		from = time.Date(last.Year(), last.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -11, 0)
+	} else {
+		var ok bool
+		if from, _, ok = query.ParsePeriod(fromText); !ok {
+			return time.Time{}, time.Time{}, errors.NewFieldValidationError("from", "invalid date "+fromText)
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.NewFieldValidationError("from", "from should be before to")
+	}
+	return from, to, nil
+}
+
+// periodStart returns the first day of the column holding date
+func periodStart(cols string, date time.Time) time.Time {
+	switch cols {
+	case PivotColsYear:
+		return time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	case PivotColsQuarter:
+		month := time.Month((int(date.Month())-1)/3*3 + 1)
+		return time.Date(date.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// nextPeriod returns the first day of the column after the one starting at start
+func nextPeriod(cols string, start time.Time) time.Time {
+	switch cols {
+	case PivotColsYear:
+		return start.AddDate(1, 0, 0)
+	case PivotColsQuarter:
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// periodName names the column starting at start, e.g. 2026-03, 2026-Q1 or 2026
+func periodName(cols string, start time.Time) string {
+	switch cols {
+	case PivotColsYear:
+		return start.Format("2006")
+	case PivotColsQuarter:
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	default:
+		return start.Format("2006-01")
+	}
+}
+
+// buildPivot lays out the cash flows of flowType between from and the day before to,
+// rolling each category up into the ones above it in the tree
+func buildPivot(cols, flowType string, from, to time.Time, cashFlowList []model.CashFlowEntity, categoryTree []model.CategoryTreeNode) model.PivotReport {
+	report := model.PivotReport{
+		Rows:  PivotRowsCategory,
+		Cols:  cols,
+		Type:  flowType,
+		From:  util.FormatDateToStringWithDash(from),
+		To:    util.FormatDateToStringWithDash(to.AddDate(0, 0, -1)),
+		Items: []model.PivotRow{},
+	}
+	columnIndexMap := make(map[string]int)
+	for start := periodStart(cols, from); start.Before(to); start = nextPeriod(cols, start) {
+		name := periodName(cols, start)
+		columnIndexMap[name] = len(report.Columns)
+		report.Columns = append(report.Columns, name)
+	}
+
+	cellMap := make(map[primitive.ObjectID]*pivotCell)
+	for _, cashFlow := range cashFlowList {
+		if (cashFlow.FlowType == model.FlowTypeIncome) != (flowType == model.FlowTypeIncome) {
+			continue
+		}
+		index, ok := columnIndexMap[periodName(cols, periodStart(cols, cashFlow.BelongsDate))]
+		if !ok {
+			continue
+		}
+		cell := cellMap[cashFlow.CategoryId]
+		if cell == nil {
+			cell = &pivotCell{values: make([]model.Money, len(report.Columns))}
+			cellMap[cashFlow.CategoryId] = cell
+		}
+		cell.values[index] = cell.values[index].Add(cashFlow.Amount)
+		cell.count++
+	}
+
+	for _, root := range sortedTreeNodes(categoryTree) {
+		report.Items = append(report.Items, pivotRows(root, "", 0, cellMap, len(report.Columns))...)
+	}
+
+	// What is left belongs to no category of the tree
+	uncategorized := pivotCell{values: make([]model.Money, len(report.Columns))}
+	for _, cell := range cellMap {
+		for index, value := range cell.values {
+			uncategorized.values[index] = uncategorized.values[index].Add(value)
+		}
+		uncategorized.count += cell.count
+	}
+	if uncategorized.count > 0 {
+		report.Items = append(report.Items, newPivotRow("", UncategorizedName, UncategorizedName, 0, uncategorized))
+	}
+
+	report.Totals = make([]model.Money, len(report.Columns))
+	for _, row := range report.Items {
+		if row.Depth != 0 {
+			continue
+		}
+		for index, value := range row.Values {
+			report.Totals[index] = report.Totals[index].Add(value)
+		}
+		report.GrandTotal = report.GrandTotal.Add(row.Total)
+	}
+	report.Average = report.GrandTotal.DivInt(int64(len(report.Columns))).Round(model.MoneyDisplayScale)
+	return report
+}
+
+// pivotRows returns the row of node followed by the rows below it, leaving out categories without
+// cash flows; the cells it uses are taken out of cellMap
+func pivotRows(node model.CategoryTreeNode, parentPath string, depth int, cellMap map[primitive.ObjectID]*pivotCell, columnCount int) []model.PivotRow {
+	path := node.Name
+	if parentPath != "" {
+		path = parentPath + "/" + node.Name
+	}
+
+	rolledUp := pivotCell{values: make([]model.Money, columnCount)}
+	if cell := cellMap[node.Id]; cell != nil {
+		copy(rolledUp.values, cell.values)
+		rolledUp.count = cell.count
+		delete(cellMap, node.Id)
+	}
+
+	var childRowList []model.PivotRow
+	for _, child := range sortedTreeNodes(node.Children) {
+		rowList := pivotRows(child, path, depth+1, cellMap, columnCount)
+		if len(rowList) == 0 {
+			continue
+		}
+		for index, value := range rowList[0].Values {
+			rolledUp.values[index] = rolledUp.values[index].Add(value)
+		}
+		rolledUp.count += rowList[0].Count
+		childRowList = append(childRowList, rowList...)
+	}
+
+	if rolledUp.count == 0 {
+		return nil
+	}
+	return append([]model.PivotRow{newPivotRow(node.Id.Hex(), node.Name, path, depth, rolledUp)}, childRowList...)
+}
+
+func newPivotRow(categoryId, name, path string, depth int, cell pivotCell) model.PivotRow {
+	row := model.PivotRow{
+		CategoryId: categoryId,
+		Name:       name,
+		Path:       path,
+		Depth:      depth,
+		Values:     cell.values,
+		Count:      cell.count,
+	}
+	for _, value := range cell.values {
+		row.Total = row.Total.Add(value)
+	}
+	row.Average = row.Total.DivInt(int64(len(cell.values))).Round(model.MoneyDisplayScale)
+	return row
+}
+
+// sortedTreeNodes orders categories by name, since the tree comes in no particular order
+func sortedTreeNodes(nodeList []model.CategoryTreeNode) []model.CategoryTreeNode {
+	sortedList := append([]model.CategoryTreeNode(nil), nodeList...)
+	sort.Slice(sortedList, func(i, j int) bool {
+		return strings.ToLower(sortedList[i].Name) < strings.ToLower(sortedList[j].Name)
+	})
+	return sortedList
+}
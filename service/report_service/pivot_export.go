@@ -0,0 +1,170 @@
+package report_service
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/xuri/excelize/v2"
+)
+
+const pivotSheetName = "pivot"
+
+// pivotHeader returns the title row of a pivot report: the category, a column per period, total and average
+func pivotHeader(report model.PivotReport) []string {
+	header := append([]string{"Category"}, report.Columns...)
+	return append(header, "Total", "Average")
+}
+
+// WritePivotCsv writes a pivot report as CSV, naming categories by their full path and ending with the totals
+func WritePivotCsv(w io.Writer, report model.PivotReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(pivotHeader(report)); err != nil {
+		return err
+	}
+	for _, row := range report.Items {
+		if err := writer.Write(pivotCsvRecord(row.Path, row.Values, row.Total, row.Average)); err != nil {
+			return err
+		}
+	}
+	if err := writer.Write(pivotCsvRecord("Total", report.Totals, report.GrandTotal, report.Average)); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func pivotCsvRecord(label string, valueList []model.Money, total, average model.Money) []string {
+	record := []string{label}
+	for _, value := range valueList {
+		record = append(record, value.String())
+	}
+	return append(record, total.String(), average.String())
+}
+
+// pivotStyles are the cell styles of the pivot sheet
+type pivotStyles struct {
+	header      int
+	categoryTop int
+	numberTop   int
+	number      int
+	totalLabel  int
+	totalNumber int
+}
+
+func newPivotStyles(file *excelize.File) (pivotStyles, error) {
+	var styles pivotStyles
+	var err error
+	amountFormat := "#,##0.00"
+	topBorder := []excelize.Border{{Type: "top", Color: "000000", Style: 1}}
+
+	styleList := []struct {
+		target *int
+		style  *excelize.Style
+	}{
+		{&styles.header, &excelize.Style{
+			Font:      &excelize.Font{Bold: true},
+			Fill:      excelize.Fill{Type: "pattern", Color: []string{"DDEBF7"}, Pattern: 1},
+			Alignment: &excelize.Alignment{Horizontal: "center"},
+		}},
+		{&styles.categoryTop, &excelize.Style{Font: &excelize.Font{Bold: true}}},
+		{&styles.numberTop, &excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: &amountFormat}},
+		{&styles.number, &excelize.Style{CustomNumFmt: &amountFormat}},
+		{&styles.totalLabel, &excelize.Style{Font: &excelize.Font{Bold: true}, Border: topBorder}},
+		{&styles.totalNumber, &excelize.Style{Font: &excelize.Font{Bold: true}, Border: topBorder, CustomNumFmt: &amountFormat}},
+	}
+	for _, item := range styleList {
+		if *item.target, err = file.NewStyle(item.style); err != nil {
+			return pivotStyles{}, err
+		}
+	}
+	return styles, nil
+}
+
+// WritePivotXlsx writes a pivot report as a formatted spreadsheet: categories indented under their
+// parents, top level categories and the totals in bold, amounts as numbers and the header frozen
+func WritePivotXlsx(w io.Writer, report model.PivotReport) error {
+	file := excelize.NewFile()
+	defer file.Close()
+	if err := file.SetSheetName("Sheet1", pivotSheetName); err != nil {
+		return err
+	}
+	styles, err := newPivotStyles(file)
+	if err != nil {
+		return err
+	}
+
+	header := pivotHeader(report)
+	lastColumn, _ := excelize.ColumnNumberToName(len(header))
+	for index, title := range header {
+		cell, _ := excelize.CoordinatesToCellName(index+1, 1)
+		if err := file.SetCellValue(pivotSheetName, cell, title); err != nil {
+			return err
+		}
+	}
+	if err := file.SetCellStyle(pivotSheetName, "A1", lastColumn+"1", styles.header); err != nil {
+		return err
+	}
+
+	indentStyleMap := make(map[int]int)
+	rowNumber := 1
+	for _, row := range report.Items {
+		rowNumber++
+		labelStyle, numberStyle := 0, styles.number
+		if row.Depth == 0 {
+			labelStyle, numberStyle = styles.categoryTop, styles.numberTop
+		}
+		if row.Depth > 0 {
+			if _, ok := indentStyleMap[row.Depth]; !ok {
+				style := &excelize.Style{Alignment: &excelize.Alignment{Indent: row.Depth * 2}}
+				if indentStyleMap[row.Depth], err = file.NewStyle(style); err != nil {
+					return err
+				}
+			}
+			labelStyle = indentStyleMap[row.Depth]
+		}
+		if err := writePivotXlsxRow(file, rowNumber, row.Name, row.Values, row.Total, row.Average, labelStyle, numberStyle); err != nil {
+			return err
+		}
+	}
+	rowNumber++
+	if err := writePivotXlsxRow(file, rowNumber, "Total", report.Totals, report.GrandTotal, report.Average, styles.totalLabel, styles.totalNumber); err != nil {
+		return err
+	}
+
+	if err := file.SetColWidth(pivotSheetName, "A", "A", 32); err != nil {
+		return err
+	}
+	if err := file.SetColWidth(pivotSheetName, "B", lastColumn, 14); err != nil {
+		return err
+	}
+	if err := file.SetPanes(pivotSheetName, &excelize.Panes{
+		Freeze: true, XSplit: 1, YSplit: 1, TopLeftCell: "B2", ActivePane: "bottomRight",
+	}); err != nil {
+		return err
+	}
+	return file.Write(w)
+}
+
+func writePivotXlsxRow(file *excelize.File, rowNumber int, label string, valueList []model.Money, total, average model.Money, labelStyle, numberStyle int) error {
+	labelCell, _ := excelize.CoordinatesToCellName(1, rowNumber)
+	if err := file.SetCellValue(pivotSheetName, labelCell, label); err != nil {
+		return err
+	}
+	if labelStyle != 0 {
+		if err := file.SetCellStyle(pivotSheetName, labelCell, labelCell, labelStyle); err != nil {
+			return err
+		}
+	}
+
+	amountList := append(append([]model.Money(nil), valueList...), total, average)
+	for index, amount := range amountList {
+		cell, _ := excelize.CoordinatesToCellName(index+2, rowNumber)
+		if err := file.SetCellValue(pivotSheetName, cell, amount.Float64()); err != nil {
+			return err
+		}
+	}
+	firstCell, _ := excelize.CoordinatesToCellName(2, rowNumber)
+	lastCell, _ := excelize.CoordinatesToCellName(len(amountList)+1, rowNumber)
+	return file.SetCellStyle(pivotSheetName, firstCell, lastCell, numberStyle)
+}
@@ -0,0 +1,171 @@
+package report_service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util/testutil"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newCategoryNode(name string, children ...model.CategoryTreeNode) model.CategoryTreeNode {
+	node := model.NewCategoryTreeNode(model.CategoryEntity{Id: primitive.NewObjectID(), Name: name})
+	node.Children = append(node.Children, children...)
+	return node
+}
+
+// samplePivot covers January to March 2026 with Food > Groceries, Food > Dining and Rent
+func samplePivot() model.PivotReport {
+	groceries := newCategoryNode("Groceries")
+	dining := newCategoryNode("Dining")
+	food := newCategoryNode("Food", groceries, dining)
+	rent := newCategoryNode("Rent")
+	salary := newCategoryNode("Salary")
+	gone := newCategoryNode("Gone")
+
+	cashFlowList := []model.CashFlowEntity{
+		testutil.NewCashFlow(groceries.Id, "2026-01-03", model.FlowTypeOutcome, "", 40),
+		testutil.NewCashFlow(groceries.Id, "2026-02-10", model.FlowTypeOutcome, "", 60),
+		testutil.NewCashFlow(dining.Id, "2026-01-20", "EXPENSE", "", 30),
+		testutil.NewCashFlow(food.Id, "2026-03-01", model.FlowTypeOutcome, "", 5),
+		testutil.NewCashFlow(rent.Id, "2026-01-01", model.FlowTypeOutcome, "", 1000),
+		testutil.NewCashFlow(salary.Id, "2026-01-25", model.FlowTypeIncome, "", 3000),
+		testutil.NewCashFlow(gone.Id, "2026-02-02", model.FlowTypeOutcome, "", 7),
+	}
+	categoryTree := []model.CategoryTreeNode{rent, food, salary}
+	return buildPivot(PivotColsMonth, model.FlowTypeOutcome, testutil.Day("2026-01-01"), testutil.Day("2026-04-01"), cashFlowList, categoryTree)
+}
+
+func TestBuildPivot(t *testing.T) {
+	report := samplePivot()
+
+	if strings.Join(report.Columns, ",") != "2026-01,2026-02,2026-03" {
+		t.Fatalf("unexpected columns %v", report.Columns)
+	}
+	if report.From != "2026-01-01" || report.To != "2026-03-31" {
+		t.Errorf("unexpected range %s to %s", report.From, report.To)
+	}
+
+	var pathList []string
+	for _, row := range report.Items {
+		pathList = append(pathList, row.Path)
+	}
+	expectedPaths := "Food,Food/Dining,Food/Groceries,Rent," + UncategorizedName
+	if strings.Join(pathList, ",") != expectedPaths {
+		t.Fatalf("expected rows %s, got %v", expectedPaths, pathList)
+	}
+
+	food := report.Items[0]
+	if food.Values[0].String() != "70.00" || food.Values[1].String() != "60.00" || food.Values[2].String() != "5.00" {
+		t.Errorf("expected Food to include its children, got %v", food.Values)
+	}
+	if food.Total.String() != "135.00" || food.Average.String() != "45.00" || food.Count != 4 {
+		t.Errorf("unexpected Food total %s, average %s, count %d", food.Total, food.Average, food.Count)
+	}
+	if report.Items[1].Depth != 1 || report.Items[1].Total.String() != "30.00" {
+		t.Errorf("unexpected Dining row %+v", report.Items[1])
+	}
+
+	if report.Totals[0].String() != "1070.00" || report.Totals[1].String() != "67.00" || report.Totals[2].String() != "5.00" {
+		t.Errorf("expected column totals over top level rows only, got %v", report.Totals)
+	}
+	if report.GrandTotal.String() != "1142.00" || report.Average.String() != "380.67" {
+		t.Errorf("unexpected grand total %s and average %s", report.GrandTotal, report.Average)
+	}
+}
+
+func TestBuildPivot_Quarters(t *testing.T) {
+	salary := newCategoryNode("Salary")
+	cashFlowList := []model.CashFlowEntity{
+		testutil.NewCashFlow(salary.Id, "2025-02-25", model.FlowTypeIncome, "", 100),
+		testutil.NewCashFlow(salary.Id, "2025-03-25", model.FlowTypeIncome, "", 100),
+		testutil.NewCashFlow(salary.Id, "2025-11-25", model.FlowTypeIncome, "", 100),
+	}
+	report := buildPivot(PivotColsQuarter, model.FlowTypeIncome, testutil.Day("2025-01-01"), testutil.Day("2026-01-01"),
+		cashFlowList, []model.CategoryTreeNode{salary})
+
+	if strings.Join(report.Columns, ",") != "2025-Q1,2025-Q2,2025-Q3,2025-Q4" {
+		t.Fatalf("unexpected columns %v", report.Columns)
+	}
+	if report.Items[0].Values[0].String() != "200.00" || report.Items[0].Values[3].String() != "100.00" {
+		t.Errorf("unexpected quarters %v", report.Items[0].Values)
+	}
+}
+
+func TestParseReportRange(t *testing.T) {
+	now := testutil.Day("2026-10-19")
+
+	from, to, err := parseReportRange("", "", now)
+	if err != nil || !from.Equal(testutil.Day("2025-11-01")) || !to.Equal(testutil.Day("2026-11-01")) {
+		t.Errorf("expected the last 12 months by default, got %v to %v, %v", from, to, err)
+	}
+	from, to, err = parseReportRange("2026-01", "2026-06", now)
+	if err != nil || !from.Equal(testutil.Day("2026-01-01")) || !to.Equal(testutil.Day("2026-07-01")) {
+		t.Errorf("expected whole months, got %v to %v, %v", from, to, err)
+	}
+	from, to, err = parseReportRange("2025", "2025", now)
+	if err != nil || !from.Equal(testutil.Day("2025-01-01")) || !to.Equal(testutil.Day("2026-01-01")) {
+		t.Errorf("expected the whole year, got %v to %v, %v", from, to, err)
+	}
+
+	for _, pair := range [][2]string{{"2026-07", "2026-06"}, {"someday", ""}, {"", "2026-13"}} {
+		if _, _, err := parseReportRange(pair[0], pair[1], now); err == nil {
+			t.Errorf("%v: expected an error", pair)
+		}
+	}
+}
+
+func TestPivotService_InvalidParameters(t *testing.T) {
+	for _, parameters := range [][5]string{
+		{"month", "category", "", "", ""},
+		{"category", "week", "", "", ""},
+		{"category", "month", "", "", "transfer"},
+	} {
+		if _, err := PivotService(parameters[0], parameters[1], parameters[2], parameters[3], parameters[4]); err == nil {
+			t.Errorf("%v: expected an error", parameters)
+		}
+	}
+}
+
+func TestWritePivotCsv(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WritePivotCsv(&buffer, samplePivot()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	lineList := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if lineList[0] != "Category,2026-01,2026-02,2026-03,Total,Average" {
+		t.Errorf("unexpected header %s", lineList[0])
+	}
+	if lineList[2] != "Food/Dining,30.00,0.00,0.00,30.00,10.00" {
+		t.Errorf("unexpected row %s", lineList[2])
+	}
+	if lineList[len(lineList)-1] != "Total,1070.00,67.00,5.00,1142.00,380.67" {
+		t.Errorf("unexpected totals %s", lineList[len(lineList)-1])
+	}
+}
+
+func TestWritePivotXlsx(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WritePivotXlsx(&buffer, samplePivot()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	file, err := excelize.OpenReader(&buffer)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer file.Close()
+	rowList, err := file.GetRows(pivotSheetName)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(rowList) != 7 || rowList[2][0] != "Dining" || rowList[6][0] != "Total" {
+		t.Errorf("unexpected rows %v", rowList)
+	}
+	if rowList[6][4] != "1,142.00" {
+		t.Errorf("expected formatted amounts, got %s", rowList[6][4])
+	}
+}
@@ -0,0 +1,120 @@
+package revision_service
+
+import (
+	"sort"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/revision_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CashFlowFields returns the fields of a cash flow tracked in its history
+func CashFlowFields(entity model.CashFlowEntity) map[string]string {
+	return map[string]string{
+		"belongs_date": util.FormatDateToStringWithDash(entity.BelongsDate),
+		"category_id":  entity.CategoryId.Hex(),
+		"flow_type":    entity.FlowType,
+		"amount":       entity.Amount.String(),
+		"description":  entity.Description,
+		"remark":       entity.Remark,
+	}
+}
+
+// CategoryFields returns the fields of a category tracked in its history
+func CategoryFields(entity model.CategoryEntity) map[string]string {
+	return map[string]string{
+		"parent_id": entity.ParentId.Hex(),
+		"name":      entity.Name,
+		"type":      entity.Type,
+		"remark":    entity.Remark,
+	}
+}
+
+// Diff lists the fields that differ between before and after, by field name
+// A nil before, as for a create, reports every field of after
+func Diff(before, after map[string]string) []model.RevisionChange {
+	fieldList := make([]string, 0, len(after))
+	for field := range after {
+		fieldList = append(fieldList, field)
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			fieldList = append(fieldList, field)
+		}
+	}
+	sort.Strings(fieldList)
+
+	changeList := []model.RevisionChange{}
+	for _, field := range fieldList {
+		if before[field] != after[field] {
+			changeList = append(changeList, model.RevisionChange{Field: field, Before: before[field], After: after[field]})
+		}
+	}
+	return changeList
+}
+
+// RecordChange appends a revision holding the fields that differ between before and after
+// An update that changed nothing is not recorded. A failure is logged and does not undo the change.
+func RecordChange(entityType string, entityId primitive.ObjectID, action string,
+	before, after map[string]string, origin model.ChangeOrigin) {
+	changeList := Diff(before, after)
+	if action == model.RevisionActionUpdate && len(changeList) == 0 {
+		return
+	}
+	insertRevision(model.NewRevision(entityType, entityId, action, changeList, origin))
+}
+
+// RecordRevert appends the revision of a revert back to revertedTo
+func RecordRevert(entityType string, entityId primitive.ObjectID, revertedTo string,
+	before, after map[string]string, origin model.ChangeOrigin) {
+	revision := model.NewRevision(entityType, entityId, model.RevisionActionRevert, Diff(before, after), origin)
+	revision.RevertedTo = revertedTo
+	insertRevision(revision)
+}
+
+func insertRevision(revision model.RevisionEntity) {
+	if err := revision_mapper.INSTANCE.InsertRevision(revision); err != nil {
+		util.Logger.Errorw("record revision failed",
+			"entity_type", revision.EntityType, "entity_id", revision.EntityId.Hex(), "error", err)
+	}
+}
+
+// GetHistory returns the revisions of an entity, oldest first
+func GetHistory(entityType, plainEntityId string) ([]model.RevisionEntity, error) {
+	revisionList, err := revision_mapper.INSTANCE.GetRevisionsByEntity(entityType, plainEntityId)
+	if err != nil {
+		return nil, errors.NewDatabaseError("query revisions failed", err)
+	}
+	if revisionList == nil {
+		revisionList = []model.RevisionEntity{}
+	}
+	return revisionList, nil
+}
+
+// StateAt rewinds the current fields of an entity to how they were right after the given revision
+// by undoing, newest first, every revision recorded after it
+func StateAt(current map[string]string, history []model.RevisionEntity, plainRevisionId string) (map[string]string, error) {
+	targetIndex := -1
+	for index, revision := range history {
+		if revision.Id.Hex() == plainRevisionId {
+			targetIndex = index
+			break
+		}
+	}
+	if targetIndex < 0 {
+		return nil, errors.NewNotFoundError("revision " + plainRevisionId + " not found in history")
+	}
+
+	state := make(map[string]string, len(current))
+	for field, value := range current {
+		state[field] = value
+	}
+	for index := len(history) - 1; index > targetIndex; index-- {
+		for _, change := range history[index].Changes {
+			state[change.Field] = change.Before
+		}
+	}
+	return state, nil
+}
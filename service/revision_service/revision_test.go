@@ -0,0 +1,74 @@
+package revision_service
+
+import (
+	"testing"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]string{"amount": "12.5", "description": "lunch", "remark": ""}
+	after := map[string]string{"amount": "13", "description": "lunch", "remark": "team"}
+
+	changeList := Diff(before, after)
+	if len(changeList) != 2 {
+		t.Fatalf("expected 2 changes, got %v", changeList)
+	}
+	if changeList[0] != (model.RevisionChange{Field: "amount", Before: "12.5", After: "13"}) {
+		t.Errorf("unexpected first change %v", changeList[0])
+	}
+	if changeList[1] != (model.RevisionChange{Field: "remark", Before: "", After: "team"}) {
+		t.Errorf("unexpected second change %v", changeList[1])
+	}
+
+	if created := Diff(nil, after); len(created) != 3 {
+		t.Errorf("a create should report every field, got %v", created)
+	}
+	if unchanged := Diff(after, after); unchanged == nil || len(unchanged) != 0 {
+		t.Errorf("expected an empty change list, got %v", unchanged)
+	}
+}
+
+func TestStateAt(t *testing.T) {
+	newRevision := func(changes ...model.RevisionChange) model.RevisionEntity {
+		return model.RevisionEntity{Id: primitive.NewObjectID(), Changes: changes}
+	}
+	history := []model.RevisionEntity{
+		newRevision(model.RevisionChange{Field: "amount", Before: "", After: "10"},
+			model.RevisionChange{Field: "description", Before: "", After: "lunch"}),
+		newRevision(model.RevisionChange{Field: "amount", Before: "10", After: "12"}),
+		newRevision(),
+		newRevision(model.RevisionChange{Field: "amount", Before: "12", After: "15"},
+			model.RevisionChange{Field: "description", Before: "lunch", After: "dinner"}),
+	}
+	current := map[string]string{"amount": "15", "description": "dinner"}
+
+	cases := []struct {
+		index int
+		want  map[string]string
+	}{
+		{0, map[string]string{"amount": "10", "description": "lunch"}},
+		{1, map[string]string{"amount": "12", "description": "lunch"}},
+		{2, map[string]string{"amount": "12", "description": "lunch"}},
+		{3, current},
+	}
+	for _, c := range cases {
+		state, err := StateAt(current, history, history[c.index].Id.Hex())
+		if err != nil {
+			t.Fatalf("revision %d: %v", c.index, err)
+		}
+		for field, value := range c.want {
+			if state[field] != value {
+				t.Errorf("revision %d: expected %s=%q, got %q", c.index, field, value, state[field])
+			}
+		}
+	}
+	if current["amount"] != "15" {
+		t.Error("StateAt should not modify the current fields")
+	}
+
+	if _, err := StateAt(current, history, primitive.NewObjectID().Hex()); err == nil {
+		t.Error("an unknown revision should be rejected")
+	}
+}
@@ -0,0 +1,34 @@
+package util
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// ActorHeader names the caller of an API request in the change history
+const ActorHeader = "X-Actor"
+
+// GetCLIActor returns the operating system user running the command
+func GetCLIActor() string {
+	if currentUser, err := user.Current(); err == nil && currentUser.Username != "" {
+		return currentUser.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// GetRequestActor returns the caller of an API request: the X-Actor header, or else the client address
+func GetRequestActor(r *http.Request) string {
+	if actor := strings.TrimSpace(r.Header.Get(ActorHeader)); actor != "" {
+		return actor
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
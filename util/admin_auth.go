@@ -23,7 +23,7 @@ func VerifyAdminToken(token string) error {
 
 	// Check if provided token matches configured token
 	if token == "" || token != adminToken {
-		Logger.Errorw("Invalid ADMIN_TOKEN provided", "provided_token", token)
+		Logger.Errorw("Invalid ADMIN_TOKEN provided", "token_provided", token != "")
 		return AdminTokenError
 	}
 
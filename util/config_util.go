@@ -68,6 +68,73 @@ func initDefaultValues() {
 	}
 	configurationMap["api.schema.validation"] = schemaValidation
 
+	// Refuse to start the server when migrations are pending: true/false
+	schemaVerify := os.Getenv("SCHEMA_VERIFY")
+	if schemaVerify == "" {
+		schemaVerify = "false"
+	}
+	configurationMap["db.schema.verify"] = schemaVerify
+
+	// Backup encoding: compression none/gzip/zstd, encryption when a passphrase is set
+	backupCompression := os.Getenv("BACKUP_COMPRESSION")
+	if backupCompression == "" {
+		backupCompression = "none"
+	}
+	configurationMap["backup.compression"] = backupCompression
+	configurationMap["backup.passphrase"] = os.Getenv("BACKUP_PASSPHRASE")
+
+	// Scheduled backups inside `server start`: cron expression (empty disables), output directory
+	// and grandfather-father-son retention (daily/weekly/monthly copies to keep)
+	configurationMap["backup.schedule"] = os.Getenv("BACKUP_SCHEDULE")
+	backupDir := os.Getenv("BACKUP_DIR")
+	if backupDir == "" {
+		backupDir = "./backups"
+	}
+	configurationMap["backup.dir"] = backupDir
+	keepDaily := os.Getenv("BACKUP_KEEP_DAILY")
+	if keepDaily == "" {
+		keepDaily = "7"
+	}
+	configurationMap["backup.keep.daily"] = keepDaily
+	keepWeekly := os.Getenv("BACKUP_KEEP_WEEKLY")
+	if keepWeekly == "" {
+		keepWeekly = "4"
+	}
+	configurationMap["backup.keep.weekly"] = keepWeekly
+	keepMonthly := os.Getenv("BACKUP_KEEP_MONTHLY")
+	if keepMonthly == "" {
+		keepMonthly = "12"
+	}
+	configurationMap["backup.keep.monthly"] = keepMonthly
+
+	// Deleted cash flows stay in the trash this many days before they are purged (0 keeps them forever)
+	trashRetentionDays := os.Getenv("TRASH_RETENTION_DAYS")
+	if trashRetentionDays == "" {
+		trashRetentionDays = "30"
+	}
+	configurationMap["trash.retention.days"] = trashRetentionDays
+
+	// Responses to requests with an Idempotency-Key header are replayed for this many hours
+	idempotencyWindowHours := os.Getenv("IDEMPOTENCY_WINDOW_HOURS")
+	if idempotencyWindowHours == "" {
+		idempotencyWindowHours = "24"
+	}
+	configurationMap["idempotency.window.hours"] = idempotencyWindowHours
+
+	// POST /api/cash/batch and cash batch refuse more operations than this in one call
+	batchMaxSize := os.Getenv("BATCH_MAX_SIZE")
+	if batchMaxSize == "" {
+		batchMaxSize = "500"
+	}
+	configurationMap["batch.max.size"] = batchMaxSize
+
+	// Run anomaly detection over the imported dates after each import: true/false
+	insightsAfterImport := os.Getenv("INSIGHTS_AFTER_IMPORT")
+	if insightsAfterImport == "" {
+		insightsAfterImport = "false"
+	}
+	configurationMap["insights.after.import"] = insightsAfterImport
+
 	// CORS origins
 	corsOrigins := os.Getenv("CORS_ORIGINS")
 	configurationMap["cors.origins"] = corsOrigins
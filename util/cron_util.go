@@ -0,0 +1,167 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression
+// Supports the five standard fields (minute hour day-of-month month day-of-week) with
+// "*", lists, ranges and steps, the descriptors @hourly/@daily/@weekly/@monthly/@yearly,
+// and "@every <duration>" for fixed intervals.
+type CronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	dayOfMonthAny, dayOfWeekAny                bool
+	every                                      time.Duration
+}
+
+// cronSearchLimit bounds Next for expressions that can never match (e.g. "0 0 31 2 *")
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// ParseCronSchedule parses a cron expression such as "30 2 * * *" or "@every 6h"
+func ParseCronSchedule(expression string) (*CronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if strings.HasPrefix(expression, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expression, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron interval %q: %w", expression, err)
+		}
+		if every < time.Minute {
+			return nil, fmt.Errorf("cron interval %q must be at least one minute", expression)
+		}
+		return &CronSchedule{every: every}, nil
+	}
+	if standard, isExist := cronDescriptors[expression]; isExist {
+		expression = standard
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expression, len(fields))
+	}
+
+	schedule := &CronSchedule{
+		dayOfMonthAny: fields[2] == "*",
+		dayOfWeekAny:  fields[4] == "*",
+	}
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid cron minute: %w", err)
+	}
+	if schedule.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid cron hour: %w", err)
+	}
+	if schedule.dayOfMonth, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid cron day of month: %w", err)
+	}
+	if schedule.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid cron month: %w", err)
+	}
+	if schedule.dayOfWeek, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid cron day of week: %w", err)
+	}
+	// Both 0 and 7 mean Sunday
+	if schedule.dayOfWeek&(1<<7) != 0 {
+		schedule.dayOfWeek |= 1
+	}
+	return schedule, nil
+}
+
+// parseCronField turns one field into a bit set of the allowed values
+func parseCronField(field string, minValue, maxValue int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			parsedStep, err := strconv.Atoi(part[slash+1:])
+			if err != nil || parsedStep <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:slash], parsedStep
+		}
+
+		low, high := minValue, maxValue
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var errLow, errHigh error
+			low, errLow = strconv.Atoi(bounds[0])
+			high, errHigh = strconv.Atoi(bounds[1])
+			if errLow != nil || errHigh != nil || low > high {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			low = value
+			// "5/15" means from 5 to the maximum every 15
+			if step == 1 {
+				high = value
+			}
+		}
+		if low < minValue || high > maxValue {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, minValue, maxValue)
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first activation strictly after the given time, in the same location
+// Returns the zero time when the expression never matches
+func (schedule *CronSchedule) Next(after time.Time) time.Time {
+	if schedule.every > 0 {
+		return after.Add(schedule.every)
+	}
+
+	location := after.Location()
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchLimit)
+	for next.Before(limit) {
+		if schedule.month&(1<<uint(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !schedule.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+		if schedule.hour&(1<<uint(next.Hour())) == 0 {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+		if schedule.minute&(1<<uint(next.Minute())) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+// matchesDay follows cron semantics: when both day fields are restricted, either may match
+func (schedule *CronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonthMatch := schedule.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeekMatch := schedule.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if schedule.dayOfMonthAny || schedule.dayOfWeekAny {
+		return dayOfMonthMatch && dayOfWeekMatch
+	}
+	return dayOfMonthMatch || dayOfWeekMatch
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule_Next(t *testing.T) {
+	// 2024-05-01 is a Wednesday
+	base := time.Date(2024, 5, 1, 10, 17, 30, 0, time.UTC)
+
+	cases := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 1, 10, 18, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2024, 5, 2, 2, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 6h", base.Add(6 * time.Hour)},
+		// Day of month OR day of week when both are restricted: the 13th or any Friday
+		{"0 0 13 * 5", time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range cases {
+		schedule, err := ParseCronSchedule(testCase.expression)
+		if err != nil {
+			t.Errorf("ParseCronSchedule(%q) failed: %v", testCase.expression, err)
+			continue
+		}
+		if next := schedule.Next(base); !next.Equal(testCase.expected) {
+			t.Errorf("Next(%q) = %v, expected %v", testCase.expression, next, testCase.expected)
+		}
+	}
+}
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 10s",
+		"@every soon",
+	} {
+		if _, err := ParseCronSchedule(expression); err == nil {
+			t.Errorf("ParseCronSchedule(%q) should fail", expression)
+		}
+	}
+}
+
+func TestCronSchedule_NeverMatches(t *testing.T) {
+	schedule, err := ParseCronSchedule("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("ParseCronSchedule failed: %v", err)
+	}
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Errorf("expected zero time for an impossible date, got %v", next)
+	}
+}
@@ -15,8 +15,15 @@ var (
 )
 
 var (
-	CashFlowTableName = "cash_flows"
-	CategoryTableName = "categories"
+	CashFlowTableName        = "cash_flows"
+	CategoryTableName        = "categories"
+	SchemaMigrationTableName = "schema_migrations"
+	TombstoneTableName       = "tombstones"
+	RevisionTableName        = "revisions"
+	AuditTableName           = "audit_log"
+	IdempotencyTableName     = "idempotency_keys"
+	GoalTableName            = "goals"
+	DebtTableName            = "debts"
 )
 
 func initMongoDbConnection() {
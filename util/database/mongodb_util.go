@@ -66,6 +66,16 @@ func GetMongoCollection(collectionName string) *mongo.Collection {
 	return mongoDatabase.Collection(collectionName)
 }
 
+// GetMongoDatabase returns the database handle from the connection pool, for database-level commands
+func GetMongoDatabase() *mongo.Database {
+	if mongoClient == nil || mongoDatabase == nil {
+		if err := InitMongoDbConnection(); err != nil {
+			log.Fatal("Failed to initialize MongoDB connection:", err)
+		}
+	}
+	return mongoDatabase
+}
+
 // OpenMongoDbConnection sets the current collection (for backward compatibility)
 // Deprecated: Use GetMongoCollection instead
 func OpenMongoDbConnection(collectionName string) {
@@ -178,6 +188,15 @@ func UpdateManyInMongoDB(filter, data bson.D) int64 {
 	return result.ModifiedCount
 }
 
+// VersionFilter matches documents at the given version; documents written before
+// versioning have no version field and count as version 0
+func VersionFilter(version int64) primitive.E {
+	if version == 0 {
+		return primitive.E{Key: "version", Value: bson.M{"$in": bson.A{int64(0), nil}}}
+	}
+	return primitive.E{Key: "version", Value: version}
+}
+
 func DeleteManyInMongoDB(filter bson.D) int64 {
 	checkDbConnection()
 
@@ -55,3 +55,16 @@ func CloseMySqlConnection() {
 	isConnected = false
 	util.Logger.Debugln("database connection closed")
 }
+
+// ParseMySqlTime parses a DATETIME/TIMESTAMP column read without parseTime
+// Values are written from UTC time.Time, so they are read back as UTC
+func ParseMySqlTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	parsedTime, err := time.ParseInLocation("2006-01-02 15:04:05.999999", value, time.UTC)
+	if err != nil {
+		util.Logger.Warnw("parse mysql time failed", "value", value, "error", err)
+	}
+	return parsedTime
+}
@@ -121,6 +121,12 @@ func ToTimezone(t time.Time) time.Time {
 	return t.In(timezone)
 }
 
+// Today returns the current date in the configured timezone, at midnight UTC like the stored dates
+func Today() time.Time {
+	now := time.Now().In(GetTimezone())
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
 func FormatDateFromStringWithoutDash(dateString string) time.Time {
 	// Try to parse with the specified format first
 	date, err := time.Parse(defaultDateFormatInString, dateString)
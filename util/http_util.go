@@ -49,31 +49,8 @@ func ComposeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}
 
 	// Check if data is an error
 	if err, ok := data.(error); ok {
-		// Check if it's an AppError from errors package
-		if appErr, ok := err.(*errors.AppError); ok {
-			// Create detailed error response from AppError
-			errorInfo := &ErrorInfo{
-				Code:    string(appErr.Code),
-				Message: appErr.Message,
-				Field:   appErr.Field,
-			}
-
-			// Add cause details if available
-			if appErr.Cause != nil {
-				errorInfo.Details = appErr.Cause.Error()
-			}
-
-			response = ResponseWrapper{
-				Error: errorInfo,
-			}
-		} else {
-			// Create generic error response for standard errors
-			response = ResponseWrapper{
-				Error: &ErrorInfo{
-					Code:    "INTERNAL_ERROR",
-					Message: err.Error(),
-				},
-			}
+		response = ResponseWrapper{
+			Error: NewErrorInfo(err),
 		}
 	} else if errMap, ok := data.(map[string]string); ok {
 		// Check if data is an error map
@@ -124,6 +101,57 @@ func ComposeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}
 	json.NewEncoder(w).Encode(response)
 }
 
+// NewErrorInfo describes an error the way error responses do
+// An AppError keeps its code, field and cause, any other error is reported as INTERNAL_ERROR
+func NewErrorInfo(err error) *ErrorInfo {
+	// Check if it's an AppError from errors package
+	if appErr, ok := err.(*errors.AppError); ok {
+		errorInfo := &ErrorInfo{
+			Code:    string(appErr.Code),
+			Message: appErr.Message,
+			Field:   appErr.Field,
+		}
+
+		// Add cause details if available
+		if appErr.Cause != nil {
+			errorInfo.Details = appErr.Cause.Error()
+		}
+		return errorInfo
+	}
+
+	// Create generic error info for standard errors
+	return &ErrorInfo{
+		Code:    "INTERNAL_ERROR",
+		Message: err.Error(),
+	}
+}
+
+// GetErrorStatus returns the HTTP status matching the code of an AppError, or 500 for any other error
+func GetErrorStatus(err error) int {
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	switch appErr.Code {
+	case errors.ErrNotFound:
+		return http.StatusNotFound
+	case errors.ErrInvalidInput, errors.ErrValidation:
+		return http.StatusBadRequest
+	case errors.ErrAlreadyExists:
+		return http.StatusConflict
+	case errors.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case errors.ErrPrecondition:
+		return http.StatusPreconditionFailed
+	case errors.ErrPreconditionRequired:
+		return http.StatusPreconditionRequired
+	case errors.ErrBatchAborted:
+		return http.StatusFailedDependency
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // SendFile sends a file as an HTTP response
 func SendFile(w http.ResponseWriter, file io.Reader) {
 	if _, err := io.Copy(w, file); err != nil {
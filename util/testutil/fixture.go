@@ -0,0 +1,30 @@
+// Package testutil holds the fixtures shared by service tests; only _test.go files import it.
+package testutil
+
+import (
+	"time"
+
+	"github.com/macar-x/cashlenx-server/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Day parses a yyyy-MM-dd date at midnight UTC and panics on anything else, so a typo fails the test
+func Day(text string) time.Time {
+	date, err := time.Parse("2006-01-02", text)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+// NewCashFlow returns a cash flow with a fresh id in the given category
+func NewCashFlow(categoryId primitive.ObjectID, date, flowType, description string, amount int64) model.CashFlowEntity {
+	return model.CashFlowEntity{
+		Id:          primitive.NewObjectID(),
+		CategoryId:  categoryId,
+		BelongsDate: Day(date),
+		FlowType:    flowType,
+		Description: description,
+		Amount:      model.NewMoneyFromInt(amount),
+	}
+}
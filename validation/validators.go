@@ -2,12 +2,21 @@ package validation
 
 import (
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/mapper/category_mapper"
+	"github.com/macar-x/cashlenx-server/model"
+	"github.com/macar-x/cashlenx-server/query"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var maxMoneyAmount, _ = model.NewMoneyFromString("999999999.99")
+
+// maxPlanTextLength bounds the name and remark of goals and debts
+const maxPlanTextLength = 200
+
 // NewValidationError creates a new validation error
 func NewValidationError(field, message string) error {
 	return errors.NewFieldValidationError(field, message)
@@ -95,13 +104,26 @@ func ValidateDateRange(fromStr, toStr string) error {
 	return nil
 }
 
-// ValidateAmount validates monetary amount (must be positive)
-func ValidateAmount(amount float64) error {
-	if amount <= 0 {
+// ValidateMoney validates an exact monetary amount (must be positive)
+func ValidateMoney(amount model.Money) error {
+	if !amount.IsPositive() {
 		return NewValidationError("amount", "must be positive")
 	}
 
-	if amount > 999999999.99 {
+	if amount.Cmp(maxMoneyAmount) > 0 {
+		return NewValidationError("amount", "exceeds maximum allowed value")
+	}
+
+	return nil
+}
+
+// ValidateMoneyAllowZero validates an exact monetary amount that may be zero, as set by a patch
+func ValidateMoneyAllowZero(amount model.Money) error {
+	if amount.IsNegative() {
+		return NewValidationError("amount", "cannot be negative")
+	}
+
+	if amount.Cmp(maxMoneyAmount) > 0 {
 		return NewValidationError("amount", "exceeds maximum allowed value")
 	}
 
@@ -153,6 +175,24 @@ func ValidateDescription(desc string) error {
 	return nil
 }
 
+// ValidateRemark validates remark text
+func ValidateRemark(remark string) error {
+	if len(remark) > 500 {
+		return NewValidationError("remark", "too long (max 500 characters)")
+	}
+
+	return nil
+}
+
+// ValidateCategoryType validates category type (income or expense)
+func ValidateCategoryType(categoryType string) error {
+	if categoryType != "income" && categoryType != "expense" {
+		return NewValidationError("type", "must be income or expense")
+	}
+
+	return nil
+}
+
 // ValidateFlowType validates flow type (INCOME or OUTCOME)
 func ValidateFlowType(flowType string) error {
 	if flowType != "INCOME" && flowType != "OUTCOME" {
@@ -170,3 +210,56 @@ func ValidateRequired(field, value string) error {
 
 	return nil
 }
+
+// ValidateNameAndRemark validates the name and remark of a goal or debt and returns the trimmed name
+func ValidateNameAndRemark(name, remark string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", NewValidationError("name", "cannot be empty")
+	}
+	if len(name) > maxPlanTextLength {
+		return "", NewValidationError("name", "too long (max 200 characters)")
+	}
+	if len(remark) > maxPlanTextLength {
+		return "", NewValidationError("remark", "too long (max 200 characters)")
+	}
+	return name, nil
+}
+
+// ValidateStartDate parses an optional start date, which defaults to defaultStart
+func ValidateStartDate(startDate string, defaultStart time.Time) (time.Time, error) {
+	if startDate == "" {
+		return defaultStart, nil
+	}
+	date, _, ok := query.ParsePeriod(startDate)
+	if !ok {
+		return time.Time{}, NewValidationError("start_date", "invalid date "+startDate)
+	}
+	return date, nil
+}
+
+// ResolveCategory finds a category by id, or by name without one; field prefixes the names of
+// the fields in errors. Neither id nor name is no category, or an error when required
+func ResolveCategory(field, categoryPlainId, categoryName string, required bool) (primitive.ObjectID, error) {
+	if categoryPlainId != "" {
+		if err := ValidateID(categoryPlainId); err != nil {
+			return primitive.NilObjectID, NewValidationError(field+"_id", "invalid ID format")
+		}
+		categoryEntity := category_mapper.INSTANCE.GetCategoryByObjectId(categoryPlainId)
+		if categoryEntity.IsEmpty() {
+			return primitive.NilObjectID, NewValidationError(field+"_id", "category not found")
+		}
+		return categoryEntity.Id, nil
+	}
+	if categoryName == "" {
+		if required {
+			return primitive.NilObjectID, NewValidationError(field+"_name", "cannot be empty")
+		}
+		return primitive.NilObjectID, nil
+	}
+	categoryEntity := category_mapper.INSTANCE.GetCategoryByName(categoryName)
+	if categoryEntity.IsEmpty() {
+		return primitive.NilObjectID, NewValidationError(field+"_name", "category not found: "+categoryName)
+	}
+	return categoryEntity.Id, nil
+}
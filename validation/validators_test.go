@@ -1,7 +1,12 @@
 package validation
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/macar-x/cashlenx-server/errors"
+	"github.com/macar-x/cashlenx-server/model"
 )
 
 func TestValidateDate(t *testing.T) {
@@ -55,30 +60,6 @@ func TestValidateDateRange(t *testing.T) {
 	}
 }
 
-func TestValidateAmount(t *testing.T) {
-	tests := []struct {
-		name    string
-		amount  float64
-		wantErr bool
-	}{
-		{"Valid amount", 100.50, false},
-		{"Zero amount", 0, true},
-		{"Negative amount", -50.00, true},
-		{"Very large amount", 1000000000.00, true},
-		{"Small positive", 0.01, false},
-		{"Maximum valid", 999999999.99, false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := ValidateAmount(tt.amount)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("ValidateAmount() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
 func TestValidateID(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -171,3 +152,117 @@ func TestValidateFlowType(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateMoney(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  string
+		wantErr bool
+	}{
+		{"Valid amount", "100.50", false},
+		{"Zero amount", "0", true},
+		{"Negative amount", "-50.00", true},
+		{"Very large amount", "1000000000.00", true},
+		{"Small positive", "0.01", false},
+		{"Maximum valid", "999999999.99", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, _ := model.NewMoneyFromString(tt.amount)
+			err := ValidateMoney(amount)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMoney() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMoneyAllowZero(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  string
+		wantErr bool
+	}{
+		{"Valid amount", "100.50", false},
+		{"Zero amount", "0", false},
+		{"Negative amount", "-0.01", true},
+		{"Very large amount", "1000000000.00", true},
+		{"Maximum valid", "999999999.99", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, _ := model.NewMoneyFromString(tt.amount)
+			err := ValidateMoneyAllowZero(amount)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMoneyAllowZero() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCategoryType(t *testing.T) {
+	tests := []struct {
+		name         string
+		categoryType string
+		wantErr      bool
+	}{
+		{"Valid income", "income", false},
+		{"Valid expense", "expense", false},
+		{"Invalid uppercase", "INCOME", true},
+		{"Empty type", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCategoryType(tt.categoryType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCategoryType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateNameAndRemark(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputName string
+		remark    string
+		wantName  string
+		wantField string
+	}{
+		{"Trimmed name", "  Holiday ", "", "Holiday", ""},
+		{"Blank name", "   ", "", "", "name"},
+		{"Name too long", strings.Repeat("a", 201), "", "", "name"},
+		{"Remark too long", "Car", strings.Repeat("a", 201), "", "remark"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, err := ValidateNameAndRemark(tt.inputName, tt.remark)
+			if tt.wantField == "" {
+				if err != nil || name != tt.wantName {
+					t.Errorf("ValidateNameAndRemark() = %q, %v, want %q", name, err, tt.wantName)
+				}
+				return
+			}
+			if appError, ok := err.(*errors.AppError); !ok || appError.Field != tt.wantField {
+				t.Errorf("ValidateNameAndRemark() error = %v, want an error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidateStartDate(t *testing.T) {
+	defaultStart := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	if date, err := ValidateStartDate("", defaultStart); err != nil || !date.Equal(defaultStart) {
+		t.Errorf("an empty start date should default, got %v, %v", date, err)
+	}
+	if date, err := ValidateStartDate("2026-03", defaultStart); err != nil || !date.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("a month should start on its first day, got %v, %v", date, err)
+	}
+	if _, err := ValidateStartDate("someday", defaultStart); err == nil {
+		t.Error("an invalid start date should be rejected")
+	}
+}